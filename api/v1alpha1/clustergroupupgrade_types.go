@@ -31,6 +31,11 @@ type RemediationStrategySpec struct {
 	MaxConcurrency int `json:"maxConcurrency"`
 	//+kubebuilder:default=240
 	Timeout int `json:"timeout,omitempty"`
+	// ClusterVersionFailingTimeout is how long, in minutes, the ClusterVersion of a cluster being upgraded can
+	// report the Failing condition before the remediation of the cluster is failed, without waiting for the
+	// batch timeout
+	//+kubebuilder:default=30
+	ClusterVersionFailingTimeout int `json:"clusterVersionFailingTimeout,omitempty"`
 }
 
 // NamespacedCR defines the name and namespace of a custom resource
@@ -61,8 +66,33 @@ type BeforeEnable struct {
 	// to be deleted for the specified clusters. Labels applied to the clusters either
 	// defined in spec.clusters or selected by spec.clusterSelector.
 	DeleteClusterLabels map[string]string `json:"deleteClusterLabels,omitempty"`
+	// This field lists the MachineConfigPools paused on each cluster before its
+	// remediation starts, such as before its platform upgrade, so that the nodes of
+	// the pools are not rebooted until the pools are unpaused by
+	// afterCompletion.unpauseMachineConfigPools.
+	PauseMachineConfigPools []string `json:"pauseMachineConfigPools,omitempty"`
 }
 
+// UnpauseMachineConfigPools defines when MachineConfigPools are unpaused
+type UnpauseMachineConfigPools struct {
+	// This field lists the MachineConfigPools to unpause. It defaults to the pools of
+	// beforeEnable.pauseMachineConfigPools.
+	Pools []string `json:"pools,omitempty"`
+	// This field defines when the pools are unpaused: Cluster, once the cluster
+	// completed its remediation, Batch, once all the clusters of its batch did,
+	// or Upgrade, in a separate phase once all the batches completed.
+	//+kubebuilder:validation:Enum=Cluster;Batch;Upgrade
+	//+kubebuilder:default=Cluster
+	When string `json:"when,omitempty"`
+}
+
+// UnpauseMachineConfigPools possible values of when
+const (
+	UnpauseAfterCluster = "Cluster"
+	UnpauseAfterBatch   = "Batch"
+	UnpauseAfterUpgrade = "Upgrade"
+)
+
 // AfterCompletion defines the actions to be done after upgrade is completed
 type AfterCompletion struct {
 	// This field defines a map of key/value pairs that identify the cluster labels
@@ -76,6 +106,20 @@ type AfterCompletion struct {
 	// This field defines whether clean up the resources created for upgrade
 	//+kubebuilder:default=true
 	DeleteObjects *bool `json:"deleteObjects,omitempty"`
+	// This field defines whether to report the inventory of the images cached on the
+	// clusters that completed the upgrade. The inventory is summarized in
+	// status.imageInventory and stored in the pre-cache-inventory configmap of the
+	// cluster namespace on the hub.
+	ReportImageInventory bool `json:"reportImageInventory,omitempty"`
+	// This field defines whether to remove the cached images that are no longer
+	// referenced by the release the clusters were upgraded to. Only images from the
+	// repositories of the release and of the pre-cached images are removed, as long
+	// as they are not used by any container. Implies reportImageInventory.
+	PruneImages bool `json:"pruneImages,omitempty"`
+	// This field defines the MachineConfigPools unpaused once the clusters are
+	// remediated, and when. The clusters are complete once the unpaused pools
+	// report they are updated.
+	UnpauseMachineConfigPools *UnpauseMachineConfigPools `json:"unpauseMachineConfigPools,omitempty"`
 }
 
 // BatchTimeoutAction selections
@@ -94,6 +138,131 @@ type OperatorUpgradeSpec struct {
 	Namespace string `json:"namespace,omitempty"`
 }
 
+// PlatformUpgradeVersion defines a release the clusters are upgraded to
+type PlatformUpgradeVersion struct {
+	Version string `json:"version"`
+	// The release image of the version. When not specified, it is looked up in the update graph
+	// of the channel.
+	Image string `json:"image,omitempty"`
+}
+
+// PlatformUpgradeSpec defines an OpenShift upgrade through a chain of versions, such as an
+// EUS-to-EUS upgrade
+type PlatformUpgradeSpec struct {
+	// This field lists the versions each cluster is upgraded to in turn. The last one is the
+	// target version. The upgrade to a version starts once the cluster completed the previous one.
+	//+kubebuilder:validation:MinItems=1
+	Versions []PlatformUpgradeVersion `json:"versions"`
+	// This field defines the channel set on the clusters for the upgrade. It must contain all the
+	// versions of the chain.
+	Channel string `json:"channel,omitempty"`
+	// This field defines the update service the release images and upgrade paths are looked up in.
+	// When not specified, the public OpenShift update service is used.
+	Upstream string `json:"upstream,omitempty"`
+	// This field determines whether the worker MachineConfigPool is paused until the last version
+	// is reached, so that the worker nodes are rebooted only once.
+	//+kubebuilder:default=false
+	PauseWorkerPools bool `json:"pauseWorkerPools,omitempty"`
+}
+
+// SeedImageRef defines the seed image the clusters are upgraded to by an image-based upgrade
+type SeedImageRef struct {
+	// The OpenShift version of the seed image
+	Version string `json:"version,omitempty"`
+	// The seed image
+	Image string `json:"image,omitempty"`
+	// The name of the secret, on the clusters, holding the credentials to pull the seed image
+	PullSecretRef string `json:"pullSecretRef,omitempty"`
+}
+
+// ImageBasedUpgradeSpec defines an image-based upgrade driven by the ImageBasedUpgrade CR
+// of the lifecycle agent on each cluster
+type ImageBasedUpgradeSpec struct {
+	SeedImageRef SeedImageRef `json:"seedImageRef,omitempty"`
+	// This field lists the stages the ImageBasedUpgrade CR of each cluster is moved through,
+	// in turn: Prep, Upgrade, Rollback and Finalize. A cluster is moved to the next stage once
+	// it completed the previous one. Finalize moves the CR back to Idle.
+	//+kubebuilder:validation:MinItems=1
+	//+kubebuilder:default={Prep,Upgrade,Finalize}
+	Stages []string `json:"stages,omitempty"`
+	// This field determines whether a cluster that failed the Upgrade stage is rolled back
+	// and finalized before its remediation is failed.
+	//+kubebuilder:default=true
+	AutoRollbackOnFailure *bool `json:"autoRollbackOnFailure,omitempty"`
+}
+
+// ImageBasedUpgrade stages
+const (
+	ImageBasedUpgradeStagePrep     = "Prep"
+	ImageBasedUpgradeStageUpgrade  = "Upgrade"
+	ImageBasedUpgradeStageRollback = "Rollback"
+	ImageBasedUpgradeStageFinalize = "Finalize"
+)
+
+// Backup retention of the recovery partition content once the upgrade is completed
+const (
+	BackupRetentionKeep  = "Keep"
+	BackupRetentionClean = "Clean"
+)
+
+// Backup compression of the archives of the recovery partition content
+const (
+	BackupCompressionNone = "None"
+	BackupCompressionGzip = "Gzip"
+	BackupCompressionZstd = "Zstd"
+)
+
+// BackupObjectStorage defines the S3-compatible object storage the backup is uploaded to
+type BackupObjectStorage struct {
+	// This field defines the name of a secret in the namespace of the ClusterGroupUpgrade holding the
+	// endpoint, bucket, accessKeyId and secretAccessKey entries of the object storage, and optionally its
+	// region, prefix and ca.crt entries.
+	Secret string `json:"secret"`
+	// This field determines whether the backup content is removed from /var/recovery once uploaded. The
+	// clusters whose backup is removed can't be restored from the hub, so it can't be set along with restore.
+	//+kubebuilder:default=false
+	RemoveLocalCopy bool `json:"removeLocalCopy,omitempty"`
+}
+
+// BackupOptions defines the timeout, the retention, the format and the destination of the backup
+type BackupOptions struct {
+	// This field defines the maximum time in minutes for the backup of a cluster.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=8
+	Timeout int `json:"timeout,omitempty"`
+	// This field determines whether the backup content in /var/recovery is kept or cleaned on the clusters
+	// that completed the upgrade.
+	//+kubebuilder:validation:Enum=Keep;Clean
+	//+kubebuilder:default=Keep
+	Retention string `json:"retention,omitempty"`
+	// This field defines the compression of the backup. The backup is archived when it is compressed or
+	// encrypted, and copied as is otherwise.
+	//+kubebuilder:validation:Enum=None;Gzip;Zstd
+	//+kubebuilder:default=None
+	Compression string `json:"compression,omitempty"`
+	// This field defines the name of a secret in the namespace of the ClusterGroupUpgrade holding the key
+	// encrypting the backup, under its "key" entry. The backup is not encrypted when empty.
+	EncryptionKeySecret string `json:"encryptionKeySecret,omitempty"`
+	// This field defines the object storage the backup is uploaded to, once taken in /var/recovery.
+	ObjectStorage *BackupObjectStorage `json:"objectStorage,omitempty"`
+}
+
+// RestoreSpec defines the clusters restored from their backup once the upgrade is completed
+type RestoreSpec struct {
+	// This field determines whether the clusters that failed or timed out the remediation are restored
+	// from their backup.
+	//+kubebuilder:default=false
+	OnFailure bool `json:"onFailure,omitempty"`
+	// This field lists additional clusters restored from their backup, whatever the outcome of their
+	// remediation.
+	Clusters []string `json:"clusters,omitempty"`
+	// This field defines the maximum time in minutes for the restore of a cluster, including the reboots
+	// of the node.
+	//+kubebuilder:validation:Minimum=1
+	//+kubebuilder:default=60
+	Timeout int `json:"timeout,omitempty"`
+}
+
 // ClusterGroupUpgradeSpec defines the desired state of ClusterGroupUpgrade
 type ClusterGroupUpgradeSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
@@ -103,6 +272,13 @@ type ClusterGroupUpgradeSpec struct {
 	//+kubebuilder:default=false
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Backup",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:bool"}
 	Backup bool `json:"backup,omitempty"`
+	// This field defines the timeout of the backup and the retention of the backup content once the upgrade is completed.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Backup Options",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	BackupOptions *BackupOptions `json:"backupOptions,omitempty"`
+	// This field determines which clusters are restored from the backup taken prior to the upgrade, once
+	// the upgrade is completed. It requires backup to be enabled.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Restore",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	Restore *RestoreSpec `json:"restore,omitempty"`
 	// This field determines whether container image pre-caching will be done on all the clusters
 	// matching the selector.
 	// If required, the pre-caching process starts immediately on all clusters irrespectively of
@@ -114,12 +290,24 @@ type ClusterGroupUpgradeSpec struct {
 	// pre-caching configurations.
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="PreCachingConfigRef",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
 	PreCachingConfigRef PreCachingConfigCR `json:"preCachingConfigRef,omitempty"`
+	// This field determines whether to run the pre-caching job on clusters that already hold the content
+	// pre-cached by a previous ClusterGroupUpgrade. By default, these clusters skip pre-caching.
+	//+kubebuilder:default=false
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="PreCachingForceRefresh",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:bool"}
+	PreCachingForceRefresh bool `json:"preCachingForceRefresh,omitempty"`
 	// This field determines when the upgrade starts. While false, the upgrade doesn't start. The policies,
 	// placement rules and placement bindings are created, but clusters are not added to the placement rule.
 	// Once set to true, the clusters start being upgraded, one batch at a time.
 	//+kubebuilder:default=true
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Enable",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:bool"}
 	Enable *bool `json:"enable,omitempty"`
+	// This field determines whether the ClusterGroupUpgrade only computes its plan. The clusters are validated
+	// and the remediation plan, the non-compliant policies of each cluster and the pre-caching spec are written
+	// to the status, but no policy, placement rule, ManagedClusterAction or cluster label is created or changed.
+	// It has no effect once the upgrade started.
+	//+kubebuilder:default=false
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Dry Run",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:bool"}
+	DryRun bool `json:"dryRun,omitempty"`
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Clusters",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
 	Clusters []string `json:"clusters,omitempty"`
 	// This field holds a label common to multiple clusters that will be updated.
@@ -170,14 +358,38 @@ type ClusterGroupUpgradeSpec struct {
 	//   - Abort
 	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="BatchTimeoutAction",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
 	BatchTimeoutAction string `json:"batchTimeoutAction,omitempty"`
+	// This field defines an OpenShift upgrade through a chain of versions. TALO upgrades each cluster
+	// of the current batch to the versions in turn, before remediating the managed policies. The
+	// intermediate releases are pre-cached along with the target release.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Platform Upgrade",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	PlatformUpgrade *PlatformUpgradeSpec `json:"platformUpgrade,omitempty"`
+	// This field defines an image-based upgrade. TALO moves the ImageBasedUpgrade CR of each
+	// cluster of the current batch through the stages, before remediating the managed policies.
+	//+operator-sdk:csv:customresourcedefinitions:type=spec,displayName="Image Based Upgrade",xDescriptors={"urn:alm:descriptor:com.tectonic.ui:text"}
+	ImageBasedUpgrade *ImageBasedUpgradeSpec `json:"imageBasedUpgrade,omitempty"`
+}
+
+// ClusterVersionProgress stores the progress of the platform upgrade of a cluster, as reported by its ClusterVersion
+type ClusterVersionProgress struct {
+	CurrentVersion string `json:"currentVersion,omitempty"`
+	DesiredVersion string `json:"desiredVersion,omitempty"`
+	// Progressing is the message of the Progressing condition
+	Progressing string `json:"progressing,omitempty"`
+	// Failing is the message of the Failing condition, while its status is True
+	Failing string `json:"failing,omitempty"`
+	// FailingSince is when the Failing condition was first seen True
+	FailingSince metav1.Time `json:"failingSince,omitempty"`
 }
 
 // ClusterRemediationProgress stores the remediation progress of a cluster
 type ClusterRemediationProgress struct {
-	// State should be one of the following: NotStarted, InProgress, Completed
+	// State should be one of the following: NotStarted, InProgress, Completed, Failed
 	State            string      `json:"state,omitempty"`
 	PolicyIndex      *int        `json:"policyIndex,omitempty"`
 	FirstCompliantAt metav1.Time `json:"firstComplaintAt,omitempty"`
+	// PolicyStartedAt is the time the remediation of the current policy started
+	PolicyStartedAt metav1.Time             `json:"policyStartedAt,omitempty"`
+	ClusterVersion  *ClusterVersionProgress `json:"clusterVersion,omitempty"`
 }
 
 // ClusterRemediationProgress possible states
@@ -185,6 +397,7 @@ const (
 	NotStarted = "NotStarted"
 	InProgress = "InProgress"
 	Completed  = "Completed"
+	Failed     = "Failed"
 )
 
 // UpgradeStatus defines the observed state of the upgrade
@@ -193,6 +406,12 @@ type UpgradeStatus struct {
 	CompletedAt           metav1.Time `json:"completedAt,omitempty"`
 	CurrentBatch          int         `json:"currentBatch,omitempty"`
 	CurrentBatchStartedAt metav1.Time `json:"currentBatchStartedAt,omitempty"`
+	// CompletionReported is set once the event and the notification of the completion are issued, after the
+	// completion is recorded
+	CompletionReported bool `json:"completionReported,omitempty"`
+	// ReportCreated is set once the report of the upgrade is written, after the restore of the clusters and
+	// the cleanup of the backups
+	ReportCreated bool `json:"reportCreated,omitempty"`
 
 	CurrentBatchRemediationProgress map[string]*ClusterRemediationProgress `json:"currentBatchRemediationProgress,omitempty"`
 }
@@ -214,6 +433,58 @@ type ClusterState struct {
 	Name          string        `json:"name"`
 	State         string        `json:"state"`
 	CurrentPolicy *PolicyStatus `json:"currentPolicy,omitempty"`
+	Message       string        `json:"message,omitempty"`
+}
+
+// UpgradeSummary summarizes the progress of the remediation of the clusters
+type UpgradeSummary struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	// InProgress counts the clusters of the current batch being remediated
+	InProgress int `json:"inProgress"`
+	// Failed counts the clusters whose remediation failed or timed out
+	Failed int `json:"failed"`
+	// Pending counts the clusters whose remediation did not start
+	Pending int `json:"pending"`
+	// PercentComplete is the percentage of the clusters whose remediation ended, completed or failed
+	PercentComplete int `json:"percentComplete"`
+	// EstimatedCompletionTime is estimated from the average remediation duration of the clusters of the
+	// previous batches, and is bounded by the timeout of the upgrade. It is set while the upgrade is in
+	// progress, from the second batch
+	EstimatedCompletionTime *metav1.Time `json:"estimatedCompletionTime,omitempty"`
+}
+
+// ClusterPlan holds the plan of a cluster computed by a dry run
+type ClusterPlan struct {
+	Name string `json:"name"`
+	// Batch of the cluster in the remediation plan, starting from 1
+	Batch int `json:"batch"`
+	// NonCompliantPolicies lists the managed policies the cluster is not compliant with, in the order they
+	// are remediated
+	NonCompliantPolicies []string `json:"nonCompliantPolicies,omitempty"`
+}
+
+// DryRunStatus defines the plan computed by the last dry run
+type DryRunStatus struct {
+	ComputedAt metav1.Time   `json:"computedAt,omitempty"`
+	Clusters   []ClusterPlan `json:"clusters,omitempty"`
+}
+
+// ClusterTransition records a state transition of a cluster
+type ClusterTransition struct {
+	// Reason is the reason of the event recorded for the transition
+	Reason string `json:"reason"`
+	// State is the remediation, pre-caching or backup state of the cluster after the transition
+	State   string      `json:"state"`
+	Message string      `json:"message,omitempty"`
+	Time    metav1.Time `json:"time"`
+}
+
+// ClusterHistory holds the latest state transitions of a cluster, oldest first. Once the history of all the
+// clusters grows too large, only the transitions giving the remediation times are kept for the clusters
+// with the oldest transitions, or none
+type ClusterHistory struct {
+	Transitions []ClusterTransition `json:"transitions,omitempty"`
 }
 
 // PrecachingSpec defines the pre-caching software spec derived from policies
@@ -221,27 +492,130 @@ type PrecachingSpec struct {
 	PlatformImage                string   `json:"platformImage,omitempty"`
 	OperatorsIndexes             []string `json:"operatorsIndexes,omitempty"`
 	OperatorsPackagesAndChannels []string `json:"operatorsPackagesAndChannels,omitempty"`
-	ExcludePrecachePatterns      []string `json:"excludePrecachePatterns,omitempty"`
-	SpaceRequired                string   `json:"spaceRequired,omitempty"`
-	AdditionalImages             []string `json:"additionalImages,omitempty"`
+	// OperatorsImages is the list of operator bundle related images resolved on the hub
+	// from the operator indexes. When empty, the indexes are resolved on the spoke.
+	OperatorsImages         []string `json:"operatorsImages,omitempty"`
+	ExcludePrecachePatterns []string `json:"excludePrecachePatterns,omitempty"`
+	SpaceRequired           string   `json:"spaceRequired,omitempty"`
+	AdditionalImages        []string `json:"additionalImages,omitempty"`
+	// IntermediatePlatformImages are the release images of the intermediate versions of a
+	// platform upgrade through a chain of versions
+	IntermediatePlatformImages []string `json:"intermediatePlatformImages,omitempty"`
 }
 
 // PrecachingStatus defines the observed pre-caching status
 type PrecachingStatus struct {
 	Spec   *PrecachingSpec   `json:"spec,omitempty"`
 	Status map[string]string `json:"status,omitempty"`
+	// SpaceRequiredSource records how Spec.SpaceRequired was determined:
+	// PreCachingConfig, Overrides, Estimated (from the image layer sizes) or Default
+	SpaceRequiredSource string `json:"spaceRequiredSource,omitempty"`
+	// ClusterOverrides maps a cluster to the PreCachingConfig cluster override it matches
+	ClusterOverrides map[string]string `json:"clusterOverrides,omitempty"`
+	// OverrideSpecs holds the pre-caching spec of the clusters matching each cluster override
+	OverrideSpecs map[string]*PrecachingSpec `json:"overrideSpecs,omitempty"`
+	// SkippedClusters lists the clusters that already held the pre-caching content and
+	// went straight to Succeeded without running the pre-caching job
+	SkippedClusters []string `json:"skippedClusters,omitempty"`
 	//+kubebuilder:deprecatedversion:warning="PrecachingStatus.Clusters is deprecated"
 	Clusters []string `json:"clusters,omitempty"`
 }
 
+// ClusterBackupReport defines the backup reported by a cluster
+type ClusterBackupReport struct {
+	SizeBytes   int64           `json:"sizeBytes"`
+	Duration    metav1.Duration `json:"duration"`
+	CompletedAt metav1.Time     `json:"completedAt"`
+	ObjectKey   string          `json:"objectKey,omitempty"`
+}
+
 // BackupStatus defines the observed backup status
 type BackupStatus struct {
-	StartedAt metav1.Time       `json:"startedAt,omitempty"`
-	Status    map[string]string `json:"status,omitempty"`
+	StartedAt metav1.Time                    `json:"startedAt,omitempty"`
+	Status    map[string]string              `json:"status,omitempty"`
+	Reports   map[string]ClusterBackupReport `json:"reports,omitempty"`
+	// Cleanup holds the state of the cleanup of the backup content, with the Clean retention
+	Cleanup map[string]string `json:"cleanup,omitempty"`
 	//+kubebuilder:deprecatedversion:warning="BackupStatus.Clusters is deprecated"
 	Clusters []string `json:"clusters,omitempty"`
 }
 
+// RestoreStatus defines the observed restore status
+type RestoreStatus struct {
+	StartedAt metav1.Time       `json:"startedAt,omitempty"`
+	Status    map[string]string `json:"status,omitempty"`
+}
+
+// ClusterImageInventory defines the inventory of the images cached on a cluster
+type ClusterImageInventory struct {
+	Images         int         `json:"images"`
+	SizeBytes      int64       `json:"sizeBytes"`
+	PrunedImages   int         `json:"prunedImages,omitempty"`
+	ReclaimedBytes int64       `json:"reclaimedBytes,omitempty"`
+	ReportedAt     metav1.Time `json:"reportedAt,omitempty"`
+}
+
+// ImageInventoryStatus defines the observed image inventory status
+type ImageInventoryStatus struct {
+	StartedAt metav1.Time                      `json:"startedAt,omitempty"`
+	Status    map[string]string                `json:"status,omitempty"`
+	Clusters  map[string]ClusterImageInventory `json:"clusters,omitempty"`
+}
+
+// ClusterPlatformUpgradeStatus defines the progress of the platform upgrade of a cluster
+type ClusterPlatformUpgradeStatus struct {
+	// State is one of NotStarted, PausingWorkerPools, Upgrading, UnpausingWorkerPools or Completed
+	State string `json:"state"`
+	// Version is the version the cluster is being upgraded to
+	Version string `json:"version,omitempty"`
+	// CompletedVersions lists the versions of the chain the cluster completed the upgrade to
+	CompletedVersions []string    `json:"completedVersions,omitempty"`
+	StartedAt         metav1.Time `json:"startedAt,omitempty"`
+	Message           string      `json:"message,omitempty"`
+}
+
+// ClusterImageBasedUpgradeStatus defines the progress of the image-based upgrade of a cluster
+type ClusterImageBasedUpgradeStatus struct {
+	// Stage is the stage the cluster is being moved to
+	Stage string `json:"stage,omitempty"`
+	// State is one of InProgress, Completed or Failed
+	State string `json:"state"`
+	// CompletedStages lists the stages the cluster completed
+	CompletedStages []string `json:"completedStages,omitempty"`
+	// RolledBack is set when the cluster was rolled back after failing the Upgrade stage
+	RolledBack bool        `json:"rolledBack,omitempty"`
+	StartedAt  metav1.Time `json:"startedAt,omitempty"`
+	Message    string      `json:"message,omitempty"`
+}
+
+// ImageBasedUpgradeStatus defines the observed image-based upgrade status
+type ImageBasedUpgradeStatus struct {
+	Clusters map[string]*ClusterImageBasedUpgradeStatus `json:"clusters,omitempty"`
+}
+
+// MachineConfigPoolStatus stores the state of a MachineConfigPool of a cluster
+type MachineConfigPoolStatus struct {
+	Cluster string `json:"cluster"`
+	Name    string `json:"name"`
+	// State is one of Pausing, Paused, Unpausing, Updating or Updated
+	State string `json:"state"`
+	// Updated and Degraded are the status of the conditions of the pool
+	Updated             bool  `json:"updated"`
+	Degraded            bool  `json:"degraded"`
+	MachineCount        int64 `json:"machineCount,omitempty"`
+	UpdatedMachineCount int64 `json:"updatedMachineCount,omitempty"`
+	// Message is the message of the Degraded condition, while the pool is degraded, or the failure of the action
+	// pausing or unpausing the pool
+	Message string `json:"message,omitempty"`
+}
+
+// PlatformUpgradeStatus defines the observed platform upgrade status
+type PlatformUpgradeStatus struct {
+	// Images maps the versions of the chain to their release image
+	Images   map[string]string                        `json:"images,omitempty"`
+	Clusters map[string]*ClusterPlatformUpgradeStatus `json:"clusters,omitempty"`
+}
+
 // ClusterGroupUpgradeStatus defines the observed state of ClusterGroupUpgrade
 type ClusterGroupUpgradeStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
@@ -270,12 +644,28 @@ type ClusterGroupUpgradeStatus struct {
 	ManagedPoliciesContent map[string]string `json:"managedPoliciesContent,omitempty"`
 	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Clusters"
 	Clusters []ClusterState `json:"clusters,omitempty"`
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Cluster History"
+	ClusterHistory map[string]*ClusterHistory `json:"clusterHistory,omitempty"`
 	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Status"
 	Status UpgradeStatus `json:"status,omitempty"`
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Dry Run"
+	DryRun *DryRunStatus `json:"dryRun,omitempty"`
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Summary"
+	Summary *UpgradeSummary `json:"summary,omitempty"`
 	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Precaching"
 	Precaching *PrecachingStatus `json:"precaching,omitempty"`
 	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Backup"
 	Backup *BackupStatus `json:"backup,omitempty"`
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Restore"
+	Restore *RestoreStatus `json:"restore,omitempty"`
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Image Inventory"
+	ImageInventory *ImageInventoryStatus `json:"imageInventory,omitempty"`
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Platform Upgrade"
+	PlatformUpgrade *PlatformUpgradeStatus `json:"platformUpgrade,omitempty"`
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Image Based Upgrade"
+	ImageBasedUpgrade *ImageBasedUpgradeStatus `json:"imageBasedUpgrade,omitempty"`
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Machine Config Pools"
+	MachineConfigPools []MachineConfigPoolStatus `json:"machineConfigPools,omitempty"`
 	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Computed Maximum Concurrency"
 	ComputedMaxConcurrency int `json:"computedMaxConcurrency,omitempty"`
 }
@@ -286,6 +676,13 @@ type ClusterGroupUpgradeStatus struct {
 //+kubebuilder:resource:path=clustergroupupgrades,shortName=cgu
 //+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 //+kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.conditions[-1:].reason"
+//+kubebuilder:printcolumn:name="Clusters",type="integer",JSONPath=".status.summary.total"
+//+kubebuilder:printcolumn:name="Completed",type="integer",JSONPath=".status.summary.completed"
+//+kubebuilder:printcolumn:name="In Progress",type="integer",JSONPath=".status.summary.inProgress",priority=1
+//+kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.summary.failed"
+//+kubebuilder:printcolumn:name="Pending",type="integer",JSONPath=".status.summary.pending",priority=1
+//+kubebuilder:printcolumn:name="Percent",type="integer",JSONPath=".status.summary.percentComplete"
+//+kubebuilder:printcolumn:name="ETA",type="string",JSONPath=".status.summary.estimatedCompletionTime"
 //+kubebuilder:printcolumn:name="Details",type="string",JSONPath=".status.conditions[-1:].message"
 
 // ClusterGroupUpgrade is the Schema for the ClusterGroupUpgrades API
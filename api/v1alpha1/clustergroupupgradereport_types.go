@@ -0,0 +1,96 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterReport records the outcome of the remediation of a cluster
+type ClusterReport struct {
+	Name string `json:"name"`
+	// Batch of the cluster in the remediation plan, starting from 1. Zero when the cluster was not in the plan
+	Batch int `json:"batch,omitempty"`
+	// State is the final remediation state of the cluster: complete, timedout, failed, or NotStarted when the
+	// upgrade ended before the batch of the cluster
+	State         string        `json:"state"`
+	CurrentPolicy *PolicyStatus `json:"currentPolicy,omitempty"`
+	Message       string        `json:"message,omitempty"`
+	// StartedAt is the start of the batch of the cluster
+	StartedAt metav1.Time `json:"startedAt,omitempty"`
+	// CompletedAt is the time the cluster reached its final remediation state
+	CompletedAt metav1.Time      `json:"completedAt,omitempty"`
+	Duration    *metav1.Duration `json:"duration,omitempty"`
+}
+
+// ClusterGroupUpgradeReportSpec defines the record of a completed ClusterGroupUpgrade
+type ClusterGroupUpgradeReportSpec struct {
+	// Important: Run "make generate" to regenerate code after modifying this file
+
+	// Name of the ClusterGroupUpgrade reported, in the namespace of the report
+	ClusterGroupUpgrade string `json:"clusterGroupUpgrade"`
+	// UID of the ClusterGroupUpgrade reported
+	ClusterGroupUpgradeUID string `json:"clusterGroupUpgradeUID,omitempty"`
+	// Succeeded reports the status of the Succeeded condition of the ClusterGroupUpgrade
+	Succeeded bool `json:"succeeded"`
+	// Result is the reason of the Succeeded condition, such as Completed, TimedOut or Failed
+	Result  string `json:"result"`
+	Message string `json:"message,omitempty"`
+
+	StartedAt   metav1.Time      `json:"startedAt,omitempty"`
+	CompletedAt metav1.Time      `json:"completedAt"`
+	Duration    *metav1.Duration `json:"duration,omitempty"`
+
+	RemediationPlan                       [][]string                `json:"remediationPlan,omitempty"`
+	ManagedPoliciesForUpgrade             []ManagedPolicyForUpgrade `json:"managedPoliciesForUpgrade,omitempty"`
+	ManagedPoliciesCompliantBeforeUpgrade []string                  `json:"managedPoliciesCompliantBeforeUpgrade,omitempty"`
+	// Clusters holds the outcome of the remediation of each cluster, in the order of the remediation plan
+	Clusters   []ClusterReport   `json:"clusters,omitempty"`
+	Precaching *PrecachingStatus `json:"precaching,omitempty"`
+	Backup     *BackupStatus     `json:"backup,omitempty"`
+	Restore    *RestoreStatus    `json:"restore,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:resource:path=clustergroupupgradereports,shortName=cgureport
+//+kubebuilder:printcolumn:name="Upgrade",type="string",JSONPath=".spec.clusterGroupUpgrade"
+//+kubebuilder:printcolumn:name="Result",type="string",JSONPath=".spec.result"
+//+kubebuilder:printcolumn:name="Completed",type="date",JSONPath=".spec.completedAt"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// ClusterGroupUpgradeReport is the Schema for the clustergroupupgradereports API. The report of a
+// ClusterGroupUpgrade is written once by TALM, when the upgrade completes, and its spec is immutable.
+// The validation rule enforcing it is added by config/crd/patches/validation_in_clustergroupupgradereports.yaml
+type ClusterGroupUpgradeReport struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ClusterGroupUpgradeReportSpec `json:"spec,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// ClusterGroupUpgradeReportList contains a list of ClusterGroupUpgradeReport
+type ClusterGroupUpgradeReportList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterGroupUpgradeReport `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterGroupUpgradeReport{}, &ClusterGroupUpgradeReportList{})
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// NotificationEvent is a lifecycle event of a ClusterGroupUpgrade
+// +kubebuilder:validation:Enum=UpgradeStarted;BatchCompleted;BatchTimedOut;ClusterFailed;UpgradeTimedOut;UpgradeSucceeded;UpgradeFailed
+type NotificationEvent string
+
+// Lifecycle events of a ClusterGroupUpgrade notified to the NotificationTargets
+const (
+	NotificationUpgradeStarted   NotificationEvent = "UpgradeStarted"
+	NotificationBatchCompleted   NotificationEvent = "BatchCompleted"
+	NotificationBatchTimedOut    NotificationEvent = "BatchTimedOut"
+	NotificationClusterFailed    NotificationEvent = "ClusterFailed"
+	NotificationUpgradeTimedOut  NotificationEvent = "UpgradeTimedOut"
+	NotificationUpgradeSucceeded NotificationEvent = "UpgradeSucceeded"
+	NotificationUpgradeFailed    NotificationEvent = "UpgradeFailed"
+)
+
+// States of the delivery of a notification
+const (
+	NotificationDelivered = "Delivered"
+	NotificationFailed    = "Failed"
+)
+
+// NotificationAuthHeader sets a header of the notification requests from a secret
+type NotificationAuthHeader struct {
+	// Name of the header
+	// +kubebuilder:default=Authorization
+	Name string `json:"name,omitempty"`
+	// Name of the secret holding the value of the header, in the namespace of the operator. The secret must be
+	// labelled openshift-cluster-group-upgrades/notificationSecret=true
+	Secret string `json:"secret"`
+	// Key of the value of the header in the secret
+	// +kubebuilder:default=authorization
+	Key string `json:"key,omitempty"`
+}
+
+// NotificationTargetSpec defines the desired state of NotificationTarget
+type NotificationTargetSpec struct {
+	// Important: Run "make generate" to regenerate code after modifying this file
+
+	// URL the notifications are POSTed to from the operator pod. The redirections aren't followed
+	// +kubebuilder:validation:Pattern=`^https?://`
+	URL string `json:"url"`
+	// Header holding the credentials of the notification requests, such as a bearer token
+	AuthHeader *NotificationAuthHeader `json:"authHeader,omitempty"`
+	// Events notified to the target, all the events when empty
+	Events []NotificationEvent `json:"events,omitempty"`
+	// Label selector for the ClusterGroupUpgrades notified to the target, among the ClusterGroupUpgrades of the
+	// namespace of the NotificationTarget. All the ClusterGroupUpgrades of the namespace when empty
+	ClusterGroupUpgradeSelector *metav1.LabelSelector `json:"clusterGroupUpgradeSelector,omitempty"`
+	// Go template of the body of the notification requests, rendered with the fields of the notification.
+	// The notification is sent as a JSON object when empty
+	PayloadTemplate string `json:"payloadTemplate,omitempty"`
+	// Content type of the body of the notification requests
+	// +kubebuilder:default="application/json"
+	ContentType string `json:"contentType,omitempty"`
+	// Number of times a failed delivery is retried, with an exponential backoff
+	// +kubebuilder:default=3
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=10
+	Retries *int `json:"retries,omitempty"`
+}
+
+// NotificationDelivery records the delivery of a notification
+type NotificationDelivery struct {
+	// ClusterGroupUpgrade notified (<namespace/name> string entry)
+	ClusterGroupUpgrade string            `json:"clusterGroupUpgrade"`
+	Event               NotificationEvent `json:"event"`
+	Cluster             string            `json:"cluster,omitempty"`
+	// State is Delivered or Failed
+	State    string `json:"state"`
+	Attempts int    `json:"attempts"`
+	// Message is the error of the last attempt, when the delivery failed
+	Message string      `json:"message,omitempty"`
+	Time    metav1.Time `json:"time"`
+}
+
+// NotificationTargetStatus defines the observed state of NotificationTarget
+type NotificationTargetStatus struct {
+	// Latest deliveries of notifications to the target, oldest first
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Deliveries"
+	Deliveries []NotificationDelivery `json:"deliveries,omitempty"`
+	// Number of notifications delivered
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Delivered"
+	Delivered int64 `json:"delivered,omitempty"`
+	// Number of notifications that could not be delivered
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Failed"
+	Failed int64 `json:"failed,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="URL",type="string",JSONPath=".spec.url"
+//+kubebuilder:printcolumn:name="Delivered",type="integer",JSONPath=".status.delivered"
+//+kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failed"
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// NotificationTarget is the Schema for the notificationtargets API
+type NotificationTarget struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   NotificationTargetSpec   `json:"spec,omitempty"`
+	Status NotificationTargetStatus `json:"status,omitempty"`
+}
+
+//+kubebuilder:object:root=true
+
+// NotificationTargetList contains a list of NotificationTarget
+type NotificationTargetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []NotificationTarget `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&NotificationTarget{}, &NotificationTargetList{})
+}
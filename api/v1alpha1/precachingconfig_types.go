@@ -35,6 +35,21 @@ type PlatformPreCachingSpec struct {
 	PreCacheImage string `json:"preCacheImage,omitempty"`
 }
 
+// ClusterPreCachingOverride modifies the pre-caching values for the clusters matching the selector.
+type ClusterPreCachingOverride struct {
+	// Name of the override, must be unique within the PreCachingConfig
+	Name string `json:"name"`
+	// Label selector for the ManagedClusters the override applies to. A cluster takes
+	// the first override it matches
+	ClusterSelector metav1.LabelSelector `json:"clusterSelector"`
+	// List of additional image pull specs, pre-cached in addition to spec.additionalImages
+	AdditionalImages []string `json:"additionalImages,omitempty"`
+	// List of patterns to exclude from pre-caching, in addition to spec.excludePrecachePatterns
+	ExcludePrecachePatterns []string `json:"excludePrecachePatterns,omitempty"`
+	// Replace the pre-cached OLM index images (list of image pull specs)
+	OperatorsIndexes []string `json:"operatorsIndexes,omitempty"`
+}
+
 // PreCachingConfigSpec defines the desired state of PreCachingConfig
 type PreCachingConfigSpec struct {
 	// Important: Run "make generate" to regenerate code after modifying this file
@@ -47,16 +62,33 @@ type PreCachingConfigSpec struct {
 	ExcludePrecachePatterns []string `json:"excludePrecachePatterns,omitempty"`
 	// List of additional image pull specs for the pre-caching job
 	AdditionalImages []string `json:"additionalImages,omitempty"`
+	// Per-cluster overrides selected by ManagedCluster labels
+	ClusterOverrides []ClusterPreCachingOverride `json:"clusterOverrides,omitempty"`
+}
+
+// PreCachingConfigStatus defines the observed state of PreCachingConfig
+type PreCachingConfigStatus struct {
+	// Conditions reporting the validation of the PreCachingConfig
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Conditions"
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ClusterGroupUpgrades referencing the PreCachingConfig (list of <namespace/name> string entries)
+	// +operator-sdk:csv:customresourcedefinitions:type=status,displayName="Referenced By"
+	ReferencedBy []string `json:"referencedBy,omitempty"`
 }
 
 //+kubebuilder:object:root=true
+//+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+//+kubebuilder:printcolumn:name="State",type="string",JSONPath=".status.conditions[?(@.type=='Validated')].reason"
+//+kubebuilder:printcolumn:name="Details",type="string",JSONPath=".status.conditions[?(@.type=='Validated')].message"
 
 // PreCachingConfig is the Schema for the precachingconfigs API
 type PreCachingConfig struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
-	Spec PreCachingConfigSpec `json:"spec,omitempty"`
+	Spec   PreCachingConfigSpec   `json:"spec,omitempty"`
+	Status PreCachingConfigStatus `json:"status,omitempty"`
 }
 
 //+kubebuilder:object:root=true
@@ -65,6 +65,11 @@ func (in *AfterCompletion) DeepCopyInto(out *AfterCompletion) {
 		*out = new(bool)
 		**out = **in
 	}
+	if in.UnpauseMachineConfigPools != nil {
+		in, out := &in.UnpauseMachineConfigPools, &out.UnpauseMachineConfigPools
+		*out = new(UnpauseMachineConfigPools)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AfterCompletion.
@@ -77,6 +82,41 @@ func (in *AfterCompletion) DeepCopy() *AfterCompletion {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupObjectStorage) DeepCopyInto(out *BackupObjectStorage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupObjectStorage.
+func (in *BackupObjectStorage) DeepCopy() *BackupObjectStorage {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupObjectStorage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupOptions) DeepCopyInto(out *BackupOptions) {
+	*out = *in
+	if in.ObjectStorage != nil {
+		in, out := &in.ObjectStorage, &out.ObjectStorage
+		*out = new(BackupObjectStorage)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupOptions.
+func (in *BackupOptions) DeepCopy() *BackupOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 	*out = *in
@@ -88,6 +128,20 @@ func (in *BackupStatus) DeepCopyInto(out *BackupStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.Reports != nil {
+		in, out := &in.Reports, &out.Reports
+		*out = make(map[string]ClusterBackupReport, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Cleanup != nil {
+		in, out := &in.Cleanup, &out.Cleanup
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 	if in.Clusters != nil {
 		in, out := &in.Clusters, &out.Clusters
 		*out = make([]string, len(*in))
@@ -122,6 +176,11 @@ func (in *BeforeEnable) DeepCopyInto(out *BeforeEnable) {
 			(*out)[key] = val
 		}
 	}
+	if in.PauseMachineConfigPools != nil {
+		in, out := &in.PauseMachineConfigPools, &out.PauseMachineConfigPools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BeforeEnable.
@@ -149,6 +208,23 @@ func (in *BlockingCR) DeepCopy() *BlockingCR {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterBackupReport) DeepCopyInto(out *ClusterBackupReport) {
+	*out = *in
+	out.Duration = in.Duration
+	in.CompletedAt.DeepCopyInto(&out.CompletedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterBackupReport.
+func (in *ClusterBackupReport) DeepCopy() *ClusterBackupReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterBackupReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterGroupUpgrade) DeepCopyInto(out *ClusterGroupUpgrade) {
 	*out = *in
@@ -208,9 +284,142 @@ func (in *ClusterGroupUpgradeList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGroupUpgradeReport) DeepCopyInto(out *ClusterGroupUpgradeReport) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGroupUpgradeReport.
+func (in *ClusterGroupUpgradeReport) DeepCopy() *ClusterGroupUpgradeReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGroupUpgradeReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterGroupUpgradeReport) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGroupUpgradeReportList) DeepCopyInto(out *ClusterGroupUpgradeReportList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterGroupUpgradeReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGroupUpgradeReportList.
+func (in *ClusterGroupUpgradeReportList) DeepCopy() *ClusterGroupUpgradeReportList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGroupUpgradeReportList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterGroupUpgradeReportList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterGroupUpgradeReportSpec) DeepCopyInto(out *ClusterGroupUpgradeReportSpec) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	in.CompletedAt.DeepCopyInto(&out.CompletedAt)
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.RemediationPlan != nil {
+		in, out := &in.RemediationPlan, &out.RemediationPlan
+		*out = make([][]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+		}
+	}
+	if in.ManagedPoliciesForUpgrade != nil {
+		in, out := &in.ManagedPoliciesForUpgrade, &out.ManagedPoliciesForUpgrade
+		*out = make([]ManagedPolicyForUpgrade, len(*in))
+		copy(*out, *in)
+	}
+	if in.ManagedPoliciesCompliantBeforeUpgrade != nil {
+		in, out := &in.ManagedPoliciesCompliantBeforeUpgrade, &out.ManagedPoliciesCompliantBeforeUpgrade
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterReport, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Precaching != nil {
+		in, out := &in.Precaching, &out.Precaching
+		*out = new(PrecachingStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(BackupStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(RestoreStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGroupUpgradeReportSpec.
+func (in *ClusterGroupUpgradeReportSpec) DeepCopy() *ClusterGroupUpgradeReportSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterGroupUpgradeReportSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterGroupUpgradeSpec) DeepCopyInto(out *ClusterGroupUpgradeSpec) {
 	*out = *in
+	if in.BackupOptions != nil {
+		in, out := &in.BackupOptions, &out.BackupOptions
+		*out = new(BackupOptions)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(RestoreSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	out.PreCachingConfigRef = in.PreCachingConfigRef
 	if in.Enable != nil {
 		in, out := &in.Enable, &out.Enable
@@ -250,6 +459,16 @@ func (in *ClusterGroupUpgradeSpec) DeepCopyInto(out *ClusterGroupUpgradeSpec) {
 		copy(*out, *in)
 	}
 	in.Actions.DeepCopyInto(&out.Actions)
+	if in.PlatformUpgrade != nil {
+		in, out := &in.PlatformUpgrade, &out.PlatformUpgrade
+		*out = new(PlatformUpgradeSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageBasedUpgrade != nil {
+		in, out := &in.ImageBasedUpgrade, &out.ImageBasedUpgrade
+		*out = new(ImageBasedUpgradeSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGroupUpgradeSpec.
@@ -336,7 +555,32 @@ func (in *ClusterGroupUpgradeStatus) DeepCopyInto(out *ClusterGroupUpgradeStatus
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.ClusterHistory != nil {
+		in, out := &in.ClusterHistory, &out.ClusterHistory
+		*out = make(map[string]*ClusterHistory, len(*in))
+		for key, val := range *in {
+			var outVal *ClusterHistory
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(ClusterHistory)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
 	in.Status.DeepCopyInto(&out.Status)
+	if in.DryRun != nil {
+		in, out := &in.DryRun, &out.DryRun
+		*out = new(DryRunStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Summary != nil {
+		in, out := &in.Summary, &out.Summary
+		*out = new(UpgradeSummary)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Precaching != nil {
 		in, out := &in.Precaching, &out.Precaching
 		*out = new(PrecachingStatus)
@@ -347,6 +591,31 @@ func (in *ClusterGroupUpgradeStatus) DeepCopyInto(out *ClusterGroupUpgradeStatus
 		*out = new(BackupStatus)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(RestoreStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageInventory != nil {
+		in, out := &in.ImageInventory, &out.ImageInventory
+		*out = new(ImageInventoryStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PlatformUpgrade != nil {
+		in, out := &in.PlatformUpgrade, &out.PlatformUpgrade
+		*out = new(PlatformUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageBasedUpgrade != nil {
+		in, out := &in.ImageBasedUpgrade, &out.ImageBasedUpgrade
+		*out = new(ImageBasedUpgradeStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MachineConfigPools != nil {
+		in, out := &in.MachineConfigPools, &out.MachineConfigPools
+		*out = make([]MachineConfigPoolStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterGroupUpgradeStatus.
@@ -360,127 +629,666 @@ func (in *ClusterGroupUpgradeStatus) DeepCopy() *ClusterGroupUpgradeStatus {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterRemediationProgress) DeepCopyInto(out *ClusterRemediationProgress) {
+func (in *ClusterHistory) DeepCopyInto(out *ClusterHistory) {
 	*out = *in
-	if in.PolicyIndex != nil {
-		in, out := &in.PolicyIndex, &out.PolicyIndex
-		*out = new(int)
-		**out = **in
+	if in.Transitions != nil {
+		in, out := &in.Transitions, &out.Transitions
+		*out = make([]ClusterTransition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	in.FirstCompliantAt.DeepCopyInto(&out.FirstCompliantAt)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterRemediationProgress.
-func (in *ClusterRemediationProgress) DeepCopy() *ClusterRemediationProgress {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterHistory.
+func (in *ClusterHistory) DeepCopy() *ClusterHistory {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterRemediationProgress)
+	out := new(ClusterHistory)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ClusterState) DeepCopyInto(out *ClusterState) {
+func (in *ClusterImageBasedUpgradeStatus) DeepCopyInto(out *ClusterImageBasedUpgradeStatus) {
 	*out = *in
-	if in.CurrentPolicy != nil {
-		in, out := &in.CurrentPolicy, &out.CurrentPolicy
-		*out = new(PolicyStatus)
-		**out = **in
+	if in.CompletedStages != nil {
+		in, out := &in.CompletedStages, &out.CompletedStages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterState.
-func (in *ClusterState) DeepCopy() *ClusterState {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterImageBasedUpgradeStatus.
+func (in *ClusterImageBasedUpgradeStatus) DeepCopy() *ClusterImageBasedUpgradeStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ClusterState)
+	out := new(ClusterImageBasedUpgradeStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ManagedPolicyForUpgrade) DeepCopyInto(out *ManagedPolicyForUpgrade) {
+func (in *ClusterImageInventory) DeepCopyInto(out *ClusterImageInventory) {
 	*out = *in
+	in.ReportedAt.DeepCopyInto(&out.ReportedAt)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedPolicyForUpgrade.
-func (in *ManagedPolicyForUpgrade) DeepCopy() *ManagedPolicyForUpgrade {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterImageInventory.
+func (in *ClusterImageInventory) DeepCopy() *ClusterImageInventory {
 	if in == nil {
 		return nil
 	}
-	out := new(ManagedPolicyForUpgrade)
+	out := new(ClusterImageInventory)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *NamespacedCR) DeepCopyInto(out *NamespacedCR) {
+func (in *ClusterPlan) DeepCopyInto(out *ClusterPlan) {
 	*out = *in
+	if in.NonCompliantPolicies != nil {
+		in, out := &in.NonCompliantPolicies, &out.NonCompliantPolicies
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedCR.
-func (in *NamespacedCR) DeepCopy() *NamespacedCR {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPlan.
+func (in *ClusterPlan) DeepCopy() *ClusterPlan {
 	if in == nil {
 		return nil
 	}
-	out := new(NamespacedCR)
+	out := new(ClusterPlan)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *OperatorUpgradeSpec) DeepCopyInto(out *OperatorUpgradeSpec) {
+func (in *ClusterPlatformUpgradeStatus) DeepCopyInto(out *ClusterPlatformUpgradeStatus) {
 	*out = *in
+	if in.CompletedVersions != nil {
+		in, out := &in.CompletedVersions, &out.CompletedVersions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorUpgradeSpec.
-func (in *OperatorUpgradeSpec) DeepCopy() *OperatorUpgradeSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPlatformUpgradeStatus.
+func (in *ClusterPlatformUpgradeStatus) DeepCopy() *ClusterPlatformUpgradeStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(OperatorUpgradeSpec)
+	out := new(ClusterPlatformUpgradeStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PlatformPreCachingSpec) DeepCopyInto(out *PlatformPreCachingSpec) {
+func (in *ClusterPreCachingOverride) DeepCopyInto(out *ClusterPreCachingOverride) {
 	*out = *in
-	if in.OperatorsIndexes != nil {
-		in, out := &in.OperatorsIndexes, &out.OperatorsIndexes
+	in.ClusterSelector.DeepCopyInto(&out.ClusterSelector)
+	if in.AdditionalImages != nil {
+		in, out := &in.AdditionalImages, &out.AdditionalImages
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
-	if in.OperatorsPackagesAndChannels != nil {
-		in, out := &in.OperatorsPackagesAndChannels, &out.OperatorsPackagesAndChannels
+	if in.ExcludePrecachePatterns != nil {
+		in, out := &in.ExcludePrecachePatterns, &out.ExcludePrecachePatterns
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OperatorsIndexes != nil {
+		in, out := &in.OperatorsIndexes, &out.OperatorsIndexes
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformPreCachingSpec.
-func (in *PlatformPreCachingSpec) DeepCopy() *PlatformPreCachingSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterPreCachingOverride.
+func (in *ClusterPreCachingOverride) DeepCopy() *ClusterPreCachingOverride {
 	if in == nil {
 		return nil
 	}
-	out := new(PlatformPreCachingSpec)
+	out := new(ClusterPreCachingOverride)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PolicyStatus) DeepCopyInto(out *PolicyStatus) {
+func (in *ClusterRemediationProgress) DeepCopyInto(out *ClusterRemediationProgress) {
 	*out = *in
+	if in.PolicyIndex != nil {
+		in, out := &in.PolicyIndex, &out.PolicyIndex
+		*out = new(int)
+		**out = **in
+	}
+	in.FirstCompliantAt.DeepCopyInto(&out.FirstCompliantAt)
+	in.PolicyStartedAt.DeepCopyInto(&out.PolicyStartedAt)
+	if in.ClusterVersion != nil {
+		in, out := &in.ClusterVersion, &out.ClusterVersion
+		*out = new(ClusterVersionProgress)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyStatus.
-func (in *PolicyStatus) DeepCopy() *PolicyStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterRemediationProgress.
+func (in *ClusterRemediationProgress) DeepCopy() *ClusterRemediationProgress {
 	if in == nil {
 		return nil
 	}
-	out := new(PolicyStatus)
+	out := new(ClusterRemediationProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterReport) DeepCopyInto(out *ClusterReport) {
+	*out = *in
+	if in.CurrentPolicy != nil {
+		in, out := &in.CurrentPolicy, &out.CurrentPolicy
+		*out = new(PolicyStatus)
+		**out = **in
+	}
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	in.CompletedAt.DeepCopyInto(&out.CompletedAt)
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterReport.
+func (in *ClusterReport) DeepCopy() *ClusterReport {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterReport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterState) DeepCopyInto(out *ClusterState) {
+	*out = *in
+	if in.CurrentPolicy != nil {
+		in, out := &in.CurrentPolicy, &out.CurrentPolicy
+		*out = new(PolicyStatus)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterState.
+func (in *ClusterState) DeepCopy() *ClusterState {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterState)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterTransition) DeepCopyInto(out *ClusterTransition) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterTransition.
+func (in *ClusterTransition) DeepCopy() *ClusterTransition {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterTransition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterVersionProgress) DeepCopyInto(out *ClusterVersionProgress) {
+	*out = *in
+	in.FailingSince.DeepCopyInto(&out.FailingSince)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterVersionProgress.
+func (in *ClusterVersionProgress) DeepCopy() *ClusterVersionProgress {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterVersionProgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DryRunStatus) DeepCopyInto(out *DryRunStatus) {
+	*out = *in
+	in.ComputedAt.DeepCopyInto(&out.ComputedAt)
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]ClusterPlan, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DryRunStatus.
+func (in *DryRunStatus) DeepCopy() *DryRunStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(DryRunStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageBasedUpgradeSpec) DeepCopyInto(out *ImageBasedUpgradeSpec) {
+	*out = *in
+	out.SeedImageRef = in.SeedImageRef
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AutoRollbackOnFailure != nil {
+		in, out := &in.AutoRollbackOnFailure, &out.AutoRollbackOnFailure
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageBasedUpgradeSpec.
+func (in *ImageBasedUpgradeSpec) DeepCopy() *ImageBasedUpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageBasedUpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageBasedUpgradeStatus) DeepCopyInto(out *ImageBasedUpgradeStatus) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make(map[string]*ClusterImageBasedUpgradeStatus, len(*in))
+		for key, val := range *in {
+			var outVal *ClusterImageBasedUpgradeStatus
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(ClusterImageBasedUpgradeStatus)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageBasedUpgradeStatus.
+func (in *ImageBasedUpgradeStatus) DeepCopy() *ImageBasedUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageBasedUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageInventoryStatus) DeepCopyInto(out *ImageInventoryStatus) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make(map[string]ClusterImageInventory, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageInventoryStatus.
+func (in *ImageInventoryStatus) DeepCopy() *ImageInventoryStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageInventoryStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MachineConfigPoolStatus) DeepCopyInto(out *MachineConfigPoolStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MachineConfigPoolStatus.
+func (in *MachineConfigPoolStatus) DeepCopy() *MachineConfigPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(MachineConfigPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManagedPolicyForUpgrade) DeepCopyInto(out *ManagedPolicyForUpgrade) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManagedPolicyForUpgrade.
+func (in *ManagedPolicyForUpgrade) DeepCopy() *ManagedPolicyForUpgrade {
+	if in == nil {
+		return nil
+	}
+	out := new(ManagedPolicyForUpgrade)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NamespacedCR) DeepCopyInto(out *NamespacedCR) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NamespacedCR.
+func (in *NamespacedCR) DeepCopy() *NamespacedCR {
+	if in == nil {
+		return nil
+	}
+	out := new(NamespacedCR)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationAuthHeader) DeepCopyInto(out *NotificationAuthHeader) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationAuthHeader.
+func (in *NotificationAuthHeader) DeepCopy() *NotificationAuthHeader {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationAuthHeader)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationDelivery) DeepCopyInto(out *NotificationDelivery) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationDelivery.
+func (in *NotificationDelivery) DeepCopy() *NotificationDelivery {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationDelivery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationTarget) DeepCopyInto(out *NotificationTarget) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationTarget.
+func (in *NotificationTarget) DeepCopy() *NotificationTarget {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationTarget)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NotificationTarget) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationTargetList) DeepCopyInto(out *NotificationTargetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]NotificationTarget, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationTargetList.
+func (in *NotificationTargetList) DeepCopy() *NotificationTargetList {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationTargetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *NotificationTargetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationTargetSpec) DeepCopyInto(out *NotificationTargetSpec) {
+	*out = *in
+	if in.AuthHeader != nil {
+		in, out := &in.AuthHeader, &out.AuthHeader
+		*out = new(NotificationAuthHeader)
+		**out = **in
+	}
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]NotificationEvent, len(*in))
+		copy(*out, *in)
+	}
+	if in.ClusterGroupUpgradeSelector != nil {
+		in, out := &in.ClusterGroupUpgradeSelector, &out.ClusterGroupUpgradeSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Retries != nil {
+		in, out := &in.Retries, &out.Retries
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationTargetSpec.
+func (in *NotificationTargetSpec) DeepCopy() *NotificationTargetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationTargetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotificationTargetStatus) DeepCopyInto(out *NotificationTargetStatus) {
+	*out = *in
+	if in.Deliveries != nil {
+		in, out := &in.Deliveries, &out.Deliveries
+		*out = make([]NotificationDelivery, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotificationTargetStatus.
+func (in *NotificationTargetStatus) DeepCopy() *NotificationTargetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NotificationTargetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OperatorUpgradeSpec) DeepCopyInto(out *OperatorUpgradeSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OperatorUpgradeSpec.
+func (in *OperatorUpgradeSpec) DeepCopy() *OperatorUpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OperatorUpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformPreCachingSpec) DeepCopyInto(out *PlatformPreCachingSpec) {
+	*out = *in
+	if in.OperatorsIndexes != nil {
+		in, out := &in.OperatorsIndexes, &out.OperatorsIndexes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.OperatorsPackagesAndChannels != nil {
+		in, out := &in.OperatorsPackagesAndChannels, &out.OperatorsPackagesAndChannels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformPreCachingSpec.
+func (in *PlatformPreCachingSpec) DeepCopy() *PlatformPreCachingSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformPreCachingSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformUpgradeSpec) DeepCopyInto(out *PlatformUpgradeSpec) {
+	*out = *in
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]PlatformUpgradeVersion, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformUpgradeSpec.
+func (in *PlatformUpgradeSpec) DeepCopy() *PlatformUpgradeSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformUpgradeSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformUpgradeStatus) DeepCopyInto(out *PlatformUpgradeStatus) {
+	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make(map[string]*ClusterPlatformUpgradeStatus, len(*in))
+		for key, val := range *in {
+			var outVal *ClusterPlatformUpgradeStatus
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(ClusterPlatformUpgradeStatus)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformUpgradeStatus.
+func (in *PlatformUpgradeStatus) DeepCopy() *PlatformUpgradeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformUpgradeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlatformUpgradeVersion) DeepCopyInto(out *PlatformUpgradeVersion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlatformUpgradeVersion.
+func (in *PlatformUpgradeVersion) DeepCopy() *PlatformUpgradeVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(PlatformUpgradeVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PolicyStatus) DeepCopyInto(out *PolicyStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PolicyStatus.
+func (in *PolicyStatus) DeepCopy() *PolicyStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PolicyStatus)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -491,6 +1299,7 @@ func (in *PreCachingConfig) DeepCopyInto(out *PreCachingConfig) {
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreCachingConfig.
@@ -572,6 +1381,13 @@ func (in *PreCachingConfigSpec) DeepCopyInto(out *PreCachingConfigSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.ClusterOverrides != nil {
+		in, out := &in.ClusterOverrides, &out.ClusterOverrides
+		*out = make([]ClusterPreCachingOverride, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreCachingConfigSpec.
@@ -584,6 +1400,33 @@ func (in *PreCachingConfigSpec) DeepCopy() *PreCachingConfigSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreCachingConfigStatus) DeepCopyInto(out *PreCachingConfigStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ReferencedBy != nil {
+		in, out := &in.ReferencedBy, &out.ReferencedBy
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreCachingConfigStatus.
+func (in *PreCachingConfigStatus) DeepCopy() *PreCachingConfigStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PreCachingConfigStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PrecachingSpec) DeepCopyInto(out *PrecachingSpec) {
 	*out = *in
@@ -597,6 +1440,11 @@ func (in *PrecachingSpec) DeepCopyInto(out *PrecachingSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.OperatorsImages != nil {
+		in, out := &in.OperatorsImages, &out.OperatorsImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.ExcludePrecachePatterns != nil {
 		in, out := &in.ExcludePrecachePatterns, &out.ExcludePrecachePatterns
 		*out = make([]string, len(*in))
@@ -607,6 +1455,11 @@ func (in *PrecachingSpec) DeepCopyInto(out *PrecachingSpec) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.IntermediatePlatformImages != nil {
+		in, out := &in.IntermediatePlatformImages, &out.IntermediatePlatformImages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrecachingSpec.
@@ -634,6 +1487,33 @@ func (in *PrecachingStatus) DeepCopyInto(out *PrecachingStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.ClusterOverrides != nil {
+		in, out := &in.ClusterOverrides, &out.ClusterOverrides
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.OverrideSpecs != nil {
+		in, out := &in.OverrideSpecs, &out.OverrideSpecs
+		*out = make(map[string]*PrecachingSpec, len(*in))
+		for key, val := range *in {
+			var outVal *PrecachingSpec
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = new(PrecachingSpec)
+				(*in).DeepCopyInto(*out)
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.SkippedClusters != nil {
+		in, out := &in.SkippedClusters, &out.SkippedClusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Clusters != nil {
 		in, out := &in.Clusters, &out.Clusters
 		*out = make([]string, len(*in))
@@ -671,6 +1551,84 @@ func (in *RemediationStrategySpec) DeepCopy() *RemediationStrategySpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
+	*out = *in
+	if in.Clusters != nil {
+		in, out := &in.Clusters, &out.Clusters
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreSpec.
+func (in *RestoreSpec) DeepCopy() *RestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreStatus) DeepCopyInto(out *RestoreStatus) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.Status != nil {
+		in, out := &in.Status, &out.Status
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreStatus.
+func (in *RestoreStatus) DeepCopy() *RestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SeedImageRef) DeepCopyInto(out *SeedImageRef) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SeedImageRef.
+func (in *SeedImageRef) DeepCopy() *SeedImageRef {
+	if in == nil {
+		return nil
+	}
+	out := new(SeedImageRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnpauseMachineConfigPools) DeepCopyInto(out *UnpauseMachineConfigPools) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnpauseMachineConfigPools.
+func (in *UnpauseMachineConfigPools) DeepCopy() *UnpauseMachineConfigPools {
+	if in == nil {
+		return nil
+	}
+	out := new(UnpauseMachineConfigPools)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UpgradeStatus) DeepCopyInto(out *UpgradeStatus) {
 	*out = *in
@@ -703,3 +1661,22 @@ func (in *UpgradeStatus) DeepCopy() *UpgradeStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UpgradeSummary) DeepCopyInto(out *UpgradeSummary) {
+	*out = *in
+	if in.EstimatedCompletionTime != nil {
+		in, out := &in.EstimatedCompletionTime, &out.EstimatedCompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UpgradeSummary.
+func (in *UpgradeSummary) DeepCopy() *UpgradeSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(UpgradeSummary)
+	in.DeepCopyInto(out)
+	return out
+}
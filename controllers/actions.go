@@ -6,6 +6,7 @@ import (
 
 	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
 	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
@@ -43,6 +44,8 @@ func (r *ClusterGroupUpgradeReconciler) takeActionsAfterCompletion(
 	clusterState := ranv1alpha1.ClusterState{
 		Name: cluster, State: utils.ClusterRemediationComplete}
 	clusterGroupUpgrade.Status.Clusters = append(clusterGroupUpgrade.Status.Clusters, clusterState)
+	r.recordClusterTransition(clusterGroupUpgrade, cluster, corev1.EventTypeNormal,
+		eventReasonClusterRemediationComplete, clusterState.State, "remediation completed")
 
 	actionsAfterCompletion := clusterGroupUpgrade.Spec.Actions.AfterCompletion
 	// Add/delete cluster labels
@@ -153,6 +156,11 @@ func (r *ClusterGroupUpgradeReconciler) deleteResources(
 	}
 	clusterGroupUpgrade.Status.CopiedPolicies = nil
 
+	err = r.deleteJobSecretsPolicy(ctx, clusterGroupUpgrade, "")
+	if err != nil {
+		return fmt.Errorf("failed to delete the backup secrets policies for CGU %s: %v", clusterGroupUpgrade.Name, err)
+	}
+
 	err = r.jobAndViewFinalCleanup(ctx, clusterGroupUpgrade)
 	if err != nil {
 		return fmt.Errorf("failed to delete precaching objects for CGU %s: %v", clusterGroupUpgrade.Name, err)
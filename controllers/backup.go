@@ -3,6 +3,7 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
@@ -10,6 +11,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // Backup states
@@ -23,10 +25,37 @@ const (
 )
 
 const (
-	backupJobTimeout       = 480
-	backupJobTimeoutBuffer = 720
+	defaultBackupTimeout    = 8 * time.Minute
+	backupJobTimeoutBuffer  = 720
+	backupCleanupJobTimeout = 300
 )
 
+// backupEncryptionKey is the entry of the backup encryption key secret holding the key
+const backupEncryptionKey = "key"
+
+// Backup report configmap keys
+const (
+	backupReportSizeKey        = "sizeBytes"
+	backupReportDurationKey    = "durationSeconds"
+	backupReportCompletedAtKey = "completedAt"
+	backupReportObjectKeyKey   = "objectKey"
+)
+
+// Entries of the backup object storage secret copied to the spokes
+var (
+	backupObjectStorageRequiredEntries = []string{"endpoint", "bucket", "accessKeyId", "secretAccessKey"}
+	backupObjectStorageOptionalEntries = []string{"region", "prefix", "ca.crt"}
+)
+
+// getBackupTimeout returns the maximum time for the backup of a cluster
+// returns: time.Duration
+func getBackupTimeout(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) time.Duration {
+	if clusterGroupUpgrade.Spec.BackupOptions == nil || clusterGroupUpgrade.Spec.BackupOptions.Timeout <= 0 {
+		return defaultBackupTimeout
+	}
+	return time.Duration(clusterGroupUpgrade.Spec.BackupOptions.Timeout) * time.Minute
+}
+
 func (r *ClusterGroupUpgradeReconciler) reconcileBackup(
 	ctx context.Context,
 	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
@@ -45,8 +74,8 @@ func (r *ClusterGroupUpgradeReconciler) reconcileBackup(
 		backupCondition := meta.FindStatusCondition(clusterGroupUpgrade.Status.Conditions, string(utils.ConditionTypes.BackupSuceeded))
 		r.Log.Info("[reconcileBackup]", "FindStatusCondition", backupCondition)
 		if backupCondition != nil && backupCondition.Status == metav1.ConditionTrue {
-			// Backup is done
-			return nil
+			// Backup is done, the secrets of the backup job are no longer needed on the clusters
+			return r.deleteJobSecretsPolicy(ctx, clusterGroupUpgrade, backup)
 		}
 
 		// Backup is required and not marked as done
@@ -60,7 +89,14 @@ func (r *ClusterGroupUpgradeReconciler) triggerBackup(ctx context.Context,
 	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
 	clusters []string) error {
 
-	isTimedOut := time.Since(clusterGroupUpgrade.Status.Backup.StartedAt.Time) > time.Duration(backupJobTimeout+backupJobTimeoutBuffer)*time.Second
+	isTimedOut := time.Since(clusterGroupUpgrade.Status.Backup.StartedAt.Time) >
+		getBackupTimeout(clusterGroupUpgrade)+backupJobTimeoutBuffer*time.Second
+
+	secretsPolicy, err := r.ensureJobSecretsPolicy(ctx, clusterGroupUpgrade, backup, backupNamespace,
+		clusters, getBackupJobSecrets(clusterGroupUpgrade, backup))
+	if err != nil {
+		return err
+	}
 
 	for _, cluster := range clusters {
 		var (
@@ -76,13 +112,13 @@ func (r *ClusterGroupUpgradeReconciler) triggerBackup(ctx context.Context,
 		switch currentState {
 		// Initial State
 		case BackupStatePreparingToStart:
-			nextState, err = r.backupPreparing(ctx, cluster)
+			nextState, err = r.backupPreparing(ctx, clusterGroupUpgrade, cluster)
 
 		case BackupStateStarting:
-			nextState, err = r.backupStarting(ctx, clusterGroupUpgrade, cluster)
+			nextState, err = r.backupStarting(ctx, clusterGroupUpgrade, cluster, secretsPolicy)
 
 		case BackupStateActive:
-			nextState, err = r.backupActive(ctx, cluster)
+			nextState, err = r.backupActive(ctx, clusterGroupUpgrade, cluster)
 
 		// Final states that don't change for the life of the CR
 		case BackupStateSucceeded, BackupStateTimeout, BackupStateError:
@@ -114,6 +150,10 @@ func (r *ClusterGroupUpgradeReconciler) triggerBackup(ctx context.Context,
 				continue
 			}
 		}
+		if currentState != nextState {
+			r.recordFsmTransition(clusterGroupUpgrade, cluster, eventReasonClusterBackup, "backup",
+				currentState, nextState, BackupStateTimeout, BackupStateError)
+		}
 		clusterGroupUpgrade.Status.Backup.Status[cluster] = nextState
 	}
 	r.checkAllBackupDone(clusterGroupUpgrade)
@@ -122,7 +162,8 @@ func (r *ClusterGroupUpgradeReconciler) triggerBackup(ctx context.Context,
 
 // backupPreparing handles conditions in BackupStatePreparingToStart
 // returns: error
-func (r *ClusterGroupUpgradeReconciler) backupPreparing(ctx context.Context, cluster string) (string, error) {
+func (r *ClusterGroupUpgradeReconciler) backupPreparing(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (string, error) {
 
 	currentState, nextState := BackupStatePreparingToStart, BackupStateStarting
 	r.Log.Info("[triggerBackup]", "currentState", currentState, "condition", "entry",
@@ -134,7 +175,7 @@ func (r *ClusterGroupUpgradeReconciler) backupPreparing(ctx context.Context, clu
 		return currentState, err
 	}
 
-	spec, err := r.getBackupJobTemplateData(cluster)
+	spec, err := r.getBackupJobTemplateData(clusterGroupUpgrade, cluster)
 	if err != nil {
 		return currentState, err
 	}
@@ -149,10 +190,11 @@ func (r *ClusterGroupUpgradeReconciler) backupPreparing(ctx context.Context, clu
 	return nextState, nil
 }
 
-// backupStarting handles conditions in BackupStateStarting
+// backupStarting handles conditions in BackupStateStarting. The job is deployed once the policy copying its
+// secrets is compliant on the cluster
 // returns: error
 func (r *ClusterGroupUpgradeReconciler) backupStarting(ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
-	cluster string) (string, error) {
+	cluster, secretsPolicy string) (string, error) {
 
 	nextState, currentState := BackupStateStarting, BackupStateStarting
 	var condition string
@@ -162,7 +204,7 @@ func (r *ClusterGroupUpgradeReconciler) backupStarting(ctx context.Context, clus
 		return currentState, err
 	}
 	r.Log.Info("[starting]", "starting started condition: ", condition)
-	spec, err := r.getBackupJobTemplateData(cluster)
+	spec, err := r.getBackupJobTemplateData(clusterGroupUpgrade, cluster)
 	if err != nil {
 		return currentState, err
 	}
@@ -170,7 +212,7 @@ func (r *ClusterGroupUpgradeReconciler) backupStarting(ctx context.Context, clus
 	r.Log.Info("[starting]", "conditions: ", condition)
 	switch condition {
 	case DependenciesNotPresent:
-		err := r.createResourcesFromTemplates(ctx, spec, backupDependenciesCreateTemplates)
+		err = r.createResourcesFromTemplates(ctx, spec, backupDependenciesCreateTemplates)
 		if err != nil {
 			return currentState, err
 		}
@@ -178,17 +220,20 @@ func (r *ClusterGroupUpgradeReconciler) backupStarting(ctx context.Context, clus
 	case NoJobView, NoJobFoundOnSpoke:
 		r.Log.Info("[triggerbackup]", "currentState", currentState, "condition", NoJobFoundOnSpoke,
 			"cluster", cluster, "nextState", BackupStateStarting)
+		delivered, err := r.areJobSecretsDelivered(ctx, clusterGroupUpgrade, secretsPolicy, cluster)
+		if err != nil || !delivered {
+			r.Log.Info("[starting] waiting for the backup secrets", "cluster", cluster, "policy", secretsPolicy)
+			return currentState, err
+		}
 		err = r.deployWorkload(ctx, clusterGroupUpgrade, cluster, backup, backupJobView[0].resourceName, backupCreateTemplates)
 		if err != nil {
 			return currentState, err
 		}
 
-	case JobActive:
+	case JobActive, JobSucceeded:
+		// The backup is reported in the active state
 		nextState = BackupStateActive
 
-	case JobSucceeded:
-		nextState = BackupStateSucceeded
-
 	case JobDeadline:
 		nextState = BackupStateTimeout
 
@@ -205,7 +250,8 @@ func (r *ClusterGroupUpgradeReconciler) backupStarting(ctx context.Context, clus
 
 // backupActive handles conditions in BackupStateActive
 // returns: error
-func (r *ClusterGroupUpgradeReconciler) backupActive(ctx context.Context, cluster string) (string, error) {
+func (r *ClusterGroupUpgradeReconciler) backupActive(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (string, error) {
 
 	nextState, currentState := BackupStateActive, BackupStateActive
 	// log nextState, to be deleted
@@ -221,7 +267,13 @@ func (r *ClusterGroupUpgradeReconciler) backupActive(ctx context.Context, cluste
 		nextState = BackupStateActive
 
 	case JobSucceeded:
-		nextState = BackupStateSucceeded
+		// Wait for the view of the backup report to be refreshed
+		reported, err := r.collectBackupReport(ctx, clusterGroupUpgrade, cluster)
+		if err != nil || !reported {
+			return currentState, err
+		}
+		// The backup succeeds once verified against its manifest
+		return r.verifyBackup(ctx, clusterGroupUpgrade, cluster)
 
 	case JobDeadline:
 		nextState = BackupStateTimeout
@@ -239,6 +291,49 @@ func (r *ClusterGroupUpgradeReconciler) backupActive(ctx context.Context, cluste
 
 }
 
+// verifyBackup runs the job verifying the backup content against the manifest written by the backup job
+// returns: string - the next backup state
+//
+//	error
+func (r *ClusterGroupUpgradeReconciler) verifyBackup(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (string, error) {
+
+	nextState, currentState := BackupStateActive, BackupStateActive
+
+	condition, err := r.getActiveConditions(ctx, cluster, backupVerifyJobView[0].resourceName)
+	if err != nil {
+		return currentState, err
+	}
+	r.Log.Info("[verifyBackup]", "conditions: ", condition)
+
+	switch condition {
+	case NoJobView, NoJobFoundOnSpoke:
+		err = r.deployWorkload(ctx, clusterGroupUpgrade, cluster, backupVerify,
+			backupVerifyJobView[0].resourceName, backupVerifyCreateTemplates)
+		if err != nil {
+			return currentState, err
+		}
+
+	case JobActive:
+		nextState = BackupStateActive
+
+	case JobSucceeded:
+		nextState = BackupStateSucceeded
+
+	case JobDeadline:
+		nextState = BackupStateTimeout
+
+	case JobBackoffLimitExceeded:
+		// The backup doesn't match its manifest
+		nextState = BackupStateError
+
+	default:
+		return currentState, fmt.Errorf(
+			"[verifyBackup] unknown condition %v in %s state", condition, currentState)
+	}
+	return nextState, nil
+}
+
 // checkAllBackupDone handles alleviation of BackupDone==False condition
 func (r *ClusterGroupUpgradeReconciler) checkAllBackupDone(
 	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) {
@@ -301,3 +396,257 @@ func (r *ClusterGroupUpgradeReconciler) checkAllBackupDone(
 	}
 
 }
+
+// parseBackupReport parses the backup reported by the job in the backup report configmap
+// returns: ranv1alpha1.ClusterBackupReport
+//
+//	error
+func parseBackupReport(data map[string]string) (ranv1alpha1.ClusterBackupReport, error) {
+	var report ranv1alpha1.ClusterBackupReport
+	sizeBytes, err := strconv.ParseInt(data[backupReportSizeKey], 10, 64)
+	if err != nil {
+		return report, fmt.Errorf("invalid backup size: %w", err)
+	}
+	durationSeconds, err := strconv.ParseInt(data[backupReportDurationKey], 10, 64)
+	if err != nil {
+		return report, fmt.Errorf("invalid backup duration: %w", err)
+	}
+	completedAt, err := time.Parse(time.RFC3339, data[backupReportCompletedAtKey])
+	if err != nil {
+		return report, fmt.Errorf("invalid backup completion time: %w", err)
+	}
+	report.SizeBytes = sizeBytes
+	report.Duration = metav1.Duration{Duration: time.Duration(durationSeconds) * time.Second}
+	report.CompletedAt = metav1.NewTime(completedAt)
+	// Only reported when the backup is uploaded to an object storage
+	report.ObjectKey = data[backupReportObjectKeyKey]
+	return report, nil
+}
+
+// collectBackupReport reads the backup reported by the spoke job and stores it in the CGU status.
+// Recovery images that don't report the backup leave the configmap missing, jobs deployed without the view
+// of the report are not waited for either
+// returns: bool - true when the backup was reported or is not going to be
+//
+//	error
+func (r *ClusterGroupUpgradeReconciler) collectBackupReport(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (bool, error) {
+
+	view, present, err := r.getView(ctx, backupCreateTemplates[2].resourceName, cluster)
+	if err != nil {
+		return false, err
+	}
+	if !present {
+		// The job was deployed without the view of the report
+		r.Log.Info("[collectBackupReport] No backup report view", "cluster", cluster)
+		return true, nil
+	}
+	viewConditions, exists, err := unstructured.NestedSlice(view.Object, jobsInitialStatus...)
+	if err != nil || !exists {
+		return false, err
+	}
+	processing, err := checkViewProcessing(viewConditions)
+	if err != nil {
+		return false, err
+	}
+	if !processing {
+		r.Log.Info("[collectBackupReport] Backup not reported", "cluster", cluster)
+		return true, nil
+	}
+	data, found, err := unstructured.NestedStringMap(view.Object, "status", "result", "data")
+	if err != nil || !found {
+		return false, err
+	}
+	report, err := parseBackupReport(data)
+	if err != nil {
+		return false, err
+	}
+	if clusterGroupUpgrade.Status.Backup.Reports == nil {
+		clusterGroupUpgrade.Status.Backup.Reports = make(map[string]ranv1alpha1.ClusterBackupReport)
+	}
+	clusterGroupUpgrade.Status.Backup.Reports[cluster] = report
+	return true, nil
+}
+
+// getBackupCleanupClusters returns the clusters whose backup content is cleaned: the clusters that completed
+// the upgrade with a successful backup, with the Clean retention. The clusters to restore keep their backup
+// returns: []string
+func getBackupCleanupClusters(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) []string {
+	options := clusterGroupUpgrade.Spec.BackupOptions
+	if options == nil || options.Retention != ranv1alpha1.BackupRetentionClean || clusterGroupUpgrade.Status.Backup == nil {
+		return nil
+	}
+	restoreClusters := make(map[string]bool)
+	for _, cluster := range getRestoreClusters(clusterGroupUpgrade) {
+		restoreClusters[cluster] = true
+	}
+	var clusters []string
+	for _, clusterState := range clusterGroupUpgrade.Status.Clusters {
+		if clusterState.State == utils.ClusterRemediationComplete && !restoreClusters[clusterState.Name] &&
+			clusterGroupUpgrade.Status.Backup.Status[clusterState.Name] == BackupStateSucceeded {
+			clusters = append(clusters, clusterState.Name)
+		}
+	}
+	return clusters
+}
+
+// reconcileBackupCleanup cleans the backup content of the clusters once the upgrade is completed
+// returns: bool - true when all the clusters reached a final cleanup state
+//
+//	error
+func (r *ClusterGroupUpgradeReconciler) reconcileBackupCleanup(
+	ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) (bool, error) {
+
+	clusters := getBackupCleanupClusters(clusterGroupUpgrade)
+	if len(clusters) == 0 {
+		return true, nil
+	}
+	if clusterGroupUpgrade.Status.Backup.Cleanup == nil {
+		clusterGroupUpgrade.Status.Backup.Cleanup = make(map[string]string)
+	}
+
+	isTimedOut := time.Since(clusterGroupUpgrade.Status.Status.CompletedAt.Time) >
+		time.Duration(backupCleanupJobTimeout+backupJobTimeoutBuffer)*time.Second
+	done := true
+
+	for _, cluster := range clusters {
+		var (
+			currentState, nextState string
+			err                     error
+			ok                      bool
+		)
+		if currentState, ok = clusterGroupUpgrade.Status.Backup.Cleanup[cluster]; !ok {
+			currentState = BackupStatePreparingToStart
+		}
+
+		r.Log.Info("[reconcileBackupCleanup]", "currentState", currentState, "cluster", cluster)
+		switch currentState {
+		// Initial State
+		case BackupStatePreparingToStart:
+			nextState, err = r.backupCleanupPreparing(ctx, clusterGroupUpgrade, cluster)
+
+		case BackupStateStarting, BackupStateActive:
+			nextState, err = r.backupCleanupRunning(ctx, clusterGroupUpgrade, cluster, currentState)
+
+		// Final states that don't change for the life of the CR
+		case BackupStateSucceeded, BackupStateTimeout, BackupStateError:
+			r.Log.Info("[reconcileBackupCleanup]", "cluster", cluster, "final state", currentState)
+			continue
+
+		default:
+			return false, fmt.Errorf("[reconcileBackupCleanup] unknown state %s", currentState)
+		}
+
+		if err != nil {
+			r.Log.Info("[reconcileBackupCleanup]", "cluster", cluster, "err", err)
+		}
+
+		if isTimedOut && (nextState == BackupStatePreparingToStart || nextState == BackupStateStarting ||
+			nextState == BackupStateActive) {
+			nextState = BackupStateTimeout
+		}
+
+		if currentState != nextState {
+			r.Log.Info("[reconcileBackupCleanup]", "previousState", currentState, "nextState", nextState, "cluster", cluster)
+		}
+		if nextState == BackupStateSucceeded {
+			// cleanup for succeeded clusters
+			if err := r.jobAndViewCleanup(ctx, cluster, append(backupCleanupViews, backupCleanupMCAs...), backupDeleteTemplates); err != nil {
+				r.Log.Error(err, "[reconcileBackupCleanup] failed to cleanup for", "cluster", cluster)
+				// skip cluster status transition if cleanup not successful
+				done = false
+				continue
+			}
+		}
+		clusterGroupUpgrade.Status.Backup.Cleanup[cluster] = nextState
+		if nextState != BackupStateSucceeded && nextState != BackupStateTimeout && nextState != BackupStateError {
+			done = false
+		}
+	}
+	return done, nil
+}
+
+// backupCleanupPreparing handles conditions in BackupStatePreparingToStart of the backup cleanup
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) backupCleanupPreparing(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (string, error) {
+
+	currentState, nextState := BackupStatePreparingToStart, BackupStateStarting
+
+	// delete the managedclusterview and managedclusteraction objects left by the backup
+	err := r.deleteManagedClusterResources(ctx, cluster, append(append(backupViews, backupMCAs...), backupCleanupViews...))
+	if err != nil {
+		return currentState, err
+	}
+
+	spec, err := r.getBackupJobTemplateData(clusterGroupUpgrade, cluster)
+	if err != nil {
+		return currentState, err
+	}
+
+	// delete namespace in the spoke with managedclusteraction
+	err = r.createResourcesFromTemplates(ctx, spec, backupDeleteTemplates)
+	if err != nil {
+		return currentState, err
+	}
+	return nextState, nil
+}
+
+// backupCleanupRunning handles conditions in BackupStateStarting and BackupStateActive of the backup cleanup
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) backupCleanupRunning(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster, currentState string) (string, error) {
+
+	nextState := currentState
+	var condition string
+	var err error
+	if currentState == BackupStateStarting {
+		condition, err = r.getStartingConditions(ctx, cluster, backupCleanupJobView[0].resourceName, backup)
+	} else {
+		condition, err = r.getActiveConditions(ctx, cluster, backupCleanupJobView[0].resourceName)
+	}
+	if err != nil {
+		return currentState, err
+	}
+	r.Log.Info("[backupCleanupRunning]", "conditions: ", condition)
+
+	switch condition {
+	case DependenciesNotPresent:
+		spec, err := r.getBackupJobTemplateData(clusterGroupUpgrade, cluster)
+		if err != nil {
+			return currentState, err
+		}
+		err = r.createResourcesFromTemplates(ctx, spec, backupDependenciesCreateTemplates)
+		if err != nil {
+			return currentState, err
+		}
+
+	case NoJobView, NoJobFoundOnSpoke:
+		if currentState == BackupStateActive {
+			return currentState, fmt.Errorf("[backupCleanupRunning] backup cleanup job not found")
+		}
+		err = r.deployWorkload(ctx, clusterGroupUpgrade, cluster, backupCleanup,
+			backupCleanupJobView[0].resourceName, backupCleanupCreateTemplates)
+		if err != nil {
+			return currentState, err
+		}
+
+	case JobActive:
+		nextState = BackupStateActive
+
+	case JobSucceeded:
+		nextState = BackupStateSucceeded
+
+	case JobDeadline:
+		nextState = BackupStateTimeout
+
+	case JobBackoffLimitExceeded:
+		nextState = BackupStateError
+
+	default:
+		return currentState, fmt.Errorf(
+			"[backupCleanupRunning] unknown condition %v in %s state", condition, currentState)
+	}
+	return nextState, nil
+}
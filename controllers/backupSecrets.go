@@ -0,0 +1,282 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// Namespaces of the backup and restore jobs on the clusters
+const (
+	backupNamespace  = "openshift-talo-backup"
+	restoreNamespace = "openshift-talo-restore"
+)
+
+// jobSecret is a secret of the ClusterGroupUpgrade namespace copied to the clusters for a backup or restore job
+type jobSecret struct {
+	// name of the secret created in the namespace of the job
+	name string
+	// source is the name of the secret in the ClusterGroupUpgrade namespace
+	source string
+	// entries copied from the source secret
+	entries []string
+}
+
+// getBackupJobSecrets returns the secrets mounted by the backup or restore job
+// returns: []jobSecret
+func getBackupJobSecrets(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, job string) []jobSecret {
+	var secrets []jobSecret
+	options := clusterGroupUpgrade.Spec.BackupOptions
+	if options == nil {
+		return secrets
+	}
+	if options.EncryptionKeySecret != "" {
+		secrets = append(secrets, jobSecret{
+			name:    "backup-encryption-key",
+			source:  options.EncryptionKeySecret,
+			entries: []string{backupEncryptionKey},
+		})
+	}
+	// Only the backup job uploads the backup. The entries missing from the source secret are copied empty,
+	// and the required ones are checked by the job
+	if options.ObjectStorage != nil && job == backup {
+		secrets = append(secrets, jobSecret{
+			name:    "backup-object-storage",
+			source:  options.ObjectStorage.Secret,
+			entries: append(append([]string{}, backupObjectStorageRequiredEntries...), backupObjectStorageOptionalEntries...),
+		})
+	}
+	return secrets
+}
+
+// newJobSecretsPolicy builds the policy enforcing the secrets of a job on the clusters. The secret data are
+// hub templates resolved by the policy propagator from the secrets of the ClusterGroupUpgrade namespace, so
+// that the data are only held by secrets on the hub and encrypted in the policies replicated to the clusters
+// returns: *unstructured.Unstructured
+func newJobSecretsPolicy(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, job, name, namespace string,
+	secrets []jobSecret) *unstructured.Unstructured {
+
+	var objectTemplates []interface{}
+	for _, secret := range secrets {
+		data := make(map[string]interface{})
+		for _, entry := range secret.entries {
+			data[entry] = fmt.Sprintf(`{{hub fromSecret "%s" "%s" "%s" hub}}`,
+				clusterGroupUpgrade.Namespace, secret.source, entry)
+		}
+		objectTemplates = append(objectTemplates, map[string]interface{}{
+			"complianceType": "musthave",
+			"objectDefinition": map[string]interface{}{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"type":       "Opaque",
+				"metadata": map[string]interface{}{
+					"name":      secret.name,
+					"namespace": namespace,
+				},
+				"data": data,
+			},
+		})
+	}
+
+	u := &unstructured.Unstructured{}
+	u.Object = map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": clusterGroupUpgrade.Namespace,
+			"labels":    jobSecretsLabels(clusterGroupUpgrade, job),
+		},
+		"spec": map[string]interface{}{
+			"disabled":          false,
+			"remediationAction": utils.RemediationActionEnforce,
+			"policy-templates": []interface{}{
+				map[string]interface{}{
+					"objectDefinition": map[string]interface{}{
+						"apiVersion": "policy.open-cluster-management.io/v1",
+						"kind":       "ConfigurationPolicy",
+						"metadata": map[string]interface{}{
+							"name": name,
+						},
+						"spec": map[string]interface{}{
+							"remediationAction":   utils.RemediationActionEnforce,
+							"severity":            "low",
+							"pruneObjectBehavior": "DeleteIfCreated",
+							"object-templates":    objectTemplates,
+						},
+					},
+				},
+			},
+		},
+	}
+	u.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "policy.open-cluster-management.io",
+		Kind:    "Policy",
+		Version: "v1",
+	})
+	return u
+}
+
+// jobSecretsLabels returns the labels of the objects delivering the secrets of a job
+// returns: map[string]interface{}
+func jobSecretsLabels(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, job string) map[string]interface{} {
+	return map[string]interface{}{
+		"app":                                    "openshift-cluster-group-upgrades",
+		utils.SecretsForClusterGroupUpgradeLabel: clusterGroupUpgrade.Name,
+		utils.SecretsForJobLabel:                 job,
+		utils.ExcludeFromClusterBackup:           "true",
+	}
+}
+
+// newJobSecretsPlacementRule builds the PlacementRule selecting the clusters the secrets of a job are copied to
+// returns: *unstructured.Unstructured
+func newJobSecretsPlacementRule(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, job, name string,
+	clusters []string) *unstructured.Unstructured {
+
+	var placementClusters []interface{}
+	for _, cluster := range clusters {
+		placementClusters = append(placementClusters, map[string]interface{}{"name": cluster})
+	}
+	u := &unstructured.Unstructured{}
+	u.Object = map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": clusterGroupUpgrade.Namespace,
+			"labels":    jobSecretsLabels(clusterGroupUpgrade, job),
+		},
+		"spec": map[string]interface{}{
+			"clusterConditions": []interface{}{
+				map[string]interface{}{
+					"type":   "ManagedClusterConditionAvailable",
+					"status": "True",
+				},
+			},
+			"clusters": placementClusters,
+		},
+	}
+	u.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "apps.open-cluster-management.io",
+		Kind:    "PlacementRule",
+		Version: "v1",
+	})
+	return u
+}
+
+// newJobSecretsPlacementBinding builds the PlacementBinding of the policy copying the secrets of a job
+// returns: *unstructured.Unstructured
+func newJobSecretsPlacementBinding(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, job, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.Object = map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": clusterGroupUpgrade.Namespace,
+			"labels":    jobSecretsLabels(clusterGroupUpgrade, job),
+		},
+		"placementRef": map[string]interface{}{
+			"name":     name,
+			"kind":     "PlacementRule",
+			"apiGroup": "apps.open-cluster-management.io",
+		},
+		"subjects": []interface{}{
+			map[string]interface{}{
+				"name":     name,
+				"kind":     "Policy",
+				"apiGroup": "policy.open-cluster-management.io",
+			},
+		},
+	}
+	u.SetGroupVersionKind(schema.GroupVersionKind{
+		Group:   "policy.open-cluster-management.io",
+		Kind:    "PlacementBinding",
+		Version: "v1",
+	})
+	return u
+}
+
+// ensureJobSecretsPolicy creates or updates the policy copying the secrets of a job to the clusters, with its
+// PlacementRule and PlacementBinding
+// returns: string - the name of the policy, empty when the job mounts no secret
+//
+//	error
+func (r *ClusterGroupUpgradeReconciler) ensureJobSecretsPolicy(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, job, namespace string,
+	clusters []string, secrets []jobSecret) (string, error) {
+
+	if len(secrets) == 0 {
+		return "", nil
+	}
+	name := utils.GetSafeResourceName(utils.GetResourceName(clusterGroupUpgrade, job+"-secrets"),
+		clusterGroupUpgrade, utils.MaxPolicyNameLength, len(clusterGroupUpgrade.Namespace)+1)
+
+	for _, object := range []*unstructured.Unstructured{
+		newJobSecretsPolicy(clusterGroupUpgrade, job, name, namespace, secrets),
+		newJobSecretsPlacementRule(clusterGroupUpgrade, job, name, clusters),
+		newJobSecretsPlacementBinding(clusterGroupUpgrade, job, name),
+	} {
+		if err := r.createOrUpdateUnstructured(ctx, clusterGroupUpgrade, object); err != nil {
+			return "", fmt.Errorf("failed to ensure the %s %s: %w", object.GetKind(), name, err)
+		}
+	}
+	return name, nil
+}
+
+// createOrUpdateUnstructured creates the object owned by the ClusterGroupUpgrade, or updates it if it exists
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) createOrUpdateUnstructured(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, object *unstructured.Unstructured) error {
+
+	if err := controllerutil.SetControllerReference(clusterGroupUpgrade, object, r.Scheme); err != nil {
+		return err
+	}
+	existing := &unstructured.Unstructured{}
+	existing.SetGroupVersionKind(object.GroupVersionKind())
+	err := r.Client.Get(ctx, client.ObjectKeyFromObject(object), existing)
+	if errors.IsNotFound(err) {
+		return r.Client.Create(ctx, object)
+	}
+	if err != nil {
+		return err
+	}
+	object.SetResourceVersion(existing.GetResourceVersion())
+	return r.Client.Update(ctx, object)
+}
+
+// areJobSecretsDelivered checks the policy copying the secrets of a job is compliant on the cluster, that is
+// the secrets exist in the namespace of the job
+// returns: bool, error
+func (r *ClusterGroupUpgradeReconciler) areJobSecretsDelivered(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, policyName, cluster string) (bool, error) {
+
+	if policyName == "" {
+		return true, nil
+	}
+	policy, err := r.getPolicyByName(ctx, policyName, clusterGroupUpgrade.Namespace)
+	if err != nil {
+		return false, err
+	}
+	return r.getClusterComplianceWithPolicy(cluster, policy) == utils.ClusterStatusCompliant, nil
+}
+
+// deleteJobSecretsPolicy deletes the policy copying the secrets of a job to the clusters, with its
+// PlacementRule and PlacementBinding. All jobs are selected when job is empty
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) deleteJobSecretsPolicy(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, job string) error {
+
+	labels := map[string]string{utils.SecretsForClusterGroupUpgradeLabel: clusterGroupUpgrade.Name}
+	if job != "" {
+		labels[utils.SecretsForJobLabel] = job
+	}
+	if err := utils.DeletePlacementBindings(ctx, r.Client, clusterGroupUpgrade.Namespace, labels); err != nil {
+		return err
+	}
+	if err := utils.DeletePlacementRules(ctx, r.Client, clusterGroupUpgrade.Namespace, labels); err != nil {
+		return err
+	}
+	return utils.DeletePolicies(ctx, r.Client, clusterGroupUpgrade.Namespace, labels)
+}
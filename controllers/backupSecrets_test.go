@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestBackupSecrets_getBackupJobSecrets(t *testing.T) {
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"},
+	}
+	// The backup is neither encrypted nor uploaded by default
+	assert.Empty(t, getBackupJobSecrets(cgu, backup))
+
+	cgu.Spec.BackupOptions = &ranv1alpha1.BackupOptions{EncryptionKeySecret: "backup-key"}
+	assert.Equal(t, []jobSecret{
+		{name: "backup-encryption-key", source: "backup-key", entries: []string{"key"}},
+	}, getBackupJobSecrets(cgu, backup))
+
+	cgu.Spec.BackupOptions.ObjectStorage = &ranv1alpha1.BackupObjectStorage{Secret: "backup-storage"}
+	assert.Equal(t, []jobSecret{
+		{name: "backup-encryption-key", source: "backup-key", entries: []string{"key"}},
+		{name: "backup-object-storage", source: "backup-storage", entries: []string{
+			"endpoint", "bucket", "accessKeyId", "secretAccessKey", "region", "prefix", "ca.crt"}},
+	}, getBackupJobSecrets(cgu, backup))
+	// The restore job doesn't download the backup
+	assert.Equal(t, []jobSecret{
+		{name: "backup-encryption-key", source: "backup-key", entries: []string{"key"}},
+	}, getBackupJobSecrets(cgu, restore))
+}
+
+func TestBackupSecrets_ensureJobSecretsPolicy(t *testing.T) {
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cgu", Namespace: "default",
+			Annotations: map[string]string{utils.NameSuffixAnnotation: "kuttl"},
+		},
+		Spec: ranv1alpha1.ClusterGroupUpgradeSpec{
+			BackupOptions: &ranv1alpha1.BackupOptions{EncryptionKeySecret: "backup-key"},
+		},
+	}
+	fakeClient, err := getFakeClientFromObjects(cgu)
+	if err != nil {
+		t.Fatalf("error in creating fake client: %v", err)
+	}
+	r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+	// No policy is needed when the job mounts no secret
+	name, err := r.ensureJobSecretsPolicy(context.TODO(), cgu, backup, backupNamespace, []string{"spoke1"}, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, name)
+
+	name, err = r.ensureJobSecretsPolicy(context.TODO(), cgu, backup, backupNamespace,
+		[]string{"spoke1", "spoke2"}, getBackupJobSecrets(cgu, backup))
+	assert.NoError(t, err)
+	assert.Equal(t, "cgu-backup-secrets-kuttl", name)
+
+	policy, err := r.getPolicyByName(context.TODO(), name, "default")
+	assert.NoError(t, err)
+	assert.Equal(t, "cgu", policy.GetLabels()[utils.SecretsForClusterGroupUpgradeLabel])
+	assert.Empty(t, policy.GetLabels()["openshift-cluster-group-upgrades/clusterGroupUpgrade"])
+	templates, _, _ := unstructured.NestedSlice(policy.Object, "spec", "policy-templates")
+	objectTemplates, _, _ := unstructured.NestedSlice(templates[0].(map[string]interface{}),
+		"objectDefinition", "spec", "object-templates")
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       "Opaque",
+		"metadata":   map[string]interface{}{"name": "backup-encryption-key", "namespace": "openshift-talo-backup"},
+		"data":       map[string]interface{}{"key": `{{hub fromSecret "default" "backup-key" "key" hub}}`},
+	}, objectTemplates[0].(map[string]interface{})["objectDefinition"])
+
+	placementRule := &unstructured.Unstructured{}
+	placementRule.SetGroupVersionKind(schema.GroupVersionKind{
+		Group: "apps.open-cluster-management.io", Kind: "PlacementRule", Version: "v1"})
+	err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: "default"}, placementRule)
+	assert.NoError(t, err)
+	clusters, _, _ := unstructured.NestedSlice(placementRule.Object, "spec", "clusters")
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "spoke1"}, map[string]interface{}{"name": "spoke2"},
+	}, clusters)
+
+	// The job is deployed once the policy is compliant on the cluster
+	delivered, err := r.areJobSecretsDelivered(context.TODO(), cgu, name, "spoke1")
+	assert.NoError(t, err)
+	assert.False(t, delivered)
+	policy.Object["status"] = map[string]interface{}{"status": []interface{}{
+		map[string]interface{}{"clustername": "spoke1", "compliant": utils.ClusterStatusCompliant},
+	}}
+	assert.NoError(t, fakeClient.Update(context.TODO(), policy))
+	delivered, err = r.areJobSecretsDelivered(context.TODO(), cgu, name, "spoke1")
+	assert.NoError(t, err)
+	assert.True(t, delivered)
+
+	assert.NoError(t, r.deleteJobSecretsPolicy(context.TODO(), cgu, backup))
+	policies := &unstructured.UnstructuredList{}
+	policies.SetGroupVersionKind(schema.GroupVersionKind{
+		Group: "policy.open-cluster-management.io", Kind: "PolicyList", Version: "v1"})
+	assert.NoError(t, fakeClient.List(context.TODO(), policies, client.InNamespace("default")))
+	assert.Empty(t, policies.Items)
+}
@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var backupReportData = map[string]interface{}{
+	"sizeBytes": "28991029248", "durationSeconds": "390", "completedAt": "2023-08-15T14:30:00Z",
+}
+
+func TestBackup_parseBackupReport(t *testing.T) {
+	report, err := parseBackupReport(map[string]string{
+		"sizeBytes": "28991029248", "durationSeconds": "390", "completedAt": "2023-08-15T14:30:00Z",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(28991029248), report.SizeBytes)
+	assert.Equal(t, 390*time.Second, report.Duration.Duration)
+	assert.True(t, report.CompletedAt.Equal(&metav1.Time{Time: time.Date(2023, 8, 15, 14, 30, 0, 0, time.UTC)}))
+
+	assert.Empty(t, report.ObjectKey)
+
+	report, err = parseBackupReport(map[string]string{
+		"sizeBytes": "28991029248", "durationSeconds": "390", "completedAt": "2023-08-15T14:30:00Z",
+		"objectKey": "sno/spoke1/default/cgu/recovery-backup.tar",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "sno/spoke1/default/cgu/recovery-backup.tar", report.ObjectKey)
+
+	_, err = parseBackupReport(map[string]string{"sizeBytes": "large"})
+	assert.Error(t, err)
+}
+
+func TestBackup_getBackupOptions(t *testing.T) {
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+	cgu.Status.Clusters = []ranv1alpha1.ClusterState{
+		{Name: "spoke1", State: utils.ClusterRemediationComplete},
+		{Name: "spoke2", State: utils.ClusterRemediationComplete},
+		{Name: "spoke3", State: utils.ClusterRemediationFailed},
+		{Name: "spoke4", State: utils.ClusterRemediationComplete},
+	}
+	cgu.Status.Backup = &ranv1alpha1.BackupStatus{Status: map[string]string{
+		"spoke1": BackupStateSucceeded, "spoke2": BackupStateSucceeded,
+		"spoke3": BackupStateSucceeded, "spoke4": BackupStateTimeout,
+	}}
+	assert.Equal(t, defaultBackupTimeout, getBackupTimeout(cgu))
+	assert.Nil(t, getBackupCleanupClusters(cgu))
+
+	cgu.Spec.BackupOptions = &ranv1alpha1.BackupOptions{Timeout: 20, Retention: ranv1alpha1.BackupRetentionKeep}
+	assert.Equal(t, 20*time.Minute, getBackupTimeout(cgu))
+	assert.Nil(t, getBackupCleanupClusters(cgu))
+
+	cgu.Spec.BackupOptions.Retention = ranv1alpha1.BackupRetentionClean
+	assert.Equal(t, []string{"spoke1", "spoke2"}, getBackupCleanupClusters(cgu))
+
+	// The clusters to restore keep their backup
+	cgu.Spec.Restore = &ranv1alpha1.RestoreSpec{Clusters: []string{"spoke2"}}
+	assert.Equal(t, []string{"spoke1"}, getBackupCleanupClusters(cgu))
+}
+
+func TestBackup_collectBackupReport(t *testing.T) {
+	testcases := []struct {
+		name             string
+		objs             []client.Object
+		expectedReported bool
+		expectedReports  map[string]ranv1alpha1.ClusterBackupReport
+	}{
+		{
+			name:             "report view not deployed",
+			expectedReported: true,
+		},
+		{
+			name: "report view not refreshed",
+			objs: []client.Object{func() client.Object {
+				view := newUnstructuredView("view-backup-report", "spoke1", nil)
+				unstructured.RemoveNestedField(view.Object, "status")
+				return view
+			}()},
+			expectedReported: false,
+		},
+		{
+			name:             "backup not reported",
+			objs:             []client.Object{newUnstructuredNotFoundView("view-backup-report", "spoke1", `configmaps "backup-report" not found`)},
+			expectedReported: true,
+		},
+		{
+			name: "backup reported",
+			objs: []client.Object{newUnstructuredView("view-backup-report", "spoke1", map[string]interface{}{
+				"data": backupReportData,
+			})},
+			expectedReported: true,
+			expectedReports: map[string]ranv1alpha1.ClusterBackupReport{
+				"spoke1": {
+					SizeBytes:   28991029248,
+					Duration:    metav1.Duration{Duration: 390 * time.Second},
+					CompletedAt: metav1.NewTime(time.Date(2023, 8, 15, 14, 30, 0, 0, time.UTC)),
+				},
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+			cgu.Status.Backup = &ranv1alpha1.BackupStatus{Status: map[string]string{"spoke1": BackupStateActive}}
+
+			fakeClient, _ := getFakeClientFromObjects(tc.objs...)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			reported, err := r.collectBackupReport(context.TODO(), cgu, "spoke1")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedReported, reported)
+			assert.Equal(t, len(tc.expectedReports), len(cgu.Status.Backup.Reports))
+			for cluster, expected := range tc.expectedReports {
+				report := cgu.Status.Backup.Reports[cluster]
+				assert.Equal(t, expected.SizeBytes, report.SizeBytes)
+				assert.Equal(t, expected.Duration, report.Duration)
+				assert.True(t, expected.CompletedAt.Equal(&report.CompletedAt))
+			}
+		})
+	}
+}
+
+func TestBackup_reconcileBackupCleanup(t *testing.T) {
+	t.Setenv("RECOVERY_IMG", "quay.io/openshift-kni/cluster-group-upgrades-operator-recovery:latest")
+
+	testcases := []struct {
+		name            string
+		cleanupStatus   string
+		completedAt     time.Time
+		objs            []client.Object
+		expectedState   string
+		expectedDone    bool
+		expectedActions []string
+	}{
+		{
+			name:            "cleanup preparing",
+			completedAt:     time.Now(),
+			expectedState:   BackupStateStarting,
+			expectedActions: []string{"backup-crb-delete", "backup-ns-delete"},
+		},
+		{
+			name:          "cleanup job active",
+			cleanupStatus: BackupStateActive,
+			completedAt:   time.Now(),
+			objs: []client.Object{newUnstructuredView("view-backup-cleanup-job", "spoke1", map[string]interface{}{
+				"status": map[string]interface{}{"active": int64(1)},
+			})},
+			expectedState: BackupStateActive,
+		},
+		{
+			name:          "cleanup timed out",
+			cleanupStatus: BackupStateActive,
+			completedAt:   time.Now().Add(-time.Hour),
+			objs: []client.Object{newUnstructuredView("view-backup-cleanup-job", "spoke1", map[string]interface{}{
+				"status": map[string]interface{}{"active": int64(1)},
+			})},
+			expectedState: BackupStateTimeout,
+			expectedDone:  true,
+		},
+		{
+			name:          "cleanup succeeded",
+			cleanupStatus: BackupStateActive,
+			completedAt:   time.Now(),
+			objs: []client.Object{newUnstructuredView("view-backup-cleanup-job", "spoke1", map[string]interface{}{
+				"status": map[string]interface{}{"succeeded": int64(1)},
+			})},
+			expectedState:   BackupStateSucceeded,
+			expectedDone:    true,
+			expectedActions: []string{"backup-crb-delete", "backup-ns-delete"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"},
+			}
+			cgu.Spec.BackupOptions = &ranv1alpha1.BackupOptions{Retention: ranv1alpha1.BackupRetentionClean}
+			cgu.Status.Clusters = []ranv1alpha1.ClusterState{
+				{Name: "spoke1", State: utils.ClusterRemediationComplete},
+			}
+			cgu.Status.Status.CompletedAt = metav1.NewTime(tc.completedAt)
+			cgu.Status.Backup = &ranv1alpha1.BackupStatus{Status: map[string]string{"spoke1": BackupStateSucceeded}}
+			if tc.cleanupStatus != "" {
+				cgu.Status.Backup.Cleanup = map[string]string{"spoke1": tc.cleanupStatus}
+			}
+
+			fakeClient, _ := getFakeClientFromObjects(tc.objs...)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			done, err := r.reconcileBackupCleanup(context.TODO(), cgu)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDone, done)
+			assert.Equal(t, map[string]string{"spoke1": tc.expectedState}, cgu.Status.Backup.Cleanup)
+
+			actions := &unstructured.UnstructuredList{}
+			actions.SetGroupVersionKind(actionGroupVersionKind())
+			assert.NoError(t, fakeClient.List(context.TODO(), actions, client.InNamespace("spoke1")))
+			var names []string
+			for _, action := range actions.Items {
+				names = append(names, action.GetName())
+			}
+			assert.Equal(t, tc.expectedActions, names)
+		})
+	}
+}
+
+func TestBackup_backupActive(t *testing.T) {
+	t.Setenv("RECOVERY_IMG", "quay.io/openshift-kni/cluster-group-upgrades-operator-recovery:latest")
+
+	backupJobView := newUnstructuredView("view-backup-job", "spoke1", map[string]interface{}{
+		"status": map[string]interface{}{"succeeded": int64(1)},
+	})
+	testcases := []struct {
+		name            string
+		objs            []client.Object
+		expectedState   string
+		expectedActions []string
+	}{
+		{
+			name:            "backup verification starting",
+			objs:            []client.Object{backupJobView},
+			expectedState:   BackupStateActive,
+			expectedActions: []string{"backup-verify-job-create"},
+		},
+		{
+			name: "backup verified",
+			objs: []client.Object{backupJobView,
+				newUnstructuredView("view-backup-verify-job", "spoke1", map[string]interface{}{
+					"status": map[string]interface{}{"succeeded": int64(1)},
+				})},
+			expectedState: BackupStateSucceeded,
+		},
+		{
+			name: "backup doesn't match its manifest",
+			objs: []client.Object{backupJobView,
+				newUnstructuredView("view-backup-verify-job", "spoke1", map[string]interface{}{
+					"status": map[string]interface{}{
+						"failed": int64(1),
+						"conditions": []interface{}{
+							map[string]interface{}{"type": "Failed", "status": "True", "reason": "BackoffLimitExceeded"},
+						},
+					},
+				})},
+			expectedState: BackupStateError,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"},
+			}
+			cgu.Status.Backup = &ranv1alpha1.BackupStatus{Status: map[string]string{"spoke1": BackupStateActive}}
+
+			fakeClient, _ := getFakeClientFromObjects(tc.objs...)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			nextState, err := r.backupActive(context.TODO(), cgu, "spoke1")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedState, nextState)
+
+			actions := &unstructured.UnstructuredList{}
+			actions.SetGroupVersionKind(actionGroupVersionKind())
+			assert.NoError(t, fakeClient.List(context.TODO(), actions, client.InNamespace("spoke1")))
+			var names []string
+			for _, action := range actions.Items {
+				names = append(names, action.GetName())
+			}
+			assert.Equal(t, tc.expectedActions, names)
+		})
+	}
+}
+
+func TestBackup_getBackupJobTemplateData(t *testing.T) {
+	t.Setenv("RECOVERY_IMG", "quay.io/openshift-kni/cluster-group-upgrades-operator-recovery:latest")
+
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"},
+	}
+	r := &ClusterGroupUpgradeReconciler{Log: logr.Discard(), Scheme: testscheme}
+
+	// The backup is neither encrypted nor uploaded by default
+	spec, err := r.getBackupJobTemplateData(cgu, "spoke1")
+	assert.NoError(t, err)
+	assert.Equal(t, "none", spec.BackupCompression)
+	assert.False(t, spec.BackupEncrypted)
+	assert.Empty(t, spec.BackupObjectName)
+
+	cgu.Spec.BackupOptions = &ranv1alpha1.BackupOptions{
+		Compression:         ranv1alpha1.BackupCompressionGzip,
+		EncryptionKeySecret: "backup-key",
+		ObjectStorage:       &ranv1alpha1.BackupObjectStorage{Secret: "backup-storage", RemoveLocalCopy: true},
+	}
+	spec, err = r.getBackupJobTemplateData(cgu, "spoke1")
+	assert.NoError(t, err)
+	assert.Equal(t, "gzip", spec.BackupCompression)
+	assert.True(t, spec.BackupEncrypted)
+	assert.Equal(t, "spoke1/default/cgu/recovery-backup.tar", spec.BackupObjectName)
+	assert.True(t, spec.BackupRemoveLocal)
+}
@@ -46,6 +46,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
 	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/updategraph"
 	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
 )
@@ -53,9 +54,15 @@ import (
 // ClusterGroupUpgradeReconciler reconciles a ClusterGroupUpgrade object
 type ClusterGroupUpgradeReconciler struct {
 	client.Client
-	Log      logr.Logger
-	Scheme   *runtime.Scheme
-	Recorder record.EventRecorder
+	// APIReader reads the objects that aren't cached, such as the hub pull secret
+	APIReader client.Reader
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+	Recorder  record.EventRecorder
+	// UpdateGraph is the client of the update service, shared to cache the update graphs
+	UpdateGraph *updategraph.Client
+	// notifier delivers the lifecycle events to the NotificationTargets
+	notifier *notifier
 }
 
 type policiesInfo struct {
@@ -98,6 +105,9 @@ func requeueWithCustomInterval(interval time.Duration) ctrl.Result {
 //+kubebuilder:rbac:groups=ran.openshift.io,resources=precachingconfigs,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=ran.openshift.io,resources=precachingconfigs/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=ran.openshift.io,resources=precachingconfigs/finalizers,verbs=update
+//+kubebuilder:rbac:groups=ran.openshift.io,resources=notificationtargets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=ran.openshift.io,resources=notificationtargets/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ran.openshift.io,resources=clustergroupupgradereports,verbs=get;list;watch;create
 //+kubebuilder:rbac:groups=apps.open-cluster-management.io,resources=placementrules,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=placementbindings,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=policy.open-cluster-management.io,resources=policies,verbs=get;list;watch;create;update;patch;delete
@@ -105,7 +115,12 @@ func requeueWithCustomInterval(interval time.Duration) ctrl.Result {
 //+kubebuilder:rbac:groups=action.open-cluster-management.io,resources=managedclusteractions,verbs=create;update;delete;get;list;watch;patch
 //+kubebuilder:rbac:groups=view.open-cluster-management.io,resources=managedclusterviews,verbs=create;update;delete;get;list;watch;patch
 //+kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// The secrets are read from the API server, without list and watch. Only the hub pull secret is granted cluster
+// wide, the auth secrets of the notifications are read from the operator namespace, granted by the
+// notification-secret-role Role of config/rbac
+//+kubebuilder:rbac:groups="",resources=secrets,resourceNames=pull-secret,verbs=get
 //+kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+//+kubebuilder:rbac:groups=config.openshift.io,resources=imagedigestmirrorsets,verbs=get;list;watch
 //+kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;patch;delete
 
@@ -131,6 +146,10 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 	}()
 
 	nextReconcile = doNotRequeue()
+	// Drop the notifications of a previous reconciliation that returned before updating the status
+	if r.notifier != nil {
+		r.notifier.discard(req.NamespacedName)
+	}
 	// Wait a bit so that API server/etcd syncs up and this reconcile has a better chance of getting the updated CGU and policies
 	time.Sleep(statusUpdateWaitInMilliSeconds * time.Millisecond)
 	clusterGroupUpgrade := &ranv1alpha1.ClusterGroupUpgrade{}
@@ -162,6 +181,18 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 
 	if suceededCondition != nil {
 		if clusterGroupUpgrade.Status.Status.CompletedAt.IsZero() {
+			var inventoryDone bool
+			inventoryDone, err = r.reconcileImageInventory(ctx, clusterGroupUpgrade)
+			if err != nil {
+				return
+			}
+			if !inventoryDone {
+				// Objects are deleted once the image inventory of all the clusters is reported
+				nextReconcile = requeueWithShortInterval()
+				err = r.updateStatus(ctx, clusterGroupUpgrade)
+				return
+			}
+
 			deleteObjects := clusterGroupUpgrade.Spec.Actions.AfterCompletion.DeleteObjects
 			if deleteObjects == nil || *deleteObjects {
 				err = r.deleteResources(ctx, clusterGroupUpgrade)
@@ -170,10 +201,7 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 				}
 			}
 
-			if suceededCondition.Status == metav1.ConditionTrue {
-				r.Recorder.Event(clusterGroupUpgrade, corev1.EventTypeNormal, suceededCondition.Reason, suceededCondition.Message)
-			} else {
-				r.Recorder.Event(clusterGroupUpgrade, corev1.EventTypeWarning, suceededCondition.Reason, suceededCondition.Message)
+			if suceededCondition.Status != metav1.ConditionTrue {
 				r.handleBatchTimeout(ctx, clusterGroupUpgrade)
 			}
 			// Set completion time only after post actions are executed with no errors
@@ -181,6 +209,47 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 			clusterGroupUpgrade.Status.Status.CurrentBatch = 0
 			clusterGroupUpgrade.Status.Status.CurrentBatchStartedAt = metav1.Time{}
 			clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress = nil
+
+			// The report, the event and the notification of the completion follow once the completion is persisted
+			nextReconcile = requeueImmediately()
+			err = r.updateStatus(ctx, clusterGroupUpgrade)
+			return
+		}
+
+		if !clusterGroupUpgrade.Status.Status.CompletionReported {
+			r.reportCompletion(clusterGroupUpgrade, suceededCondition)
+		}
+
+		// Restore the clusters from their backup once the upgrade is completed, and clean the backup of the others
+		var restoreDone, cleanupDone bool
+		restoreDone, err = r.reconcileRestore(ctx, clusterGroupUpgrade)
+		if err != nil {
+			r.Log.Error(err, "reconcileRestore error")
+			return
+		}
+		if restoreDone {
+			cleanupDone, err = r.reconcileBackupCleanup(ctx, clusterGroupUpgrade)
+			if err != nil {
+				r.Log.Error(err, "reconcileBackupCleanup error")
+				return
+			}
+		}
+		// Follow the unpausing of the pools released by the clusters that failed or timed out
+		var poolsReleased bool
+		poolsReleased, err = r.reconcileReleasedMachineConfigPools(ctx, clusterGroupUpgrade)
+		if err != nil {
+			r.Log.Error(err, "reconcileReleasedMachineConfigPools error")
+			return
+		}
+		if !restoreDone || !cleanupDone || !poolsReleased {
+			nextReconcile = requeueWithShortInterval()
+		} else if !clusterGroupUpgrade.Status.Status.ReportCreated {
+			// Keep a record of the upgrade, with the results of the restore and the cleanup, that outlives the
+			// ClusterGroupUpgrade and its objects
+			err = r.createReport(ctx, clusterGroupUpgrade)
+			if err != nil {
+				return
+			}
 		}
 	} else if progressingCondition == nil || progressingCondition.Status == metav1.ConditionFalse {
 
@@ -220,7 +289,28 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 		}
 		if allManagedPoliciesExist {
 
-			err = r.validateOpenshiftUpgradeVersion(clusterGroupUpgrade, managedPoliciesInfo.presentPolicies)
+			err = r.validateOpenshiftUpgradeVersion(ctx, clusterGroupUpgrade, clusters, managedPoliciesInfo.presentPolicies)
+			if err != nil {
+				nextReconcile = requeueWithLongInterval()
+				err = r.updateStatus(ctx, clusterGroupUpgrade)
+				return
+			}
+
+			err = r.validatePlatformUpgrade(ctx, clusterGroupUpgrade, clusters)
+			if err != nil {
+				nextReconcile = requeueWithLongInterval()
+				err = r.updateStatus(ctx, clusterGroupUpgrade)
+				return
+			}
+
+			err = validateImageBasedUpgrade(clusterGroupUpgrade)
+			if err != nil {
+				nextReconcile = requeueWithLongInterval()
+				err = r.updateStatus(ctx, clusterGroupUpgrade)
+				return
+			}
+
+			err = validateRestore(clusterGroupUpgrade)
 			if err != nil {
 				nextReconcile = requeueWithLongInterval()
 				err = r.updateStatus(ctx, clusterGroupUpgrade)
@@ -248,6 +338,17 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 			// Recheck clusters list for any changes to the plan
 			clusters = utils.GetClustersListFromRemediationPlan(clusterGroupUpgrade)
 
+			// A dry run only records the plan, without creating the resources of the upgrade
+			if clusterGroupUpgrade.Spec.DryRun {
+				err = r.reconcileDryRun(ctx, clusterGroupUpgrade, clusters, managedPoliciesInfo)
+				if err != nil {
+					return
+				}
+				nextReconcile = requeueWithLongInterval()
+				err = r.updateStatus(ctx, clusterGroupUpgrade)
+				return
+			}
+
 			// Create the needed resources for starting the upgrade.
 			var isPolicyErr bool
 			isPolicyErr, err = r.reconcileResources(ctx, clusterGroupUpgrade, managedPoliciesInfo.presentPolicies)
@@ -337,6 +438,11 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 					return
 				}
 				// fallthrough to the enable check
+			} else if precachingSpecCondition.Reason == string(utils.ConditionReasons.InProgress) {
+				// wait for the operator index images resolved in the background
+				nextReconcile = requeueWithShortInterval()
+				r.updateStatus(ctx, clusterGroupUpgrade)
+				return
 			} else {
 				// wait for cgu update with valid policies for precaching spec
 				nextReconcile = requeueWithLongInterval()
@@ -360,6 +466,7 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 
 		if clusterGroupUpgrade.Status.Status.StartedAt.IsZero() {
 			clusterGroupUpgrade.Status.Status.StartedAt = metav1.Now()
+			r.notify(clusterGroupUpgrade, ranv1alpha1.NotificationUpgradeStarted, "", 0, "The upgrade started")
 		}
 		// Check if there are any CRs that are blocking the start of the current one and are not yet completed.
 		var blockingCRsNotCompleted, blockingCRsMissing []string
@@ -508,6 +615,7 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 		// Check whether we have time left on the cgu timeout
 		if time.Since(clusterGroupUpgrade.Status.Status.StartedAt.Time) > time.Duration(clusterGroupUpgrade.Spec.RemediationStrategy.Timeout)*time.Minute {
 			// We are completely out of time
+			recordBatchDuration(clusterGroupUpgrade, batchResultTimedOut)
 			utils.SetStatusCondition(
 				&clusterGroupUpgrade.Status.Conditions,
 				utils.ConditionTypes.Progressing,
@@ -535,6 +643,10 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 				// If the upgrade is completed for the current batch, cleanup and move to the next.
 				r.Log.Info("[Reconcile] Upgrade completed for batch", "batchIndex", clusterGroupUpgrade.Status.Status.CurrentBatch)
 				r.cleanupPlacementRules(ctx, clusterGroupUpgrade)
+				recordBatchDuration(clusterGroupUpgrade, batchResultCompleted)
+				r.notify(clusterGroupUpgrade, ranv1alpha1.NotificationBatchCompleted, "",
+					clusterGroupUpgrade.Status.Status.CurrentBatch,
+					fmt.Sprintf("Batch %d completed", clusterGroupUpgrade.Status.Status.CurrentBatch))
 				clusterGroupUpgrade.Status.Status.CurrentBatchStartedAt = metav1.Time{}
 				clusterGroupUpgrade.Status.Status.CurrentBatch++
 				nextReconcile = requeueImmediately()
@@ -560,6 +672,10 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 					if time.Since(clusterGroupUpgrade.Status.Status.CurrentBatchStartedAt.Time) > currentBatchTimeout {
 						// We want to immediately continue to the next reconcile regardless of the timeout action
 						nextReconcile = requeueImmediately()
+						recordBatchDuration(clusterGroupUpgrade, batchResultTimedOut)
+						r.notify(clusterGroupUpgrade, ranv1alpha1.NotificationBatchTimedOut, "",
+							clusterGroupUpgrade.Status.Status.CurrentBatch,
+							fmt.Sprintf("Batch %d timed out", clusterGroupUpgrade.Status.Status.CurrentBatch))
 
 						// Check if this was a canary or not
 						if len(clusterGroupUpgrade.Spec.RemediationStrategy.Canaries) != 0 &&
@@ -618,19 +734,27 @@ func (r *ClusterGroupUpgradeReconciler) Reconcile(ctx context.Context, req ctrl.
 				return
 			}
 			if isUpgradeComplete {
+				recordBatchDuration(clusterGroupUpgrade, batchResultCompleted)
+				r.notify(clusterGroupUpgrade, ranv1alpha1.NotificationBatchCompleted, "",
+					clusterGroupUpgrade.Status.Status.CurrentBatch,
+					fmt.Sprintf("Batch %d completed", clusterGroupUpgrade.Status.Status.CurrentBatch))
+			}
+			if isUpgradeComplete {
+				// The clusters whose remediation failed are reported by the ClustersFailed condition
+				message := getUpgradeCompletedMessage(clusterGroupUpgrade)
 				utils.SetStatusCondition(
 					&clusterGroupUpgrade.Status.Conditions,
 					utils.ConditionTypes.Progressing,
 					utils.ConditionReasons.Completed,
 					metav1.ConditionFalse,
-					"All clusters are compliant with all the managed policies",
+					message,
 				)
 				utils.SetStatusCondition(
 					&clusterGroupUpgrade.Status.Conditions,
 					utils.ConditionTypes.Succeeded,
 					utils.ConditionReasons.Completed,
 					metav1.ConditionTrue,
-					"All clusters are compliant with all the managed policies",
+					message,
 				)
 				nextReconcile = requeueImmediately()
 			} else {
@@ -680,6 +804,9 @@ func (r *ClusterGroupUpgradeReconciler) handleBatchTimeout(ctx context.Context,
 			clusterState.State = utils.ClusterRemediationTimedout
 			utils.DeleteMultiCloudObjects(ctx, r.Client, clusterGroupUpgrade, batchClusterName)
 			clusterGroupUpgrade.Status.Clusters = append(clusterGroupUpgrade.Status.Clusters, clusterState)
+			r.recordClusterTransition(clusterGroupUpgrade, batchClusterName, corev1.EventTypeWarning,
+				eventReasonClusterRemediationTimedOut, clusterState.State,
+				"the batch timed out before the remediation started")
 		} else if clusterStatus.State == ranv1alpha1.InProgress {
 			clusterState.State = utils.ClusterRemediationTimedout
 
@@ -698,8 +825,25 @@ func (r *ClusterGroupUpgradeReconciler) handleBatchTimeout(ctx context.Context,
 					Name:   clusterGroupUpgrade.Status.ManagedPoliciesForUpgrade[policyIndex].Name,
 					Status: utils.ClusterStatusNonCompliant}
 			}
-			utils.DeleteMultiCloudObjects(ctx, r.Client, clusterGroupUpgrade, batchClusterName)
+			if err := r.releaseMachineConfigPools(ctx, clusterGroupUpgrade, batchClusterName); err != nil {
+				r.Log.Error(err, "[addClustersStatusOnTimeout] Failed to release the MachineConfigPools", "cluster", batchClusterName)
+			}
 			clusterGroupUpgrade.Status.Clusters = append(clusterGroupUpgrade.Status.Clusters, clusterState)
+			message := "the batch timed out"
+			if clusterState.CurrentPolicy != nil {
+				message = fmt.Sprintf("the batch timed out while remediating policy %s", clusterState.CurrentPolicy.Name)
+			}
+			r.recordClusterTransition(clusterGroupUpgrade, batchClusterName, corev1.EventTypeWarning,
+				eventReasonClusterRemediationTimedOut, clusterState.State, message)
+		} else if clusterStatus.State == ranv1alpha1.Completed {
+			// The pools of the completed clusters waiting for the end of the batch are not unpaused by it anymore
+			pausedPools, err := r.getPausedMachineConfigPools(ctx, clusterGroupUpgrade, batchClusterName)
+			if err == nil {
+				err = r.unpausePausedMachineConfigPools(ctx, clusterGroupUpgrade, batchClusterName, pausedPools)
+			}
+			if err != nil {
+				r.Log.Error(err, "[addClustersStatusOnTimeout] Failed to unpause the MachineConfigPools", "cluster", batchClusterName)
+			}
 		}
 	}
 }
@@ -714,7 +858,9 @@ func (r *ClusterGroupUpgradeReconciler) initializeRemediationPolicyForBatch(
 	for _, batchClusterName := range clusterGroupUpgrade.Status.RemediationPlan[batchIndex] {
 		clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[batchClusterName] = new(ranv1alpha1.ClusterRemediationProgress)
 		clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[batchClusterName].State = ranv1alpha1.NotStarted
-
+		r.recordClusterTransition(clusterGroupUpgrade, batchClusterName, corev1.EventTypeNormal,
+			eventReasonClusterBatchStarted, ranv1alpha1.NotStarted,
+			fmt.Sprintf("batch %d started", clusterGroupUpgrade.Status.Status.CurrentBatch))
 	}
 
 	r.Log.Info("[initializeRemediationPolicyForBatch]",
@@ -741,6 +887,11 @@ func (r *ClusterGroupUpgradeReconciler) getNextRemediationPoliciesForBatch(
 	isBatchComplete := true
 	isSoaking := false
 
+	// The pools released by the clusters that failed or timed out are unpaused without holding the batch
+	if _, err := r.trackReleasedMachineConfigPools(ctx, clusterGroupUpgrade); err != nil {
+		return false, isSoaking, err
+	}
+
 	for _, clusterName := range clusterGroupUpgrade.Status.RemediationPlan[batchIndex] {
 		// nil check to avoid panic in edge cases
 		if clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress == nil {
@@ -755,7 +906,41 @@ func (r *ClusterGroupUpgradeReconciler) getNextRemediationPoliciesForBatch(
 			clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName].PolicyIndex = new(int)
 			*clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName].PolicyIndex = 0
 			clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName].State = ranv1alpha1.InProgress
-		} else if clusterProgressState == ranv1alpha1.Completed {
+		} else if clusterProgressState == ranv1alpha1.Completed || clusterProgressState == ranv1alpha1.Failed {
+			continue
+		}
+
+		// The pools are paused before the remediation of the cluster starts
+		poolsPaused, err := r.pauseMachineConfigPools(ctx, clusterGroupUpgrade, clusterName)
+		if err != nil {
+			return false, isSoaking, err
+		}
+		if !poolsPaused {
+			isBatchComplete = false
+			continue
+		}
+
+		// The managed policies are remediated once the cluster completed the platform upgrade
+		platformUpgradeDone, err := r.reconcilePlatformUpgrade(ctx, clusterGroupUpgrade, clusterName)
+		if err != nil {
+			return false, isSoaking, err
+		}
+		if !platformUpgradeDone {
+			if clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName].State != ranv1alpha1.Failed {
+				isBatchComplete = false
+			}
+			continue
+		}
+
+		// Or once the cluster completed the image-based upgrade
+		imageBasedUpgradeDone, err := r.reconcileImageBasedUpgrade(ctx, clusterGroupUpgrade, clusterName)
+		if err != nil {
+			return false, isSoaking, err
+		}
+		if !imageBasedUpgradeDone {
+			if clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName].State != ranv1alpha1.Failed {
+				isBatchComplete = false
+			}
 			continue
 		}
 		currentPolicyIndex := *clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName].PolicyIndex
@@ -770,9 +955,21 @@ func (r *ClusterGroupUpgradeReconciler) getNextRemediationPoliciesForBatch(
 		}
 
 		if currentPolicyIndex >= numberOfPolicies {
+			// The cluster is complete once its pools are unpaused and updated
+			poolsUnpaused, err := r.unpauseMachineConfigPools(
+				ctx, clusterGroupUpgrade, ranv1alpha1.UnpauseAfterCluster, []string{clusterName})
+			if err != nil {
+				return false, isSoaking, err
+			}
+			if !poolsUnpaused {
+				isBatchComplete = false
+				continue
+			}
+			recordPolicyRemediation(clusterGroupUpgrade,
+				clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName], currentPolicyIndex)
 			clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName].PolicyIndex = nil
 			clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName].State = ranv1alpha1.Completed
-			err := r.takeActionsAfterCompletion(ctx, clusterGroupUpgrade, clusterName)
+			err = r.takeActionsAfterCompletion(ctx, clusterGroupUpgrade, clusterName)
 			if err != nil {
 				return false, isSoaking, err
 			}
@@ -783,10 +980,34 @@ func (r *ClusterGroupUpgradeReconciler) getNextRemediationPoliciesForBatch(
 			}
 		} else {
 			isBatchComplete = false
+			clusterProgress := clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName]
+			if clusterProgress.PolicyStartedAt.IsZero() || *clusterProgress.PolicyIndex != currentPolicyIndex {
+				r.recordClusterTransition(clusterGroupUpgrade, clusterName, corev1.EventTypeNormal,
+					eventReasonClusterPolicyRemediating, ranv1alpha1.InProgress,
+					fmt.Sprintf("remediating policy %s",
+						clusterGroupUpgrade.Status.ManagedPoliciesForUpgrade[currentPolicyIndex].Name))
+			}
+			recordPolicyRemediation(clusterGroupUpgrade, clusterProgress, currentPolicyIndex)
 			*clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName].PolicyIndex = currentPolicyIndex
 		}
 	}
 
+	if isBatchComplete {
+		// The batch is complete once the pools of its completed clusters are unpaused and updated
+		var completedClusters []string
+		for _, clusterName := range clusterGroupUpgrade.Status.RemediationPlan[batchIndex] {
+			if clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName].State == ranv1alpha1.Completed {
+				completedClusters = append(completedClusters, clusterName)
+			}
+		}
+		var err error
+		isBatchComplete, err = r.unpauseMachineConfigPools(
+			ctx, clusterGroupUpgrade, ranv1alpha1.UnpauseAfterBatch, completedClusters)
+		if err != nil {
+			return false, isSoaking, err
+		}
+	}
+
 	r.Log.Info("[getNextRemediationPoliciesForBatch]", "plan", clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress, "isBatchComplete", isBatchComplete)
 	return isBatchComplete, isSoaking, nil
 }
@@ -818,7 +1039,8 @@ func (r *ClusterGroupUpgradeReconciler) updatePlacementRules(ctx context.Context
 
 	policiesToUpdate := make(map[int][]string)
 	for clusterName, clusterProgress := range clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress {
-		if clusterProgress.State != ranv1alpha1.InProgress {
+		if clusterProgress.State != ranv1alpha1.InProgress || !isPlatformUpgradeCompleted(clusterGroupUpgrade, clusterName) ||
+			!isImageBasedUpgradeCompleted(clusterGroupUpgrade, clusterName) {
 			continue
 		}
 		clusterNames := policiesToUpdate[*clusterProgress.PolicyIndex]
@@ -1389,9 +1611,21 @@ func (r *ClusterGroupUpgradeReconciler) isUpgradeComplete(ctx context.Context, c
 	}
 
 	if isBatchComplete {
+		failedClusters := make(map[string]bool)
+		for _, clusterName := range getFailedClusters(clusterGroupUpgrade) {
+			failedClusters[clusterName] = true
+		}
 		// Check previous batches
 		for i := 0; i < len(clusterGroupUpgrade.Status.RemediationPlan)-1; i++ {
 			for _, batchClusterName := range clusterGroupUpgrade.Status.RemediationPlan[i] {
+				// The clusters that failed are not remediated anymore
+				if failedClusters[batchClusterName] {
+					continue
+				}
+				if !isPlatformUpgradeCompleted(clusterGroupUpgrade, batchClusterName) ||
+					!isImageBasedUpgradeCompleted(clusterGroupUpgrade, batchClusterName) {
+					return false, false, nil
+				}
 				// Start with policy index 0 as we don't keep progress info from previous batches
 				nextNonCompliantPolicyIndex, isSoaking, err := r.getNextNonCompliantPolicyForCluster(ctx, clusterGroupUpgrade, batchClusterName, 0)
 				if err != nil || nextNonCompliantPolicyIndex < len(clusterGroupUpgrade.Status.ManagedPoliciesForUpgrade) {
@@ -1402,7 +1636,17 @@ func (r *ClusterGroupUpgradeReconciler) isUpgradeComplete(ctx context.Context, c
 	} else {
 		return false, isSoaking, nil
 	}
-	return true, isSoaking, nil
+
+	// The pools of the completed clusters are unpaused in a separate phase once all the batches completed
+	var completedClusters []string
+	for _, clusterState := range clusterGroupUpgrade.Status.Clusters {
+		if clusterState.State == utils.ClusterRemediationComplete {
+			completedClusters = append(completedClusters, clusterState.Name)
+		}
+	}
+	poolsUnpaused, err := r.unpauseMachineConfigPools(
+		ctx, clusterGroupUpgrade, ranv1alpha1.UnpauseAfterUpgrade, completedClusters)
+	return poolsUnpaused, isSoaking, err
 }
 
 func (r *ClusterGroupUpgradeReconciler) ensureBatchPlacementBinding(
@@ -1706,6 +1950,23 @@ func (r *ClusterGroupUpgradeReconciler) buildRemediationPlan(ctx context.Context
 	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusters []string, managedPolicies []*unstructured.Unstructured) {
 	// Get all clusters from the CR that are non compliant with at least one of the managedPolicies.
 	clusterNonCompliantWithManagedPoliciesMap := r.getClustersNonCompliantWithManagedPolicies(clusters, managedPolicies)
+	// Clusters not yet at the target version of the platform upgrade need to be remediated too
+	if clusterGroupUpgrade.Spec.PlatformUpgrade != nil {
+		for _, cluster := range clusters {
+			if r.isPlatformUpgradeNeeded(ctx, clusterGroupUpgrade, cluster) {
+				clusterNonCompliantWithManagedPoliciesMap[cluster] = true
+			}
+		}
+	}
+
+	// As well as the clusters that did not complete the image-based upgrade
+	if clusterGroupUpgrade.Spec.ImageBasedUpgrade != nil {
+		for _, cluster := range clusters {
+			if !isImageBasedUpgradeCompleted(clusterGroupUpgrade, cluster) {
+				clusterNonCompliantWithManagedPoliciesMap[cluster] = true
+			}
+		}
+	}
 
 	// Create remediation plan
 	var remediationPlan [][]string
@@ -1715,7 +1976,7 @@ func (r *ClusterGroupUpgradeReconciler) buildRemediationPlan(ctx context.Context
 			if clusterNonCompliantWithManagedPoliciesMap[canary] {
 				remediationPlan = append(remediationPlan, []string{canary})
 				isCanary[canary] = true
-			} else if *clusterGroupUpgrade.Spec.Enable {
+			} else if *clusterGroupUpgrade.Spec.Enable && !clusterGroupUpgrade.Spec.DryRun {
 				r.takeActionsAfterCompletion(ctx, clusterGroupUpgrade, canary)
 			}
 		}
@@ -1729,7 +1990,7 @@ func (r *ClusterGroupUpgradeReconciler) buildRemediationPlan(ctx context.Context
 			if clusterNonCompliantWithManagedPoliciesMap[cluster] {
 				batch = append(batch, cluster)
 				clusterCount++
-			} else if *clusterGroupUpgrade.Spec.Enable {
+			} else if *clusterGroupUpgrade.Spec.Enable && !clusterGroupUpgrade.Spec.DryRun {
 				r.takeActionsAfterCompletion(ctx, clusterGroupUpgrade, cluster)
 			}
 		}
@@ -1946,15 +2207,23 @@ func (r *ClusterGroupUpgradeReconciler) updateChildResourceNamesInStatus(ctx con
 }
 
 func (r *ClusterGroupUpgradeReconciler) updateStatus(ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) error {
+	clusterGroupUpgrade.Status.Summary = newUpgradeSummary(clusterGroupUpgrade, time.Now())
 	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
 		err := r.Status().Update(ctx, clusterGroupUpgrade)
 		return err
 	})
 
 	if err != nil {
+		if r.notifier != nil {
+			r.notifier.discard(client.ObjectKeyFromObject(clusterGroupUpgrade))
+		}
 		return err
 	}
 
+	// The events are notified once their status is persisted
+	if r.notifier != nil {
+		r.notifier.release(client.ObjectKeyFromObject(clusterGroupUpgrade))
+	}
 	return nil
 }
 
@@ -2108,6 +2377,13 @@ func (r *ClusterGroupUpgradeReconciler) getCGUControllerWorkerCount() (count int
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClusterGroupUpgradeReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.Recorder = mgr.GetEventRecorderFor("ClusterGroupUpgrade")
+	if err := registerCGUCollector(mgr.GetClient(), r.APIReader); err != nil {
+		return err
+	}
+	r.notifier = newNotifier(mgr.GetClient(), r.APIReader, r.Log.WithName("notifier"))
+	if err := mgr.Add(r.notifier); err != nil {
+		return err
+	}
 
 	placementRuleUnstructured := &unstructured.Unstructured{}
 	placementRuleUnstructured.SetGroupVersionKind(schema.GroupVersionKind{
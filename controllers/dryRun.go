@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// getDryRunClusterPlans lists the clusters of the remediation plan with the managed policies they are not
+// compliant with
+// returns: []ranv1alpha1.ClusterPlan
+func (r *ClusterGroupUpgradeReconciler) getDryRunClusterPlans(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
+	managedPolicies []*unstructured.Unstructured) []ranv1alpha1.ClusterPlan {
+
+	var clusterPlans []ranv1alpha1.ClusterPlan
+	for batchIndex, batch := range clusterGroupUpgrade.Status.RemediationPlan {
+		for _, cluster := range batch {
+			clusterPlan := ranv1alpha1.ClusterPlan{Name: cluster, Batch: batchIndex + 1}
+			for _, managedPolicy := range managedPolicies {
+				if r.getClusterComplianceWithPolicy(cluster, managedPolicy) == utils.ClusterStatusNonCompliant {
+					clusterPlan.NonCompliantPolicies = append(clusterPlan.NonCompliantPolicies, managedPolicy.GetName())
+				}
+			}
+			clusterPlans = append(clusterPlans, clusterPlan)
+		}
+	}
+	return clusterPlans
+}
+
+// reconcileDryRun writes the plan of the ClusterGroupUpgrade to its status: the remediation plan, already
+// built, the non-compliant policies of each cluster and the pre-caching spec. Nothing is created on the hub
+// or the clusters
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) reconcileDryRun(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusters []string, managedPoliciesInfo policiesInfo) error {
+
+	clusterGroupUpgrade.Status.DryRun = &ranv1alpha1.DryRunStatus{
+		ComputedAt: metav1.Now(),
+		Clusters:   r.getDryRunClusterPlans(clusterGroupUpgrade, managedPoliciesInfo.presentPolicies),
+	}
+
+	message := fmt.Sprintf("Dry run: %d clusters in %d batches, no change is made to the clusters",
+		len(clusterGroupUpgrade.Status.DryRun.Clusters), len(clusterGroupUpgrade.Status.RemediationPlan))
+	if clusterGroupUpgrade.Spec.PreCaching && len(clusters) > 0 {
+		initializePrecachingStatus(clusterGroupUpgrade)
+		// Already compliant policies are passed as the catalog source info is needed by precaching
+		err := r.computePrecachingSpec(ctx, clusterGroupUpgrade, clusters,
+			append(managedPoliciesInfo.presentPolicies, managedPoliciesInfo.compliantPolicies...))
+		if err != nil {
+			return err
+		}
+	}
+
+	r.Log.Info("[reconcileDryRun]", "remediationPlan", clusterGroupUpgrade.Status.RemediationPlan)
+	utils.SetStatusCondition(
+		&clusterGroupUpgrade.Status.Conditions,
+		utils.ConditionTypes.Progressing,
+		utils.ConditionReasons.DryRun,
+		metav1.ConditionFalse,
+		message,
+	)
+	return nil
+}
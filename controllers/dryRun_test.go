@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+// newDryRunPolicy returns a policy with the compliance of the clusters
+func newDryRunPolicy(name string, compliance map[string]string) *unstructured.Unstructured {
+	var status []interface{}
+	for cluster, compliant := range compliance {
+		status = append(status, map[string]interface{}{"clustername": cluster, "compliant": compliant})
+	}
+	policy := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"status": status},
+	}}
+	policy.SetName(name)
+	policy.SetNamespace("default")
+	return policy
+}
+
+func TestDryRun_reconcileDryRun(t *testing.T) {
+	fakeClient, err := getFakeClientFromObjects()
+	if err != nil {
+		t.Fatalf("error in creating fake client: %v", err)
+	}
+	r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+	enable := true
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"},
+		Spec:       ranv1alpha1.ClusterGroupUpgradeSpec{Enable: &enable, DryRun: true},
+	}
+	cgu.Status.RemediationPlan = [][]string{{"spoke1", "spoke2"}, {"spoke3"}}
+	policies := policiesInfo{presentPolicies: []*unstructured.Unstructured{
+		newDryRunPolicy("policy1", map[string]string{
+			"spoke1": utils.ClusterStatusNonCompliant, "spoke2": utils.ClusterStatusCompliant,
+			"spoke3": utils.ClusterStatusPending}),
+		newDryRunPolicy("policy2", map[string]string{
+			"spoke1": utils.ClusterStatusNonCompliant, "spoke2": utils.ClusterStatusNonCompliant}),
+	}}
+
+	err = r.reconcileDryRun(context.TODO(), cgu, []string{"spoke1", "spoke2", "spoke3"}, policies)
+	assert.NoError(t, err)
+	assert.False(t, cgu.Status.DryRun.ComputedAt.IsZero())
+	assert.Equal(t, []ranv1alpha1.ClusterPlan{
+		{Name: "spoke1", Batch: 1, NonCompliantPolicies: []string{"policy1", "policy2"}},
+		{Name: "spoke2", Batch: 1, NonCompliantPolicies: []string{"policy2"}},
+		{Name: "spoke3", Batch: 2, NonCompliantPolicies: []string{"policy1"}},
+	}, cgu.Status.DryRun.Clusters)
+	assert.Nil(t, cgu.Status.Precaching)
+
+	condition := meta.FindStatusCondition(cgu.Status.Conditions, string(utils.ConditionTypes.Progressing))
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, string(utils.ConditionReasons.DryRun), condition.Reason)
+	assert.Equal(t, "Dry run: 3 clusters in 2 batches, no change is made to the clusters", condition.Message)
+}
+
+func TestDryRun_buildRemediationPlan(t *testing.T) {
+	testcases := []struct {
+		name           string
+		dryRun         bool
+		expectedLabels map[string]string
+		expectedStates []ranv1alpha1.ClusterState
+	}{
+		{
+			name:           "dry run",
+			dryRun:         true,
+			expectedLabels: map[string]string{"name": "spoke2"},
+		},
+		{
+			name:           "upgrade",
+			expectedLabels: map[string]string{"name": "spoke2", "upgraded": "true"},
+			expectedStates: []ranv1alpha1.ClusterState{{Name: "spoke2", State: utils.ClusterRemediationComplete}},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			managedCluster := &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "spoke2", Labels: map[string]string{"name": "spoke2"}}}
+			fakeClient, err := getFakeClientFromObjects(managedCluster)
+			if err != nil {
+				t.Fatalf("error in creating fake client: %v", err)
+			}
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+			enable := true
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"},
+				Spec: ranv1alpha1.ClusterGroupUpgradeSpec{
+					Enable: &enable,
+					DryRun: tc.dryRun,
+					Actions: ranv1alpha1.Actions{AfterCompletion: ranv1alpha1.AfterCompletion{
+						AddClusterLabels: map[string]string{"upgraded": "true"}}},
+					RemediationStrategy: &ranv1alpha1.RemediationStrategySpec{MaxConcurrency: 2},
+				},
+			}
+			cgu.Status.ComputedMaxConcurrency = 2
+			policies := []*unstructured.Unstructured{newDryRunPolicy("policy1", map[string]string{
+				"spoke1": utils.ClusterStatusNonCompliant, "spoke2": utils.ClusterStatusCompliant})}
+
+			r.buildRemediationPlan(context.TODO(), cgu, []string{"spoke1", "spoke2"}, policies)
+			assert.Equal(t, [][]string{{"spoke1"}}, cgu.Status.RemediationPlan)
+			assert.Equal(t, tc.expectedStates, cgu.Status.Clusters)
+
+			err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: "spoke2"}, managedCluster)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedLabels, managedCluster.Labels)
+		})
+	}
+}
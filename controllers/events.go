@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// maxClusterTransitions bounds the transitions kept in the history of each cluster
+	maxClusterTransitions = 20
+	// maxClusterHistoryTransitions bounds the transitions kept in the history of all the clusters, about
+	// 350 KB, so that the status of a CGU with thousands of clusters stays far below the size limit of etcd
+	maxClusterHistoryTransitions = 2000
+)
+
+// Reasons of the events recorded for the state transitions of the clusters
+const (
+	eventReasonClusterBatchStarted        = "ClusterBatchStarted"
+	eventReasonClusterPolicyRemediating   = "ClusterPolicyRemediating"
+	eventReasonClusterRemediationComplete = "ClusterRemediationCompleted"
+	eventReasonClusterRemediationTimedOut = "ClusterRemediationTimedOut"
+	eventReasonClusterRemediationFailed   = "ClusterRemediationFailed"
+	eventReasonClusterPrecaching          = "ClusterPrecaching"
+	eventReasonClusterBackup              = "ClusterBackup"
+	eventReasonClusterRestore             = "ClusterRestore"
+	// The failures of the actions pausing or unpausing the MachineConfigPools
+	eventReasonMachineConfigPoolActionFailed = "MachineConfigPoolActionFailed"
+)
+
+// recordClusterTransition records a state transition of a cluster as an event of the ClusterGroupUpgrade
+// and in the history of the cluster, which keeps the latest maxClusterTransitions transitions
+func (r *ClusterGroupUpgradeReconciler) recordClusterTransition(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
+	cluster, eventType, reason, state, message string) {
+
+	r.Log.Info("[recordClusterTransition]", "cluster", cluster, "reason", reason, "state", state)
+	if r.Recorder != nil {
+		r.Recorder.Event(clusterGroupUpgrade, eventType, reason, fmt.Sprintf("Cluster %s: %s", cluster, message))
+	}
+
+	if clusterGroupUpgrade.Status.ClusterHistory == nil {
+		clusterGroupUpgrade.Status.ClusterHistory = make(map[string]*ranv1alpha1.ClusterHistory)
+	}
+	history, ok := clusterGroupUpgrade.Status.ClusterHistory[cluster]
+	if !ok || history == nil {
+		history = &ranv1alpha1.ClusterHistory{}
+		clusterGroupUpgrade.Status.ClusterHistory[cluster] = history
+	}
+	history.Transitions = append(history.Transitions, ranv1alpha1.ClusterTransition{
+		Reason: reason, State: state, Message: message, Time: metav1.Now()})
+	if len(history.Transitions) > maxClusterTransitions {
+		history.Transitions = history.Transitions[len(history.Transitions)-maxClusterTransitions:]
+	}
+	trimClusterHistory(clusterGroupUpgrade)
+}
+
+// isRemediationTransition tells whether the transition is one of those read by getClusterRemediationTimes
+func isRemediationTransition(transition ranv1alpha1.ClusterTransition) bool {
+	switch transition.Reason {
+	case eventReasonClusterBatchStarted, eventReasonClusterRemediationComplete,
+		eventReasonClusterRemediationTimedOut, eventReasonClusterRemediationFailed:
+		return true
+	}
+	return false
+}
+
+// trimClusterHistory brings the history of all the clusters back to three quarters of
+// maxClusterHistoryTransitions once it holds more. Starting with the clusters whose last transition is the
+// oldest, the histories are first reduced to the transitions giving the remediation times of the clusters,
+// then removed
+func trimClusterHistory(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) {
+	total := 0
+	for _, history := range clusterGroupUpgrade.Status.ClusterHistory {
+		if history != nil {
+			total += len(history.Transitions)
+		}
+	}
+	if total <= maxClusterHistoryTransitions {
+		return
+	}
+
+	clusters := make([]string, 0, len(clusterGroupUpgrade.Status.ClusterHistory))
+	for cluster, history := range clusterGroupUpgrade.Status.ClusterHistory {
+		if history == nil || len(history.Transitions) == 0 {
+			delete(clusterGroupUpgrade.Status.ClusterHistory, cluster)
+			continue
+		}
+		clusters = append(clusters, cluster)
+	}
+	lastTransition := func(cluster string) time.Time {
+		transitions := clusterGroupUpgrade.Status.ClusterHistory[cluster].Transitions
+		return transitions[len(transitions)-1].Time.Time
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if lastTransition(clusters[i]).Equal(lastTransition(clusters[j])) {
+			return clusters[i] < clusters[j]
+		}
+		return lastTransition(clusters[i]).Before(lastTransition(clusters[j]))
+	})
+
+	target := maxClusterHistoryTransitions * 3 / 4
+	for _, cluster := range clusters {
+		if total <= target {
+			return
+		}
+		history := clusterGroupUpgrade.Status.ClusterHistory[cluster]
+		var transitions []ranv1alpha1.ClusterTransition
+		for _, transition := range history.Transitions {
+			if isRemediationTransition(transition) {
+				transitions = append(transitions, transition)
+			}
+		}
+		total -= len(history.Transitions) - len(transitions)
+		history.Transitions = transitions
+		if len(transitions) == 0 {
+			delete(clusterGroupUpgrade.Status.ClusterHistory, cluster)
+		}
+	}
+	for _, cluster := range clusters {
+		if total <= target {
+			return
+		}
+		if history, ok := clusterGroupUpgrade.Status.ClusterHistory[cluster]; ok {
+			total -= len(history.Transitions)
+			delete(clusterGroupUpgrade.Status.ClusterHistory, cluster)
+		}
+	}
+}
+
+// recordFsmTransition records the transition of a cluster between two pre-caching, backup or restore states, as a
+// warning when the cluster ends in one of the failed states
+func (r *ClusterGroupUpgradeReconciler) recordFsmTransition(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
+	cluster, reason, name, previousState, nextState string, failedStates ...string) {
+
+	eventType := corev1.EventTypeNormal
+	for _, failedState := range failedStates {
+		if nextState == failedState {
+			eventType = corev1.EventTypeWarning
+		}
+	}
+	r.recordClusterTransition(clusterGroupUpgrade, cluster, eventType, reason, nextState,
+		fmt.Sprintf("%s moved from %s to %s", name, previousState, nextState))
+}
+
+// getClusterRemediationTimes reads from the history of a cluster the start of its batch and the time it reached
+// its final remediation state. The start of the batch is missing when the cluster had more transitions than
+// the history keeps
+// returns: metav1.Time (started at), metav1.Time (completed at)
+func getClusterRemediationTimes(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
+	cluster, state string) (metav1.Time, metav1.Time) {
+
+	var startedAt, completedAt metav1.Time
+	history := clusterGroupUpgrade.Status.ClusterHistory[cluster]
+	if history == nil {
+		return startedAt, completedAt
+	}
+	for _, transition := range history.Transitions {
+		switch transition.Reason {
+		case eventReasonClusterBatchStarted:
+			startedAt = transition.Time
+		case eventReasonClusterRemediationComplete, eventReasonClusterRemediationTimedOut,
+			eventReasonClusterRemediationFailed:
+			if transition.State == state {
+				completedAt = transition.Time
+			}
+		}
+	}
+	return startedAt, completedAt
+}
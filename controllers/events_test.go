@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+// drainEvents returns the events recorded by a fake recorder
+func drainEvents(recorder *record.FakeRecorder) []string {
+	var events []string
+	for {
+		select {
+		case event := <-recorder.Events:
+			events = append(events, event)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEvents_recordClusterTransition(t *testing.T) {
+	recorder := record.NewFakeRecorder(100)
+	r := &ClusterGroupUpgradeReconciler{Log: logr.Discard(), Recorder: recorder}
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"}}
+
+	for i := 1; i <= maxClusterTransitions+5; i++ {
+		r.recordClusterTransition(cgu, "spoke1", corev1.EventTypeNormal, eventReasonClusterBatchStarted,
+			ranv1alpha1.NotStarted, fmt.Sprintf("batch %d started", i))
+	}
+	r.recordClusterTransition(cgu, "spoke2", corev1.EventTypeWarning, eventReasonClusterRemediationFailed,
+		utils.ClusterRemediationFailed, "ClusterVersion failing")
+
+	// The history keeps the latest transitions
+	transitions := cgu.Status.ClusterHistory["spoke1"].Transitions
+	assert.Len(t, transitions, maxClusterTransitions)
+	assert.Equal(t, "batch 6 started", transitions[0].Message)
+	assert.Equal(t, fmt.Sprintf("batch %d started", maxClusterTransitions+5), transitions[len(transitions)-1].Message)
+	assert.Equal(t, []ranv1alpha1.ClusterTransition{{
+		Reason: eventReasonClusterRemediationFailed, State: utils.ClusterRemediationFailed,
+		Message: "ClusterVersion failing", Time: cgu.Status.ClusterHistory["spoke2"].Transitions[0].Time,
+	}}, cgu.Status.ClusterHistory["spoke2"].Transitions)
+	assert.False(t, cgu.Status.ClusterHistory["spoke2"].Transitions[0].Time.IsZero())
+
+	// All the transitions are recorded as events
+	events := drainEvents(recorder)
+	assert.Len(t, events, maxClusterTransitions+6)
+	assert.Equal(t, "Normal ClusterBatchStarted Cluster spoke1: batch 1 started", events[0])
+	assert.Equal(t, "Warning ClusterRemediationFailed Cluster spoke2: ClusterVersion failing", events[len(events)-1])
+}
+
+func TestEvents_trimClusterHistory(t *testing.T) {
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+	cgu.Status.ClusterHistory = make(map[string]*ranv1alpha1.ClusterHistory)
+	start := metav1.Now()
+	at := func(minutes int) metav1.Time { return metav1.NewTime(start.Add(time.Duration(minutes) * time.Minute)) }
+	total := 0
+	for i := 0; total+maxClusterTransitions <= maxClusterHistoryTransitions; i++ {
+		// Each cluster went through its batch and 18 pre-caching transitions, the lower indexes first
+		history := &ranv1alpha1.ClusterHistory{}
+		history.Transitions = append(history.Transitions,
+			ranv1alpha1.ClusterTransition{Reason: eventReasonClusterBatchStarted, State: ranv1alpha1.NotStarted, Time: at(i)})
+		for j := 0; j < maxClusterTransitions-2; j++ {
+			history.Transitions = append(history.Transitions,
+				ranv1alpha1.ClusterTransition{Reason: eventReasonClusterPrecaching, State: PrecacheStateStarting, Time: at(i)})
+		}
+		history.Transitions = append(history.Transitions,
+			ranv1alpha1.ClusterTransition{Reason: eventReasonClusterRemediationComplete, State: utils.ClusterRemediationComplete, Time: at(i)})
+		cgu.Status.ClusterHistory[fmt.Sprintf("spoke%04d", i)] = history
+		total += len(history.Transitions)
+	}
+
+	// Nothing is trimmed while the history holds no more than the bound
+	trimClusterHistory(cgu)
+	assert.Equal(t, total, countClusterTransitions(cgu))
+
+	// Over the bound, the oldest clusters only keep their remediation times
+	r := &ClusterGroupUpgradeReconciler{Log: logr.Discard()}
+	r.recordClusterTransition(cgu, "spoke9999", corev1.EventTypeNormal, eventReasonClusterBatchStarted,
+		ranv1alpha1.NotStarted, "batch 2 started")
+	assert.LessOrEqual(t, countClusterTransitions(cgu), maxClusterHistoryTransitions*3/4)
+	assert.Len(t, cgu.Status.ClusterHistory["spoke0000"].Transitions, 2)
+	startedAt, completedAt := getClusterRemediationTimes(cgu, "spoke0000", utils.ClusterRemediationComplete)
+	assert.Equal(t, at(0), startedAt)
+	assert.Equal(t, at(0), completedAt)
+	assert.Len(t, cgu.Status.ClusterHistory["spoke0099"].Transitions, maxClusterTransitions)
+	assert.Len(t, cgu.Status.ClusterHistory["spoke9999"].Transitions, 1)
+
+	// The oldest histories are removed once reduced
+	for i := 0; i < maxClusterHistoryTransitions; i++ {
+		r.recordClusterTransition(cgu, fmt.Sprintf("other%04d", i), corev1.EventTypeNormal, eventReasonClusterBatchStarted,
+			ranv1alpha1.NotStarted, "batch 3 started")
+	}
+	assert.LessOrEqual(t, countClusterTransitions(cgu), maxClusterHistoryTransitions)
+	assert.NotContains(t, cgu.Status.ClusterHistory, "spoke0000")
+	assert.Contains(t, cgu.Status.ClusterHistory, fmt.Sprintf("other%04d", maxClusterHistoryTransitions-1))
+}
+
+// countClusterTransitions counts the transitions in the history of all the clusters
+func countClusterTransitions(cgu *ranv1alpha1.ClusterGroupUpgrade) int {
+	total := 0
+	for _, history := range cgu.Status.ClusterHistory {
+		total += len(history.Transitions)
+	}
+	return total
+}
+
+func TestEvents_recordFsmTransition(t *testing.T) {
+	testcases := []struct {
+		name          string
+		nextState     string
+		expectedEvent string
+	}{
+		{
+			name:          "backup started",
+			nextState:     BackupStateActive,
+			expectedEvent: "Normal ClusterBackup Cluster spoke1: backup moved from Starting to Active",
+		},
+		{
+			name:          "backup timed out",
+			nextState:     BackupStateTimeout,
+			expectedEvent: "Warning ClusterBackup Cluster spoke1: backup moved from Starting to BackupTimeout",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(10)
+			r := &ClusterGroupUpgradeReconciler{Log: logr.Discard(), Recorder: recorder}
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+
+			r.recordFsmTransition(cgu, "spoke1", eventReasonClusterBackup, "backup",
+				BackupStateStarting, tc.nextState, BackupStateTimeout, BackupStateError)
+			assert.Equal(t, []string{tc.expectedEvent}, drainEvents(recorder))
+			assert.Equal(t, tc.nextState, cgu.Status.ClusterHistory["spoke1"].Transitions[0].State)
+		})
+	}
+}
+
+func TestEvents_handleBatchTimeout(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	fakeClient, _ := getFakeClientFromObjects()
+	r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme, Recorder: recorder}
+
+	policyIndex := 0
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"}}
+	cgu.Status.RemediationPlan = [][]string{{"spoke1", "spoke2", "spoke3"}}
+	cgu.Status.ManagedPoliciesForUpgrade = []ranv1alpha1.ManagedPolicyForUpgrade{{Name: "policy1", Namespace: "default"}}
+	cgu.Status.Status.CurrentBatch = 1
+	cgu.Status.Status.CurrentBatchRemediationProgress = map[string]*ranv1alpha1.ClusterRemediationProgress{
+		"spoke1": {State: ranv1alpha1.InProgress, PolicyIndex: &policyIndex},
+		"spoke2": {State: ranv1alpha1.Completed},
+	}
+
+	r.handleBatchTimeout(context.TODO(), cgu)
+	assert.ElementsMatch(t, []string{
+		"Warning ClusterRemediationTimedOut Cluster spoke1: the batch timed out while remediating policy policy1",
+		"Warning ClusterRemediationTimedOut Cluster spoke3: the batch timed out before the remediation started",
+	}, drainEvents(recorder))
+	assert.NotContains(t, cgu.Status.ClusterHistory, "spoke2")
+	for _, cluster := range []string{"spoke1", "spoke3"} {
+		assert.Equal(t, utils.ClusterRemediationTimedout, cgu.Status.ClusterHistory[cluster].Transitions[0].State)
+	}
+}
@@ -0,0 +1,286 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	imageBasedUpgradeName     = "upgrade"
+	imageBasedUpgradeResource = "imagebasedupgrade"
+	// imageBasedUpgradeStageIdle is the stage of the ImageBasedUpgrade CR once finalized
+	imageBasedUpgradeStageIdle = "Idle"
+)
+
+// imageBasedUpgradeStageConditions maps the stages to the ImageBasedUpgrade stage they set and the
+// conditions reporting their progress and completion
+var imageBasedUpgradeStageConditions = map[string]struct {
+	stage, inProgress, completed string
+}{
+	ranv1alpha1.ImageBasedUpgradeStagePrep:     {"Prep", "PrepInProgress", "PrepCompleted"},
+	ranv1alpha1.ImageBasedUpgradeStageUpgrade:  {"Upgrade", "UpgradeInProgress", "UpgradeCompleted"},
+	ranv1alpha1.ImageBasedUpgradeStageRollback: {"Rollback", "RollbackInProgress", "RollbackCompleted"},
+	ranv1alpha1.ImageBasedUpgradeStageFinalize: {imageBasedUpgradeStageIdle, "FinalizeInProgress", "Idle"},
+}
+
+// getImageBasedUpgradeStages returns the stages of the image-based upgrade, Prep, Upgrade and Finalize
+// by default
+// returns: []string
+func getImageBasedUpgradeStages(imageBasedUpgrade *ranv1alpha1.ImageBasedUpgradeSpec) []string {
+	if len(imageBasedUpgrade.Stages) == 0 {
+		return []string{
+			ranv1alpha1.ImageBasedUpgradeStagePrep,
+			ranv1alpha1.ImageBasedUpgradeStageUpgrade,
+			ranv1alpha1.ImageBasedUpgradeStageFinalize,
+		}
+	}
+	return imageBasedUpgrade.Stages
+}
+
+// validateImageBasedUpgrade checks the stages of the image-based upgrade and the seed image they need
+// returns: error
+func validateImageBasedUpgrade(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) error {
+	imageBasedUpgrade := clusterGroupUpgrade.Spec.ImageBasedUpgrade
+	if imageBasedUpgrade == nil {
+		return nil
+	}
+
+	var err error
+	if clusterGroupUpgrade.Spec.PlatformUpgrade != nil {
+		err = errors.New("platformUpgrade and imageBasedUpgrade are mutually exclusive")
+	}
+	for _, stage := range getImageBasedUpgradeStages(imageBasedUpgrade) {
+		if err != nil {
+			break
+		}
+		if _, ok := imageBasedUpgradeStageConditions[stage]; !ok {
+			err = fmt.Errorf("unknown image-based upgrade stage %s", stage)
+		} else if stage == ranv1alpha1.ImageBasedUpgradeStagePrep &&
+			(imageBasedUpgrade.SeedImageRef.Image == "" || imageBasedUpgrade.SeedImageRef.Version == "") {
+			err = errors.New("image-based upgrade with the Prep stage must have a seed image and version")
+		}
+	}
+	if err != nil {
+		utils.SetStatusCondition(
+			&clusterGroupUpgrade.Status.Conditions,
+			utils.ConditionTypes.Validated,
+			utils.ConditionReasons.InvalidImageBasedUpgrade,
+			metav1.ConditionFalse,
+			err.Error(),
+		)
+		return err
+	}
+	if clusterGroupUpgrade.Status.ImageBasedUpgrade == nil {
+		clusterGroupUpgrade.Status.ImageBasedUpgrade = &ranv1alpha1.ImageBasedUpgradeStatus{}
+	}
+	return nil
+}
+
+// isImageBasedUpgradeCompleted returns whether the cluster completed the image-based upgrade of the CGU.
+// It is always true for CGUs without an image-based upgrade
+// returns: bool
+func isImageBasedUpgradeCompleted(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) bool {
+	if clusterGroupUpgrade.Spec.ImageBasedUpgrade == nil {
+		return true
+	}
+	if clusterGroupUpgrade.Status.ImageBasedUpgrade == nil {
+		return false
+	}
+	status, ok := clusterGroupUpgrade.Status.ImageBasedUpgrade.Clusters[cluster]
+	return ok && status.State == ranv1alpha1.Completed
+}
+
+// getNextImageBasedUpgradeStage returns the next stage of the cluster. A cluster rolled back after
+// failing the Upgrade stage is only finalized
+// returns: string - the stage, empty once all the stages are completed
+func getNextImageBasedUpgradeStage(
+	imageBasedUpgrade *ranv1alpha1.ImageBasedUpgradeSpec, clusterStatus *ranv1alpha1.ClusterImageBasedUpgradeStatus) string {
+
+	completed := make(map[string]bool)
+	for _, stage := range clusterStatus.CompletedStages {
+		completed[stage] = true
+	}
+	stages := getImageBasedUpgradeStages(imageBasedUpgrade)
+	if clusterStatus.RolledBack {
+		stages = []string{ranv1alpha1.ImageBasedUpgradeStageFinalize}
+	}
+	for _, stage := range stages {
+		if !completed[stage] {
+			return stage
+		}
+	}
+	return ""
+}
+
+// getImageBasedUpgradeCondition returns the condition of the ImageBasedUpgrade CR
+// returns: string - status, string - reason, string - message, all empty when the condition is missing
+func getImageBasedUpgradeCondition(imageBasedUpgrade *unstructured.Unstructured, conditionType string) (string, string, string) {
+	conditions, _, _ := unstructured.NestedSlice(imageBasedUpgrade.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok || condition["type"] != conditionType {
+			continue
+		}
+		status, _ := condition["status"].(string)
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		return status, reason, message
+	}
+	return "", "", ""
+}
+
+// getImageBasedUpgradeView ensures the view of the ImageBasedUpgrade CR of the cluster
+// returns: *unstructured.Unstructured - the ImageBasedUpgrade, nil until the view reports it, error
+func (r *ClusterGroupUpgradeReconciler) getImageBasedUpgradeView(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (*unstructured.Unstructured, error) {
+
+	kind := utils.ImageBasedUpgradeGroupVersionKind().Kind
+	mcvName := utils.GetMultiCloudObjectName(clusterGroupUpgrade, kind, imageBasedUpgradeName)
+	safeName := utils.GetSafeResourceName(mcvName, clusterGroupUpgrade, utils.MaxObjectNameLength, 0)
+	mcv, err := utils.EnsureManagedClusterView(
+		ctx, r.Client, safeName, mcvName, cluster, kind+"."+utils.ImageBasedUpgradeGroupVersionKind().Group,
+		imageBasedUpgradeName, "", clusterGroupUpgrade.Namespace+"-"+clusterGroupUpgrade.Name)
+	if err != nil {
+		return nil, err
+	}
+	return utils.GetManagedClusterViewResult(mcv)
+}
+
+// requestImageBasedUpgradeStage moves the ImageBasedUpgrade CR of the cluster to the stage through an action.
+// The seed image is set along with the Prep stage
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) requestImageBasedUpgradeStage(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string,
+	imageBasedUpgrade *unstructured.Unstructured, stage string) error {
+
+	update := imageBasedUpgrade.DeepCopy()
+	if err := unstructured.SetNestedField(update.Object, stage, "spec", "stage"); err != nil {
+		return err
+	}
+	if stage == ranv1alpha1.ImageBasedUpgradeStagePrep {
+		seedImageRef := clusterGroupUpgrade.Spec.ImageBasedUpgrade.SeedImageRef
+		seedImage := map[string]interface{}{
+			"version": seedImageRef.Version,
+			"image":   seedImageRef.Image,
+		}
+		if seedImageRef.PullSecretRef != "" {
+			seedImage["pullSecretRef"] = map[string]interface{}{"name": seedImageRef.PullSecretRef}
+		}
+		if err := unstructured.SetNestedMap(update.Object, seedImage, "spec", "seedImageRef"); err != nil {
+			return err
+		}
+	}
+
+	kind := utils.ImageBasedUpgradeGroupVersionKind().Kind
+	mcaName := utils.GetMultiCloudObjectName(clusterGroupUpgrade, kind, imageBasedUpgradeName+"-"+strings.ToLower(stage))
+	safeName := utils.GetSafeResourceName(mcaName, clusterGroupUpgrade, utils.MaxObjectNameLength, 0)
+	created, err := utils.EnsureManagedClusterActionForUpdate(
+		ctx, r.Client, safeName, cluster, clusterGroupUpgrade.Namespace+"-"+clusterGroupUpgrade.Name, imageBasedUpgradeResource, update)
+	if created {
+		r.Log.Info("[requestImageBasedUpgradeStage] Requested the stage", "cluster", cluster, "stage", stage)
+	}
+	return err
+}
+
+// reconcileImageBasedUpgrade moves the ImageBasedUpgrade CR of the cluster through the stages of the
+// image-based upgrade. A cluster failing the Upgrade stage is rolled back and finalized, when requested,
+// before its remediation is failed
+// returns: bool - true once the cluster completed the image-based upgrade, error
+func (r *ClusterGroupUpgradeReconciler) reconcileImageBasedUpgrade(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (bool, error) {
+
+	if isImageBasedUpgradeCompleted(clusterGroupUpgrade, cluster) {
+		return true, nil
+	}
+	imageBasedUpgrade := clusterGroupUpgrade.Spec.ImageBasedUpgrade
+	if clusterGroupUpgrade.Status.ImageBasedUpgrade == nil {
+		clusterGroupUpgrade.Status.ImageBasedUpgrade = &ranv1alpha1.ImageBasedUpgradeStatus{}
+	}
+	status := clusterGroupUpgrade.Status.ImageBasedUpgrade
+	if status.Clusters == nil {
+		status.Clusters = make(map[string]*ranv1alpha1.ClusterImageBasedUpgradeStatus)
+	}
+	clusterStatus, ok := status.Clusters[cluster]
+	if !ok {
+		clusterStatus = &ranv1alpha1.ClusterImageBasedUpgradeStatus{
+			State:     ranv1alpha1.InProgress,
+			StartedAt: metav1.Now(),
+		}
+		status.Clusters[cluster] = clusterStatus
+	}
+	if clusterStatus.State == ranv1alpha1.Failed {
+		return false, nil
+	}
+
+	ibu, err := r.getImageBasedUpgradeView(ctx, clusterGroupUpgrade, cluster)
+	if err != nil || ibu == nil {
+		return false, err
+	}
+
+	for {
+		if clusterStatus.Stage == "" {
+			clusterStatus.Stage = getNextImageBasedUpgradeStage(imageBasedUpgrade, clusterStatus)
+		}
+		r.Log.Info("[reconcileImageBasedUpgrade]", "cluster", cluster, "stage", clusterStatus.Stage,
+			"completedStages", clusterStatus.CompletedStages)
+
+		if clusterStatus.Stage == "" {
+			if clusterStatus.RolledBack {
+				clusterStatus.State = ranv1alpha1.Failed
+				return false, r.failClusterRemediation(ctx, clusterGroupUpgrade, cluster, clusterStatus.Message)
+			}
+			clusterStatus.State = ranv1alpha1.Completed
+			clusterStatus.Message = "Image-based upgrade completed"
+			return true, nil
+		}
+
+		conditions := imageBasedUpgradeStageConditions[clusterStatus.Stage]
+		// The conditions are only relevant once the CR is at the stage
+		currentStage, _, _ := unstructured.NestedString(ibu.Object, "spec", "stage")
+		if currentStage != conditions.stage {
+			// The failure of the Upgrade stage is kept while the cluster is rolled back
+			if !clusterStatus.RolledBack {
+				clusterStatus.Message = fmt.Sprintf("Moving to stage %s", clusterStatus.Stage)
+			}
+			return false, r.requestImageBasedUpgradeStage(ctx, clusterGroupUpgrade, cluster, ibu, conditions.stage)
+		}
+
+		completed, reason, message := getImageBasedUpgradeCondition(ibu, conditions.completed)
+		switch {
+		case completed == "True":
+			clusterStatus.CompletedStages = append(clusterStatus.CompletedStages, clusterStatus.Stage)
+			clusterStatus.Stage = ""
+			continue
+
+		case completed == "False" && reason == "Failed":
+			failure := fmt.Sprintf("%s stage failed: %s", clusterStatus.Stage, message)
+			autoRollback := imageBasedUpgrade.AutoRollbackOnFailure == nil || *imageBasedUpgrade.AutoRollbackOnFailure
+			if clusterStatus.Stage == ranv1alpha1.ImageBasedUpgradeStageUpgrade && autoRollback {
+				clusterStatus.RolledBack = true
+				clusterStatus.Stage = ranv1alpha1.ImageBasedUpgradeStageRollback
+				clusterStatus.Message = failure
+				// The failure of the Upgrade stage is reported once the cluster is rolled back
+				r.Log.Info("[reconcileImageBasedUpgrade] Rolling back", "cluster", cluster, "failure", failure)
+				failure = ""
+			}
+			if failure != "" {
+				clusterStatus.State = ranv1alpha1.Failed
+				clusterStatus.Message = failure
+				return false, r.failClusterRemediation(ctx, clusterGroupUpgrade, cluster, failure)
+			}
+			continue
+		}
+
+		if _, _, progress := getImageBasedUpgradeCondition(ibu, conditions.inProgress); progress != "" && !clusterStatus.RolledBack {
+			clusterStatus.Message = progress
+		}
+		return false, nil
+	}
+}
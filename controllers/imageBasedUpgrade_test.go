@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	actionv1beta1 "github.com/stolostron/cluster-lifecycle-api/action/v1beta1"
+	viewv1beta1 "github.com/stolostron/cluster-lifecycle-api/view/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newImageBasedUpgradeResult builds an ImageBasedUpgrade at the stage with the conditions,
+// each given as type, status, reason and message
+func newImageBasedUpgradeResult(stage string, conditions ...[4]string) map[string]interface{} {
+	var items []interface{}
+	for _, condition := range conditions {
+		items = append(items, map[string]interface{}{
+			"type": condition[0], "status": condition[1], "reason": condition[2], "message": condition[3],
+		})
+	}
+	return map[string]interface{}{
+		"apiVersion": "lca.openshift.io/v1",
+		"kind":       "ImageBasedUpgrade",
+		"metadata":   map[string]interface{}{"name": "upgrade", "resourceVersion": "5"},
+		"spec":       map[string]interface{}{"stage": stage},
+		"status":     map[string]interface{}{"conditions": items},
+	}
+}
+
+func TestImageBasedUpgrade_reconcileImageBasedUpgrade(t *testing.T) {
+	const ibuView = "cgu-default-imagebasedupgrade-upgrade-kuttl"
+	noAutoRollback := false
+	testcases := []struct {
+		name            string
+		autoRollback    *bool
+		clusterStatus   *ranv1alpha1.ClusterImageBasedUpgradeStatus
+		objs            []client.Object
+		expectedDone    bool
+		expectedStatus  ranv1alpha1.ClusterImageBasedUpgradeStatus
+		expectedActions []string
+		expectedStage   string
+		expectedFailed  string
+	}{
+		{
+			name: "view not processed yet",
+			objs: []client.Object{&viewv1beta1.ManagedClusterView{
+				ObjectMeta: metav1.ObjectMeta{Name: ibuView, Namespace: "spoke1"}}},
+			expectedStatus: ranv1alpha1.ClusterImageBasedUpgradeStatus{State: ranv1alpha1.InProgress},
+		},
+		{
+			name: "move to the Prep stage",
+			objs: []client.Object{newPlatformUpgradeView(ibuView, "spoke1", newImageBasedUpgradeResult("Idle",
+				[4]string{"Idle", "True", "Idle", "Idle"}))},
+			expectedStatus: ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Prep", Message: "Moving to stage Prep"},
+			expectedActions: []string{"cgu-default-imagebasedupgrade-upgrade-prep-kuttl"},
+			expectedStage:   "Prep",
+		},
+		{
+			name: "Prep stage in progress",
+			clusterStatus: &ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Prep"},
+			objs: []client.Object{newPlatformUpgradeView(ibuView, "spoke1", newImageBasedUpgradeResult("Prep",
+				[4]string{"PrepInProgress", "True", "InProgress", "Pulling the seed image"}))},
+			expectedStatus: ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Prep", Message: "Pulling the seed image"},
+		},
+		{
+			name: "move to the Upgrade stage",
+			clusterStatus: &ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Prep"},
+			objs: []client.Object{newPlatformUpgradeView(ibuView, "spoke1", newImageBasedUpgradeResult("Prep",
+				[4]string{"PrepCompleted", "True", "Completed", "Prep completed"}))},
+			expectedStatus: ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Upgrade", CompletedStages: []string{"Prep"},
+				Message: "Moving to stage Upgrade"},
+			expectedActions: []string{"cgu-default-imagebasedupgrade-upgrade-upgrade-kuttl"},
+			expectedStage:   "Upgrade",
+		},
+		{
+			name: "Prep stage failed",
+			clusterStatus: &ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Prep"},
+			objs: []client.Object{newPlatformUpgradeView(ibuView, "spoke1", newImageBasedUpgradeResult("Prep",
+				[4]string{"PrepCompleted", "False", "Failed", "failed to pull the seed image"}))},
+			expectedStatus: ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.Failed, Stage: "Prep", Message: "Prep stage failed: failed to pull the seed image"},
+			expectedFailed: "Prep stage failed: failed to pull the seed image",
+		},
+		{
+			name: "roll back a failed upgrade",
+			clusterStatus: &ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Upgrade", CompletedStages: []string{"Prep"}},
+			objs: []client.Object{newPlatformUpgradeView(ibuView, "spoke1", newImageBasedUpgradeResult("Upgrade",
+				[4]string{"UpgradeCompleted", "False", "Failed", "cluster operators degraded"}))},
+			expectedStatus: ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Rollback", CompletedStages: []string{"Prep"}, RolledBack: true,
+				Message: "Upgrade stage failed: cluster operators degraded"},
+			expectedActions: []string{"cgu-default-imagebasedupgrade-upgrade-rollback-kuttl"},
+			expectedStage:   "Rollback",
+		},
+		{
+			name:         "failed upgrade without automatic rollback",
+			autoRollback: &noAutoRollback,
+			clusterStatus: &ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Upgrade", CompletedStages: []string{"Prep"}},
+			objs: []client.Object{newPlatformUpgradeView(ibuView, "spoke1", newImageBasedUpgradeResult("Upgrade",
+				[4]string{"UpgradeCompleted", "False", "Failed", "cluster operators degraded"}))},
+			expectedStatus: ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.Failed, Stage: "Upgrade", CompletedStages: []string{"Prep"},
+				Message: "Upgrade stage failed: cluster operators degraded"},
+			expectedFailed: "Upgrade stage failed: cluster operators degraded",
+		},
+		{
+			name: "finalize the rolled back cluster",
+			clusterStatus: &ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Rollback", CompletedStages: []string{"Prep"}, RolledBack: true,
+				Message: "Upgrade stage failed: cluster operators degraded"},
+			objs: []client.Object{newPlatformUpgradeView(ibuView, "spoke1", newImageBasedUpgradeResult("Rollback",
+				[4]string{"RollbackCompleted", "True", "Completed", "Rollback completed"}))},
+			expectedStatus: ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Finalize", CompletedStages: []string{"Prep", "Rollback"},
+				RolledBack: true, Message: "Upgrade stage failed: cluster operators degraded"},
+			expectedActions: []string{"cgu-default-imagebasedupgrade-upgrade-idle-kuttl"},
+			expectedStage:   "Idle",
+		},
+		{
+			name: "rolled back cluster finalized",
+			clusterStatus: &ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Finalize", CompletedStages: []string{"Prep", "Rollback"},
+				RolledBack: true, Message: "Upgrade stage failed: cluster operators degraded"},
+			objs: []client.Object{newPlatformUpgradeView(ibuView, "spoke1", newImageBasedUpgradeResult("Idle",
+				[4]string{"Idle", "True", "Idle", "Idle"}))},
+			expectedStatus: ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.Failed, CompletedStages: []string{"Prep", "Rollback", "Finalize"},
+				RolledBack: true, Message: "Upgrade stage failed: cluster operators degraded"},
+			expectedFailed: "Upgrade stage failed: cluster operators degraded",
+		},
+		{
+			name: "upgraded cluster finalized",
+			clusterStatus: &ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.InProgress, Stage: "Finalize", CompletedStages: []string{"Prep", "Upgrade"}},
+			objs: []client.Object{newPlatformUpgradeView(ibuView, "spoke1", newImageBasedUpgradeResult("Idle",
+				[4]string{"Idle", "True", "Idle", "Idle"}))},
+			expectedDone: true,
+			expectedStatus: ranv1alpha1.ClusterImageBasedUpgradeStatus{
+				State: ranv1alpha1.Completed, CompletedStages: []string{"Prep", "Upgrade", "Finalize"},
+				Message: "Image-based upgrade completed"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cgu", Namespace: "default",
+					Annotations: map[string]string{utils.NameSuffixAnnotation: "kuttl"},
+				},
+			}
+			cgu.Spec.ImageBasedUpgrade = &ranv1alpha1.ImageBasedUpgradeSpec{
+				SeedImageRef: ranv1alpha1.SeedImageRef{
+					Version: "4.16.5", Image: "registry.example.com/seed:4.16.5", PullSecretRef: "seed-pull-secret"},
+				AutoRollbackOnFailure: tc.autoRollback,
+			}
+			cgu.Status.Status.CurrentBatchRemediationProgress = map[string]*ranv1alpha1.ClusterRemediationProgress{
+				"spoke1": {State: ranv1alpha1.InProgress},
+			}
+			if tc.clusterStatus != nil {
+				cgu.Status.ImageBasedUpgrade = &ranv1alpha1.ImageBasedUpgradeStatus{
+					Clusters: map[string]*ranv1alpha1.ClusterImageBasedUpgradeStatus{"spoke1": tc.clusterStatus},
+				}
+			}
+
+			fakeClient, _ := getFakeClientFromObjects(tc.objs...)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			done, err := r.reconcileImageBasedUpgrade(context.TODO(), cgu, "spoke1")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDone, done)
+			assert.Equal(t, tc.expectedDone, isImageBasedUpgradeCompleted(cgu, "spoke1"))
+
+			clusterStatus := *cgu.Status.ImageBasedUpgrade.Clusters["spoke1"]
+			clusterStatus.StartedAt = metav1.Time{}
+			assert.Equal(t, tc.expectedStatus, clusterStatus)
+
+			if tc.expectedFailed != "" {
+				assert.Equal(t, ranv1alpha1.Failed, cgu.Status.Status.CurrentBatchRemediationProgress["spoke1"].State)
+				assert.Equal(t, []ranv1alpha1.ClusterState{{
+					Name: "spoke1", State: utils.ClusterRemediationFailed, Message: tc.expectedFailed,
+				}}, cgu.Status.Clusters)
+			} else {
+				assert.Empty(t, cgu.Status.Clusters)
+			}
+
+			actions := &actionv1beta1.ManagedClusterActionList{}
+			assert.NoError(t, fakeClient.List(context.TODO(), actions, client.InNamespace("spoke1")))
+			var names []string
+			for _, action := range actions.Items {
+				names = append(names, action.Name)
+				assert.Equal(t, imageBasedUpgradeResource, action.Spec.KubeWork.Resource)
+				object := map[string]interface{}{}
+				assert.NoError(t, json.Unmarshal(action.Spec.KubeWork.ObjectTemplate.Raw, &object))
+				spec := object["spec"].(map[string]interface{})
+				assert.Equal(t, tc.expectedStage, spec["stage"])
+				if tc.expectedStage == "Prep" {
+					assert.Equal(t, map[string]interface{}{
+						"version":       "4.16.5",
+						"image":         "registry.example.com/seed:4.16.5",
+						"pullSecretRef": map[string]interface{}{"name": "seed-pull-secret"},
+					}, spec["seedImageRef"])
+				} else {
+					assert.NotContains(t, spec, "seedImageRef")
+				}
+			}
+			assert.Equal(t, tc.expectedActions, names)
+		})
+	}
+}
+
+func TestImageBasedUpgrade_validateImageBasedUpgrade(t *testing.T) {
+	seedImageRef := ranv1alpha1.SeedImageRef{Version: "4.16.5", Image: "registry.example.com/seed:4.16.5"}
+	testcases := []struct {
+		name            string
+		imageBasedSpec  *ranv1alpha1.ImageBasedUpgradeSpec
+		platformUpgrade bool
+		expectedErr     string
+	}{
+		{
+			name:           "default stages",
+			imageBasedSpec: &ranv1alpha1.ImageBasedUpgradeSpec{SeedImageRef: seedImageRef},
+		},
+		{
+			name:           "Prep stage without a seed image",
+			imageBasedSpec: &ranv1alpha1.ImageBasedUpgradeSpec{},
+			expectedErr:    "image-based upgrade with the Prep stage must have a seed image and version",
+		},
+		{
+			name:           "finalize only",
+			imageBasedSpec: &ranv1alpha1.ImageBasedUpgradeSpec{Stages: []string{"Finalize"}},
+		},
+		{
+			name:           "unknown stage",
+			imageBasedSpec: &ranv1alpha1.ImageBasedUpgradeSpec{Stages: []string{"Abort"}},
+			expectedErr:    "unknown image-based upgrade stage Abort",
+		},
+		{
+			name:            "with a platform upgrade",
+			imageBasedSpec:  &ranv1alpha1.ImageBasedUpgradeSpec{SeedImageRef: seedImageRef},
+			platformUpgrade: true,
+			expectedErr:     "platformUpgrade and imageBasedUpgrade are mutually exclusive",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+			cgu.Spec.ImageBasedUpgrade = tc.imageBasedSpec
+			if tc.platformUpgrade {
+				cgu.Spec.PlatformUpgrade = &ranv1alpha1.PlatformUpgradeSpec{
+					Versions: []ranv1alpha1.PlatformUpgradeVersion{{Version: "4.16.5"}}}
+			}
+			err := validateImageBasedUpgrade(cgu)
+			condition := meta.FindStatusCondition(cgu.Status.Conditions, string(utils.ConditionTypes.Validated))
+			if tc.expectedErr == "" {
+				assert.NoError(t, err)
+				assert.Nil(t, condition)
+				assert.NotNil(t, cgu.Status.ImageBasedUpgrade)
+				return
+			}
+			assert.EqualError(t, err, tc.expectedErr)
+			assert.Equal(t, string(utils.ConditionReasons.InvalidImageBasedUpgrade), condition.Reason)
+			assert.Equal(t, tc.expectedErr, condition.Message)
+		})
+	}
+}
@@ -0,0 +1,322 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Image inventory states
+const (
+	InventoryStatePreparingToStart = "PreparingToStart"
+	InventoryStateStarting         = "Starting"
+	InventoryStateActive           = "Active"
+	InventoryStateSucceeded        = "Succeeded"
+	InventoryStateTimeout          = "InventoryTimeout"
+	InventoryStateError            = "UnrecoverableError"
+)
+
+const (
+	inventoryJobTimeout       = 600
+	inventoryJobTimeoutBuffer = 600
+	inventoryImagesKey        = "images.json"
+	inventorySummaryKey       = "summary.json"
+)
+
+// reconcileImageInventory runs the image inventory job on the clusters that completed the upgrade
+// returns: bool - true when all the clusters reached a final inventory state
+//
+//	error
+func (r *ClusterGroupUpgradeReconciler) reconcileImageInventory(
+	ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) (bool, error) {
+
+	afterCompletion := clusterGroupUpgrade.Spec.Actions.AfterCompletion
+	if !afterCompletion.ReportImageInventory && !afterCompletion.PruneImages {
+		return true, nil
+	}
+
+	var clusters []string
+	for _, clusterState := range clusterGroupUpgrade.Status.Clusters {
+		if clusterState.State == utils.ClusterRemediationComplete {
+			clusters = append(clusters, clusterState.Name)
+		}
+	}
+	if len(clusters) == 0 {
+		return true, nil
+	}
+
+	if clusterGroupUpgrade.Status.ImageInventory == nil {
+		clusterGroupUpgrade.Status.ImageInventory = &ranv1alpha1.ImageInventoryStatus{
+			Status:    make(map[string]string),
+			Clusters:  make(map[string]ranv1alpha1.ClusterImageInventory),
+			StartedAt: metav1.Now(),
+		}
+	}
+	return r.triggerImageInventory(ctx, clusterGroupUpgrade, clusters)
+}
+
+func (r *ClusterGroupUpgradeReconciler) triggerImageInventory(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
+	clusters []string) (bool, error) {
+
+	isTimedOut := time.Since(clusterGroupUpgrade.Status.ImageInventory.StartedAt.Time) >
+		time.Duration(inventoryJobTimeout+inventoryJobTimeoutBuffer)*time.Second
+	done := true
+
+	for _, cluster := range clusters {
+		var (
+			currentState, nextState string
+			err                     error
+			ok                      bool
+		)
+		if currentState, ok = clusterGroupUpgrade.Status.ImageInventory.Status[cluster]; !ok {
+			currentState = InventoryStatePreparingToStart
+		}
+
+		r.Log.Info("[triggerImageInventory]", "currentState", currentState, "cluster", cluster)
+		switch currentState {
+		// Initial State
+		case InventoryStatePreparingToStart:
+			nextState, err = r.inventoryPreparing(ctx, clusterGroupUpgrade, cluster)
+
+		case InventoryStateStarting:
+			nextState, err = r.inventoryStarting(ctx, clusterGroupUpgrade, cluster)
+
+		case InventoryStateActive:
+			nextState, err = r.inventoryActive(ctx, clusterGroupUpgrade, cluster)
+
+		// Final states that don't change for the life of the CR
+		case InventoryStateSucceeded, InventoryStateTimeout, InventoryStateError:
+			r.Log.Info("[triggerImageInventory]", "cluster", cluster, "final state", currentState)
+			continue
+
+		default:
+			return false, fmt.Errorf("[triggerImageInventory] unknown state %s", currentState)
+		}
+
+		if err != nil {
+			r.Log.Info("[triggerImageInventory]", "cluster", cluster, "err", err)
+		}
+
+		if isTimedOut && (nextState == InventoryStatePreparingToStart || nextState == InventoryStateStarting ||
+			nextState == InventoryStateActive) {
+			nextState = InventoryStateTimeout
+		}
+
+		if currentState != nextState {
+			r.Log.Info("[triggerImageInventory]", "previousState", currentState, "nextState", nextState, "cluster", cluster)
+		}
+		if nextState == InventoryStateSucceeded {
+			// cleanup for succeeded clusters
+			if err := r.jobAndViewCleanup(ctx, cluster, inventoryViews, inventoryDeleteTemplates); err != nil {
+				r.Log.Error(err, "[triggerImageInventory] failed to cleanup for", "cluster", cluster)
+				// skip cluster status transition if cleanup not successful
+				done = false
+				continue
+			}
+		}
+		clusterGroupUpgrade.Status.ImageInventory.Status[cluster] = nextState
+		if nextState != InventoryStateSucceeded && nextState != InventoryStateTimeout && nextState != InventoryStateError {
+			done = false
+		}
+	}
+	return done, nil
+}
+
+// inventoryPreparing handles conditions in InventoryStatePreparingToStart
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) inventoryPreparing(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (string, error) {
+
+	currentState, nextState := InventoryStatePreparingToStart, InventoryStateStarting
+
+	// delete managedclusterview objects if present
+	err := r.deleteManagedClusterResources(ctx, cluster, append(inventoryViews, inventoryMCAs...))
+	if err != nil {
+		return currentState, err
+	}
+
+	spec, err := r.getInventoryJobTemplateData(ctx, clusterGroupUpgrade, cluster)
+	if err != nil {
+		return currentState, err
+	}
+
+	// delete namespace in the spoke with managedclusteraction
+	err = r.createResourcesFromTemplates(ctx, spec, inventoryDeleteTemplates)
+	if err != nil {
+		return currentState, err
+	}
+	return nextState, nil
+}
+
+// inventoryStarting handles conditions in InventoryStateStarting
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) inventoryStarting(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (string, error) {
+
+	nextState, currentState := InventoryStateStarting, InventoryStateStarting
+
+	condition, err := r.getStartingConditions(ctx, cluster, inventoryJobView[0].resourceName, inventory)
+	if err != nil {
+		return currentState, err
+	}
+	r.Log.Info("[inventoryStarting]", "conditions: ", condition)
+	switch condition {
+	case DependenciesNotPresent:
+		spec, err := r.getInventoryJobTemplateData(ctx, clusterGroupUpgrade, cluster)
+		if err != nil {
+			return currentState, err
+		}
+		err = r.createResourcesFromTemplates(ctx, spec, inventoryDependenciesCreateTemplates)
+		if err != nil {
+			return currentState, err
+		}
+
+	case NoJobView, NoJobFoundOnSpoke:
+		err = r.deployWorkload(ctx, clusterGroupUpgrade, cluster, inventory, inventoryJobView[0].resourceName, inventoryCreateTemplates)
+		if err != nil {
+			return currentState, err
+		}
+
+	case JobActive, JobSucceeded:
+		// The inventory is collected in the active state
+		nextState = InventoryStateActive
+
+	case JobDeadline:
+		nextState = InventoryStateTimeout
+
+	case JobBackoffLimitExceeded:
+		nextState = InventoryStateError
+
+	default:
+		return currentState, fmt.Errorf(
+			"[inventoryStarting] unknown condition %v in %s state", condition, currentState)
+	}
+	return nextState, nil
+}
+
+// inventoryActive handles conditions in InventoryStateActive
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) inventoryActive(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (string, error) {
+
+	nextState, currentState := InventoryStateActive, InventoryStateActive
+
+	condition, err := r.getActiveConditions(ctx, cluster, inventoryJobView[0].resourceName)
+	if err != nil {
+		return currentState, err
+	}
+
+	switch condition {
+	case JobActive:
+		nextState = InventoryStateActive
+
+	case JobSucceeded:
+		// Wait for the view of the inventory configmap to be refreshed
+		reported, err := r.collectImageInventory(ctx, clusterGroupUpgrade, cluster)
+		if err != nil {
+			return currentState, err
+		}
+		if reported {
+			nextState = InventoryStateSucceeded
+		}
+
+	case JobDeadline:
+		nextState = InventoryStateTimeout
+
+	case JobBackoffLimitExceeded:
+		nextState = InventoryStateError
+
+	default:
+		return currentState, fmt.Errorf("[inventoryActive] unknown condition %s in %s state",
+			condition, currentState)
+	}
+	return nextState, nil
+}
+
+// collectImageInventory reads the inventory reported by the spoke job, stores its summary in the
+// CGU status and its images in the inventory configmap of the cluster namespace
+// returns: bool - true when the inventory was reported
+//
+//	error
+func (r *ClusterGroupUpgradeReconciler) collectImageInventory(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (bool, error) {
+
+	view, present, err := r.getView(ctx, inventoryCreateTemplates[2].resourceName, cluster)
+	if err != nil || !present {
+		return false, err
+	}
+	data, found, err := unstructured.NestedStringMap(view.Object, "status", "result", "data")
+	if err != nil || !found {
+		return false, err
+	}
+	summary, err := parseImageInventorySummary(data)
+	if err != nil {
+		return false, err
+	}
+
+	configMap := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: utils.ImageInventoryConfigMapName, Namespace: cluster}, configMap)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+	notFound := errors.IsNotFound(err)
+	configMap.Name = utils.ImageInventoryConfigMapName
+	configMap.Namespace = cluster
+	configMap.Labels = map[string]string{
+		utils.ExcludeFromClusterBackup: "true",
+	}
+	configMap.Annotations = map[string]string{
+		utils.ImageInventoryCGUAnnotation: clusterGroupUpgrade.Namespace + "/" + clusterGroupUpgrade.Name,
+	}
+	configMap.Data = map[string]string{
+		inventoryImagesKey:  data[inventoryImagesKey],
+		inventorySummaryKey: data[inventorySummaryKey],
+	}
+	if notFound {
+		err = r.Create(ctx, configMap)
+	} else {
+		err = r.Update(ctx, configMap)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	// The pruned images may have been pre-cached, the next pre-caching of the cluster isn't skipped
+	if summary.PrunedImages > 0 {
+		if err := r.clearPrecachingSpec(ctx, cluster); err != nil {
+			return false, err
+		}
+	}
+
+	summary.ReportedAt = metav1.Now()
+	clusterGroupUpgrade.Status.ImageInventory.Clusters[cluster] = summary
+	r.Log.Info("[collectImageInventory]", "cluster", cluster, "images", summary.Images,
+		"prunedImages", summary.PrunedImages)
+	return true, nil
+}
+
+// parseImageInventorySummary parses the inventory summary reported by the spoke job
+// returns: ranv1alpha1.ClusterImageInventory, error
+func parseImageInventorySummary(data map[string]string) (ranv1alpha1.ClusterImageInventory, error) {
+	var summary ranv1alpha1.ClusterImageInventory
+	content, ok := data[inventorySummaryKey]
+	if !ok {
+		return summary, fmt.Errorf("%s not found in the image inventory", inventorySummaryKey)
+	}
+	if err := json.Unmarshal([]byte(content), &summary); err != nil {
+		return summary, fmt.Errorf("invalid image inventory summary: %w", err)
+	}
+	return summary, nil
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestImageInventory_reconcileImageInventory(t *testing.T) {
+	summary := `{"images":8,"sizeBytes":3600,"prunedImages":2,"reclaimedBytes":600}`
+	jobView := newUnstructuredView("view-inventory-job", "spoke1", map[string]interface{}{
+		"status": map[string]interface{}{"succeeded": int64(1)},
+	})
+	configMapView := newUnstructuredView("view-inventory-configmap", "spoke1", map[string]interface{}{
+		"data": map[string]interface{}{"images.json": "[]", "summary.json": summary},
+	})
+	emptyConfigMapView := newUnstructuredView("view-inventory-configmap", "spoke1", map[string]interface{}{})
+	// The record of the pre-cached spec is cleared once images are pruned
+	managedCluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{
+		Name: "spoke1", Annotations: map[string]string{utils.PrecachedSpecAnnotation: "hash"}}}
+
+	testcases := []struct {
+		name              string
+		afterCompletion   ranv1alpha1.AfterCompletion
+		objs              []client.Object
+		expectedDone      bool
+		expectedState     string
+		expectedInventory *ranv1alpha1.ClusterImageInventory
+	}{
+		{
+			name:         "inventory not requested",
+			expectedDone: true,
+		},
+		{
+			name:            "inventory reported",
+			afterCompletion: ranv1alpha1.AfterCompletion{PruneImages: true},
+			objs:            []client.Object{jobView, configMapView, managedCluster},
+			expectedDone:    true,
+			expectedState:   InventoryStateSucceeded,
+			expectedInventory: &ranv1alpha1.ClusterImageInventory{
+				Images: 8, SizeBytes: 3600, PrunedImages: 2, ReclaimedBytes: 600,
+			},
+		},
+		{
+			name:            "inventory not yet reported",
+			afterCompletion: ranv1alpha1.AfterCompletion{ReportImageInventory: true},
+			objs:            []client.Object{jobView, emptyConfigMapView},
+			expectedDone:    false,
+			expectedState:   InventoryStateActive,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"},
+			}
+			cgu.Spec.Actions.AfterCompletion = tc.afterCompletion
+			cgu.Status.Clusters = []ranv1alpha1.ClusterState{
+				{Name: "spoke1", State: utils.ClusterRemediationComplete},
+				{Name: "spoke2", State: utils.ClusterRemediationTimedout},
+			}
+			cgu.Status.ImageInventory = &ranv1alpha1.ImageInventoryStatus{
+				StartedAt: metav1.Now(),
+				Status:    map[string]string{"spoke1": InventoryStateActive},
+				Clusters:  map[string]ranv1alpha1.ClusterImageInventory{},
+			}
+			if !tc.afterCompletion.ReportImageInventory && !tc.afterCompletion.PruneImages {
+				cgu.Status.ImageInventory = nil
+			}
+
+			fakeClient, _ := getFakeClientFromObjects(tc.objs...)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			done, err := r.reconcileImageInventory(context.TODO(), cgu)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDone, done)
+			if tc.expectedState == "" {
+				assert.Nil(t, cgu.Status.ImageInventory)
+				return
+			}
+			assert.Equal(t, map[string]string{"spoke1": tc.expectedState}, cgu.Status.ImageInventory.Status)
+
+			configMap := &corev1.ConfigMap{}
+			err = fakeClient.Get(context.TODO(), types.NamespacedName{
+				Name: utils.ImageInventoryConfigMapName, Namespace: "spoke1"}, configMap)
+			if tc.expectedInventory == nil {
+				assert.Error(t, err)
+				assert.NotContains(t, cgu.Status.ImageInventory.Clusters, "spoke1")
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, summary, configMap.Data["summary.json"])
+			assert.Equal(t, "default/cgu", configMap.Annotations[utils.ImageInventoryCGUAnnotation])
+
+			inventory := cgu.Status.ImageInventory.Clusters["spoke1"]
+			assert.False(t, inventory.ReportedAt.IsZero())
+			inventory.ReportedAt = metav1.Time{}
+			assert.Equal(t, *tc.expectedInventory, inventory)
+
+			_, present, err := r.getView(context.TODO(), "view-inventory-configmap", "spoke1")
+			assert.NoError(t, err)
+			assert.False(t, present)
+
+			cluster := &clusterv1.ManagedCluster{}
+			assert.NoError(t, fakeClient.Get(context.TODO(), types.NamespacedName{Name: "spoke1"}, cluster))
+			assert.NotContains(t, cluster.Annotations, utils.PrecachedSpecAnnotation)
+		})
+	}
+}
@@ -0,0 +1,426 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	actionv1beta1 "github.com/stolostron/cluster-lifecycle-api/action/v1beta1"
+	viewv1beta1 "github.com/stolostron/cluster-lifecycle-api/view/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// MachineConfigPool states of a cluster
+const (
+	MachineConfigPoolStatePausing   = "Pausing"
+	MachineConfigPoolStatePaused    = "Paused"
+	MachineConfigPoolStateUnpausing = "Unpausing"
+	MachineConfigPoolStateUpdating  = "Updating"
+	MachineConfigPoolStateUpdated   = "Updated"
+)
+
+// releasedMachineConfigPoolsTimeout bounds how long the pools released by the failed clusters are followed
+// after the completion of the upgrade
+const releasedMachineConfigPoolsTimeout = 10 * time.Minute
+
+// machineConfigPoolViewName returns the name of the view of the pool
+// returns: string
+func machineConfigPoolViewName(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, pool string) string {
+	mcvName := utils.GetMultiCloudObjectName(clusterGroupUpgrade, utils.MachineConfigPoolGroupVersionKind().Kind, pool)
+	return utils.GetSafeResourceName(mcvName, clusterGroupUpgrade, utils.MaxObjectNameLength, 0)
+}
+
+// machineConfigPoolActionName returns the name of the action pausing or unpausing the pool
+// returns: string
+func machineConfigPoolActionName(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, pool, action string) string {
+	mcaName := utils.GetMultiCloudObjectName(clusterGroupUpgrade, utils.MachineConfigPoolGroupVersionKind().Kind, pool+"-"+action)
+	return utils.GetSafeResourceName(mcaName, clusterGroupUpgrade, utils.MaxObjectNameLength, 0)
+}
+
+// findMachineConfigPoolStatus returns the status of the pool of the cluster
+// returns: *ranv1alpha1.MachineConfigPoolStatus - nil when the pool was not seen yet
+func findMachineConfigPoolStatus(
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster, pool string) *ranv1alpha1.MachineConfigPoolStatus {
+
+	for i := range clusterGroupUpgrade.Status.MachineConfigPools {
+		status := &clusterGroupUpgrade.Status.MachineConfigPools[i]
+		if status.Cluster == cluster && status.Name == pool {
+			return status
+		}
+	}
+	return nil
+}
+
+// getMachineConfigPoolStatus returns the status of the pool of the cluster, adding it when missing
+// returns: *ranv1alpha1.MachineConfigPoolStatus
+func getMachineConfigPoolStatus(
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster, pool string) *ranv1alpha1.MachineConfigPoolStatus {
+
+	if status := findMachineConfigPoolStatus(clusterGroupUpgrade, cluster, pool); status != nil {
+		return status
+	}
+	clusterGroupUpgrade.Status.MachineConfigPools = append(clusterGroupUpgrade.Status.MachineConfigPools,
+		ranv1alpha1.MachineConfigPoolStatus{Cluster: cluster, Name: pool})
+	return &clusterGroupUpgrade.Status.MachineConfigPools[len(clusterGroupUpgrade.Status.MachineConfigPools)-1]
+}
+
+// updateMachineConfigPoolStatus records the Updated and Degraded conditions and the machine counts of the pool
+func updateMachineConfigPoolStatus(status *ranv1alpha1.MachineConfigPoolStatus, machineConfigPool *unstructured.Unstructured) {
+	status.Updated, status.Degraded, status.Message = false, false, ""
+	conditions, _, _ := unstructured.NestedSlice(machineConfigPool.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok || condition["status"] != "True" {
+			continue
+		}
+		switch condition["type"] {
+		case "Updated":
+			status.Updated = true
+		case "Degraded":
+			status.Degraded = true
+			status.Message, _ = condition["message"].(string)
+		}
+	}
+	status.MachineCount, _, _ = unstructured.NestedInt64(machineConfigPool.Object, "status", "machineCount")
+	status.UpdatedMachineCount, _, _ = unstructured.NestedInt64(machineConfigPool.Object, "status", "updatedMachineCount")
+}
+
+// setMachineConfigPoolPaused pauses or unpauses a MachineConfigPool of the cluster through an action, and
+// records the state of the pool in the status
+// returns: bool - true once the view of the pool reports the requested state, error
+func (r *ClusterGroupUpgradeReconciler) setMachineConfigPoolPaused(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster, pool string, paused bool) (bool, error) {
+
+	kind := utils.MachineConfigPoolGroupVersionKind().Kind
+	mcvName := utils.GetMultiCloudObjectName(clusterGroupUpgrade, kind, pool)
+	safeName := machineConfigPoolViewName(clusterGroupUpgrade, pool)
+	mcv, err := utils.EnsureManagedClusterView(
+		ctx, r.Client, safeName, mcvName, cluster, kind+"."+utils.MachineConfigPoolGroupVersionKind().Group,
+		pool, "", clusterGroupUpgrade.Namespace+"-"+clusterGroupUpgrade.Name)
+	if err != nil {
+		return false, err
+	}
+	machineConfigPool, err := utils.GetManagedClusterViewResult(mcv)
+	if err != nil || machineConfigPool == nil {
+		return false, err
+	}
+	status := getMachineConfigPoolStatus(clusterGroupUpgrade, cluster, pool)
+	updateMachineConfigPoolStatus(status, machineConfigPool)
+	currentlyPaused, _, _ := unstructured.NestedBool(machineConfigPool.Object, "spec", "paused")
+	if currentlyPaused == paused {
+		switch {
+		case paused:
+			status.State = MachineConfigPoolStatePaused
+		case status.Updated:
+			status.State = MachineConfigPoolStateUpdated
+		default:
+			status.State = MachineConfigPoolStateUpdating
+		}
+		return true, nil
+	}
+
+	return false, r.requestMachineConfigPoolPaused(ctx, clusterGroupUpgrade, cluster, machineConfigPool, status, paused)
+}
+
+// requestMachineConfigPoolPaused creates the action pausing or unpausing the pool retrieved by its view
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) requestMachineConfigPoolPaused(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string,
+	machineConfigPool *unstructured.Unstructured, status *ranv1alpha1.MachineConfigPoolStatus, paused bool) error {
+
+	update := machineConfigPool.DeepCopy()
+	if err := unstructured.SetNestedField(update.Object, paused, "spec", "paused"); err != nil {
+		return err
+	}
+	action := "unpause"
+	status.State = MachineConfigPoolStateUnpausing
+	if paused {
+		action = "pause"
+		status.State = MachineConfigPoolStatePausing
+	}
+	safeName := machineConfigPoolActionName(clusterGroupUpgrade, status.Name, action)
+
+	// The failure of the action is recorded before the action is deleted and created again
+	mca := &actionv1beta1.ManagedClusterAction{}
+	err := r.Get(ctx, types.NamespacedName{Name: safeName, Namespace: cluster}, mca)
+	if err == nil {
+		condition := meta.FindStatusCondition(mca.Status.Conditions, actionv1beta1.ConditionActionCompleted)
+		if condition != nil && condition.Status == metav1.ConditionFalse {
+			status.Message = fmt.Sprintf("failed to %s the pool: %s", action, condition.Message)
+			r.recordClusterTransition(clusterGroupUpgrade, cluster, corev1.EventTypeWarning,
+				eventReasonMachineConfigPoolActionFailed, status.State,
+				fmt.Sprintf("failed to %s the MachineConfigPool %s: %s", action, status.Name, condition.Message))
+		}
+	} else if !errors.IsNotFound(err) {
+		return err
+	}
+
+	created, err := utils.EnsureManagedClusterActionForUpdate(
+		ctx, r.Client, safeName, cluster, clusterGroupUpgrade.Namespace+"-"+clusterGroupUpgrade.Name, machineConfigPoolResource, update)
+	if created {
+		r.Log.Info("[requestMachineConfigPoolPaused] Requested to "+action+" the pool", "cluster", cluster, "pool", status.Name)
+	}
+	return err
+}
+
+// getPausedMachineConfigPools returns the last result of the views of the pools the CGU paused on the cluster.
+// Pools without a view, such as the pools of the clusters completed in a previous batch and waiting for the
+// Upgrade stage, are left out
+// returns: map[string]*unstructured.Unstructured - the pools by name, error
+func (r *ClusterGroupUpgradeReconciler) getPausedMachineConfigPools(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (map[string]*unstructured.Unstructured, error) {
+
+	pausedPools := make(map[string]*unstructured.Unstructured)
+	for _, status := range clusterGroupUpgrade.Status.MachineConfigPools {
+		if status.Cluster != cluster ||
+			(status.State != MachineConfigPoolStatePausing && status.State != MachineConfigPoolStatePaused) {
+			continue
+		}
+		mcv := &viewv1beta1.ManagedClusterView{}
+		err := r.Get(ctx, types.NamespacedName{
+			Name: machineConfigPoolViewName(clusterGroupUpgrade, status.Name), Namespace: cluster}, mcv)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				r.Log.Info("[getPausedMachineConfigPools] No view of the pool, leaving it paused", "cluster", cluster, "pool", status.Name)
+				continue
+			}
+			return nil, err
+		}
+		machineConfigPool, err := utils.GetManagedClusterViewResult(mcv)
+		if err != nil {
+			return nil, err
+		}
+		if machineConfigPool == nil {
+			r.Log.Info("[getPausedMachineConfigPools] The view of the pool has no result, leaving it paused", "cluster", cluster, "pool", status.Name)
+			continue
+		}
+		pausedPools[status.Name] = machineConfigPool
+	}
+	return pausedPools, nil
+}
+
+// unpausePausedMachineConfigPools requests to unpause the pools the CGU paused on the cluster, whatever
+// actions.afterCompletion.unpauseMachineConfigPools says, so that they are not left paused once the CGU
+// gave up on the cluster
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) unpausePausedMachineConfigPools(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string,
+	pausedPools map[string]*unstructured.Unstructured) error {
+
+	for pool, machineConfigPool := range pausedPools {
+		status := findMachineConfigPoolStatus(clusterGroupUpgrade, cluster, pool)
+		if err := r.requestMachineConfigPoolPaused(ctx, clusterGroupUpgrade, cluster, machineConfigPool, status, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// releaseMachineConfigPools deletes the views and actions of a cluster whose remediation failed or timed
+// out, and unpauses the pools the CGU paused on it from the last result of their views. The views of these
+// pools are kept, so that trackReleasedMachineConfigPools follows the unpausing
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) releaseMachineConfigPools(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) error {
+
+	pausedPools, err := r.getPausedMachineConfigPools(ctx, clusterGroupUpgrade, cluster)
+	if err != nil {
+		return err
+	}
+	var views []string
+	for pool := range pausedPools {
+		views = append(views, machineConfigPoolViewName(clusterGroupUpgrade, pool))
+	}
+	if err := utils.DeleteManagedClusterViews(ctx, r.Client, clusterGroupUpgrade, cluster, views...); err != nil {
+		return err
+	}
+	if err := utils.DeleteManagedClusterActions(ctx, r.Client, clusterGroupUpgrade, cluster); err != nil {
+		return err
+	}
+	return r.unpausePausedMachineConfigPools(ctx, clusterGroupUpgrade, cluster, pausedPools)
+}
+
+// getReleasedClusters returns the clusters whose remediation failed or timed out
+// returns: map[string]bool
+func getReleasedClusters(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) map[string]bool {
+	released := make(map[string]bool)
+	for _, clusterState := range clusterGroupUpgrade.Status.Clusters {
+		if clusterState.State == utils.ClusterRemediationFailed || clusterState.State == utils.ClusterRemediationTimedout {
+			released[clusterState.Name] = true
+		}
+	}
+	return released
+}
+
+// deleteMachineConfigPoolTracking deletes the view of the pool and the action unpausing it
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) deleteMachineConfigPoolTracking(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster, pool string, withAction bool) error {
+
+	mcv := &viewv1beta1.ManagedClusterView{ObjectMeta: metav1.ObjectMeta{
+		Name: machineConfigPoolViewName(clusterGroupUpgrade, pool), Namespace: cluster}}
+	if err := r.Delete(ctx, mcv); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if !withAction {
+		return nil
+	}
+	mca := &actionv1beta1.ManagedClusterAction{ObjectMeta: metav1.ObjectMeta{
+		Name: machineConfigPoolActionName(clusterGroupUpgrade, pool, "unpause"), Namespace: cluster}}
+	if err := r.Delete(ctx, mca); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// trackReleasedMachineConfigPools follows the unpausing of the pools released by the clusters whose remediation
+// failed or timed out. The view and the action of a pool are kept until the view reports the pool unpaused, and
+// the failed actions are recorded in the status and created again
+// returns: bool - true once no released pool is being unpaused, error
+func (r *ClusterGroupUpgradeReconciler) trackReleasedMachineConfigPools(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) (bool, error) {
+
+	released := getReleasedClusters(clusterGroupUpgrade)
+	done := true
+	for i := range clusterGroupUpgrade.Status.MachineConfigPools {
+		cluster, pool := clusterGroupUpgrade.Status.MachineConfigPools[i].Cluster, clusterGroupUpgrade.Status.MachineConfigPools[i].Name
+		if clusterGroupUpgrade.Status.MachineConfigPools[i].State != MachineConfigPoolStateUnpausing || !released[cluster] {
+			continue
+		}
+		unpaused, err := r.setMachineConfigPoolPaused(ctx, clusterGroupUpgrade, cluster, pool, false)
+		if err != nil {
+			return false, err
+		}
+		if !unpaused {
+			done = false
+			continue
+		}
+		r.Log.Info("[trackReleasedMachineConfigPools] The pool is unpaused", "cluster", cluster, "pool", pool)
+		if err := r.deleteMachineConfigPoolTracking(ctx, clusterGroupUpgrade, cluster, pool, true); err != nil {
+			return false, err
+		}
+	}
+	return done, nil
+}
+
+// reconcileReleasedMachineConfigPools follows the pools released by the failed clusters once the upgrade is
+// completed, for up to releasedMachineConfigPoolsTimeout. The pools whose unpausing isn't confirmed by then are
+// recorded in the status, and their views are deleted while the actions unpausing them are left in place
+// returns: bool - true once the released pools are unpaused or given up, error
+func (r *ClusterGroupUpgradeReconciler) reconcileReleasedMachineConfigPools(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) (bool, error) {
+
+	done, err := r.trackReleasedMachineConfigPools(ctx, clusterGroupUpgrade)
+	if err != nil || done {
+		return done, err
+	}
+	if time.Since(clusterGroupUpgrade.Status.Status.CompletedAt.Time) < releasedMachineConfigPoolsTimeout {
+		return false, nil
+	}
+
+	released := getReleasedClusters(clusterGroupUpgrade)
+	for i := range clusterGroupUpgrade.Status.MachineConfigPools {
+		status := &clusterGroupUpgrade.Status.MachineConfigPools[i]
+		if status.State != MachineConfigPoolStateUnpausing || !released[status.Cluster] {
+			continue
+		}
+		message := fmt.Sprintf("the unpausing of the pool wasn't confirmed %s after the completion of the upgrade",
+			releasedMachineConfigPoolsTimeout)
+		if status.Message != "" {
+			message += ": " + status.Message
+		}
+		status.Message = message
+		r.recordClusterTransition(clusterGroupUpgrade, status.Cluster, corev1.EventTypeWarning,
+			eventReasonMachineConfigPoolActionFailed, status.State,
+			fmt.Sprintf("the unpausing of the MachineConfigPool %s wasn't confirmed", status.Name))
+		if err := r.deleteMachineConfigPoolTracking(ctx, clusterGroupUpgrade, status.Cluster, status.Name, false); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// pauseMachineConfigPools pauses the pools of actions.beforeEnable.pauseMachineConfigPools on the cluster.
+// Pools the CGU already unpaused are left alone
+// returns: bool - true once all the pools are paused, error
+func (r *ClusterGroupUpgradeReconciler) pauseMachineConfigPools(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (bool, error) {
+
+	done := true
+	for _, pool := range clusterGroupUpgrade.Spec.Actions.BeforeEnable.PauseMachineConfigPools {
+		if status := findMachineConfigPoolStatus(clusterGroupUpgrade, cluster, pool); status != nil {
+			switch status.State {
+			case MachineConfigPoolStateUnpausing, MachineConfigPoolStateUpdating, MachineConfigPoolStateUpdated:
+				continue
+			}
+		}
+		paused, err := r.setMachineConfigPoolPaused(ctx, clusterGroupUpgrade, cluster, pool, true)
+		if err != nil {
+			return false, err
+		}
+		done = done && paused
+	}
+	return done, nil
+}
+
+// isMachineConfigPoolPausedByActions returns whether the pool is paused by actions.beforeEnable.pauseMachineConfigPools,
+// in which case only actions.afterCompletion.unpauseMachineConfigPools unpauses it
+// returns: bool
+func isMachineConfigPoolPausedByActions(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, pool string) bool {
+	for _, paused := range clusterGroupUpgrade.Spec.Actions.BeforeEnable.PauseMachineConfigPools {
+		if paused == pool {
+			return true
+		}
+	}
+	return false
+}
+
+// getMachineConfigPoolsToUnpause returns the pools unpaused after completion and when
+// returns: []string - the pools, string - when they are unpaused
+func getMachineConfigPoolsToUnpause(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) ([]string, string) {
+	unpause := clusterGroupUpgrade.Spec.Actions.AfterCompletion.UnpauseMachineConfigPools
+	if unpause == nil {
+		return nil, ""
+	}
+	when := unpause.When
+	if when == "" {
+		when = ranv1alpha1.UnpauseAfterCluster
+	}
+	if len(unpause.Pools) > 0 {
+		return unpause.Pools, when
+	}
+	return clusterGroupUpgrade.Spec.Actions.BeforeEnable.PauseMachineConfigPools, when
+}
+
+// unpauseMachineConfigPools unpauses the pools of actions.afterCompletion.unpauseMachineConfigPools on the
+// clusters, when they are to be unpaused at this stage of the upgrade
+// returns: bool - true once the pools of all the clusters are unpaused and updated, error
+func (r *ClusterGroupUpgradeReconciler) unpauseMachineConfigPools(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, stage string, clusters []string) (bool, error) {
+
+	pools, when := getMachineConfigPoolsToUnpause(clusterGroupUpgrade)
+	if when != stage {
+		return true, nil
+	}
+	done := true
+	for _, cluster := range clusters {
+		for _, pool := range pools {
+			unpaused, err := r.setMachineConfigPoolPaused(ctx, clusterGroupUpgrade, cluster, pool, false)
+			if err != nil {
+				return false, err
+			}
+			if !unpaused || findMachineConfigPoolStatus(clusterGroupUpgrade, cluster, pool).State != MachineConfigPoolStateUpdated {
+				done = false
+			}
+		}
+	}
+	r.Log.Info("[unpauseMachineConfigPools]", "stage", stage, "clusters", clusters, "done", done)
+	return done, nil
+}
@@ -0,0 +1,445 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	actionv1beta1 "github.com/stolostron/cluster-lifecycle-api/action/v1beta1"
+	viewv1beta1 "github.com/stolostron/cluster-lifecycle-api/view/v1beta1"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// withMachineConfigPoolStatus sets the Updated and Degraded conditions and the machine counts of the pool
+func withMachineConfigPoolStatus(machineConfigPool map[string]interface{}, updated bool, degraded string, updatedMachines int64) map[string]interface{} {
+	conditionStatus := func(value bool) string {
+		if value {
+			return "True"
+		}
+		return "False"
+	}
+	machineConfigPool["status"] = map[string]interface{}{
+		"machineCount":        int64(3),
+		"updatedMachineCount": updatedMachines,
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Updated", "status": conditionStatus(updated)},
+			map[string]interface{}{"type": "Degraded", "status": conditionStatus(degraded != ""), "message": degraded},
+		},
+	}
+	return machineConfigPool
+}
+
+func TestMachineConfigPools_pauseAndUnpause(t *testing.T) {
+	const mcpView = "cgu-default-machineconfigpool-worker-kuttl"
+	testcases := []struct {
+		name            string
+		pause           bool
+		when            string
+		stage           string
+		poolStatus      *ranv1alpha1.MachineConfigPoolStatus
+		machineConfig   map[string]interface{}
+		expectedDone    bool
+		expectedStatus  *ranv1alpha1.MachineConfigPoolStatus
+		expectedActions []string
+	}{
+		{
+			name:          "pause the pool",
+			pause:         true,
+			machineConfig: withMachineConfigPoolStatus(newMachineConfigPoolResult(false), true, "", 3),
+			expectedStatus: &ranv1alpha1.MachineConfigPoolStatus{
+				Cluster: "spoke1", Name: "worker", State: MachineConfigPoolStatePausing,
+				Updated: true, MachineCount: 3, UpdatedMachineCount: 3,
+			},
+			expectedActions: []string{"cgu-default-machineconfigpool-worker-pause-kuttl"},
+		},
+		{
+			name:          "pool paused",
+			pause:         true,
+			machineConfig: withMachineConfigPoolStatus(newMachineConfigPoolResult(true), true, "", 3),
+			expectedDone:  true,
+			expectedStatus: &ranv1alpha1.MachineConfigPoolStatus{
+				Cluster: "spoke1", Name: "worker", State: MachineConfigPoolStatePaused,
+				Updated: true, MachineCount: 3, UpdatedMachineCount: 3,
+			},
+		},
+		{
+			name:  "pool already unpaused by the upgrade is not paused again",
+			pause: true,
+			poolStatus: &ranv1alpha1.MachineConfigPoolStatus{
+				Cluster: "spoke1", Name: "worker", State: MachineConfigPoolStateUpdating},
+			machineConfig: withMachineConfigPoolStatus(newMachineConfigPoolResult(false), false, "", 1),
+			expectedDone:  true,
+			expectedStatus: &ranv1alpha1.MachineConfigPoolStatus{
+				Cluster: "spoke1", Name: "worker", State: MachineConfigPoolStateUpdating},
+		},
+		{
+			name:          "pools unpaused at another stage",
+			when:          ranv1alpha1.UnpauseAfterUpgrade,
+			stage:         ranv1alpha1.UnpauseAfterBatch,
+			machineConfig: withMachineConfigPoolStatus(newMachineConfigPoolResult(true), false, "", 1),
+			expectedDone:  true,
+		},
+		{
+			name:          "unpause the pool",
+			stage:         ranv1alpha1.UnpauseAfterCluster,
+			machineConfig: withMachineConfigPoolStatus(newMachineConfigPoolResult(true), false, "", 1),
+			expectedStatus: &ranv1alpha1.MachineConfigPoolStatus{
+				Cluster: "spoke1", Name: "worker", State: MachineConfigPoolStateUnpausing,
+				MachineCount: 3, UpdatedMachineCount: 1,
+			},
+			expectedActions: []string{"cgu-default-machineconfigpool-worker-unpause-kuttl"},
+		},
+		{
+			name:  "wait for the degraded pool to update",
+			when:  ranv1alpha1.UnpauseAfterBatch,
+			stage: ranv1alpha1.UnpauseAfterBatch,
+			machineConfig: withMachineConfigPoolStatus(newMachineConfigPoolResult(false), false,
+				"Node worker-1 is reporting: unexpected on-disk state", 2),
+			expectedStatus: &ranv1alpha1.MachineConfigPoolStatus{
+				Cluster: "spoke1", Name: "worker", State: MachineConfigPoolStateUpdating,
+				Degraded: true, MachineCount: 3, UpdatedMachineCount: 2,
+				Message: "Node worker-1 is reporting: unexpected on-disk state",
+			},
+		},
+		{
+			name:          "pool updated",
+			when:          ranv1alpha1.UnpauseAfterUpgrade,
+			stage:         ranv1alpha1.UnpauseAfterUpgrade,
+			machineConfig: withMachineConfigPoolStatus(newMachineConfigPoolResult(false), true, "", 3),
+			expectedDone:  true,
+			expectedStatus: &ranv1alpha1.MachineConfigPoolStatus{
+				Cluster: "spoke1", Name: "worker", State: MachineConfigPoolStateUpdated,
+				Updated: true, MachineCount: 3, UpdatedMachineCount: 3,
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cgu", Namespace: "default",
+					Annotations: map[string]string{utils.NameSuffixAnnotation: "kuttl"},
+				},
+			}
+			cgu.Spec.Actions.BeforeEnable.PauseMachineConfigPools = []string{"worker"}
+			cgu.Spec.Actions.AfterCompletion.UnpauseMachineConfigPools = &ranv1alpha1.UnpauseMachineConfigPools{When: tc.when}
+			if tc.poolStatus != nil {
+				cgu.Status.MachineConfigPools = []ranv1alpha1.MachineConfigPoolStatus{*tc.poolStatus}
+			}
+
+			fakeClient, _ := getFakeClientFromObjects(newPlatformUpgradeView(mcpView, "spoke1", tc.machineConfig))
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			var done bool
+			var err error
+			if tc.pause {
+				done, err = r.pauseMachineConfigPools(context.TODO(), cgu, "spoke1")
+			} else {
+				done, err = r.unpauseMachineConfigPools(context.TODO(), cgu, tc.stage, []string{"spoke1"})
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDone, done)
+			assert.Equal(t, tc.expectedStatus, findMachineConfigPoolStatus(cgu, "spoke1", "worker"))
+
+			actions := &actionv1beta1.ManagedClusterActionList{}
+			assert.NoError(t, fakeClient.List(context.TODO(), actions, client.InNamespace("spoke1")))
+			var names []string
+			for _, action := range actions.Items {
+				names = append(names, action.Name)
+			}
+			assert.Equal(t, tc.expectedActions, names)
+		})
+	}
+}
+
+func TestMachineConfigPools_getMachineConfigPoolsToUnpause(t *testing.T) {
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+	pools, when := getMachineConfigPoolsToUnpause(cgu)
+	assert.Nil(t, pools)
+	assert.Equal(t, "", when)
+
+	cgu.Spec.Actions.BeforeEnable.PauseMachineConfigPools = []string{"worker", "worker-cnf"}
+	cgu.Spec.Actions.AfterCompletion.UnpauseMachineConfigPools = &ranv1alpha1.UnpauseMachineConfigPools{}
+	pools, when = getMachineConfigPoolsToUnpause(cgu)
+	assert.Equal(t, []string{"worker", "worker-cnf"}, pools)
+	assert.Equal(t, ranv1alpha1.UnpauseAfterCluster, when)
+
+	cgu.Spec.Actions.AfterCompletion.UnpauseMachineConfigPools = &ranv1alpha1.UnpauseMachineConfigPools{
+		Pools: []string{"worker-cnf"}, When: ranv1alpha1.UnpauseAfterBatch}
+	pools, when = getMachineConfigPoolsToUnpause(cgu)
+	assert.Equal(t, []string{"worker-cnf"}, pools)
+	assert.Equal(t, ranv1alpha1.UnpauseAfterBatch, when)
+}
+
+func TestMachineConfigPools_releaseMachineConfigPools(t *testing.T) {
+	const mcpView = "cgu-default-machineconfigpool-worker-kuttl"
+	testcases := []struct {
+		name            string
+		state           string
+		withView        bool
+		expectedState   string
+		expectedViews   []string
+		expectedActions []string
+	}{
+		{
+			name:            "paused pool is unpaused",
+			state:           MachineConfigPoolStatePaused,
+			withView:        true,
+			expectedState:   MachineConfigPoolStateUnpausing,
+			expectedViews:   []string{mcpView},
+			expectedActions: []string{"cgu-default-machineconfigpool-worker-unpause-kuttl"},
+		},
+		{
+			name:            "pool being paused is unpaused",
+			state:           MachineConfigPoolStatePausing,
+			withView:        true,
+			expectedState:   MachineConfigPoolStateUnpausing,
+			expectedViews:   []string{mcpView},
+			expectedActions: []string{"cgu-default-machineconfigpool-worker-unpause-kuttl"},
+		},
+		{
+			name:          "pool already unpaused is left alone",
+			state:         MachineConfigPoolStateUpdating,
+			withView:      true,
+			expectedState: MachineConfigPoolStateUpdating,
+		},
+		{
+			name:          "pool without a view is left paused",
+			state:         MachineConfigPoolStatePaused,
+			expectedState: MachineConfigPoolStatePaused,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cgu", Namespace: "default",
+					Annotations: map[string]string{utils.NameSuffixAnnotation: "kuttl"},
+				},
+			}
+			cgu.Spec.Actions.BeforeEnable.PauseMachineConfigPools = []string{"worker"}
+			cgu.Status.MachineConfigPools = []ranv1alpha1.MachineConfigPoolStatus{
+				{Cluster: "spoke1", Name: "worker", State: tc.state}}
+
+			// The other views and actions of the cluster are deleted
+			cvView := newPlatformUpgradeView("cgu-default-clusterversion-version-kuttl", "spoke1", newClusterVersionResult("4.14.1"))
+			cvView.Labels = map[string]string{utils.ClusterGroupUpgradeLabel: "default-cgu"}
+			pauseAction := &actionv1beta1.ManagedClusterAction{ObjectMeta: metav1.ObjectMeta{
+				Name: "cgu-default-machineconfigpool-worker-pause-kuttl", Namespace: "spoke1",
+				Labels: map[string]string{utils.ClusterGroupUpgradeLabel: "default-cgu"}}}
+			objects := []client.Object{cvView, pauseAction}
+			if tc.withView {
+				view := newPlatformUpgradeView(mcpView, "spoke1", newMachineConfigPoolResult(true))
+				view.Labels = map[string]string{utils.ClusterGroupUpgradeLabel: "default-cgu"}
+				objects = append(objects, view)
+			}
+			fakeClient, _ := getFakeClientFromObjects(objects...)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			assert.NoError(t, r.releaseMachineConfigPools(context.TODO(), cgu, "spoke1"))
+			assert.Equal(t, tc.expectedState, findMachineConfigPoolStatus(cgu, "spoke1", "worker").State)
+
+			// The view of the released pool and the action unpausing it are kept to follow the unpausing
+			views := &viewv1beta1.ManagedClusterViewList{}
+			assert.NoError(t, fakeClient.List(context.TODO(), views, client.InNamespace("spoke1")))
+			var viewNames []string
+			for _, view := range views.Items {
+				viewNames = append(viewNames, view.Name)
+			}
+			assert.Equal(t, tc.expectedViews, viewNames)
+			actions := &actionv1beta1.ManagedClusterActionList{}
+			assert.NoError(t, fakeClient.List(context.TODO(), actions, client.InNamespace("spoke1")))
+			var names []string
+			for _, action := range actions.Items {
+				names = append(names, action.Name)
+			}
+			assert.Equal(t, tc.expectedActions, names)
+		})
+	}
+}
+
+// listMachineConfigPoolTracking lists the names of the views and actions of the cluster
+func listMachineConfigPoolTracking(t *testing.T, c client.Client, cluster string) ([]string, []string) {
+	views := &viewv1beta1.ManagedClusterViewList{}
+	assert.NoError(t, c.List(context.TODO(), views, client.InNamespace(cluster)))
+	var viewNames []string
+	for _, view := range views.Items {
+		viewNames = append(viewNames, view.Name)
+	}
+	actions := &actionv1beta1.ManagedClusterActionList{}
+	assert.NoError(t, c.List(context.TODO(), actions, client.InNamespace(cluster)))
+	var actionNames []string
+	for _, action := range actions.Items {
+		actionNames = append(actionNames, action.Name)
+	}
+	return viewNames, actionNames
+}
+
+func TestMachineConfigPools_trackReleasedMachineConfigPools(t *testing.T) {
+	const (
+		mcpView     = "cgu-default-machineconfigpool-worker-kuttl"
+		mcpUnpause  = "cgu-default-machineconfigpool-worker-unpause-kuttl"
+		failedState = utils.ClusterRemediationFailed
+	)
+	testcases := []struct {
+		name            string
+		clusterState    string
+		paused          bool
+		actionFailure   string
+		expectedDone    bool
+		expectedState   string
+		expectedMessage string
+		expectedViews   []string
+		expectedActions []string
+		expectedHistory bool
+	}{
+		{
+			name:          "unpaused pool is no longer followed",
+			clusterState:  failedState,
+			expectedDone:  true,
+			expectedState: MachineConfigPoolStateUpdating,
+		},
+		{
+			name:            "pool still paused keeps its view and action",
+			clusterState:    failedState,
+			paused:          true,
+			expectedState:   MachineConfigPoolStateUnpausing,
+			expectedViews:   []string{mcpView},
+			expectedActions: []string{mcpUnpause},
+		},
+		{
+			name:            "pool of a timed out cluster is followed",
+			clusterState:    utils.ClusterRemediationTimedout,
+			paused:          true,
+			expectedState:   MachineConfigPoolStateUnpausing,
+			expectedViews:   []string{mcpView},
+			expectedActions: []string{mcpUnpause},
+		},
+		{
+			name:            "failed action is recorded before it is deleted",
+			clusterState:    failedState,
+			paused:          true,
+			actionFailure:   "the object has been modified",
+			expectedState:   MachineConfigPoolStateUnpausing,
+			expectedMessage: "failed to unpause the pool: the object has been modified",
+			expectedViews:   []string{mcpView},
+			expectedHistory: true,
+		},
+		{
+			name:            "pool of a cluster still remediated is left alone",
+			paused:          true,
+			expectedDone:    true,
+			expectedState:   MachineConfigPoolStateUnpausing,
+			expectedViews:   []string{mcpView},
+			expectedActions: []string{mcpUnpause},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cgu", Namespace: "default",
+					Annotations: map[string]string{utils.NameSuffixAnnotation: "kuttl"},
+				},
+			}
+			cgu.Status.MachineConfigPools = []ranv1alpha1.MachineConfigPoolStatus{
+				{Cluster: "spoke1", Name: "worker", State: MachineConfigPoolStateUnpausing}}
+			if tc.clusterState != "" {
+				cgu.Status.Clusters = []ranv1alpha1.ClusterState{{Name: "spoke1", State: tc.clusterState}}
+			}
+
+			view := newPlatformUpgradeView(mcpView, "spoke1", newMachineConfigPoolResult(tc.paused))
+			view.Labels = map[string]string{utils.ClusterGroupUpgradeLabel: "default-cgu"}
+			action := &actionv1beta1.ManagedClusterAction{ObjectMeta: metav1.ObjectMeta{
+				Name: mcpUnpause, Namespace: "spoke1",
+				Labels: map[string]string{utils.ClusterGroupUpgradeLabel: "default-cgu"}}}
+			if tc.actionFailure != "" {
+				action.Status.Conditions = []metav1.Condition{{
+					Type: actionv1beta1.ConditionActionCompleted, Status: metav1.ConditionFalse,
+					Reason: actionv1beta1.ReasonUpdateResourceFailed, Message: tc.actionFailure}}
+			}
+			fakeClient, _ := getFakeClientFromObjects(view, action)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+			done, err := r.trackReleasedMachineConfigPools(context.TODO(), cgu)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDone, done)
+			status := findMachineConfigPoolStatus(cgu, "spoke1", "worker")
+			assert.Equal(t, tc.expectedState, status.State)
+			assert.Equal(t, tc.expectedMessage, status.Message)
+			views, actions := listMachineConfigPoolTracking(t, fakeClient, "spoke1")
+			assert.Equal(t, tc.expectedViews, views)
+			assert.Equal(t, tc.expectedActions, actions)
+			if tc.expectedHistory {
+				transitions := cgu.Status.ClusterHistory["spoke1"].Transitions
+				assert.Len(t, transitions, 1)
+				assert.Equal(t, eventReasonMachineConfigPoolActionFailed, transitions[0].Reason)
+			} else {
+				assert.Empty(t, cgu.Status.ClusterHistory)
+			}
+		})
+	}
+}
+
+func TestMachineConfigPools_reconcileReleasedMachineConfigPools(t *testing.T) {
+	const (
+		mcpView    = "cgu-default-machineconfigpool-worker-kuttl"
+		mcpUnpause = "cgu-default-machineconfigpool-worker-unpause-kuttl"
+	)
+	testcases := []struct {
+		name            string
+		completedAgo    time.Duration
+		expectedDone    bool
+		expectedMessage string
+		expectedViews   []string
+	}{
+		{
+			name:          "pool followed after the completion",
+			completedAgo:  time.Minute,
+			expectedViews: []string{mcpView},
+		},
+		{
+			name:            "pool given up once the timeout expired",
+			completedAgo:    releasedMachineConfigPoolsTimeout + time.Minute,
+			expectedDone:    true,
+			expectedMessage: "the unpausing of the pool wasn't confirmed 10m0s after the completion of the upgrade",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cgu", Namespace: "default",
+					Annotations: map[string]string{utils.NameSuffixAnnotation: "kuttl"},
+				},
+			}
+			cgu.Status.Status.CompletedAt = metav1.NewTime(time.Now().Add(-tc.completedAgo))
+			cgu.Status.Clusters = []ranv1alpha1.ClusterState{{Name: "spoke1", State: utils.ClusterRemediationTimedout}}
+			cgu.Status.MachineConfigPools = []ranv1alpha1.MachineConfigPoolStatus{
+				{Cluster: "spoke1", Name: "worker", State: MachineConfigPoolStateUnpausing}}
+
+			view := newPlatformUpgradeView(mcpView, "spoke1", newMachineConfigPoolResult(true))
+			view.Labels = map[string]string{utils.ClusterGroupUpgradeLabel: "default-cgu"}
+			action := &actionv1beta1.ManagedClusterAction{ObjectMeta: metav1.ObjectMeta{
+				Name: mcpUnpause, Namespace: "spoke1",
+				Labels: map[string]string{utils.ClusterGroupUpgradeLabel: "default-cgu"}}}
+			fakeClient, _ := getFakeClientFromObjects(view, action)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+			done, err := r.reconcileReleasedMachineConfigPools(context.TODO(), cgu)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDone, done)
+			status := findMachineConfigPoolStatus(cgu, "spoke1", "worker")
+			assert.Equal(t, MachineConfigPoolStateUnpausing, status.State)
+			assert.Equal(t, tc.expectedMessage, status.Message)
+
+			// The action unpausing the pool is left in place when the pool is given up
+			views, actions := listMachineConfigPoolTracking(t, fakeClient, "spoke1")
+			assert.Equal(t, tc.expectedViews, views)
+			assert.Equal(t, []string{mcpUnpause}, actions)
+		})
+	}
+}
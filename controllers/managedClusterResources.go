@@ -44,22 +44,30 @@ import (
 
 // templateData provides template rendering data
 type templateData struct {
-	Cluster                 string
-	ResourceName            string
-	PlatformImage           string
-	Operators               operatorsData
-	WorkloadImage           string
-	SpaceRequired           string
-	JobTimeout              uint64
-	ViewUpdateIntervalSec   int
-	ExcludePrecachePatterns []string
-	AdditionalImages        []string
+	Cluster                    string
+	ResourceName               string
+	PlatformImage              string
+	IntermediatePlatformImages []string
+	Operators                  operatorsData
+	WorkloadImage              string
+	SpaceRequired              string
+	JobTimeout                 uint64
+	ViewUpdateIntervalSec      int
+	ExcludePrecachePatterns    []string
+	AdditionalImages           []string
+	PruneImages                bool
+	KeepImages                 []string
+	BackupCompression          string
+	BackupEncrypted            bool
+	BackupObjectName           string
+	BackupRemoveLocal          bool
 }
 
 // operatorsData provides operators data for template rendering
 type operatorsData struct {
 	Indexes             []string
 	PackagesAndChannels []string
+	Images              []string
 }
 
 // resourceTemplate define a resource template structure
@@ -125,6 +133,7 @@ var backupDependenciesCreateTemplates = []resourceTemplate{
 var backupCreateTemplates = []resourceTemplate{
 	{"backup-job-create", templates.MngClusterActCreateBackupJob},
 	{"view-backup-job", templates.MngClusterViewBackupJob},
+	{"view-backup-report", templates.MngClusterViewBackupReportConfigMap},
 }
 
 var backupJobView = []resourceTemplate{
@@ -135,6 +144,15 @@ var backupNSView = []resourceTemplate{
 	{"view-backup-namespace", templates.MngClusterViewBackupNS},
 }
 
+var backupVerifyCreateTemplates = []resourceTemplate{
+	{"backup-verify-job-create", templates.MngClusterActCreateBackupVerifyJob},
+	{"view-backup-verify-job", templates.MngClusterViewBackupVerifyJob},
+}
+
+var backupVerifyJobView = []resourceTemplate{
+	{"view-backup-verify-job", templates.MngClusterViewBackupVerifyJob},
+}
+
 var backupDeleteTemplates = []resourceTemplate{
 	{"backup-ns-delete", templates.MngClusterActDeleteBackupNS},
 	{"backup-crb-delete", templates.MngClusterActDeleteBackupCRB},
@@ -142,6 +160,8 @@ var backupDeleteTemplates = []resourceTemplate{
 
 var backupViews = []resourceTemplate{
 	{"view-backup-job", utils.ManagedClusterViewPrefix},
+	{"view-backup-report", utils.ManagedClusterViewPrefix},
+	{"view-backup-verify-job", utils.ManagedClusterViewPrefix},
 	{"view-backup-namespace", utils.ManagedClusterViewPrefix},
 }
 
@@ -150,6 +170,108 @@ var backupMCAs = []resourceTemplate{
 	{"backup-sa-create", utils.ManagedClusterActionPrefix},
 	{"backup-crb-create", utils.ManagedClusterActionPrefix},
 	{"backup-job-create", utils.ManagedClusterActionPrefix},
+	{"backup-verify-job-create", utils.ManagedClusterActionPrefix},
+}
+
+var backupCleanupCreateTemplates = []resourceTemplate{
+	{"backup-cleanup-job-create", templates.MngClusterActCreateBackupCleanupJob},
+	{"view-backup-cleanup-job", templates.MngClusterViewBackupCleanupJob},
+}
+
+var backupCleanupJobView = []resourceTemplate{
+	{"view-backup-cleanup-job", templates.MngClusterViewBackupCleanupJob},
+}
+
+var backupCleanupViews = []resourceTemplate{
+	{"view-backup-cleanup-job", utils.ManagedClusterViewPrefix},
+	{"view-backup-namespace", utils.ManagedClusterViewPrefix},
+}
+
+var backupCleanupMCAs = []resourceTemplate{
+	{"backup-ns-create", utils.ManagedClusterActionPrefix},
+	{"backup-sa-create", utils.ManagedClusterActionPrefix},
+	{"backup-crb-create", utils.ManagedClusterActionPrefix},
+	{"backup-cleanup-job-create", utils.ManagedClusterActionPrefix},
+	{"backup-ns-delete", utils.ManagedClusterActionPrefix},
+	{"backup-crb-delete", utils.ManagedClusterActionPrefix},
+}
+
+var restoreDependenciesCreateTemplates = []resourceTemplate{
+	{"restore-ns-create", templates.MngClusterActCreateRestoreNS},
+	{"restore-sa-create", templates.MngClusterActCreateRestoreSA},
+	{"restore-crb-create", templates.MngClusterActCreateRestoreRB},
+	{"view-restore-namespace", templates.MngClusterViewRestoreNS},
+}
+
+var restoreCreateTemplates = []resourceTemplate{
+	{"restore-job-create", templates.MngClusterActCreateRestoreJob},
+	{"view-restore-job", templates.MngClusterViewRestoreJob},
+}
+
+var restoreJobView = []resourceTemplate{
+	{"view-restore-job", templates.MngClusterViewRestoreJob},
+}
+
+var restoreNSView = []resourceTemplate{
+	{"view-restore-namespace", templates.MngClusterViewRestoreNS},
+}
+
+var restoreDeleteTemplates = []resourceTemplate{
+	{"restore-ns-delete", templates.MngClusterActDeleteRestoreNS},
+	{"restore-crb-delete", templates.MngClusterActDeleteRestoreCRB},
+}
+
+var restoreViews = []resourceTemplate{
+	{"view-restore-job", utils.ManagedClusterViewPrefix},
+	{"view-restore-namespace", utils.ManagedClusterViewPrefix},
+}
+
+var restoreMCAs = []resourceTemplate{
+	{"restore-ns-create", utils.ManagedClusterActionPrefix},
+	{"restore-sa-create", utils.ManagedClusterActionPrefix},
+	{"restore-crb-create", utils.ManagedClusterActionPrefix},
+	{"restore-job-create", utils.ManagedClusterActionPrefix},
+}
+
+var inventoryDependenciesCreateTemplates = []resourceTemplate{
+	{"inventory-ns-create", templates.MngClusterActCreatePrecachingNS},
+	{"inventory-spec-cm-create", templates.MngClusterActCreateInventorySpecCM},
+	{"inventory-sa-create", templates.MngClusterActCreateServiceAcct},
+	{"inventory-crb-create", templates.MngClusterActCreateClusterRoleBinding},
+	{"view-inventory-namespace", templates.MngClusterViewNamespace},
+}
+
+var inventoryCreateTemplates = []resourceTemplate{
+	{"inventory-job-create", templates.MngClusterActCreateInventoryJob},
+	{"view-inventory-job", templates.MngClusterViewInventoryJob},
+	{"view-inventory-configmap", templates.MngClusterViewInventoryConfigMap},
+}
+
+var inventoryJobView = []resourceTemplate{
+	{"view-inventory-job", templates.MngClusterViewInventoryJob},
+}
+
+var inventoryNSView = []resourceTemplate{
+	{"view-inventory-namespace", templates.MngClusterViewNamespace},
+}
+
+var inventoryDeleteTemplates = []resourceTemplate{
+	{"inventory-ns-delete", templates.MngClusterActDeletePrecachingNS},
+	{"inventory-crb-delete", templates.MngClusterActDeletePrecachingCRB},
+}
+
+var inventoryViews = []resourceTemplate{
+	{"view-inventory-job", utils.ManagedClusterViewPrefix},
+	{"view-inventory-configmap", utils.ManagedClusterViewPrefix},
+	{"view-inventory-namespace", utils.ManagedClusterViewPrefix},
+}
+
+var inventoryMCAs = []resourceTemplate{
+	{"inventory-ns-create", utils.ManagedClusterActionPrefix},
+	{"inventory-spec-cm-create", utils.ManagedClusterActionPrefix},
+	{"inventory-sa-create", utils.ManagedClusterActionPrefix},
+	{"inventory-crb-create", utils.ManagedClusterActionPrefix},
+	{"inventory-job-create", utils.ManagedClusterActionPrefix},
 }
 
 var (
@@ -157,6 +279,10 @@ var (
 	jobsFinalStatus   = []string{"status", "result", "status"}
 	precache          = "precache"
 	backup            = "backup"
+	backupCleanup     = "backupCleanup"
+	backupVerify      = "backupVerify"
+	inventory         = "inventory"
+	restore           = "restore"
 )
 
 func viewGroupVersionKind() schema.GroupVersionKind {
@@ -197,6 +323,12 @@ func (r *ClusterGroupUpgradeReconciler) createResourcesFromTemplates(
 		if err != nil {
 			return err
 		}
+		labels := obj.GetLabels()
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[utils.ManagedClusterResourceLabel] = item.resourceName
+		obj.SetLabels(labels)
 		err = r.Create(ctx, obj)
 		if err != nil {
 			if errors.IsAlreadyExists(err) {
@@ -320,7 +452,7 @@ func (r *ClusterGroupUpgradeReconciler) renderYamlTemplate(
 	return w, nil
 }
 
-// jobAndViewCleanup deletes the precaching/backup resources on hub and spoke
+// jobAndViewCleanup deletes the precaching/backup/restore resources on hub and spoke
 func (r *ClusterGroupUpgradeReconciler) jobAndViewCleanup(ctx context.Context,
 	cluster string, hubResourceTemplates []resourceTemplate, spokeResourceTemplates []resourceTemplate) error {
 
@@ -334,7 +466,7 @@ func (r *ClusterGroupUpgradeReconciler) jobAndViewCleanup(ctx context.Context,
 	return r.createResourcesFromTemplates(ctx, &data, spokeResourceTemplates)
 }
 
-// jobAndViewFinalCleanup deletes all remaining precaching/backup objects. Called when upgrade is done or CR is deleted
+// jobAndViewFinalCleanup deletes all remaining precaching/backup/restore objects. Called when upgrade is done or CR is deleted
 // returns: 			error
 func (r *ClusterGroupUpgradeReconciler) jobAndViewFinalCleanup(
 	ctx context.Context,
@@ -351,6 +483,17 @@ func (r *ClusterGroupUpgradeReconciler) jobAndViewFinalCleanup(
 		}
 	}
 
+	if clusterGroupUpgrade.Status.ImageInventory != nil {
+		for cluster, status := range clusterGroupUpgrade.Status.ImageInventory.Status {
+			if status != InventoryStateSucceeded {
+				err := r.jobAndViewCleanup(ctx, cluster, append(inventoryViews, inventoryMCAs...), inventoryDeleteTemplates)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
 	if clusterGroupUpgrade.Status.Backup != nil {
 		for cluster, status := range clusterGroupUpgrade.Status.Backup.Status {
 			if status != BackupStateSucceeded {
@@ -362,6 +505,28 @@ func (r *ClusterGroupUpgradeReconciler) jobAndViewFinalCleanup(
 		}
 	}
 
+	if clusterGroupUpgrade.Status.Backup != nil {
+		for cluster, status := range clusterGroupUpgrade.Status.Backup.Cleanup {
+			if status != BackupStateSucceeded {
+				err := r.jobAndViewCleanup(ctx, cluster, append(backupCleanupViews, backupCleanupMCAs...), backupDeleteTemplates)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	if clusterGroupUpgrade.Status.Restore != nil {
+		for cluster, status := range clusterGroupUpgrade.Status.Restore.Status {
+			if status != RestoreStateSucceeded && status != RestoreStateNoBackup {
+				err := r.jobAndViewCleanup(ctx, cluster, append(restoreViews, restoreMCAs...), restoreDeleteTemplates)
+				if err != nil {
+					return err
+				}
+			}
+		}
+	}
+
 	return nil
 }
 
@@ -580,12 +745,15 @@ func (r *ClusterGroupUpgradeReconciler) checkDependenciesViews(
 func (r *ClusterGroupUpgradeReconciler) checkDependencies(
 	ctx context.Context, cluster, jobType string) (bool, error) {
 
-	//nolint:ineffassign
 	var templates []resourceTemplate
-	if jobType == precache {
-		//nolint:ineffassign
+	switch jobType {
+	case precache:
 		templates = precacheDependenciesViewTemplates
-	} else {
+	case inventory:
+		templates = inventoryNSView
+	case restore:
+		templates = restoreNSView
+	default:
 		templates = backupNSView
 	}
 
@@ -644,11 +812,24 @@ func (r *ClusterGroupUpgradeReconciler) getPrecacheJobTemplateData(
 // returns: 	*templateData
 //
 //	error
-func (r *ClusterGroupUpgradeReconciler) getBackupJobTemplateData(clusterName string) (*templateData, error) {
+func (r *ClusterGroupUpgradeReconciler) getBackupJobTemplateData(
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusterName string) (*templateData, error) {
 
 	rv := new(templateData)
 	rv.Cluster = clusterName
-	rv.JobTimeout = uint64(backupJobTimeout)
+	rv.JobTimeout = uint64(getBackupTimeout(clusterGroupUpgrade).Seconds())
+	rv.BackupCompression = strings.ToLower(ranv1alpha1.BackupCompressionNone)
+	if options := clusterGroupUpgrade.Spec.BackupOptions; options != nil {
+		if options.Compression != "" {
+			rv.BackupCompression = strings.ToLower(options.Compression)
+		}
+		rv.BackupEncrypted = options.EncryptionKeySecret != ""
+		if options.ObjectStorage != nil {
+			rv.BackupObjectName = fmt.Sprintf("%s/%s/%s/recovery-backup.tar",
+				clusterName, clusterGroupUpgrade.Namespace, clusterGroupUpgrade.Name)
+			rv.BackupRemoveLocal = options.ObjectStorage.RemoveLocalCopy
+		}
+	}
 
 	rv.WorkloadImage = os.Getenv("RECOVERY_IMG")
 	r.Log.Info("[getBackupJobTemplateData]", "workload image", rv.WorkloadImage)
@@ -660,6 +841,51 @@ func (r *ClusterGroupUpgradeReconciler) getBackupJobTemplateData(clusterName str
 	return rv, nil
 }
 
+// getRestoreJobTemplateData initializes template data for the restore job creation
+// returns: 	*templateData
+//
+//	error
+func (r *ClusterGroupUpgradeReconciler) getRestoreJobTemplateData(
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusterName string) (*templateData, error) {
+
+	rv, err := r.getBackupJobTemplateData(clusterGroupUpgrade, clusterName)
+	if err != nil {
+		return rv, err
+	}
+	rv.JobTimeout = uint64(getRestoreTimeout(clusterGroupUpgrade).Seconds())
+	return rv, nil
+}
+
+// getInventoryJobTemplateData initializes template data for the image inventory job creation
+// returns: 	*templateData
+//
+//	error
+func (r *ClusterGroupUpgradeReconciler) getInventoryJobTemplateData(
+	ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusterName string) (
+	*templateData, error) {
+
+	rv := new(templateData)
+	rv.Cluster = clusterName
+	rv.JobTimeout = uint64(inventoryJobTimeout)
+	rv.PruneImages = clusterGroupUpgrade.Spec.Actions.AfterCompletion.PruneImages
+	if clusterGroupUpgrade.Status.ImageInventory != nil {
+		rv.ViewUpdateIntervalSec = utils.GetMCVUpdateInterval(len(clusterGroupUpgrade.Status.ImageInventory.Status))
+	}
+	// Images pre-cached for the upgrade are kept along with the release
+	if clusterGroupUpgrade.Status.Precaching != nil && clusterGroupUpgrade.Status.Precaching.Spec != nil {
+		precacheSpec := r.getPrecacheSpecTemplateData(clusterGroupUpgrade, clusterName)
+		rv.KeepImages = append(append(rv.KeepImages, precacheSpec.Operators.Images...), precacheSpec.AdditionalImages...)
+	}
+
+	image, err := r.getPrecacheImagePullSpec(ctx, clusterGroupUpgrade)
+	if err != nil {
+		return rv, err
+	}
+	rv.WorkloadImage = image
+	return rv, nil
+}
+
 // deployPrecachingWorkload deploys precaching workload on the spoke
 //
 //	using a set of templated manifests
@@ -683,13 +909,46 @@ func (r *ClusterGroupUpgradeReconciler) deployWorkload(
 			cluster, "status", "success")
 
 	case backup:
-		spec, err = r.getBackupJobTemplateData(cluster)
+		spec, err = r.getBackupJobTemplateData(clusterGroupUpgrade, cluster)
 		if err != nil {
 			return err
 		}
 		r.Log.Info("[deployBackupWorkload]", "getBackupJobTemplateData",
 			cluster, "spec", spec, "status", "success")
 
+	case backupVerify:
+		spec, err = r.getBackupJobTemplateData(clusterGroupUpgrade, cluster)
+		if err != nil {
+			return err
+		}
+		r.Log.Info("[deployBackupVerifyWorkload]", "getBackupJobTemplateData",
+			cluster, "spec", spec, "status", "success")
+
+	case backupCleanup:
+		spec, err = r.getBackupJobTemplateData(clusterGroupUpgrade, cluster)
+		if err != nil {
+			return err
+		}
+		spec.JobTimeout = uint64(backupCleanupJobTimeout)
+		r.Log.Info("[deployBackupCleanupWorkload]", "getBackupJobTemplateData",
+			cluster, "spec", spec, "status", "success")
+
+	case inventory:
+		spec, err = r.getInventoryJobTemplateData(ctx, clusterGroupUpgrade, cluster)
+		if err != nil {
+			return err
+		}
+		r.Log.Info("[deployInventoryWorkload]", "getInventoryJobTemplateData",
+			cluster, "status", "success")
+
+	case restore:
+		spec, err = r.getRestoreJobTemplateData(clusterGroupUpgrade, cluster)
+		if err != nil {
+			return err
+		}
+		r.Log.Info("[deployRestoreWorkload]", "getRestoreJobTemplateData",
+			cluster, "spec", spec, "status", "success")
+
 	default:
 		return fmt.Errorf("[deployWorkload] no workload found to deploy")
 	}
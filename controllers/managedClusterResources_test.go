@@ -33,11 +33,13 @@ func TestMCR_renderYamlTemplates(t *testing.T) {
 			name:         "create configmap",
 			resourceName: "precache-spec",
 			data: templateData{
-				Cluster:                 "test",
-				ResourceName:            "precache-spec",
-				ExcludePrecachePatterns: []string{"aws", "thanos"},
-				AdditionalImages:        []string{"image1:tag", "image2:tag"},
-				SpaceRequired:           "45",
+				Cluster:                    "test",
+				ResourceName:               "precache-spec",
+				ExcludePrecachePatterns:    []string{"aws", "thanos"},
+				AdditionalImages:           []string{"image1:tag", "image2:tag"},
+				Operators:                  operatorsData{Images: []string{"operator1@sha256:01", "operator2@sha256:02"}},
+				IntermediatePlatformImages: []string{"release@sha256:415"},
+				SpaceRequired:              "45",
 			},
 			template: templates.MngClusterActCreatePrecachingSpecCM,
 			result: `
@@ -61,7 +63,12 @@ spec:
           image2:tag 
         operators.indexes: ""
         operators.packagesAndChannels: ""
+        operators.images: |
+          operator1@sha256:01 
+          operator2@sha256:02 
         platform.image:
+        platform.intermediateImages: |
+          release@sha256:415 
         spaceRequired: "45"
       kind: ConfigMap
       metadata:
@@ -480,6 +487,243 @@ spec:
   kube:
     resource: clusterrolebinding
     name: pre-cache-crb
+`,
+		},
+		{
+			name:         "create encrypted backup job",
+			resourceName: "backup-job-create",
+			data: templateData{
+				Cluster:           "test",
+				WorkloadImage:     "test-image",
+				JobTimeout:        480,
+				BackupCompression: "zstd",
+				BackupEncrypted:   true,
+			},
+			template: templates.MngClusterActCreateBackupJob,
+			result: `
+apiVersion: action.open-cluster-management.io/v1beta1
+kind: ManagedClusterAction
+metadata:
+  name: backup-job-create
+  namespace: test
+spec:
+  actionType: Create
+  kube:
+    namespace: openshift-talo-backup
+    resource: job
+    template:
+      apiVersion: batch/v1
+      kind: Job
+      metadata:
+        name: backup-agent
+        namespace: openshift-talo-backup
+        annotations:
+          target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+      spec:
+        activeDeadlineSeconds: 480
+        backoffLimit: 0
+        template:
+          metadata:
+            name: backup-agent
+            annotations:
+              target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+          spec:
+            containers:
+            - args:
+              - launchBackup
+              - --compression=zstd
+              - --encryption-key-file=/etc/backup-encryption/key
+              image: test-image
+              name: container-image
+              securityContext:
+                privileged: true
+                runAsUser: 0
+              tty: true
+              volumeMounts:
+              - mountPath: /host
+                name: backup
+              - mountPath: /etc/backup-encryption
+                name: backup-encryption-key
+                readOnly: true
+            restartPolicy: Never
+            serviceAccountName: backup-agent
+            volumes:
+            - hostPath:
+                path: /
+                type: Directory
+              name: backup
+            - name: backup-encryption-key
+              secret:
+                secretName: backup-encryption-key
+`,
+		},
+		{
+			name:         "create uploaded backup job",
+			resourceName: "backup-job-create",
+			data: templateData{
+				Cluster:           "test",
+				WorkloadImage:     "test-image",
+				JobTimeout:        480,
+				BackupCompression: "none",
+				BackupObjectName:  "test/default/cgu/recovery-backup.tar",
+				BackupRemoveLocal: true,
+			},
+			template: templates.MngClusterActCreateBackupJob,
+			result: `
+apiVersion: action.open-cluster-management.io/v1beta1
+kind: ManagedClusterAction
+metadata:
+  name: backup-job-create
+  namespace: test
+spec:
+  actionType: Create
+  kube:
+    namespace: openshift-talo-backup
+    resource: job
+    template:
+      apiVersion: batch/v1
+      kind: Job
+      metadata:
+        name: backup-agent
+        namespace: openshift-talo-backup
+        annotations:
+          target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+      spec:
+        activeDeadlineSeconds: 480
+        backoffLimit: 0
+        template:
+          metadata:
+            name: backup-agent
+            annotations:
+              target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+          spec:
+            containers:
+            - args:
+              - launchBackup
+              - --compression=none
+              - --object-storage-config=/etc/backup-object-storage
+              - --object-name=test/default/cgu/recovery-backup.tar
+              - --remove-local
+              image: test-image
+              name: container-image
+              securityContext:
+                privileged: true
+                runAsUser: 0
+              tty: true
+              volumeMounts:
+              - mountPath: /host
+                name: backup
+              - mountPath: /etc/backup-object-storage
+                name: backup-object-storage
+                readOnly: true
+            restartPolicy: Never
+            serviceAccountName: backup-agent
+            volumes:
+            - hostPath:
+                path: /
+                type: Directory
+              name: backup
+            - name: backup-object-storage
+              secret:
+                secretName: backup-object-storage
+`,
+		},
+		{
+			name:         "create uploaded backup verify job",
+			resourceName: "backup-verify-job-create",
+			data: templateData{
+				Cluster:          "test",
+				WorkloadImage:    "test-image",
+				JobTimeout:       480,
+				BackupObjectName: "test/default/cgu/recovery-backup.tar",
+			},
+			template: templates.MngClusterActCreateBackupVerifyJob,
+			result: `
+apiVersion: action.open-cluster-management.io/v1beta1
+kind: ManagedClusterAction
+metadata:
+  name: backup-verify-job-create
+  namespace: test
+spec:
+  actionType: Create
+  kube:
+    namespace: openshift-talo-backup
+    resource: job
+    template:
+      apiVersion: batch/v1
+      kind: Job
+      metadata:
+        name: backup-verify
+        namespace: openshift-talo-backup
+        annotations:
+          target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+      spec:
+        activeDeadlineSeconds: 480
+        backoffLimit: 0
+        template:
+          metadata:
+            name: backup-verify
+            annotations:
+              target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+          spec:
+            containers:
+            - args:
+              - verify
+              - --object-storage-config=/etc/backup-object-storage
+              image: test-image
+              name: container-image
+              securityContext:
+                privileged: true
+                runAsUser: 0
+              tty: true
+              volumeMounts:
+              - mountPath: /host
+                name: backup
+              - mountPath: /etc/backup-object-storage
+                name: backup-object-storage
+                readOnly: true
+            restartPolicy: Never
+            serviceAccountName: backup-agent
+            volumes:
+            - hostPath:
+                path: /
+                type: Directory
+              name: backup
+            - name: backup-object-storage
+              secret:
+                secretName: backup-object-storage
+`,
+		},
+		{
+			name:         "create inventory configmap",
+			resourceName: "inventory-spec",
+			data: templateData{
+				Cluster:     "test",
+				PruneImages: true,
+				KeepImages:  []string{"operator1@sha256:01", "image1:tag"},
+			},
+			template: templates.MngClusterActCreateInventorySpecCM,
+			result: `
+apiVersion: action.open-cluster-management.io/v1beta1
+kind: ManagedClusterAction
+metadata:
+  name: inventory-spec
+  namespace: test
+spec:
+  actionType: Create
+  kube:
+    resource: configmap
+    template:
+      apiVersion: v1
+      data:
+        prune: "true"
+        keepImages: |
+          operator1@sha256:01 
+          image1:tag 
+      kind: ConfigMap
+      metadata:
+        name: pre-cache-inventory-spec
+        namespace: openshift-talo-pre-cache
 `,
 		},
 	}
@@ -24,11 +24,14 @@ import (
 	"github.com/go-logr/logr"
 	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
 	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	actionv1beta1 "github.com/stolostron/cluster-lifecycle-api/action/v1beta1"
+	viewv1beta1 "github.com/stolostron/cluster-lifecycle-api/view/v1beta1"
 	"github.com/stretchr/testify/assert"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
 	clusterv1 "open-cluster-management.io/api/cluster/v1"
@@ -47,8 +50,20 @@ func init() {
 	testscheme.AddKnownTypes(clusterv1.GroupVersion, &clusterv1.ManagedCluster{})
 	testscheme.AddKnownTypes(ranv1alpha1.GroupVersion, &ranv1alpha1.ClusterGroupUpgrade{})
 	testscheme.AddKnownTypes(ranv1alpha1.GroupVersion, &ranv1alpha1.ClusterGroupUpgradeList{})
+	testscheme.AddKnownTypes(ranv1alpha1.GroupVersion, &ranv1alpha1.PreCachingConfig{})
+	testscheme.AddKnownTypes(ranv1alpha1.GroupVersion, &ranv1alpha1.PreCachingConfigList{})
+	testscheme.AddKnownTypes(ranv1alpha1.GroupVersion, &ranv1alpha1.NotificationTarget{})
+	testscheme.AddKnownTypes(ranv1alpha1.GroupVersion, &ranv1alpha1.NotificationTargetList{})
+	testscheme.AddKnownTypes(ranv1alpha1.GroupVersion, &ranv1alpha1.ClusterGroupUpgradeReport{})
+	testscheme.AddKnownTypes(ranv1alpha1.GroupVersion, &ranv1alpha1.ClusterGroupUpgradeReportList{})
 	testscheme.AddKnownTypes(policiesv1.GroupVersion, &policiesv1.Policy{})
 	testscheme.AddKnownTypes(policiesv1.GroupVersion, &policiesv1.PolicyList{})
+	testscheme.AddKnownTypes(policiesv1.GroupVersion, &policiesv1.PlacementBinding{})
+	testscheme.AddKnownTypes(policiesv1.GroupVersion, &policiesv1.PlacementBindingList{})
+	testscheme.AddKnownTypes(actionv1beta1.GroupVersion, &actionv1beta1.ManagedClusterAction{})
+	testscheme.AddKnownTypes(actionv1beta1.GroupVersion, &actionv1beta1.ManagedClusterActionList{})
+	testscheme.AddKnownTypes(viewv1beta1.GroupVersion, &viewv1beta1.ManagedClusterView{})
+	testscheme.AddKnownTypes(viewv1beta1.GroupVersion, &viewv1beta1.ManagedClusterViewList{})
 }
 
 func getFakeClientFromObjects(objs ...client.Object) (client.WithWatch, error) {
@@ -56,6 +71,33 @@ func getFakeClientFromObjects(objs ...client.Object) (client.WithWatch, error) {
 	return c, nil
 }
 
+// newUnstructuredView returns a ManagedClusterView that retrieved the result, as read by the controllers
+// through unstructured objects
+func newUnstructuredView(name, cluster string, result map[string]interface{}) *unstructured.Unstructured {
+	view := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": name, "namespace": cluster},
+		"status": map[string]interface{}{
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Processing", "status": "True", "message": "Watching resources successfully"},
+			},
+			"result": result,
+		},
+	}}
+	view.SetGroupVersionKind(viewGroupVersionKind())
+	return view
+}
+
+// newUnstructuredNotFoundView returns a ManagedClusterView of an object missing from the cluster
+func newUnstructuredNotFoundView(name, cluster, message string) *unstructured.Unstructured {
+	view := newUnstructuredView(name, cluster, nil)
+	view.Object["status"] = map[string]interface{}{
+		"conditions": []interface{}{
+			map[string]interface{}{"type": "Processing", "status": "False", "message": message},
+		},
+	}
+	return view
+}
+
 func TestControllerReconciler(t *testing.T) {
 	testcases := []struct {
 		name         string
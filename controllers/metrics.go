@@ -0,0 +1,296 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	metricsNamespace = "cluster_group_upgrade"
+	// metricsListTimeout bounds the listing of the objects counted on a scrape
+	metricsListTimeout = 30 * time.Second
+	// metricsObjectsRefreshInterval is how often the views and actions are counted again, as they are listed
+	// from the API server rather than from the cache
+	metricsObjectsRefreshInterval = 5 * time.Minute
+	// metricsListPageSize is the number of views or actions listed per request
+	metricsListPageSize = 500
+)
+
+// Results of the batches and of the InstallPlan approvals
+const (
+	batchResultCompleted = "completed"
+	batchResultTimedOut  = "timed_out"
+
+	installPlanApproved         = "approved"
+	installPlanAlreadyApproved  = "already_approved"
+	installPlanCannotBeApproved = "cannot_be_approved"
+	installPlanPending          = "pending"
+	installPlanNoAction         = "no_action"
+	installPlanError            = "error"
+)
+
+var (
+	batchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "batch_duration_seconds",
+		Help:      "Duration of the remediation of the batches of clusters, by result",
+		// From 1 minute to about 17 hours
+		Buckets: prometheus.ExponentialBuckets(60, 2, 11),
+	}, []string{"result"})
+
+	// The policies are not a label, as their names are not bounded
+	policyRemediationDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "policy_remediation_duration_seconds",
+		Help:      "Duration of the remediation of a managed policy on a cluster",
+		// From 30 seconds to about 8.5 hours
+		Buckets: prometheus.ExponentialBuckets(30, 2, 11),
+	})
+
+	installPlanApprovals = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "install_plan_approvals_total",
+		Help:      "Number of attempts to approve the InstallPlan of a subscription, by result",
+	}, []string{"result"})
+)
+
+// installPlanResults maps the results of the approval of an InstallPlan to their metric label
+var installPlanResults = map[int]string{
+	utils.InstallPlanWasApproved:          installPlanApproved,
+	utils.InstallPlanAlreadyApproved:      installPlanAlreadyApproved,
+	utils.InstallPlanCannotBeApproved:     installPlanCannotBeApproved,
+	utils.MultiCloudPendingStatus:         installPlanPending,
+	utils.NoActionForApprovingInstallPlan: installPlanNoAction,
+}
+
+func init() {
+	metrics.Registry.MustRegister(batchDuration, policyRemediationDuration, installPlanApprovals)
+}
+
+// recordBatchDuration records the duration of the current batch once it is completed or timed out
+func recordBatchDuration(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, result string) {
+	startedAt := clusterGroupUpgrade.Status.Status.CurrentBatchStartedAt
+	if startedAt.IsZero() {
+		return
+	}
+	batchDuration.WithLabelValues(result).Observe(time.Since(startedAt.Time).Seconds())
+}
+
+// recordPolicyRemediation records the remediation duration of the policy of a cluster, when the cluster moves to
+// the next non-compliant policy or has no policy left to remediate, and starts timing the next policy
+func recordPolicyRemediation(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
+	progress *ranv1alpha1.ClusterRemediationProgress, nextPolicyIndex int) {
+
+	policies := clusterGroupUpgrade.Status.ManagedPoliciesForUpgrade
+	if progress.PolicyIndex != nil && !progress.PolicyStartedAt.IsZero() {
+		if *progress.PolicyIndex == nextPolicyIndex {
+			return
+		}
+		if *progress.PolicyIndex < len(policies) {
+			policyRemediationDuration.Observe(time.Since(progress.PolicyStartedAt.Time).Seconds())
+		}
+	}
+	if nextPolicyIndex < len(policies) {
+		progress.PolicyStartedAt = metav1.Now()
+	} else {
+		progress.PolicyStartedAt = metav1.Time{}
+	}
+}
+
+// recordInstallPlanApproval counts the attempts to approve an InstallPlan
+func recordInstallPlanApproval(status int, err error) {
+	result, ok := installPlanResults[status]
+	if err != nil || !ok {
+		result = installPlanError
+	}
+	installPlanApprovals.WithLabelValues(result).Inc()
+}
+
+// cguCollector counts the ClusterGroupUpgrades and their clusters on each scrape, and the managed cluster
+// resources every metricsObjectsRefreshInterval
+type cguCollector struct {
+	// client lists the ClusterGroupUpgrades from the cache of the manager
+	client client.Reader
+	// apiReader lists the metadata of the views and actions, which are not cached
+	apiReader client.Reader
+
+	// mu guards the last counts of the views and actions, by kind, and when they were counted
+	mu               sync.Mutex
+	objectCounts     map[string]int
+	objectsCountedAt time.Time
+
+	cgus                  *prometheus.Desc
+	clusters              *prometheus.Desc
+	precachingClusters    *prometheus.Desc
+	backupClusters        *prometheus.Desc
+	managedClusterObjects *prometheus.Desc
+}
+
+// newCGUCollector creates the collector of the ClusterGroupUpgrade metrics
+// returns: *cguCollector
+func newCGUCollector(c, apiReader client.Reader) *cguCollector {
+	return &cguCollector{
+		client:    c,
+		apiReader: apiReader,
+		cgus: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "cgus"),
+			"Number of ClusterGroupUpgrades by condition type, status and reason",
+			[]string{"condition", "status", "reason"}, nil),
+		clusters: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "clusters"),
+			"Number of clusters of the ClusterGroupUpgrades by remediation state",
+			[]string{"state"}, nil),
+		precachingClusters: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "precaching_clusters"),
+			"Number of clusters of the ClusterGroupUpgrades by pre-caching state",
+			[]string{"state"}, nil),
+		backupClusters: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "backup_clusters"),
+			"Number of clusters of the ClusterGroupUpgrades by backup state",
+			[]string{"state"}, nil),
+		managedClusterObjects: prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", "managed_cluster_resources"),
+			"Number of ManagedClusterViews and ManagedClusterActions created by TALM on the hub",
+			[]string{"kind"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *cguCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cgus
+	ch <- c.clusters
+	ch <- c.precachingClusters
+	ch <- c.backupClusters
+	ch <- c.managedClusterObjects
+}
+
+// Collect implements prometheus.Collector
+func (c *cguCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), metricsListTimeout)
+	defer cancel()
+
+	cguList := &ranv1alpha1.ClusterGroupUpgradeList{}
+	if err := c.client.List(ctx, cguList); err != nil {
+		for _, desc := range []*prometheus.Desc{c.cgus, c.clusters, c.precachingClusters, c.backupClusters} {
+			ch <- prometheus.NewInvalidMetric(desc, err)
+		}
+	} else {
+		c.collectClusterGroupUpgrades(ch, cguList.Items)
+	}
+
+	counts, err := c.getObjectCounts(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.managedClusterObjects, err)
+		return
+	}
+	for kind, count := range counts {
+		ch <- prometheus.MustNewConstMetric(c.managedClusterObjects, prometheus.GaugeValue, float64(count), kind)
+	}
+}
+
+// getObjectCounts returns the number of views and actions by kind, counting them again once the last counts
+// are older than metricsObjectsRefreshInterval. Failed counts are retried on the next scrape
+// returns: map[string]int, error
+func (c *cguCollector) getObjectCounts(ctx context.Context) (map[string]int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.objectCounts != nil && time.Since(c.objectsCountedAt) < metricsObjectsRefreshInterval {
+		return c.objectCounts, nil
+	}
+
+	counts := make(map[string]int)
+	for _, gvk := range []schema.GroupVersionKind{viewGroupVersionKind(), actionGroupVersionKind()} {
+		count, err := c.countObjects(ctx, gvk)
+		if err != nil {
+			return nil, err
+		}
+		counts[gvk.Kind] = count
+	}
+	c.objectCounts, c.objectsCountedAt = counts, time.Now()
+	return counts, nil
+}
+
+// collectClusterGroupUpgrades counts the ClusterGroupUpgrades and their clusters
+func (c *cguCollector) collectClusterGroupUpgrades(ch chan<- prometheus.Metric, cgus []ranv1alpha1.ClusterGroupUpgrade) {
+	type conditionKey struct{ condition, status, reason string }
+	conditions := make(map[conditionKey]float64)
+	clusters := make(map[string]float64)
+	precaching := make(map[string]float64)
+	backup := make(map[string]float64)
+
+	for i := range cgus {
+		status := &cgus[i].Status
+		for _, condition := range status.Conditions {
+			conditions[conditionKey{condition.Type, string(condition.Status), condition.Reason}]++
+		}
+		// The clusters that completed, failed or timed out the remediation are listed in the status,
+		// the progress of the current batch holds the others
+		for _, cluster := range status.Clusters {
+			clusters[cluster.State]++
+		}
+		for _, progress := range status.Status.CurrentBatchRemediationProgress {
+			if progress != nil && (progress.State == ranv1alpha1.NotStarted || progress.State == ranv1alpha1.InProgress) {
+				clusters[progress.State]++
+			}
+		}
+		if status.Precaching != nil {
+			for _, state := range status.Precaching.Status {
+				precaching[state]++
+			}
+		}
+		if status.Backup != nil {
+			for _, state := range status.Backup.Status {
+				backup[state]++
+			}
+		}
+	}
+
+	for key, count := range conditions {
+		ch <- prometheus.MustNewConstMetric(c.cgus, prometheus.GaugeValue, count, key.condition, key.status, key.reason)
+	}
+	for desc, counts := range map[*prometheus.Desc]map[string]float64{
+		c.clusters: clusters, c.precachingClusters: precaching, c.backupClusters: backup,
+	} {
+		for state, count := range counts {
+			ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, count, state)
+		}
+	}
+}
+
+// countObjects counts the objects of a kind created by TALM from their metadata, page by page. The views and
+// actions of the other components of the hub are left out by the label selectors
+// returns: int, error
+func (c *cguCollector) countObjects(ctx context.Context, gvk schema.GroupVersionKind) (int, error) {
+	count := 0
+	for _, label := range []string{utils.ClusterGroupUpgradeLabel, utils.ManagedClusterResourceLabel} {
+		list := &metav1.PartialObjectMetadataList{}
+		list.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+		for {
+			err := c.apiReader.List(ctx, list, client.HasLabels{label},
+				client.Limit(metricsListPageSize), client.Continue(list.Continue))
+			if err != nil {
+				return 0, err
+			}
+			count += len(list.Items)
+			if list.Continue == "" {
+				break
+			}
+		}
+	}
+	return count, nil
+}
+
+// registerCGUCollector registers the collector of the ClusterGroupUpgrade metrics in the registry of the manager
+// returns: error
+func registerCGUCollector(c, apiReader client.Reader) error {
+	err := metrics.Registry.Register(newCGUCollector(c, apiReader))
+	if errors.As(err, &prometheus.AlreadyRegisteredError{}) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// metadataReader lists the metadata of the objects of the fake client, which only lists full objects
+type metadataReader struct {
+	client.Reader
+}
+
+func (m metadataReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	metadataList, ok := list.(*metav1.PartialObjectMetadataList)
+	if !ok {
+		return m.Reader.List(ctx, list, opts...)
+	}
+	objects := &unstructured.UnstructuredList{}
+	objects.SetGroupVersionKind(metadataList.GroupVersionKind())
+	if err := m.Reader.List(ctx, objects, opts...); err != nil {
+		return err
+	}
+	metadataList.Items = nil
+	for _, object := range objects.Items {
+		metadataList.Items = append(metadataList.Items, metav1.PartialObjectMetadata{
+			ObjectMeta: metav1.ObjectMeta{Name: object.GetName(), Namespace: object.GetNamespace()},
+		})
+	}
+	return nil
+}
+
+// failingReader fails to list the objects
+type failingReader struct {
+	client.Reader
+}
+
+func (failingReader) List(context.Context, client.ObjectList, ...client.ListOption) error {
+	return errors.New("the server is currently unable to handle the request")
+}
+
+// gatherGauges returns the values of the gauges of a collector, indexed by metric name and label values
+func gatherGauges(t *testing.T, collector prometheus.Collector) map[string]map[string]float64 {
+	registry := prometheus.NewPedanticRegistry()
+	assert.NoError(t, registry.Register(collector))
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+
+	gauges := make(map[string]map[string]float64)
+	for _, family := range families {
+		gauges[family.GetName()] = make(map[string]float64)
+		for _, metric := range family.GetMetric() {
+			var labels []string
+			for _, label := range metric.GetLabel() {
+				labels = append(labels, label.GetValue())
+			}
+			gauges[family.GetName()][strings.Join(labels, ",")] = metric.GetGauge().GetValue()
+		}
+	}
+	return gauges
+}
+
+// histogramCount returns the number of observations of a histogram
+func histogramCount(t *testing.T, vec *prometheus.HistogramVec, label string) uint64 {
+	return histogramSampleCount(t, vec.WithLabelValues(label).(prometheus.Histogram))
+}
+
+// histogramSampleCount returns the number of observations of a histogram without labels
+func histogramSampleCount(t *testing.T, histogram prometheus.Histogram) uint64 {
+	metric := &dto.Metric{}
+	assert.NoError(t, histogram.Write(metric))
+	return metric.GetHistogram().GetSampleCount()
+}
+
+// newLabelledView returns a view with the label of the views and actions created by TALM
+func newLabelledView(name, cluster, label string) *unstructured.Unstructured {
+	view := newUnstructuredView(name, cluster, nil)
+	view.SetLabels(map[string]string{label: name})
+	return view
+}
+
+func TestMetrics_cguCollector(t *testing.T) {
+	cgu1 := &ranv1alpha1.ClusterGroupUpgrade{ObjectMeta: metav1.ObjectMeta{Name: "cgu1", Namespace: "default"}}
+	cgu1.Status.Conditions = []metav1.Condition{
+		{Type: "Progressing", Status: metav1.ConditionTrue, Reason: "InProgress"},
+		{Type: "Succeeded", Status: metav1.ConditionFalse, Reason: "InProgress"},
+	}
+	cgu1.Status.Clusters = []ranv1alpha1.ClusterState{
+		{Name: "spoke1", State: utils.ClusterRemediationComplete},
+		{Name: "spoke2", State: utils.ClusterRemediationFailed},
+	}
+	cgu1.Status.Status.CurrentBatchRemediationProgress = map[string]*ranv1alpha1.ClusterRemediationProgress{
+		"spoke1": {State: ranv1alpha1.Completed},
+		"spoke3": {State: ranv1alpha1.InProgress},
+		"spoke4": {State: ranv1alpha1.NotStarted},
+	}
+	cgu1.Status.Precaching = &ranv1alpha1.PrecachingStatus{Status: map[string]string{
+		"spoke1": PrecacheStateSucceeded, "spoke2": PrecacheStateSucceeded, "spoke3": PrecacheStateActive,
+	}}
+	cgu1.Status.Backup = &ranv1alpha1.BackupStatus{Status: map[string]string{"spoke1": BackupStateTimeout}}
+
+	cgu2 := &ranv1alpha1.ClusterGroupUpgrade{ObjectMeta: metav1.ObjectMeta{Name: "cgu2", Namespace: "default"}}
+	cgu2.Status.Conditions = []metav1.Condition{
+		{Type: "Progressing", Status: metav1.ConditionFalse, Reason: "Completed"},
+		{Type: "Succeeded", Status: metav1.ConditionTrue, Reason: "Completed"},
+	}
+	cgu2.Status.Clusters = []ranv1alpha1.ClusterState{{Name: "spoke5", State: utils.ClusterRemediationComplete}}
+	cgu2.Status.Backup = &ranv1alpha1.BackupStatus{Status: map[string]string{"spoke5": BackupStateSucceeded}}
+
+	// The views of the other components of the hub are not counted
+	fakeClient, _ := getFakeClientFromObjects(cgu1, cgu2,
+		newLabelledView("view-backup-job", "spoke1", utils.ManagedClusterResourceLabel),
+		newLabelledView("view-precache-job", "spoke3", utils.ManagedClusterResourceLabel),
+		newLabelledView("cgu1-machineconfigpool-worker", "spoke3", utils.ClusterGroupUpgradeLabel),
+		newUnstructuredView("search-view", "spoke3", nil))
+
+	gauges := gatherGauges(t, newCGUCollector(fakeClient, metadataReader{fakeClient}))
+	// The labels are sorted by name: condition, reason and status
+	assert.Equal(t, map[string]float64{
+		"Progressing,InProgress,True": 1, "Succeeded,InProgress,False": 1,
+		"Progressing,Completed,False": 1, "Succeeded,Completed,True": 1,
+	}, gauges["cluster_group_upgrade_cgus"])
+	assert.Equal(t, map[string]float64{
+		"complete": 2, "failed": 1, "InProgress": 1, "NotStarted": 1,
+	}, gauges["cluster_group_upgrade_clusters"])
+	assert.Equal(t, map[string]float64{"Succeeded": 2, "Active": 1}, gauges["cluster_group_upgrade_precaching_clusters"])
+	assert.Equal(t, map[string]float64{"BackupTimeout": 1, "Succeeded": 1}, gauges["cluster_group_upgrade_backup_clusters"])
+	assert.Equal(t, map[string]float64{"ManagedClusterView": 3, "ManagedClusterAction": 0},
+		gauges["cluster_group_upgrade_managed_cluster_resources"])
+}
+
+func TestMetrics_getObjectCounts(t *testing.T) {
+	fakeClient, _ := getFakeClientFromObjects(newLabelledView("view-backup-job", "spoke1", utils.ManagedClusterResourceLabel))
+	collector := newCGUCollector(fakeClient, metadataReader{fakeClient})
+	counts, err := collector.getObjectCounts(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"ManagedClusterView": 1, "ManagedClusterAction": 0}, counts)
+
+	// The views and actions are not listed again on each scrape
+	assert.NoError(t, fakeClient.Create(context.TODO(), newLabelledView("view-precache-job", "spoke1", utils.ManagedClusterResourceLabel)))
+	counts, err = collector.getObjectCounts(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, counts["ManagedClusterView"])
+
+	// But once the counts are older than the refresh interval
+	collector.objectsCountedAt = time.Now().Add(-metricsObjectsRefreshInterval)
+	counts, err = collector.getObjectCounts(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, counts["ManagedClusterView"])
+
+	// Failed counts are retried on the next scrape
+	collector.objectsCountedAt = time.Now().Add(-metricsObjectsRefreshInterval)
+	collector.apiReader = failingReader{}
+	_, err = collector.getObjectCounts(context.TODO())
+	assert.Error(t, err)
+	assert.NoError(t, fakeClient.Create(context.TODO(), newLabelledView("view-restore-job", "spoke1", utils.ManagedClusterResourceLabel)))
+	collector.apiReader = metadataReader{fakeClient}
+	counts, err = collector.getObjectCounts(context.TODO())
+	assert.NoError(t, err)
+	assert.Equal(t, 3, counts["ManagedClusterView"])
+}
+
+func TestMetrics_recordPolicyRemediation(t *testing.T) {
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+	cgu.Status.ManagedPoliciesForUpgrade = []ranv1alpha1.ManagedPolicyForUpgrade{
+		{Name: "metrics-policy1", Namespace: "default"}, {Name: "metrics-policy2", Namespace: "default"},
+	}
+	progress := &ranv1alpha1.ClusterRemediationProgress{State: ranv1alpha1.InProgress, PolicyIndex: new(int)}
+
+	count := histogramSampleCount(t, policyRemediationDuration)
+
+	// The first policy starts being timed
+	recordPolicyRemediation(cgu, progress, 0)
+	assert.False(t, progress.PolicyStartedAt.IsZero())
+	assert.Equal(t, count, histogramSampleCount(t, policyRemediationDuration))
+
+	// The cluster keeps remediating the first policy
+	progress.PolicyStartedAt = metav1.NewTime(time.Now().Add(-2 * time.Minute))
+	startedAt := progress.PolicyStartedAt
+	recordPolicyRemediation(cgu, progress, 0)
+	assert.Equal(t, startedAt, progress.PolicyStartedAt)
+
+	// The cluster moves to the second policy
+	recordPolicyRemediation(cgu, progress, 1)
+	assert.Equal(t, count+1, histogramSampleCount(t, policyRemediationDuration))
+	assert.True(t, progress.PolicyStartedAt.After(startedAt.Time))
+
+	// The cluster completes the remediation
+	*progress.PolicyIndex = 1
+	recordPolicyRemediation(cgu, progress, 2)
+	assert.Equal(t, count+2, histogramSampleCount(t, policyRemediationDuration))
+	assert.True(t, progress.PolicyStartedAt.IsZero())
+}
+
+func TestMetrics_recordBatchDuration(t *testing.T) {
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+	completed := histogramCount(t, batchDuration, batchResultCompleted)
+
+	// The batch didn't start
+	recordBatchDuration(cgu, batchResultCompleted)
+	assert.Equal(t, completed, histogramCount(t, batchDuration, batchResultCompleted))
+
+	cgu.Status.Status.CurrentBatchStartedAt = metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	recordBatchDuration(cgu, batchResultCompleted)
+	assert.Equal(t, completed+1, histogramCount(t, batchDuration, batchResultCompleted))
+}
+
+func TestMetrics_recordInstallPlanApproval(t *testing.T) {
+	count := func(result string) float64 {
+		metric := &dto.Metric{}
+		assert.NoError(t, installPlanApprovals.WithLabelValues(result).Write(metric))
+		return metric.GetCounter().GetValue()
+	}
+	approved, pending, failed := count(installPlanApproved), count(installPlanPending), count(installPlanError)
+
+	recordInstallPlanApproval(utils.InstallPlanWasApproved, nil)
+	recordInstallPlanApproval(utils.MultiCloudPendingStatus, nil)
+	recordInstallPlanApproval(utils.InstallPlanCannotBeApproved, errors.New("failed"))
+	assert.Equal(t, approved+1, count(installPlanApproved))
+	assert.Equal(t, pending+1, count(installPlanPending))
+	assert.Equal(t, failed+1, count(installPlanError))
+}
@@ -5,12 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
 	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
 	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
+// defaultClusterVersionFailingTimeout is how long the ClusterVersion can report the Failing condition
+// when the CGU doesn't set it
+const defaultClusterVersionFailingTimeout = 30 * time.Minute
+
 // ConfigurationObject defines the details of an object configured through a Policy
 type ConfigurationObject struct {
 	Kind       string  `json:"kind,omitempty"`
@@ -120,8 +127,9 @@ func (r *ClusterGroupUpgradeReconciler) getMonitoredObjects(managedPolicy *unstr
 				continue
 			}
 
+			// The ClusterVersion is cluster-scoped
 			_, ok = objectDefinitionMetadataContent["namespace"]
-			if !ok {
+			if !ok && kind != utils.ClusterVersionGroupVersionKind().Kind {
 				r.Log.Info(
 					"[getPolicyContent] Policy is missing its spec.policy-templates.objectDefinition.spec.object-templates.metadata.namespace",
 					"policyName", managedPolicyName)
@@ -132,8 +140,10 @@ func (r *ClusterGroupUpgradeReconciler) getMonitoredObjects(managedPolicy *unstr
 			object.Kind = innerObjectDefinitionContent["kind"].(string)
 			object.Name = objectDefinitionMetadataContent["name"].(string)
 			object.APIVersion = innerObjectDefinitionContent["apiVersion"].(string)
-			namespace := objectDefinitionMetadataContent["namespace"].(string)
-			object.Namespace = &namespace
+			if ok {
+				namespace := objectDefinitionMetadataContent["namespace"].(string)
+				object.Namespace = &namespace
+			}
 
 			objects = append(objects, object)
 		}
@@ -144,8 +154,7 @@ func (r *ClusterGroupUpgradeReconciler) getMonitoredObjects(managedPolicy *unstr
 }
 
 func isMonitoredObjectType(kind interface{}) bool {
-	// TODO add utils.ClusterVersionGroupVersionKind().Kind
-	if kind == utils.SubscriptionGroupVersionKind().Kind {
+	if kind == utils.SubscriptionGroupVersionKind().Kind || kind == utils.ClusterVersionGroupVersionKind().Kind {
 		return true
 	}
 	return false
@@ -156,7 +165,8 @@ func (r *ClusterGroupUpgradeReconciler) processMonitoredObjects(
 
 	reconcileSooner := false
 	for clusterName, clusterProgress := range clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress {
-		if clusterProgress.State != ranv1alpha1.InProgress {
+		if clusterProgress.State != ranv1alpha1.InProgress || !isPlatformUpgradeCompleted(clusterGroupUpgrade, clusterName) ||
+			!isImageBasedUpgradeCompleted(clusterGroupUpgrade, clusterName) {
 			continue
 		}
 		managedPolicyName := clusterGroupUpgrade.Status.ManagedPoliciesForUpgrade[*clusterProgress.PolicyIndex].Name
@@ -188,11 +198,15 @@ func (r *ClusterGroupUpgradeReconciler) processMonitoredObject(
 
 	// Get the managedClusterView for the monitored object contained in the current managedPolicy.
 	// If missing, then return error.
+	var namespace string
+	if object.Namespace != nil {
+		namespace = *object.Namespace
+	}
 	mcvName := utils.GetMultiCloudObjectName(clusterGroupUpgrade, object.Kind, object.Name)
 	safeName := utils.GetSafeResourceName(mcvName, clusterGroupUpgrade, utils.MaxObjectNameLength, 0)
 	mcv, err := utils.EnsureManagedClusterView(
 		ctx, r.Client, safeName, mcvName, clusterName, object.Kind+"."+strings.Split(object.APIVersion, "/")[0],
-		object.Name, *object.Namespace, clusterGroupUpgrade.Namespace+"-"+clusterGroupUpgrade.Name)
+		object.Name, namespace, clusterGroupUpgrade.Namespace+"-"+clusterGroupUpgrade.Name)
 	if err != nil {
 		return false, err
 	}
@@ -204,6 +218,7 @@ func (r *ClusterGroupUpgradeReconciler) processMonitoredObject(
 		// If the specific managedClusterView was found, check that it's condition Reason is "GetResourceProcessing"
 		installPlanStatus, err := utils.ProcessSubscriptionManagedClusterView(
 			ctx, r.Client, clusterGroupUpgrade, clusterName, mcv)
+		recordInstallPlanApproval(installPlanStatus, err)
 		// If there is an error in trying to approve the install plan, just print the error and continue.
 		if err != nil {
 			r.Log.Info("An error occurred trying to approve install plan", "error", err.Error())
@@ -221,7 +236,148 @@ func (r *ClusterGroupUpgradeReconciler) processMonitoredObject(
 		}
 
 	case utils.ClusterVersionGroupVersionKind().Kind:
-		// TODO gather useful info from CV status and update the cluster/policy status in CGU
+		clusterVersion, err := utils.GetManagedClusterViewResult(mcv)
+		if err != nil || clusterVersion == nil {
+			r.Log.Info("ClusterVersion not available yet, retry again later", "cluster", clusterName)
+			return true, nil
+		}
+		failing, message := updateClusterVersionProgress(clusterGroupUpgrade, clusterName, clusterVersion)
+		if failing {
+			return false, r.failClusterRemediation(ctx, clusterGroupUpgrade, clusterName, message)
+		}
+		// Check the failing ClusterVersion again sooner
+		return message != "", nil
 	}
 	return false, nil
 }
+
+// getClusterVersionFailingTimeout returns how long the ClusterVersion can report the Failing condition
+// returns: time.Duration
+func getClusterVersionFailingTimeout(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) time.Duration {
+	if timeout := clusterGroupUpgrade.Spec.RemediationStrategy.ClusterVersionFailingTimeout; timeout > 0 {
+		return time.Duration(timeout) * time.Minute
+	}
+	return defaultClusterVersionFailingTimeout
+}
+
+// updateClusterVersionProgress records the progress of the platform upgrade of the cluster in the current batch,
+// from its ClusterVersion
+// returns: bool - true when the Failing condition has lasted past the timeout, string - the failure, if any
+func updateClusterVersionProgress(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusterName string,
+	clusterVersion *unstructured.Unstructured) (bool, string) {
+
+	clusterProgress, ok := clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName]
+	if !ok {
+		return false, ""
+	}
+	if clusterProgress.ClusterVersion == nil {
+		clusterProgress.ClusterVersion = &ranv1alpha1.ClusterVersionProgress{}
+	}
+	progress := clusterProgress.ClusterVersion
+	progress.CurrentVersion = getCompletedClusterVersion(clusterVersion)
+	progress.DesiredVersion, _, _ = unstructured.NestedString(clusterVersion.Object, "status", "desired", "version")
+	if progress.DesiredVersion == "" {
+		progress.DesiredVersion, _, _ = unstructured.NestedString(clusterVersion.Object, "spec", "desiredUpdate", "version")
+	}
+
+	progress.Progressing = ""
+	failing := ""
+	conditions, _, _ := unstructured.NestedSlice(clusterVersion.Object, "status", "conditions")
+	for _, item := range conditions {
+		condition, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		message, _ := condition["message"].(string)
+		switch condition["type"] {
+		case "Progressing":
+			progress.Progressing = message
+		case "Failing":
+			if condition["status"] == "True" {
+				failing = message
+				if failing == "" {
+					failing = "Failing"
+				}
+			}
+		}
+	}
+
+	progress.Failing = failing
+	if failing == "" {
+		progress.FailingSince = metav1.Time{}
+		return false, ""
+	}
+	if progress.FailingSince.IsZero() {
+		progress.FailingSince = metav1.Now()
+	}
+	timeout := getClusterVersionFailingTimeout(clusterGroupUpgrade)
+	if time.Since(progress.FailingSince.Time) <= timeout {
+		return false, failing
+	}
+	return true, fmt.Sprintf("ClusterVersion failing for more than %s: %s", timeout, failing)
+}
+
+// failClusterRemediation stops the remediation of a cluster of the current batch, without waiting for the
+// batch timeout, and records the failure in the cluster status
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) failClusterRemediation(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusterName, message string) error {
+
+	clusterProgress := clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[clusterName]
+	r.Log.Info("[failClusterRemediation]", "cluster", clusterName, "message", message)
+	clusterState := ranv1alpha1.ClusterState{
+		Name: clusterName, State: utils.ClusterRemediationFailed, Message: message}
+	if clusterProgress.PolicyIndex != nil && *clusterProgress.PolicyIndex < len(clusterGroupUpgrade.Status.ManagedPoliciesForUpgrade) {
+		clusterState.CurrentPolicy = &ranv1alpha1.PolicyStatus{
+			Name:   clusterGroupUpgrade.Status.ManagedPoliciesForUpgrade[*clusterProgress.PolicyIndex].Name,
+			Status: utils.ClusterStatusNonCompliant}
+	}
+	clusterGroupUpgrade.Status.Clusters = append(clusterGroupUpgrade.Status.Clusters, clusterState)
+	r.recordClusterTransition(clusterGroupUpgrade, clusterName, corev1.EventTypeWarning,
+		eventReasonClusterRemediationFailed, clusterState.State, message)
+	r.notify(clusterGroupUpgrade, ranv1alpha1.NotificationClusterFailed, clusterName,
+		clusterGroupUpgrade.Status.Status.CurrentBatch, message)
+	clusterProgress.State = ranv1alpha1.Failed
+	clusterProgress.PolicyIndex = nil
+	setClustersFailedCondition(clusterGroupUpgrade)
+	return r.releaseMachineConfigPools(ctx, clusterGroupUpgrade, clusterName)
+}
+
+// setClustersFailedCondition records the clusters whose remediation failed in the ClustersFailed condition,
+// apart from the Succeeded condition of the upgrade
+func setClustersFailedCondition(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) {
+	failedClusters := getFailedClusters(clusterGroupUpgrade)
+	if len(failedClusters) == 0 {
+		return
+	}
+	utils.SetStatusCondition(
+		&clusterGroupUpgrade.Status.Conditions,
+		utils.ConditionTypes.ClustersFailed,
+		utils.ConditionReasons.Failed,
+		metav1.ConditionTrue,
+		fmt.Sprintf("Remediation failed for clusters: %s", strings.Join(failedClusters, ", ")),
+	)
+}
+
+// getUpgradeCompletedMessage returns the message of the completion of the upgrade, which lists the clusters
+// whose remediation failed, if any
+// returns: string
+func getUpgradeCompletedMessage(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) string {
+	message := "All clusters are compliant with all the managed policies"
+	if failedClusters := getFailedClusters(clusterGroupUpgrade); len(failedClusters) > 0 {
+		message = fmt.Sprintf("%s, except the failed clusters: %s", message, strings.Join(failedClusters, ", "))
+	}
+	return message
+}
+
+// getFailedClusters lists the clusters whose remediation failed
+// returns: []string
+func getFailedClusters(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) []string {
+	var clusters []string
+	for _, clusterState := range clusterGroupUpgrade.Status.Clusters {
+		if clusterState.State == utils.ClusterRemediationFailed {
+			clusters = append(clusters, clusterState.Name)
+		}
+	}
+	return clusters
+}
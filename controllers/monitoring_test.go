@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	viewv1beta1 "github.com/stolostron/cluster-lifecycle-api/view/v1beta1"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// withClusterVersionConditions sets the Progressing and Failing conditions of the ClusterVersion,
+// the Failing condition is False when its message is empty
+func withClusterVersionConditions(clusterVersion map[string]interface{}, progressing, failing string) map[string]interface{} {
+	failingStatus := "False"
+	if failing != "" {
+		failingStatus = "True"
+	}
+	status := clusterVersion["status"].(map[string]interface{})
+	status["desired"] = map[string]interface{}{
+		"version": clusterVersion["spec"].(map[string]interface{})["desiredUpdate"].(map[string]interface{})["version"],
+	}
+	status["conditions"] = []interface{}{
+		map[string]interface{}{"type": "Progressing", "status": "True", "message": progressing},
+		map[string]interface{}{"type": "Failing", "status": failingStatus, "message": failing},
+	}
+	return clusterVersion
+}
+
+func TestMonitoring_updateClusterVersionProgress(t *testing.T) {
+	testcases := []struct {
+		name             string
+		failingTimeout   int
+		failingSince     time.Duration
+		clusterVersion   map[string]interface{}
+		expectedFailing  bool
+		expectedMessage  string
+		expectedProgress ranv1alpha1.ClusterVersionProgress
+		expectedSince    bool
+	}{
+		{
+			name: "upgrade progressing",
+			clusterVersion: withClusterVersionConditions(newClusterVersionResult("4.15.20", "4.15.20/Partial", "4.14.10"),
+				"Working towards 4.15.20: 106 of 841 done (12% complete)", ""),
+			expectedProgress: ranv1alpha1.ClusterVersionProgress{
+				CurrentVersion: "4.14.10", DesiredVersion: "4.15.20",
+				Progressing: "Working towards 4.15.20: 106 of 841 done (12% complete)",
+			},
+		},
+		{
+			name: "failing condition seen for the first time",
+			clusterVersion: withClusterVersionConditions(newClusterVersionResult("4.15.20", "4.15.20/Partial", "4.14.10"),
+				"Unable to apply 4.15.20", "Cluster operator etcd is degraded"),
+			expectedMessage: "Cluster operator etcd is degraded",
+			expectedProgress: ranv1alpha1.ClusterVersionProgress{
+				CurrentVersion: "4.14.10", DesiredVersion: "4.15.20",
+				Progressing: "Unable to apply 4.15.20", Failing: "Cluster operator etcd is degraded",
+			},
+			expectedSince: true,
+		},
+		{
+			name:         "failing within the timeout",
+			failingSince: 20 * time.Minute,
+			clusterVersion: withClusterVersionConditions(newClusterVersionResult("4.15.20", "4.15.20/Partial", "4.14.10"),
+				"Unable to apply 4.15.20", "Cluster operator etcd is degraded"),
+			expectedMessage: "Cluster operator etcd is degraded",
+			expectedProgress: ranv1alpha1.ClusterVersionProgress{
+				CurrentVersion: "4.14.10", DesiredVersion: "4.15.20",
+				Progressing: "Unable to apply 4.15.20", Failing: "Cluster operator etcd is degraded",
+			},
+			expectedSince: true,
+		},
+		{
+			name:           "failing past the custom timeout",
+			failingTimeout: 10,
+			failingSince:   20 * time.Minute,
+			clusterVersion: withClusterVersionConditions(newClusterVersionResult("4.15.20", "4.15.20/Partial", "4.14.10"),
+				"Unable to apply 4.15.20", "Cluster operator etcd is degraded"),
+			expectedFailing: true,
+			expectedMessage: "ClusterVersion failing for more than 10m0s: Cluster operator etcd is degraded",
+			expectedProgress: ranv1alpha1.ClusterVersionProgress{
+				CurrentVersion: "4.14.10", DesiredVersion: "4.15.20",
+				Progressing: "Unable to apply 4.15.20", Failing: "Cluster operator etcd is degraded",
+			},
+			expectedSince: true,
+		},
+		{
+			name:         "failing condition cleared",
+			failingSince: 20 * time.Minute,
+			clusterVersion: withClusterVersionConditions(newClusterVersionResult("4.15.20", "4.15.20", "4.14.10"),
+				"Cluster version is 4.15.20", ""),
+			expectedProgress: ranv1alpha1.ClusterVersionProgress{
+				CurrentVersion: "4.15.20", DesiredVersion: "4.15.20", Progressing: "Cluster version is 4.15.20",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+			cgu.Spec.RemediationStrategy = &ranv1alpha1.RemediationStrategySpec{
+				ClusterVersionFailingTimeout: tc.failingTimeout}
+			progress := &ranv1alpha1.ClusterRemediationProgress{State: ranv1alpha1.InProgress}
+			if tc.failingSince != 0 {
+				progress.ClusterVersion = &ranv1alpha1.ClusterVersionProgress{
+					FailingSince: metav1.NewTime(time.Now().Add(-tc.failingSince))}
+			}
+			cgu.Status.Status.CurrentBatchRemediationProgress = map[string]*ranv1alpha1.ClusterRemediationProgress{
+				"spoke1": progress,
+			}
+
+			failing, message := updateClusterVersionProgress(
+				cgu, "spoke1", &unstructured.Unstructured{Object: tc.clusterVersion})
+			assert.Equal(t, tc.expectedFailing, failing)
+			assert.Equal(t, tc.expectedMessage, message)
+			assert.Equal(t, tc.expectedSince, !progress.ClusterVersion.FailingSince.IsZero())
+			progress.ClusterVersion.FailingSince = metav1.Time{}
+			assert.Equal(t, tc.expectedProgress, *progress.ClusterVersion)
+		})
+	}
+
+	// Clusters outside of the current batch are ignored
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+	failing, _ := updateClusterVersionProgress(cgu, "spoke1", &unstructured.Unstructured{Object: newClusterVersionResult("4.15.20")})
+	assert.False(t, failing)
+}
+
+func TestMonitoring_processMonitoredClusterVersion(t *testing.T) {
+	const cvView = "cgu-default-clusterversion-version-kuttl"
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cgu", Namespace: "default",
+			Annotations: map[string]string{utils.NameSuffixAnnotation: "kuttl"},
+		},
+	}
+	cgu.Spec.RemediationStrategy = &ranv1alpha1.RemediationStrategySpec{ClusterVersionFailingTimeout: 10}
+	cgu.Status.ManagedPoliciesForUpgrade = []ranv1alpha1.ManagedPolicyForUpgrade{{Name: "policy-cv", Namespace: "default"}}
+	policyIndex := 0
+	cgu.Status.Status.CurrentBatchRemediationProgress = map[string]*ranv1alpha1.ClusterRemediationProgress{
+		"spoke1": {
+			State:       ranv1alpha1.InProgress,
+			PolicyIndex: &policyIndex,
+			ClusterVersion: &ranv1alpha1.ClusterVersionProgress{
+				FailingSince: metav1.NewTime(time.Now().Add(-20 * time.Minute))},
+		},
+	}
+	view := newPlatformUpgradeView(cvView, "spoke1", withClusterVersionConditions(
+		newClusterVersionResult("4.15.20", "4.15.20/Partial", "4.14.10"),
+		"Unable to apply 4.15.20", "Cluster operator etcd is degraded"))
+	fakeClient, _ := getFakeClientFromObjects(view)
+	r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+	object := ConfigurationObject{Kind: "ClusterVersion", Name: "version", APIVersion: "config.openshift.io/v1"}
+	reconcileSooner, err := r.processMonitoredObject(context.TODO(), cgu, object, "spoke1")
+	assert.NoError(t, err)
+	assert.False(t, reconcileSooner)
+
+	assert.Equal(t, ranv1alpha1.Failed, cgu.Status.Status.CurrentBatchRemediationProgress["spoke1"].State)
+	assert.Nil(t, cgu.Status.Status.CurrentBatchRemediationProgress["spoke1"].PolicyIndex)
+	assert.Equal(t, []ranv1alpha1.ClusterState{{
+		Name:          "spoke1",
+		State:         utils.ClusterRemediationFailed,
+		CurrentPolicy: &ranv1alpha1.PolicyStatus{Name: "policy-cv", Status: utils.ClusterStatusNonCompliant},
+		Message:       "ClusterVersion failing for more than 10m0s: Cluster operator etcd is degraded",
+	}}, cgu.Status.Clusters)
+	assert.Equal(t, []string{"spoke1"}, getFailedClusters(cgu))
+
+	// The failure is reported by the ClustersFailed condition, the Succeeded condition is left to the completion
+	condition := meta.FindStatusCondition(cgu.Status.Conditions, string(utils.ConditionTypes.ClustersFailed))
+	assert.NotNil(t, condition)
+	assert.Equal(t, metav1.ConditionTrue, condition.Status)
+	assert.Equal(t, string(utils.ConditionReasons.Failed), condition.Reason)
+	assert.Equal(t, "Remediation failed for clusters: spoke1", condition.Message)
+	assert.Nil(t, meta.FindStatusCondition(cgu.Status.Conditions, string(utils.ConditionTypes.Succeeded)))
+
+	// The view is cleaned up with the cluster failure
+	err = fakeClient.Get(context.TODO(), types.NamespacedName{Name: cvView, Namespace: "spoke1"}, &viewv1beta1.ManagedClusterView{})
+	assert.True(t, errors.IsNotFound(err))
+}
+
+func TestMonitoring_getUpgradeCompletedMessage(t *testing.T) {
+	testcases := []struct {
+		name            string
+		clusters        []ranv1alpha1.ClusterState
+		expectedMessage string
+	}{
+		{
+			name:            "all clusters completed",
+			clusters:        []ranv1alpha1.ClusterState{{Name: "spoke1", State: utils.ClusterRemediationComplete}},
+			expectedMessage: "All clusters are compliant with all the managed policies",
+		},
+		{
+			name: "some clusters failed",
+			clusters: []ranv1alpha1.ClusterState{
+				{Name: "spoke1", State: utils.ClusterRemediationFailed},
+				{Name: "spoke2", State: utils.ClusterRemediationComplete},
+				{Name: "spoke3", State: utils.ClusterRemediationFailed},
+			},
+			expectedMessage: "All clusters are compliant with all the managed policies, except the failed clusters: spoke1, spoke3",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+			cgu.Status.Clusters = tc.clusters
+			assert.Equal(t, tc.expectedMessage, getUpgradeCompletedMessage(cgu))
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// notificationQueueSize bounds the notifications waiting to be delivered, further notifications are dropped
+	notificationQueueSize = 100
+	// notificationRequestTimeout bounds each attempt to deliver a notification
+	notificationRequestTimeout = 10 * time.Second
+	// maxNotificationDeliveries bounds the deliveries kept in the status of a NotificationTarget
+	maxNotificationDeliveries = 20
+
+	defaultNotificationRetries     = 3
+	defaultNotificationAuthHeader  = "Authorization"
+	defaultNotificationAuthKey     = "authorization"
+	defaultNotificationContentType = "application/json"
+)
+
+// notificationRetryInterval is the interval before the first retry of a failed delivery, doubled on each retry
+var notificationRetryInterval = 5 * time.Second
+
+// notification is a lifecycle event of a ClusterGroupUpgrade. It is the body of the notification requests, or
+// the data of the payload template of the target
+type notification struct {
+	Event     ranv1alpha1.NotificationEvent `json:"event"`
+	Namespace string                        `json:"namespace"`
+	Name      string                        `json:"name"`
+	Cluster   string                        `json:"cluster,omitempty"`
+	Batch     int                           `json:"batch,omitempty"`
+	Message   string                        `json:"message"`
+	Time      string                        `json:"time"`
+	// labels of the ClusterGroupUpgrade, matched by the selector of the targets
+	labels map[string]string
+}
+
+// notifier delivers the notifications of the ClusterGroupUpgrades to the NotificationTargets of their
+// namespace. The notifications are held until the status of the ClusterGroupUpgrade recording the event is
+// updated, then queued and delivered in order, so that slow or failing targets don't hold the reconciliation
+// of the ClusterGroupUpgrades
+type notifier struct {
+	client client.Client
+	// reader reads the secrets of the auth headers from the API server, the secrets aren't cached
+	reader client.Reader
+	// secretNamespace is the namespace of the operator, holding the secrets of the auth headers
+	secretNamespace string
+	log             logr.Logger
+	httpClient      *http.Client
+	queue           chan notification
+
+	mu sync.Mutex
+	// pending holds the notifications of each ClusterGroupUpgrade until its status is updated
+	pending map[types.NamespacedName][]notification
+}
+
+// newNotifier creates the notifier of the ClusterGroupUpgrades
+// returns: *notifier
+func newNotifier(c client.Client, reader client.Reader, log logr.Logger) *notifier {
+	secretNamespace := os.Getenv(utils.OperatorNamespaceEnv)
+	if secretNamespace == "" {
+		secretNamespace = utils.DefaultOperatorNamespace
+	}
+	return &notifier{
+		client:          c,
+		reader:          reader,
+		secretNamespace: secretNamespace,
+		log:             log,
+		httpClient: &http.Client{
+			// The redirections aren't followed, so that the requests only reach the URLs of the targets
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		queue:   make(chan notification, notificationQueueSize),
+		pending: make(map[types.NamespacedName][]notification),
+	}
+}
+
+// Start implements manager.Runnable, delivering the queued notifications until the context is done
+// returns: error
+func (n *notifier) Start(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case notif := <-n.queue:
+			n.dispatch(ctx, notif)
+		}
+	}
+}
+
+// enqueue queues a notification, which is dropped when the queue is full
+func (n *notifier) enqueue(notif notification) {
+	select {
+	case n.queue <- notif:
+	default:
+		n.log.Info("[enqueue] notification queue full, dropping the notification",
+			"clusterGroupUpgrade", notif.Namespace+"/"+notif.Name, "event", notif.Event)
+	}
+}
+
+// hold keeps a notification until the status of its ClusterGroupUpgrade is updated
+func (n *notifier) hold(notif notification) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	key := types.NamespacedName{Namespace: notif.Namespace, Name: notif.Name}
+	n.pending[key] = append(n.pending[key], notif)
+}
+
+// release queues the notifications held for the ClusterGroupUpgrade, once its status is updated
+func (n *notifier) release(key types.NamespacedName) {
+	n.mu.Lock()
+	pending := n.pending[key]
+	delete(n.pending, key)
+	n.mu.Unlock()
+	for _, notif := range pending {
+		n.enqueue(notif)
+	}
+}
+
+// discard drops the notifications held for the ClusterGroupUpgrade, whose status wasn't updated. The events
+// are notified again when the next reconciliation records them
+func (n *notifier) discard(key types.NamespacedName) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	delete(n.pending, key)
+}
+
+// dispatch delivers a notification to the matching targets and records the deliveries in their status
+func (n *notifier) dispatch(ctx context.Context, notif notification) {
+	targets := &ranv1alpha1.NotificationTargetList{}
+	if err := n.client.List(ctx, targets, client.InNamespace(notif.Namespace)); err != nil {
+		n.log.Error(err, "[dispatch] failed to list the notification targets", "namespace", notif.Namespace)
+		return
+	}
+	for i := range targets.Items {
+		target := &targets.Items[i]
+		matches, err := notificationTargetMatches(target, notif)
+		attempts := 0
+		if err == nil {
+			if !matches {
+				continue
+			}
+			attempts, err = n.deliver(ctx, target, notif)
+		}
+		if err != nil {
+			n.log.Error(err, "[dispatch] failed to deliver the notification",
+				"target", target.Name, "event", notif.Event, "attempts", attempts)
+		}
+		if err := n.recordDelivery(ctx, target, notif, attempts, err); err != nil {
+			n.log.Error(err, "[dispatch] failed to record the delivery of the notification", "target", target.Name)
+		}
+	}
+}
+
+// notificationTargetMatches checks if the target is notified of the event and the ClusterGroupUpgrade
+// returns: bool, error
+func notificationTargetMatches(target *ranv1alpha1.NotificationTarget, notif notification) (bool, error) {
+	if len(target.Spec.Events) > 0 {
+		found := false
+		for _, event := range target.Spec.Events {
+			if event == notif.Event {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	if target.Spec.ClusterGroupUpgradeSelector == nil {
+		return true, nil
+	}
+	selector, err := metav1.LabelSelectorAsSelector(target.Spec.ClusterGroupUpgradeSelector)
+	if err != nil {
+		return false, fmt.Errorf("invalid clusterGroupUpgradeSelector: %w", err)
+	}
+	return selector.Matches(labels.Set(notif.labels)), nil
+}
+
+// renderNotificationPayload renders the body of a notification request from the payload template, the
+// notification is encoded as JSON without template. The template can encode its values with the json function
+// returns: []byte, error
+func renderNotificationPayload(payloadTemplate string, notif notification) ([]byte, error) {
+	if payloadTemplate == "" {
+		return json.Marshal(notif)
+	}
+	tmpl, err := template.New("payload").Option("missingkey=error").Funcs(template.FuncMap{
+		"json": func(value interface{}) (string, error) {
+			encoded, err := json.Marshal(value)
+			return string(encoded), err
+		},
+	}).Parse(payloadTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payloadTemplate: %w", err)
+	}
+	var payload bytes.Buffer
+	if err := tmpl.Execute(&payload, notif); err != nil {
+		return nil, fmt.Errorf("failed to render payloadTemplate: %w", err)
+	}
+	return payload.Bytes(), nil
+}
+
+// getNotificationAuthHeader reads the header holding the credentials of the target from its secret, in the
+// namespace of the operator. Only the secrets labelled for the notifications are read, so that a
+// NotificationTarget can't send the other secrets of the operator namespace
+// returns: string (name), string (value), error
+func (n *notifier) getNotificationAuthHeader(
+	ctx context.Context, target *ranv1alpha1.NotificationTarget) (string, string, error) {
+
+	authHeader := target.Spec.AuthHeader
+	if authHeader == nil {
+		return "", "", nil
+	}
+	name, key := authHeader.Name, authHeader.Key
+	if name == "" {
+		name = defaultNotificationAuthHeader
+	}
+	if key == "" {
+		key = defaultNotificationAuthKey
+	}
+	secret := &corev1.Secret{}
+	if err := n.reader.Get(ctx, types.NamespacedName{Namespace: n.secretNamespace, Name: authHeader.Secret}, secret); err != nil {
+		return "", "", fmt.Errorf("failed to get the secret %s of the auth header: %w", authHeader.Secret, err)
+	}
+	if secret.Labels[utils.NotificationSecretLabel] != "true" {
+		return "", "", fmt.Errorf("the secret %s of the auth header isn't labelled %s=true",
+			authHeader.Secret, utils.NotificationSecretLabel)
+	}
+	value, ok := secret.Data[key]
+	if !ok {
+		return "", "", fmt.Errorf("the secret %s of the auth header has no %s entry", authHeader.Secret, key)
+	}
+	return name, string(value), nil
+}
+
+// deliver POSTs a notification to a target, retrying the failed attempts with an exponential backoff
+// returns: int (attempts), error (of the last attempt)
+func (n *notifier) deliver(ctx context.Context, target *ranv1alpha1.NotificationTarget, notif notification) (int, error) {
+	payload, err := renderNotificationPayload(target.Spec.PayloadTemplate, notif)
+	if err != nil {
+		return 0, err
+	}
+	header, value, err := n.getNotificationAuthHeader(ctx, target)
+	if err != nil {
+		return 0, err
+	}
+	contentType := target.Spec.ContentType
+	if contentType == "" {
+		contentType = defaultNotificationContentType
+	}
+	retries := defaultNotificationRetries
+	if target.Spec.Retries != nil {
+		retries = *target.Spec.Retries
+	}
+
+	interval := notificationRetryInterval
+	for attempt := 1; ; attempt++ {
+		retryable, err := n.post(ctx, target.Spec.URL, contentType, payload, header, value)
+		if err == nil || !retryable || attempt > retries {
+			return attempt, err
+		}
+		n.log.Info("[deliver] retrying the notification", "target", target.Name, "attempt", attempt, "err", err)
+		select {
+		case <-ctx.Done():
+			return attempt, ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+// post sends a notification request. Network errors, server errors and throttled requests are retried, the
+// redirections are failed
+// returns: bool (whether the request is retried), error
+func (n *notifier) post(ctx context.Context, url, contentType string, payload []byte, header, value string) (bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, notificationRequestTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	// Drain the response so that the connection is reused
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusTooManyRequests
+	return retryable, fmt.Errorf("unexpected response status %s", resp.Status)
+}
+
+// recordDelivery records the delivery of a notification in the status of the target, which keeps the latest
+// maxNotificationDeliveries deliveries
+// returns: error
+func (n *notifier) recordDelivery(ctx context.Context, target *ranv1alpha1.NotificationTarget,
+	notif notification, attempts int, deliveryErr error) error {
+
+	delivery := ranv1alpha1.NotificationDelivery{
+		ClusterGroupUpgrade: notif.Namespace + "/" + notif.Name,
+		Event:               notif.Event,
+		Cluster:             notif.Cluster,
+		State:               ranv1alpha1.NotificationDelivered,
+		Attempts:            attempts,
+		Time:                metav1.Now(),
+	}
+	if deliveryErr != nil {
+		delivery.State = ranv1alpha1.NotificationFailed
+		delivery.Message = deliveryErr.Error()
+	}
+
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		current := &ranv1alpha1.NotificationTarget{}
+		if err := n.client.Get(ctx, client.ObjectKeyFromObject(target), current); err != nil {
+			return err
+		}
+		current.Status.Deliveries = append(current.Status.Deliveries, delivery)
+		if len(current.Status.Deliveries) > maxNotificationDeliveries {
+			current.Status.Deliveries = current.Status.Deliveries[len(current.Status.Deliveries)-maxNotificationDeliveries:]
+		}
+		if deliveryErr != nil {
+			current.Status.Failed++
+		} else {
+			current.Status.Delivered++
+		}
+		return n.client.Status().Update(ctx, current)
+	})
+}
+
+// notify holds the notification of a lifecycle event of the ClusterGroupUpgrade to the NotificationTargets, it
+// is queued by updateStatus once the status recording the event is updated
+func (r *ClusterGroupUpgradeReconciler) notify(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
+	event ranv1alpha1.NotificationEvent, cluster string, batch int, message string) {
+
+	if r.notifier == nil {
+		return
+	}
+	r.notifier.hold(notification{
+		Event:     event,
+		Namespace: clusterGroupUpgrade.Namespace,
+		Name:      clusterGroupUpgrade.Name,
+		Cluster:   cluster,
+		Batch:     batch,
+		Message:   message,
+		Time:      time.Now().UTC().Format(time.RFC3339),
+		labels:    clusterGroupUpgrade.Labels,
+	})
+}
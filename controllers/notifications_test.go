@@ -0,0 +1,398 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// notificationRequest is a request received by the notification server
+type notificationRequest struct {
+	path          string
+	authorization string
+	contentType   string
+	body          string
+}
+
+// notificationServer is a local stand-in for the notification targets, answering the requests to each path
+// with the listed status codes in turn, then with 200
+type notificationServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	responses map[string][]int
+	requests  []notificationRequest
+	received  chan struct{}
+}
+
+func newNotificationServer(responses map[string][]int) *notificationServer {
+	s := &notificationServer{responses: responses, received: make(chan struct{}, 100)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		s.mu.Lock()
+		s.requests = append(s.requests, notificationRequest{
+			path: req.URL.Path, authorization: req.Header.Get("Authorization"),
+			contentType: req.Header.Get("Content-Type"), body: string(body),
+		})
+		status := http.StatusOK
+		if codes := s.responses[req.URL.Path]; len(codes) > 0 {
+			status, s.responses[req.URL.Path] = codes[0], codes[1:]
+		}
+		s.mu.Unlock()
+		if status >= 300 && status < 400 {
+			w.Header().Set("Location", "/tickets")
+		}
+		w.WriteHeader(status)
+		s.received <- struct{}{}
+	}))
+	return s
+}
+
+func (s *notificationServer) getRequests() []notificationRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]notificationRequest{}, s.requests...)
+}
+
+func TestNotifications_renderNotificationPayload(t *testing.T) {
+	notif := notification{
+		Event: ranv1alpha1.NotificationClusterFailed, Namespace: "default", Name: "cgu", Cluster: "spoke1", Batch: 2,
+		Message: `ClusterVersion "failing"`, Time: "2024-01-02T03:04:05Z",
+	}
+	testcases := []struct {
+		name            string
+		payloadTemplate string
+		expectedPayload string
+		expectedError   string
+	}{
+		{
+			name: "default payload",
+			expectedPayload: `{"event":"ClusterFailed","namespace":"default","name":"cgu","cluster":"spoke1","batch":2,` +
+				`"message":"ClusterVersion \"failing\"","time":"2024-01-02T03:04:05Z"}`,
+		},
+		{
+			name:            "payload template",
+			payloadTemplate: `{"text": {{ printf "%s %s/%s: %s" .Event .Namespace .Name .Message | json }}}`,
+			expectedPayload: `{"text": "ClusterFailed default/cgu: ClusterVersion \"failing\""}`,
+		},
+		{
+			name:            "invalid payload template",
+			payloadTemplate: `{{ .Event `,
+			expectedError:   "invalid payloadTemplate",
+		},
+		{
+			name:            "unknown field",
+			payloadTemplate: `{{ .Policy }}`,
+			expectedError:   "failed to render payloadTemplate",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			payload, err := renderNotificationPayload(tc.payloadTemplate, notif)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedPayload, string(payload))
+		})
+	}
+}
+
+func TestNotifications_notificationTargetMatches(t *testing.T) {
+	notif := notification{Event: ranv1alpha1.NotificationBatchCompleted, labels: map[string]string{"team": "ran"}}
+	testcases := []struct {
+		name          string
+		spec          ranv1alpha1.NotificationTargetSpec
+		expected      bool
+		expectedError bool
+	}{
+		{
+			name:     "all events",
+			expected: true,
+		},
+		{
+			name: "event filtered",
+			spec: ranv1alpha1.NotificationTargetSpec{Events: []ranv1alpha1.NotificationEvent{
+				ranv1alpha1.NotificationUpgradeStarted, ranv1alpha1.NotificationBatchCompleted}},
+			expected: true,
+		},
+		{
+			name: "event filtered out",
+			spec: ranv1alpha1.NotificationTargetSpec{Events: []ranv1alpha1.NotificationEvent{
+				ranv1alpha1.NotificationUpgradeFailed}},
+		},
+		{
+			name: "matching selector",
+			spec: ranv1alpha1.NotificationTargetSpec{ClusterGroupUpgradeSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "ran"}}},
+			expected: true,
+		},
+		{
+			name: "selector not matching",
+			spec: ranv1alpha1.NotificationTargetSpec{ClusterGroupUpgradeSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"team": "core"}}},
+		},
+		{
+			name: "invalid selector",
+			spec: ranv1alpha1.NotificationTargetSpec{ClusterGroupUpgradeSelector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "team", Operator: "Near"}}}},
+			expectedError: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			matches, err := notificationTargetMatches(&ranv1alpha1.NotificationTarget{Spec: tc.spec}, notif)
+			assert.Equal(t, tc.expectedError, err != nil)
+			assert.Equal(t, tc.expected, matches)
+		})
+	}
+}
+
+func TestNotifications_dispatch(t *testing.T) {
+	defer func(interval time.Duration) { notificationRetryInterval = interval }(notificationRetryInterval)
+	notificationRetryInterval = time.Millisecond
+
+	server := newNotificationServer(map[string][]int{
+		"/chatops": {http.StatusServiceUnavailable},
+		"/tickets": {http.StatusBadRequest},
+		"/down":    {http.StatusInternalServerError, http.StatusBadGateway},
+		"/moved":   {http.StatusFound},
+	})
+	defer server.Close()
+
+	one := 1
+	targets := []*ranv1alpha1.NotificationTarget{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "chatops", Namespace: "default"},
+			Spec: ranv1alpha1.NotificationTargetSpec{
+				URL:             server.URL + "/chatops",
+				AuthHeader:      &ranv1alpha1.NotificationAuthHeader{Secret: "chatops-token"},
+				Events:          []ranv1alpha1.NotificationEvent{ranv1alpha1.NotificationClusterFailed},
+				PayloadTemplate: `{"text": {{ printf "%s failed: %s" .Cluster .Message | json }}}`,
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "tickets", Namespace: "default"},
+			Spec:       ranv1alpha1.NotificationTargetSpec{URL: server.URL + "/tickets"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "down", Namespace: "default"},
+			Spec:       ranv1alpha1.NotificationTargetSpec{URL: server.URL + "/down", Retries: &one},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "moved", Namespace: "default"},
+			Spec:       ranv1alpha1.NotificationTargetSpec{URL: server.URL + "/moved"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "started", Namespace: "default"},
+			Spec: ranv1alpha1.NotificationTargetSpec{
+				URL:    server.URL + "/started",
+				Events: []ranv1alpha1.NotificationEvent{ranv1alpha1.NotificationUpgradeStarted},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "other-namespace", Namespace: "other"},
+			Spec:       ranv1alpha1.NotificationTargetSpec{URL: server.URL + "/other"},
+		},
+	}
+	t.Setenv(utils.OperatorNamespaceEnv, "talm")
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "chatops-token", Namespace: "talm",
+			Labels: map[string]string{utils.NotificationSecretLabel: "true"},
+		},
+		Data: map[string][]byte{"authorization": []byte("Bearer secret-token")},
+	}
+	fakeClient, _ := getFakeClientFromObjects(targets[0], targets[1], targets[2], targets[3], targets[4], targets[5], secret)
+	n := newNotifier(fakeClient, fakeClient, logr.Discard())
+
+	n.dispatch(context.TODO(), notification{
+		Event: ranv1alpha1.NotificationClusterFailed, Namespace: "default", Name: "cgu", Cluster: "spoke1",
+		Message: "ClusterVersion failing", Time: "2024-01-02T03:04:05Z",
+	})
+
+	requests := server.getRequests()
+	paths := make(map[string]int)
+	for _, request := range requests {
+		paths[request.path]++
+	}
+	// The failed deliveries are retried on server errors only, and the redirections aren't followed
+	assert.Equal(t, map[string]int{"/chatops": 2, "/tickets": 1, "/down": 2, "/moved": 1}, paths)
+	for _, request := range requests {
+		if request.path == "/chatops" {
+			assert.Equal(t, notificationRequest{
+				path: "/chatops", authorization: "Bearer secret-token", contentType: "application/json",
+				body: `{"text": "spoke1 failed: ClusterVersion failing"}`,
+			}, request)
+		}
+		if request.path == "/tickets" {
+			var body map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(request.body), &body))
+			assert.Equal(t, "ClusterFailed", body["event"])
+			assert.Empty(t, request.authorization)
+		}
+	}
+
+	expected := map[string]struct {
+		state    string
+		attempts int
+		message  string
+	}{
+		"chatops": {state: ranv1alpha1.NotificationDelivered, attempts: 2},
+		"tickets": {state: ranv1alpha1.NotificationFailed, attempts: 1, message: "unexpected response status 400 Bad Request"},
+		"down":    {state: ranv1alpha1.NotificationFailed, attempts: 2, message: "unexpected response status 502 Bad Gateway"},
+		"moved":   {state: ranv1alpha1.NotificationFailed, attempts: 1, message: "unexpected response status 302 Found"},
+	}
+	for name, exp := range expected {
+		target := &ranv1alpha1.NotificationTarget{}
+		assert.NoError(t, fakeClient.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, target))
+		if assert.Len(t, target.Status.Deliveries, 1, name) {
+			delivery := target.Status.Deliveries[0]
+			assert.Equal(t, "default/cgu", delivery.ClusterGroupUpgrade)
+			assert.Equal(t, ranv1alpha1.NotificationClusterFailed, delivery.Event)
+			assert.Equal(t, "spoke1", delivery.Cluster)
+			assert.Equal(t, exp.state, delivery.State, name)
+			assert.Equal(t, exp.attempts, delivery.Attempts, name)
+			assert.Equal(t, exp.message, delivery.Message, name)
+		}
+		if exp.state == ranv1alpha1.NotificationDelivered {
+			assert.Equal(t, int64(1), target.Status.Delivered)
+		} else {
+			assert.Equal(t, int64(1), target.Status.Failed)
+		}
+	}
+	started := &ranv1alpha1.NotificationTarget{}
+	assert.NoError(t, fakeClient.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "started"}, started))
+	assert.Empty(t, started.Status.Deliveries)
+}
+
+func TestNotifications_dispatchInvalidSecret(t *testing.T) {
+	testcases := []struct {
+		name            string
+		secret          *corev1.Secret
+		expectedMessage string
+	}{
+		{
+			name:            "missing secret",
+			expectedMessage: "failed to get the secret chatops-token of the auth header",
+		},
+		{
+			name: "secret in the namespace of the target",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "chatops-token", Namespace: "default",
+					Labels: map[string]string{utils.NotificationSecretLabel: "true"},
+				},
+				Data: map[string][]byte{"authorization": []byte("Bearer secret-token")},
+			},
+			expectedMessage: "failed to get the secret chatops-token of the auth header",
+		},
+		{
+			name: "secret not labelled for the notifications",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "chatops-token", Namespace: "talm"},
+				Data:       map[string][]byte{"authorization": []byte("Bearer secret-token")},
+			},
+			expectedMessage: "the secret chatops-token of the auth header isn't labelled " +
+				"openshift-cluster-group-upgrades/notificationSecret=true",
+		},
+		{
+			name: "secret without the key",
+			secret: &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "chatops-token", Namespace: "talm",
+					Labels: map[string]string{utils.NotificationSecretLabel: "true"},
+				},
+			},
+			expectedMessage: "the secret chatops-token of the auth header has no authorization entry",
+		},
+	}
+	t.Setenv(utils.OperatorNamespaceEnv, "talm")
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := &ranv1alpha1.NotificationTarget{
+				ObjectMeta: metav1.ObjectMeta{Name: "chatops", Namespace: "default"},
+				Spec: ranv1alpha1.NotificationTargetSpec{
+					URL:        "http://127.0.0.1:1/chatops",
+					AuthHeader: &ranv1alpha1.NotificationAuthHeader{Secret: "chatops-token"},
+				},
+			}
+			objects := []client.Object{target}
+			if tc.secret != nil {
+				objects = append(objects, tc.secret)
+			}
+			fakeClient, _ := getFakeClientFromObjects(objects...)
+			n := newNotifier(fakeClient, fakeClient, logr.Discard())
+			n.dispatch(context.TODO(), notification{Event: ranv1alpha1.NotificationUpgradeStarted, Namespace: "default", Name: "cgu"})
+
+			assert.NoError(t, fakeClient.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "chatops"}, target))
+			assert.Len(t, target.Status.Deliveries, 1)
+			assert.Equal(t, ranv1alpha1.NotificationFailed, target.Status.Deliveries[0].State)
+			assert.Equal(t, 0, target.Status.Deliveries[0].Attempts)
+			assert.Contains(t, target.Status.Deliveries[0].Message, tc.expectedMessage)
+		})
+	}
+}
+
+func TestNotifications_notify(t *testing.T) {
+	server := newNotificationServer(nil)
+	defer server.Close()
+
+	target := &ranv1alpha1.NotificationTarget{
+		ObjectMeta: metav1.ObjectMeta{Name: "chatops", Namespace: "default"},
+		Spec:       ranv1alpha1.NotificationTargetSpec{URL: server.URL + "/chatops"},
+	}
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"}}
+	fakeClient, _ := getFakeClientFromObjects(target)
+
+	// Nothing is notified without notifier
+	r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+	r.notify(cgu, ranv1alpha1.NotificationUpgradeStarted, "", 0, "The upgrade started")
+
+	r.notifier = newNotifier(fakeClient, fakeClient, logr.Discard())
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	go func() {
+		_ = r.notifier.Start(ctx)
+	}()
+	// The notifications are dropped when the status recording the events isn't updated
+	r.notify(cgu, ranv1alpha1.NotificationUpgradeStarted, "", 0, "The upgrade started")
+	assert.Error(t, r.updateStatus(ctx, cgu))
+	assert.Empty(t, r.notifier.pending)
+
+	assert.NoError(t, fakeClient.Create(ctx, cgu))
+	r.notify(cgu, ranv1alpha1.NotificationUpgradeStarted, "", 0, "The upgrade started")
+	r.notify(cgu, ranv1alpha1.NotificationBatchCompleted, "", 1, "Batch 1 completed")
+	// The notifications are held until the status is updated
+	assert.Len(t, r.notifier.pending[types.NamespacedName{Namespace: "default", Name: "cgu"}], 2)
+	assert.Empty(t, server.getRequests())
+	assert.NoError(t, r.updateStatus(ctx, cgu))
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-server.received:
+		case <-time.After(10 * time.Second):
+			t.Fatal("notification not received")
+		}
+	}
+	requests := server.getRequests()
+	if assert.Len(t, requests, 2) {
+		// The notifications are delivered in order
+		assert.Contains(t, requests[0].body, `"event":"UpgradeStarted"`)
+		assert.Contains(t, requests[1].body, `"event":"BatchCompleted","namespace":"default","name":"cgu","batch":1`)
+	}
+}
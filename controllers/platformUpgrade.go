@@ -0,0 +1,383 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/blang/semver/v4"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Platform upgrade states of a cluster
+const (
+	PlatformUpgradeStateNotStarted           = "NotStarted"
+	PlatformUpgradeStatePausingWorkerPools   = "PausingWorkerPools"
+	PlatformUpgradeStateUpgrading            = "Upgrading"
+	PlatformUpgradeStateUnpausingWorkerPools = "UnpausingWorkerPools"
+	PlatformUpgradeStateCompleted            = "Completed"
+	PlatformUpgradeStateFailed               = "Failed"
+)
+
+const (
+	clusterVersionName        = "version"
+	clusterVersionResource    = "clusterversion"
+	workerPoolName            = "worker"
+	machineConfigPoolResource = "machineconfigpool"
+)
+
+// validatePlatformUpgrade resolves the release images of the versions of the platform upgrade and
+// checks that each cluster has a recommended update for every hop of the chain
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) validatePlatformUpgrade(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusters []string) error {
+
+	platformUpgrade := clusterGroupUpgrade.Spec.PlatformUpgrade
+	if platformUpgrade == nil {
+		return nil
+	}
+	if clusterGroupUpgrade.Status.PlatformUpgrade == nil {
+		clusterGroupUpgrade.Status.PlatformUpgrade = &ranv1alpha1.PlatformUpgradeStatus{}
+	}
+	status := clusterGroupUpgrade.Status.PlatformUpgrade
+	if status.Images == nil {
+		status.Images = make(map[string]string)
+	}
+
+	upstream := getPlatformUpgradeUpstream(platformUpgrade)
+	var versions []string
+	var err error
+	for _, version := range platformUpgrade.Versions {
+		versions = append(versions, version.Version)
+		if version.Image != "" {
+			status.Images[version.Version] = version.Image
+			continue
+		}
+		if status.Images[version.Version] != "" {
+			continue
+		}
+		if platformUpgrade.Channel == "" {
+			err = fmt.Errorf("platform upgrade version %s must have an image when no channel is provided", version.Version)
+			break
+		}
+		var image string
+		image, err = r.getImageForVersionFromUpdateGraph(ctx, upstream, platformUpgrade.Channel, version.Version)
+		if err != nil {
+			break
+		}
+		status.Images[version.Version] = image
+	}
+	if err == nil && platformUpgrade.Channel != "" {
+		var invalidClusters []string
+		invalidClusters, err = r.getClustersWithoutUpgradePath(ctx, upstream, platformUpgrade.Channel, versions, clusters)
+		if err == nil && len(invalidClusters) > 0 {
+			err = fmt.Errorf("clusters without a recommended update to version %s: %s",
+				strings.Join(versions, " -> "), strings.Join(invalidClusters, "; "))
+			utils.SetStatusCondition(
+				&clusterGroupUpgrade.Status.Conditions,
+				utils.ConditionTypes.Validated,
+				utils.ConditionReasons.UpgradePathUnavailable,
+				metav1.ConditionFalse,
+				err.Error(),
+			)
+			return err
+		}
+	}
+
+	if err != nil {
+		utils.SetStatusCondition(
+			&clusterGroupUpgrade.Status.Conditions,
+			utils.ConditionTypes.Validated,
+			utils.ConditionReasons.InvalidPlatformImage,
+			metav1.ConditionFalse,
+			err.Error(),
+		)
+	}
+	return err
+}
+
+// getPlatformUpgradeUpstream returns the update service of the platform upgrade
+// returns: string
+func getPlatformUpgradeUpstream(platformUpgrade *ranv1alpha1.PlatformUpgradeSpec) string {
+	if platformUpgrade.Upstream != "" {
+		return platformUpgrade.Upstream
+	}
+	return defaultUpdateGraphUpstream
+}
+
+// getRemainingPlatformVersions returns the versions of the chain a cluster still has to be upgraded to,
+// which are the versions above its current version. The versions are compared as semantic versions, so
+// that the hops at or below the current version are dropped even when the current version is not part
+// of the chain. Versions that don't parse are kept unless equal to the current version
+// returns: []string
+func getRemainingPlatformVersions(versions []string, current string) []string {
+	currentVersion, currentErr := semver.ParseTolerant(current)
+	var remaining []string
+	for _, version := range versions {
+		if version == current {
+			continue
+		}
+		if currentErr == nil {
+			if parsed, err := semver.ParseTolerant(version); err == nil && parsed.LTE(currentVersion) {
+				continue
+			}
+		}
+		remaining = append(remaining, version)
+	}
+	return remaining
+}
+
+// getPlatformUpgradeVersions lists the versions of the chain of the platform upgrade
+// returns: []string
+func getPlatformUpgradeVersions(platformUpgrade *ranv1alpha1.PlatformUpgradeSpec) []string {
+	var versions []string
+	for _, version := range platformUpgrade.Versions {
+		versions = append(versions, version.Version)
+	}
+	return versions
+}
+
+// isPlatformUpgradeCompleted returns whether the cluster completed the platform upgrade of the CGU.
+// Always true when the CGU has no platform upgrade
+// returns: bool
+func isPlatformUpgradeCompleted(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) bool {
+	if clusterGroupUpgrade.Spec.PlatformUpgrade == nil {
+		return true
+	}
+	if clusterGroupUpgrade.Status.PlatformUpgrade == nil {
+		return false
+	}
+	status, ok := clusterGroupUpgrade.Status.PlatformUpgrade.Clusters[cluster]
+	return ok && status.State == PlatformUpgradeStateCompleted
+}
+
+// isPlatformUpgradeNeeded returns whether the cluster has to be remediated for the platform upgrade,
+// which is when versions of the chain are above its version, or its version is unknown
+// returns: bool
+func (r *ClusterGroupUpgradeReconciler) isPlatformUpgradeNeeded(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) bool {
+
+	if isPlatformUpgradeCompleted(clusterGroupUpgrade, cluster) {
+		return false
+	}
+	current, err := r.getClusterOpenshiftVersion(ctx, cluster)
+	if err != nil {
+		r.Log.Info("[isPlatformUpgradeNeeded] unable to get the cluster version", "cluster", cluster, "error", err.Error())
+		return true
+	}
+	versions := getPlatformUpgradeVersions(clusterGroupUpgrade.Spec.PlatformUpgrade)
+	return len(getRemainingPlatformVersions(versions, current)) > 0
+}
+
+// includePlatformUpgradeImages adds the release images of the platform upgrade to the pre-caching spec.
+// The target release is the platform image unless one is already set, the other releases of the chain
+// are pre-cached as intermediate platform images
+func includePlatformUpgradeImages(
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, spec *ranv1alpha1.PrecachingSpec) {
+
+	if clusterGroupUpgrade.Spec.PlatformUpgrade == nil || clusterGroupUpgrade.Status.PlatformUpgrade == nil {
+		return
+	}
+	versions := clusterGroupUpgrade.Spec.PlatformUpgrade.Versions
+	images := clusterGroupUpgrade.Status.PlatformUpgrade.Images
+	if spec.PlatformImage == "" {
+		spec.PlatformImage = images[versions[len(versions)-1].Version]
+	}
+	spec.IntermediatePlatformImages = nil
+	for _, version := range versions {
+		image := images[version.Version]
+		if image != "" && image != spec.PlatformImage {
+			spec.IntermediatePlatformImages = append(spec.IntermediatePlatformImages, image)
+		}
+	}
+}
+
+// getClusterVersionView returns the ClusterVersion of the cluster, as retrieved by a view
+// returns: *unstructured.Unstructured - nil while the view has not retrieved it, error
+func (r *ClusterGroupUpgradeReconciler) getClusterVersionView(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (*unstructured.Unstructured, error) {
+
+	mcvName := utils.GetMultiCloudObjectName(clusterGroupUpgrade, utils.ClusterVersionGroupVersionKind().Kind, clusterVersionName)
+	safeName := utils.GetSafeResourceName(mcvName, clusterGroupUpgrade, utils.MaxObjectNameLength, 0)
+	mcv, err := utils.EnsureManagedClusterView(
+		ctx, r.Client, safeName, mcvName, cluster,
+		utils.ClusterVersionGroupVersionKind().Kind+"."+utils.ClusterVersionGroupVersionKind().Group,
+		clusterVersionName, "", clusterGroupUpgrade.Namespace+"-"+clusterGroupUpgrade.Name)
+	if err != nil {
+		return nil, err
+	}
+	return utils.GetManagedClusterViewResult(mcv)
+}
+
+// getCompletedClusterVersion returns the last version the cluster completed the upgrade to, from the
+// update history of the ClusterVersion
+// returns: string
+func getCompletedClusterVersion(clusterVersion *unstructured.Unstructured) string {
+	history, _, _ := unstructured.NestedSlice(clusterVersion.Object, "status", "history")
+	for _, entry := range history {
+		update, ok := entry.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if update["state"] == "Completed" {
+			version, _ := update["version"].(string)
+			return version
+		}
+	}
+	return ""
+}
+
+// requestClusterVersionUpdate sets the desired update of the ClusterVersion of the cluster to the version
+// through an action, unless it is already requested
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) requestClusterVersionUpdate(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string,
+	clusterVersion *unstructured.Unstructured, version string) error {
+
+	platformUpgrade := clusterGroupUpgrade.Spec.PlatformUpgrade
+	desiredVersion, _, _ := unstructured.NestedString(clusterVersion.Object, "spec", "desiredUpdate", "version")
+	if desiredVersion == version {
+		return nil
+	}
+	update := clusterVersion.DeepCopy()
+	desiredUpdate := map[string]interface{}{"version": version}
+	if image := clusterGroupUpgrade.Status.PlatformUpgrade.Images[version]; image != "" {
+		desiredUpdate["image"] = image
+	}
+	if err := unstructured.SetNestedMap(update.Object, desiredUpdate, "spec", "desiredUpdate"); err != nil {
+		return err
+	}
+	if platformUpgrade.Channel != "" {
+		if err := unstructured.SetNestedField(update.Object, platformUpgrade.Channel, "spec", "channel"); err != nil {
+			return err
+		}
+	}
+	if platformUpgrade.Upstream != "" {
+		if err := unstructured.SetNestedField(update.Object, platformUpgrade.Upstream, "spec", "upstream"); err != nil {
+			return err
+		}
+	}
+
+	mcaName := utils.GetMultiCloudObjectName(clusterGroupUpgrade, utils.ClusterVersionGroupVersionKind().Kind, clusterVersionName+"-"+version)
+	safeName := utils.GetSafeResourceName(mcaName, clusterGroupUpgrade, utils.MaxObjectNameLength, 0)
+	created, err := utils.EnsureManagedClusterActionForUpdate(
+		ctx, r.Client, safeName, cluster, clusterGroupUpgrade.Namespace+"-"+clusterGroupUpgrade.Name, clusterVersionResource, update)
+	if created {
+		r.Log.Info("[requestClusterVersionUpdate] Requested the upgrade", "cluster", cluster, "version", version)
+	}
+	return err
+}
+
+// reconcilePlatformUpgrade steps the cluster through the versions of the platform upgrade. The upgrade to
+// a version is requested once the cluster completed the upgrade to the previous one. When requested, the
+// worker pool is paused before the first upgrade and unpaused after the last one, unless the pool is
+// paused by actions.beforeEnable.pauseMachineConfigPools
+// returns: bool - true once the cluster completed the platform upgrade, error
+func (r *ClusterGroupUpgradeReconciler) reconcilePlatformUpgrade(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (bool, error) {
+
+	if isPlatformUpgradeCompleted(clusterGroupUpgrade, cluster) {
+		return true, nil
+	}
+	platformUpgrade := clusterGroupUpgrade.Spec.PlatformUpgrade
+	if clusterGroupUpgrade.Status.PlatformUpgrade == nil {
+		return false, errors.New("platform upgrade images have not been resolved")
+	}
+	status := clusterGroupUpgrade.Status.PlatformUpgrade
+	if status.Clusters == nil {
+		status.Clusters = make(map[string]*ranv1alpha1.ClusterPlatformUpgradeStatus)
+	}
+	clusterStatus, ok := status.Clusters[cluster]
+	if !ok {
+		clusterStatus = &ranv1alpha1.ClusterPlatformUpgradeStatus{
+			State:     PlatformUpgradeStateNotStarted,
+			StartedAt: metav1.Now(),
+		}
+		status.Clusters[cluster] = clusterStatus
+	}
+
+	clusterVersion, err := r.getClusterVersionView(ctx, clusterGroupUpgrade, cluster)
+	if err != nil || clusterVersion == nil {
+		return false, err
+	}
+	current := getCompletedClusterVersion(clusterVersion)
+	versions := getPlatformUpgradeVersions(platformUpgrade)
+
+	for {
+		r.Log.Info("[reconcilePlatformUpgrade]", "cluster", cluster, "state", clusterStatus.State,
+			"version", clusterStatus.Version, "current", current)
+		switch clusterStatus.State {
+		case PlatformUpgradeStateNotStarted:
+			remaining := getRemainingPlatformVersions(versions, current)
+			if len(remaining) == 0 {
+				clusterStatus.State = PlatformUpgradeStateCompleted
+				clusterStatus.Message = fmt.Sprintf("Cluster is already at version %s", current)
+				continue
+			}
+			clusterStatus.Version = remaining[0]
+			if platformUpgrade.PauseWorkerPools {
+				clusterStatus.State = PlatformUpgradeStatePausingWorkerPools
+			} else {
+				clusterStatus.State = PlatformUpgradeStateUpgrading
+			}
+
+		case PlatformUpgradeStatePausingWorkerPools:
+			done, err := r.setMachineConfigPoolPaused(ctx, clusterGroupUpgrade, cluster, workerPoolName, true)
+			if err != nil || !done {
+				clusterStatus.Message = "Pausing the worker MachineConfigPool"
+				return false, err
+			}
+			clusterStatus.State = PlatformUpgradeStateUpgrading
+
+		case PlatformUpgradeStateUpgrading:
+			if len(getRemainingPlatformVersions([]string{clusterStatus.Version}, current)) > 0 {
+				clusterStatus.Message = fmt.Sprintf("Upgrading from version %s to %s", current, clusterStatus.Version)
+				if failing, message := updateClusterVersionProgress(clusterGroupUpgrade, cluster, clusterVersion); failing {
+					clusterStatus.State = PlatformUpgradeStateFailed
+					clusterStatus.Message = message
+					return false, r.failClusterRemediation(ctx, clusterGroupUpgrade, cluster, message)
+				}
+				return false, r.requestClusterVersionUpdate(ctx, clusterGroupUpgrade, cluster, clusterVersion, clusterStatus.Version)
+			}
+			clusterStatus.CompletedVersions = append(clusterStatus.CompletedVersions, current)
+			if remaining := getRemainingPlatformVersions(versions, current); len(remaining) > 0 {
+				clusterStatus.Version = remaining[0]
+			} else if platformUpgrade.PauseWorkerPools {
+				clusterStatus.State = PlatformUpgradeStateUnpausingWorkerPools
+			} else {
+				clusterStatus.State = PlatformUpgradeStateCompleted
+				clusterStatus.Message = fmt.Sprintf("Upgraded to version %s", current)
+			}
+
+		case PlatformUpgradeStateUnpausingWorkerPools:
+			if isMachineConfigPoolPausedByActions(clusterGroupUpgrade, workerPoolName) {
+				// The pool is unpaused by actions.afterCompletion.unpauseMachineConfigPools, if at all
+				r.Log.Info("[reconcilePlatformUpgrade]", "cluster", cluster, "pool", workerPoolName,
+					"unpause", "left to the actions of the CGU")
+				clusterStatus.State = PlatformUpgradeStateCompleted
+				clusterStatus.Message = fmt.Sprintf("Upgraded to version %s", current)
+				continue
+			}
+			done, err := r.setMachineConfigPoolPaused(ctx, clusterGroupUpgrade, cluster, workerPoolName, false)
+			if err != nil || !done {
+				clusterStatus.Message = "Unpausing the worker MachineConfigPool"
+				return false, err
+			}
+			clusterStatus.State = PlatformUpgradeStateCompleted
+			clusterStatus.Message = fmt.Sprintf("Upgraded to version %s", current)
+
+		case PlatformUpgradeStateCompleted:
+			return true, nil
+
+		case PlatformUpgradeStateFailed:
+			return false, nil
+
+		default:
+			return false, fmt.Errorf("unknown platform upgrade state %s of cluster %s", clusterStatus.State, cluster)
+		}
+	}
+}
@@ -0,0 +1,418 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	actionv1beta1 "github.com/stolostron/cluster-lifecycle-api/action/v1beta1"
+	viewv1beta1 "github.com/stolostron/cluster-lifecycle-api/view/v1beta1"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newPlatformUpgradeView(name, cluster string, result map[string]interface{}) *viewv1beta1.ManagedClusterView {
+	raw, _ := json.Marshal(result)
+	return &viewv1beta1.ManagedClusterView{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: cluster},
+		Status: viewv1beta1.ViewStatus{
+			Conditions: []metav1.Condition{{
+				Type:   viewv1beta1.ConditionViewProcessing,
+				Status: metav1.ConditionTrue,
+				Reason: viewv1beta1.ReasonGetResource,
+			}},
+			Result: runtime.RawExtension{Raw: raw},
+		},
+	}
+}
+
+// newClusterVersionResult builds a ClusterVersion with the update history, newest first. History entries
+// are "<version>/<state>", or "<version>" for completed updates
+func newClusterVersionResult(desired string, history ...string) map[string]interface{} {
+	var entries []interface{}
+	for _, entry := range history {
+		version, state, found := strings.Cut(entry, "/")
+		if !found {
+			state = "Completed"
+		}
+		entries = append(entries, map[string]interface{}{"version": version, "state": state})
+	}
+	return map[string]interface{}{
+		"apiVersion": "config.openshift.io/v1",
+		"kind":       "ClusterVersion",
+		"metadata":   map[string]interface{}{"name": "version", "resourceVersion": "10"},
+		"spec": map[string]interface{}{
+			"channel":       "eus-4.16",
+			"desiredUpdate": map[string]interface{}{"version": desired},
+		},
+		"status": map[string]interface{}{"history": entries},
+	}
+}
+
+func newMachineConfigPoolResult(paused bool) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "machineconfiguration.openshift.io/v1",
+		"kind":       "MachineConfigPool",
+		"metadata":   map[string]interface{}{"name": "worker"},
+		"spec":       map[string]interface{}{"paused": paused},
+	}
+}
+
+func TestPlatformUpgrade_reconcilePlatformUpgrade(t *testing.T) {
+	const (
+		cvView  = "cgu-default-clusterversion-version-kuttl"
+		mcpView = "cgu-default-machineconfigpool-worker-kuttl"
+	)
+	testcases := []struct {
+		name              string
+		pauseWorkerPools  bool
+		pausedByActions   bool
+		clusterStatus     *ranv1alpha1.ClusterPlatformUpgradeStatus
+		objs              []client.Object
+		expectedDone      bool
+		expectedStatus    ranv1alpha1.ClusterPlatformUpgradeStatus
+		expectedActions   []string
+		expectedDesired   string
+		expectedMCPPaused bool
+	}{
+		{
+			name: "view not processed yet",
+			objs: []client.Object{&viewv1beta1.ManagedClusterView{
+				ObjectMeta: metav1.ObjectMeta{Name: cvView, Namespace: "spoke1"}}},
+			expectedStatus: ranv1alpha1.ClusterPlatformUpgradeStatus{State: PlatformUpgradeStateNotStarted},
+		},
+		{
+			name:             "pause the worker pool",
+			pauseWorkerPools: true,
+			objs: []client.Object{
+				newPlatformUpgradeView(cvView, "spoke1", newClusterVersionResult("4.14.10", "4.14.10")),
+				newPlatformUpgradeView(mcpView, "spoke1", newMachineConfigPoolResult(false)),
+			},
+			expectedStatus: ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStatePausingWorkerPools, Version: "4.15.20",
+				Message: "Pausing the worker MachineConfigPool",
+			},
+			expectedActions:   []string{"cgu-default-machineconfigpool-worker-pause-kuttl"},
+			expectedMCPPaused: true,
+		},
+		{
+			name:             "upgrade to the first version",
+			pauseWorkerPools: true,
+			clusterStatus: &ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStatePausingWorkerPools, Version: "4.15.20"},
+			objs: []client.Object{
+				newPlatformUpgradeView(cvView, "spoke1", newClusterVersionResult("4.14.10", "4.14.10")),
+				newPlatformUpgradeView(mcpView, "spoke1", newMachineConfigPoolResult(true)),
+			},
+			expectedStatus: ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateUpgrading, Version: "4.15.20",
+				Message: "Upgrading from version 4.14.10 to 4.15.20",
+			},
+			expectedActions: []string{"cgu-default-clusterversion-version-4.15.20-kuttl"},
+			expectedDesired: "4.15.20",
+		},
+		{
+			name: "wait for the upgrade to complete",
+			clusterStatus: &ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateUpgrading, Version: "4.15.20"},
+			objs: []client.Object{
+				newPlatformUpgradeView(cvView, "spoke1", newClusterVersionResult("4.15.20", "4.15.20/Partial", "4.14.10")),
+			},
+			expectedStatus: ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateUpgrading, Version: "4.15.20",
+				Message: "Upgrading from version 4.14.10 to 4.15.20",
+			},
+		},
+		{
+			name: "upgrade to the next version",
+			clusterStatus: &ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateUpgrading, Version: "4.15.20"},
+			objs: []client.Object{
+				newPlatformUpgradeView(cvView, "spoke1", newClusterVersionResult("4.15.20", "4.15.20", "4.14.10")),
+			},
+			expectedStatus: ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateUpgrading, Version: "4.16.5", CompletedVersions: []string{"4.15.20"},
+				Message: "Upgrading from version 4.15.20 to 4.16.5",
+			},
+			expectedActions: []string{"cgu-default-clusterversion-version-4.16.5-kuttl"},
+			expectedDesired: "4.16.5",
+		},
+		{
+			name:             "unpause the worker pool after the last version",
+			pauseWorkerPools: true,
+			clusterStatus: &ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateUpgrading, Version: "4.16.5", CompletedVersions: []string{"4.15.20"}},
+			objs: []client.Object{
+				newPlatformUpgradeView(cvView, "spoke1", newClusterVersionResult("4.16.5", "4.16.5", "4.15.20", "4.14.10")),
+				newPlatformUpgradeView(mcpView, "spoke1", newMachineConfigPoolResult(false)),
+			},
+			expectedDone: true,
+			expectedStatus: ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateCompleted, Version: "4.16.5", CompletedVersions: []string{"4.15.20", "4.16.5"},
+				Message: "Upgraded to version 4.16.5",
+			},
+		},
+		{
+			name:             "leave the worker pool paused by the actions",
+			pauseWorkerPools: true,
+			pausedByActions:  true,
+			clusterStatus: &ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateUpgrading, Version: "4.16.5", CompletedVersions: []string{"4.15.20"}},
+			objs: []client.Object{
+				newPlatformUpgradeView(cvView, "spoke1", newClusterVersionResult("4.16.5", "4.16.5", "4.15.20", "4.14.10")),
+				newPlatformUpgradeView(mcpView, "spoke1", newMachineConfigPoolResult(true)),
+			},
+			expectedDone: true,
+			expectedStatus: ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateCompleted, Version: "4.16.5", CompletedVersions: []string{"4.15.20", "4.16.5"},
+				Message: "Upgraded to version 4.16.5",
+			},
+		},
+		{
+			name: "cluster between the versions of the chain",
+			objs: []client.Object{
+				newPlatformUpgradeView(cvView, "spoke1", newClusterVersionResult("4.15.25", "4.15.25")),
+			},
+			expectedStatus: ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateUpgrading, Version: "4.16.5",
+				Message: "Upgrading from version 4.15.25 to 4.16.5",
+			},
+			expectedActions: []string{"cgu-default-clusterversion-version-4.16.5-kuttl"},
+			expectedDesired: "4.16.5",
+		},
+		{
+			name: "cluster already past the target version",
+			objs: []client.Object{
+				newPlatformUpgradeView(cvView, "spoke1", newClusterVersionResult("4.16.8", "4.16.8")),
+			},
+			expectedDone: true,
+			expectedStatus: ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateCompleted, Message: "Cluster is already at version 4.16.8",
+			},
+		},
+		{
+			name: "cluster already at the target version",
+			objs: []client.Object{
+				newPlatformUpgradeView(cvView, "spoke1", newClusterVersionResult("4.16.5", "4.16.5")),
+			},
+			expectedDone: true,
+			expectedStatus: ranv1alpha1.ClusterPlatformUpgradeStatus{
+				State: PlatformUpgradeStateCompleted, Message: "Cluster is already at version 4.16.5",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "cgu", Namespace: "default",
+					Annotations: map[string]string{utils.NameSuffixAnnotation: "kuttl"},
+				},
+			}
+			cgu.Spec.PlatformUpgrade = &ranv1alpha1.PlatformUpgradeSpec{
+				Versions:         []ranv1alpha1.PlatformUpgradeVersion{{Version: "4.15.20"}, {Version: "4.16.5"}},
+				Channel:          "eus-4.16",
+				PauseWorkerPools: tc.pauseWorkerPools,
+			}
+			if tc.pausedByActions {
+				cgu.Spec.Actions.BeforeEnable.PauseMachineConfigPools = []string{workerPoolName}
+			}
+			cgu.Status.PlatformUpgrade = &ranv1alpha1.PlatformUpgradeStatus{
+				Images: map[string]string{
+					"4.15.20": "quay.io/openshift-release-dev/ocp-release@sha256:415",
+					"4.16.5":  "quay.io/openshift-release-dev/ocp-release@sha256:416",
+				},
+			}
+			if tc.clusterStatus != nil {
+				cgu.Status.PlatformUpgrade.Clusters = map[string]*ranv1alpha1.ClusterPlatformUpgradeStatus{
+					"spoke1": tc.clusterStatus,
+				}
+			}
+
+			fakeClient, _ := getFakeClientFromObjects(tc.objs...)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			done, err := r.reconcilePlatformUpgrade(context.TODO(), cgu, "spoke1")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedDone, done)
+			assert.Equal(t, tc.expectedDone, isPlatformUpgradeCompleted(cgu, "spoke1"))
+
+			clusterStatus := *cgu.Status.PlatformUpgrade.Clusters["spoke1"]
+			clusterStatus.StartedAt = metav1.Time{}
+			assert.Equal(t, tc.expectedStatus, clusterStatus)
+
+			actions := &actionv1beta1.ManagedClusterActionList{}
+			assert.NoError(t, fakeClient.List(context.TODO(), actions, client.InNamespace("spoke1")))
+			var names []string
+			for _, action := range actions.Items {
+				names = append(names, action.Name)
+				assert.Equal(t, actionv1beta1.UpdateActionType, action.Spec.ActionType)
+				object := map[string]interface{}{}
+				assert.NoError(t, json.Unmarshal(action.Spec.KubeWork.ObjectTemplate.Raw, &object))
+				assert.NotContains(t, object, "status")
+				spec := object["spec"].(map[string]interface{})
+				switch action.Spec.KubeWork.Resource {
+				case clusterVersionResource:
+					assert.Equal(t, map[string]interface{}{
+						"version": tc.expectedDesired,
+						"image":   cgu.Status.PlatformUpgrade.Images[tc.expectedDesired],
+					}, spec["desiredUpdate"])
+					assert.Equal(t, "eus-4.16", spec["channel"])
+				case machineConfigPoolResource:
+					assert.Equal(t, tc.expectedMCPPaused, spec["paused"])
+				default:
+					t.Errorf("unexpected action resource %s", action.Spec.KubeWork.Resource)
+				}
+			}
+			assert.Equal(t, tc.expectedActions, names)
+		})
+	}
+}
+
+func TestPlatformUpgrade_getRemainingPlatformVersions(t *testing.T) {
+	versions := []string{"4.14.30", "4.15.20", "4.16.5"}
+	testcases := []struct {
+		name     string
+		current  string
+		expected []string
+	}{
+		{name: "below the chain", current: "4.14.10", expected: versions},
+		{name: "at a version of the chain", current: "4.15.20", expected: []string{"4.16.5"}},
+		{name: "between the versions of the chain", current: "4.15.25", expected: []string{"4.16.5"}},
+		{name: "at the target version", current: "4.16.5"},
+		{name: "past the target version", current: "4.17.0"},
+		{name: "unknown version", current: "", expected: versions},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, getRemainingPlatformVersions(versions, tc.current))
+		})
+	}
+}
+
+func TestPlatformUpgrade_validatePlatformUpgrade(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "update-graph", Namespace: "openshift-cluster-group-upgrades"},
+		Data: map[string]string{
+			"eus-4.16": `{"nodes":[
+				{"version":"4.14.10","payload":"quay.io/openshift-release-dev/ocp-release@sha256:414"},
+				{"version":"4.15.20","payload":"quay.io/openshift-release-dev/ocp-release@sha256:415"},
+				{"version":"4.16.5","payload":"quay.io/openshift-release-dev/ocp-release@sha256:416"},
+				{"version":"4.14.2","payload":"quay.io/openshift-release-dev/ocp-release@sha256:4142"}],
+				"edges":[[0,1],[1,2],[3,0]]}`,
+		},
+	}
+	newCluster := func(name, version string) *clusterv1.ManagedCluster {
+		return &clusterv1.ManagedCluster{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{utils.OpenshiftVersionLabelName: version}},
+		}
+	}
+	testcases := []struct {
+		name           string
+		versions       []ranv1alpha1.PlatformUpgradeVersion
+		channel        string
+		clusters       []client.Object
+		expectedErr    bool
+		expectedReason string
+		expectedImages map[string]string
+	}{
+		{
+			name:     "images resolved and upgrade paths recommended",
+			versions: []ranv1alpha1.PlatformUpgradeVersion{{Version: "4.15.20"}, {Version: "4.16.5"}},
+			channel:  "eus-4.16",
+			clusters: []client.Object{newCluster("spoke1", "4.14.10"), newCluster("spoke2", "4.15.20")},
+			expectedImages: map[string]string{
+				"4.15.20": "quay.io/openshift-release-dev/ocp-release@sha256:415",
+				"4.16.5":  "quay.io/openshift-release-dev/ocp-release@sha256:416",
+			},
+		},
+		{
+			name:           "no direct update to the first version",
+			versions:       []ranv1alpha1.PlatformUpgradeVersion{{Version: "4.15.20"}, {Version: "4.16.5"}},
+			channel:        "eus-4.16",
+			clusters:       []client.Object{newCluster("spoke1", "4.14.2")},
+			expectedErr:    true,
+			expectedReason: string(utils.ConditionReasons.UpgradePathUnavailable),
+		},
+		{
+			name:           "version not in the channel",
+			versions:       []ranv1alpha1.PlatformUpgradeVersion{{Version: "4.15.21"}, {Version: "4.16.5"}},
+			channel:        "eus-4.16",
+			clusters:       []client.Object{newCluster("spoke1", "4.14.10")},
+			expectedErr:    true,
+			expectedReason: string(utils.ConditionReasons.InvalidPlatformImage),
+		},
+		{
+			name:           "image required without channel",
+			versions:       []ranv1alpha1.PlatformUpgradeVersion{{Version: "4.15.20", Image: "quay.io/release@sha256:415"}, {Version: "4.16.5"}},
+			clusters:       []client.Object{newCluster("spoke1", "4.14.10")},
+			expectedErr:    true,
+			expectedReason: string(utils.ConditionReasons.InvalidPlatformImage),
+		},
+		{
+			name: "images provided without channel",
+			versions: []ranv1alpha1.PlatformUpgradeVersion{
+				{Version: "4.15.20", Image: "quay.io/release@sha256:415"}, {Version: "4.16.5", Image: "quay.io/release@sha256:416"}},
+			clusters: []client.Object{newCluster("spoke1", "4.14.2")},
+			expectedImages: map[string]string{
+				"4.15.20": "quay.io/release@sha256:415",
+				"4.16.5":  "quay.io/release@sha256:416",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(UpdateGraphConfigMapEnv, "openshift-cluster-group-upgrades/update-graph")
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"},
+			}
+			cgu.Spec.PlatformUpgrade = &ranv1alpha1.PlatformUpgradeSpec{Versions: tc.versions, Channel: tc.channel}
+			var clusters []string
+			for _, cluster := range tc.clusters {
+				clusters = append(clusters, cluster.GetName())
+			}
+
+			fakeClient, _ := getFakeClientFromObjects(append(tc.clusters, configMap)...)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			err := r.validatePlatformUpgrade(context.TODO(), cgu, clusters)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				condition := meta.FindStatusCondition(cgu.Status.Conditions, string(utils.ConditionTypes.Validated))
+				assert.NotNil(t, condition)
+				assert.Equal(t, tc.expectedReason, condition.Reason)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedImages, cgu.Status.PlatformUpgrade.Images)
+		})
+	}
+}
+
+func TestPlatformUpgrade_includePlatformUpgradeImages(t *testing.T) {
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+	cgu.Spec.PlatformUpgrade = &ranv1alpha1.PlatformUpgradeSpec{
+		Versions: []ranv1alpha1.PlatformUpgradeVersion{{Version: "4.15.20"}, {Version: "4.16.5"}},
+	}
+	cgu.Status.PlatformUpgrade = &ranv1alpha1.PlatformUpgradeStatus{
+		Images: map[string]string{"4.15.20": "release@sha256:415", "4.16.5": "release@sha256:416"},
+	}
+
+	spec := ranv1alpha1.PrecachingSpec{}
+	includePlatformUpgradeImages(cgu, &spec)
+	assert.Equal(t, "release@sha256:416", spec.PlatformImage)
+	assert.Equal(t, []string{"release@sha256:415"}, spec.IntermediatePlatformImages)
+
+	// The platform image of the ClusterVersion policy is kept, the whole chain is pre-cached
+	spec = ranv1alpha1.PrecachingSpec{PlatformImage: "release@sha256:417"}
+	includePlatformUpgradeImages(cgu, &spec)
+	assert.Equal(t, "release@sha256:417", spec.PlatformImage)
+	assert.Equal(t, []string{"release@sha256:415", "release@sha256:416"}, spec.IntermediatePlatformImages)
+}
@@ -2,10 +2,15 @@ package controllers
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 
 	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 )
 
 // getPreCachingConfig: retrieves pre-caching configuration CR
@@ -74,3 +79,129 @@ func (r *ClusterGroupUpgradeReconciler) mapPreCachingConfigSpecToPrecachingSpec(
 
 	return precachingSpec
 }
+
+// applyClusterOverride returns a copy of the pre-caching spec modified by the cluster override
+func applyClusterOverride(
+	spec ranv1alpha1.PrecachingSpec, override ranv1alpha1.ClusterPreCachingOverride) ranv1alpha1.PrecachingSpec {
+
+	rv := *spec.DeepCopy()
+	rv.AdditionalImages = append(rv.AdditionalImages, override.AdditionalImages...)
+	rv.ExcludePrecachePatterns = append(rv.ExcludePrecachePatterns, override.ExcludePrecachePatterns...)
+	if len(override.OperatorsIndexes) > 0 {
+		rv.OperatorsIndexes = append([]string{}, override.OperatorsIndexes...)
+		// The operator images resolved from the replaced indexes no longer apply
+		rv.OperatorsImages = nil
+	}
+	return rv
+}
+
+// getClusterOverrideSelectors validates the cluster overrides and converts their selectors
+// returns: []labels.Selector (in the order of the overrides), error
+func getClusterOverrideSelectors(overrides []ranv1alpha1.ClusterPreCachingOverride) ([]labels.Selector, error) {
+	names := make(map[string]bool)
+	selectors := make([]labels.Selector, len(overrides))
+	for i, override := range overrides {
+		if override.Name == "" || names[override.Name] {
+			return nil, fmt.Errorf("cluster override names must be set and unique, found %q", override.Name)
+		}
+		names[override.Name] = true
+		selector, err := metav1.LabelSelectorAsSelector(&overrides[i].ClusterSelector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cluster selector in cluster override %s: %w", override.Name, err)
+		}
+		selectors[i] = selector
+	}
+	return selectors, nil
+}
+
+// matchClusterOverrides maps each cluster to the first cluster override whose selector
+// matches the ManagedCluster labels
+// returns: map[string]string (cluster to override name), error
+func (r *ClusterGroupUpgradeReconciler) matchClusterOverrides(
+	ctx context.Context, overrides []ranv1alpha1.ClusterPreCachingOverride,
+	clusters []string) (map[string]string, error) {
+
+	selectors, err := getClusterOverrideSelectors(overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	clusterOverrides := make(map[string]string)
+	for _, cluster := range clusters {
+		managedCluster := &clusterv1.ManagedCluster{}
+		if err := r.Get(ctx, types.NamespacedName{Name: cluster}, managedCluster); err != nil {
+			return nil, err
+		}
+		for i, selector := range selectors {
+			if selector.Matches(labels.Set(managedCluster.GetLabels())) {
+				clusterOverrides[cluster] = overrides[i].Name
+				break
+			}
+		}
+	}
+	return clusterOverrides, nil
+}
+
+// includeClusterOverrides computes the pre-caching spec of the clusters matching the
+// cluster overrides of the PreCachingConfig CR and stores it in the pre-caching status
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) includeClusterOverrides(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusters []string,
+	spec ranv1alpha1.PrecachingSpec, policies []*unstructured.Unstructured) error {
+
+	clusterGroupUpgrade.Status.Precaching.ClusterOverrides = nil
+	clusterGroupUpgrade.Status.Precaching.OverrideSpecs = nil
+
+	preCachingConfigSpec, err := r.getPreCachingConfigSpec(ctx, clusterGroupUpgrade)
+	if err != nil {
+		return err
+	}
+	if len(preCachingConfigSpec.ClusterOverrides) == 0 {
+		return nil
+	}
+	clusterOverrides, err := r.matchClusterOverrides(ctx, preCachingConfigSpec.ClusterOverrides, clusters)
+	if err != nil {
+		return err
+	}
+	overrideClusters := make(map[string][]string)
+	for _, cluster := range clusters {
+		if name, ok := clusterOverrides[cluster]; ok {
+			overrideClusters[name] = append(overrideClusters[name], cluster)
+		}
+	}
+
+	overrideSpecs := make(map[string]*ranv1alpha1.PrecachingSpec)
+	for _, override := range preCachingConfigSpec.ClusterOverrides {
+		matchingClusters, ok := overrideClusters[override.Name]
+		if !ok {
+			continue
+		}
+		overrideSpec := applyClusterOverride(spec, override)
+		if len(override.OperatorsIndexes) > 0 {
+			images, err := r.resolveOperatorsImages(ctx, overrideSpec)
+			if err == errIndexResolutionInProgress {
+				return err
+			}
+			if err != nil {
+				// Leave the resolution of the operator bundles to the spoke
+				r.Log.Info("[includeClusterOverrides]", "override", override.Name,
+					"hub-side operator resolution failed, resolving on the spoke", err.Error())
+			} else {
+				overrideSpec.OperatorsImages = images
+			}
+		}
+		source := clusterGroupUpgrade.Status.Precaching.SpaceRequiredSource
+		if source == SpaceRequiredSourceEstimated || source == SpaceRequiredSourceDefault {
+			overrideSpec.SpaceRequired, _ = r.getEstimatedSpaceRequired(ctx, matchingClusters, overrideSpec, policies)
+		}
+		if ok, msg := r.checkPreCacheSpecConsistency(overrideSpec); !ok {
+			return fmt.Errorf("cluster override %s: %s", override.Name, msg)
+		}
+		overrideSpecs[override.Name] = &overrideSpec
+		r.Log.Info("[includeClusterOverrides]", "override", override.Name, "clusters", matchingClusters)
+	}
+
+	clusterGroupUpgrade.Status.Precaching.ClusterOverrides = clusterOverrides
+	clusterGroupUpgrade.Status.Precaching.OverrideSpecs = overrideSpecs
+	return nil
+}
@@ -0,0 +1,286 @@
+/*
+Copyright 2023.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry"
+	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+)
+
+// preCachingConfigRecheckInterval is the interval for checking again the images of a
+// PreCachingConfig that are not available from the hub
+const preCachingConfigRecheckInterval = 10 * time.Minute
+
+// PreCachingConfigReconciler reconciles a PreCachingConfig object to validate it
+type PreCachingConfigReconciler struct {
+	client.Client
+	// APIReader reads the objects that aren't cached, such as the hub pull secret
+	APIReader client.Reader
+	Log       logr.Logger
+	Scheme    *runtime.Scheme
+}
+
+//+kubebuilder:rbac:groups=ran.openshift.io,resources=precachingconfigs,verbs=get;list;watch
+//+kubebuilder:rbac:groups=ran.openshift.io,resources=precachingconfigs/status,verbs=get;update;patch
+//+kubebuilder:rbac:groups=ran.openshift.io,resources=clustergroupupgrades,verbs=get;list;watch
+
+// Reconcile validates the PreCachingConfig independently of the ClusterGroupUpgrades using it
+//   - The spec is checked for values the pre-caching would reject: spaceRequired, cluster
+//     overrides, operator packages and channels, and image pull specs
+//   - When the spec is well formed, the images are looked up in their registries from the hub
+//   - The ClusterGroupUpgrades referencing the PreCachingConfig are listed in the status
+//
+// Note: The Controller will requeue the Request to be processed again if the returned error is non-nil or
+// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
+func (r *PreCachingConfigReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	preCachingConfig := &ranv1alpha1.PreCachingConfig{}
+	if err := r.Get(ctx, req.NamespacedName, preCachingConfig); err != nil {
+		if errors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+	status := preCachingConfig.Status.DeepCopy()
+	result := ctrl.Result{}
+
+	referencedBy, err := r.getReferencingCGUs(ctx, preCachingConfig)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	preCachingConfig.Status.ReferencedBy = referencedBy
+
+	if problems := validatePreCachingConfigSpec(preCachingConfig.Spec); len(problems) > 0 {
+		utils.SetStatusCondition(
+			&preCachingConfig.Status.Conditions,
+			utils.ConditionTypes.Validated,
+			utils.ConditionReasons.InvalidPreCachingConfig,
+			metav1.ConditionFalse,
+			fmt.Sprintf("PreCachingConfig is invalid: %s", strings.Join(problems, "; ")),
+		)
+	} else if unavailable := r.checkPreCachingConfigImages(ctx, preCachingConfig.Spec); len(unavailable) > 0 {
+		utils.SetStatusCondition(
+			&preCachingConfig.Status.Conditions,
+			utils.ConditionTypes.Validated,
+			utils.ConditionReasons.UnavailableImages,
+			metav1.ConditionFalse,
+			fmt.Sprintf("Images are not available from the hub: %s", strings.Join(unavailable, "; ")),
+		)
+		result.RequeueAfter = preCachingConfigRecheckInterval
+	} else {
+		utils.SetStatusCondition(
+			&preCachingConfig.Status.Conditions,
+			utils.ConditionTypes.Validated,
+			utils.ConditionReasons.ValidationCompleted,
+			metav1.ConditionTrue,
+			"PreCachingConfig is valid",
+		)
+	}
+
+	if !reflect.DeepEqual(status, &preCachingConfig.Status) {
+		r.Log.Info("[Reconcile]", "preCachingConfig", req.NamespacedName,
+			"referencedBy", preCachingConfig.Status.ReferencedBy)
+		if err := r.Status().Update(ctx, preCachingConfig); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return result, nil
+}
+
+// validatePreCachingConfigSpec checks the PreCachingConfig spec for values rejected by the pre-caching
+// returns: []string (problems found)
+func validatePreCachingConfigSpec(spec ranv1alpha1.PreCachingConfigSpec) []string {
+	var problems []string
+	if spec.SpaceRequired != "" {
+		if _, err := parseSpaceRequired(spec.SpaceRequired); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid spaceRequired %q: %s", spec.SpaceRequired, err))
+		}
+	}
+	if _, err := registry.ParsePackagesAndChannels(spec.Overrides.OperatorsPackagesAndChannels); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid operatorsPackagesAndChannels: %s", err))
+	}
+	if _, err := getClusterOverrideSelectors(spec.ClusterOverrides); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, image := range getPreCachingConfigImages(spec) {
+		if _, err := registry.ParseReference(image); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid image %q: %s", image, err))
+		}
+	}
+	return problems
+}
+
+// getPreCachingConfigImages lists the image pull specs of the PreCachingConfig spec
+// returns: []string
+func getPreCachingConfigImages(spec ranv1alpha1.PreCachingConfigSpec) []string {
+	var images []string
+	if spec.Overrides.PlatformImage != "" {
+		images = append(images, spec.Overrides.PlatformImage)
+	}
+	if spec.Overrides.PreCacheImage != "" {
+		images = append(images, spec.Overrides.PreCacheImage)
+	}
+	images = append(images, spec.Overrides.OperatorsIndexes...)
+	images = append(images, spec.AdditionalImages...)
+	for _, override := range spec.ClusterOverrides {
+		images = append(images, override.OperatorsIndexes...)
+		images = append(images, override.AdditionalImages...)
+	}
+
+	unique := make(map[string]bool)
+	var rv []string
+	for _, image := range images {
+		if !unique[image] {
+			unique[image] = true
+			rv = append(rv, image)
+		}
+	}
+	return rv
+}
+
+// checkPreCachingConfigImages looks up the images of the PreCachingConfig in their registries
+// returns: []string (images not available, with the reason)
+func (r *PreCachingConfigReconciler) checkPreCachingConfigImages(
+	ctx context.Context, spec ranv1alpha1.PreCachingConfigSpec) []string {
+
+	images := getPreCachingConfigImages(spec)
+	if len(images) == 0 {
+		return nil
+	}
+	credentials, err := getHubRegistryCredentials(ctx, r.APIReader)
+	if err != nil {
+		return []string{fmt.Sprintf("unable to read the hub pull secret: %s", err)}
+	}
+	registryClient := newRegistryClient(credentials)
+
+	var unavailable []string
+	for _, image := range images {
+		ref, err := registry.ParseReference(image)
+		if err != nil {
+			unavailable = append(unavailable, fmt.Sprintf("%s: %s", image, err))
+			continue
+		}
+		exists, err := registryClient.ManifestExists(ctx, ref)
+		if err != nil {
+			unavailable = append(unavailable, fmt.Sprintf("%s: %s", image, err))
+		} else if !exists {
+			unavailable = append(unavailable, fmt.Sprintf("%s: not found", image))
+		}
+	}
+	return unavailable
+}
+
+// getReferencingCGUs lists the ClusterGroupUpgrades referencing the PreCachingConfig
+// returns: []string (sorted <namespace/name> entries), error
+func (r *PreCachingConfigReconciler) getReferencingCGUs(
+	ctx context.Context, preCachingConfig *ranv1alpha1.PreCachingConfig) ([]string, error) {
+
+	cguList := &ranv1alpha1.ClusterGroupUpgradeList{}
+	if err := r.List(ctx, cguList); err != nil {
+		return nil, err
+	}
+	var referencedBy []string
+	for _, cgu := range cguList.Items {
+		if getPreCachingConfigKey(&cgu) == client.ObjectKeyFromObject(preCachingConfig) {
+			referencedBy = append(referencedBy, cgu.Namespace+"/"+cgu.Name)
+		}
+	}
+	sort.Strings(referencedBy)
+	return referencedBy, nil
+}
+
+// getPreCachingConfigKey gets the key of the PreCachingConfig referenced by the ClusterGroupUpgrade.
+// The namespace of the ClusterGroupUpgrade is assumed when the reference has none
+// returns: types.NamespacedName (empty when no PreCachingConfig is referenced)
+func getPreCachingConfigKey(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) types.NamespacedName {
+	ref := clusterGroupUpgrade.Spec.PreCachingConfigRef
+	if ref.Name == "" {
+		return types.NamespacedName{}
+	}
+	namespace := ref.Namespace
+	if namespace == "" {
+		namespace = clusterGroupUpgrade.Namespace
+	}
+	return types.NamespacedName{Namespace: namespace, Name: ref.Name}
+}
+
+// enqueueReferencedPreCachingConfig enqueues the PreCachingConfig referenced by the ClusterGroupUpgrade
+func enqueueReferencedPreCachingConfig(obj client.Object, q workqueue.RateLimitingInterface) {
+	clusterGroupUpgrade, ok := obj.(*ranv1alpha1.ClusterGroupUpgrade)
+	if !ok {
+		return
+	}
+	if key := getPreCachingConfigKey(clusterGroupUpgrade); key.Name != "" {
+		q.Add(reconcile.Request{NamespacedName: key})
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PreCachingConfigReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("preCachingConfig").
+		For(&ranv1alpha1.PreCachingConfig{},
+			// status updates do not change the generation
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &ranv1alpha1.ClusterGroupUpgrade{}},
+			// both the previous and the new PreCachingConfig are enqueued when the reference changes
+			handler.Funcs{
+				CreateFunc: func(e event.CreateEvent, q workqueue.RateLimitingInterface) {
+					enqueueReferencedPreCachingConfig(e.Object, q)
+				},
+				UpdateFunc: func(e event.UpdateEvent, q workqueue.RateLimitingInterface) {
+					enqueueReferencedPreCachingConfig(e.ObjectOld, q)
+					enqueueReferencedPreCachingConfig(e.ObjectNew, q)
+				},
+				DeleteFunc: func(e event.DeleteEvent, q workqueue.RateLimitingInterface) {
+					enqueueReferencedPreCachingConfig(e.Object, q)
+				},
+			},
+			builder.WithPredicates(predicate.Funcs{
+				GenericFunc: func(e event.GenericEvent) bool { return false },
+				CreateFunc:  func(e event.CreateEvent) bool { return true },
+				DeleteFunc:  func(e event.DeleteEvent) bool { return true },
+				UpdateFunc: func(e event.UpdateEvent) bool {
+					oldCGU, okOld := e.ObjectOld.(*ranv1alpha1.ClusterGroupUpgrade)
+					newCGU, okNew := e.ObjectNew.(*ranv1alpha1.ClusterGroupUpgrade)
+					return okOld && okNew && getPreCachingConfigKey(oldCGU) != getPreCachingConfigKey(newCGU)
+				},
+			})).
+		Complete(r)
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry/registrytest"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+func TestPreCachingConfigReconciler_Reconcile(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	image := server.AddImage("apps/app", "v1", nil, []byte("app-layer"))
+	index := server.AddImage("redhat/operator-index", "v4.12", nil, []byte("index-layer"))
+
+	defaultNewRegistryClient := newRegistryClient
+	defer func() { newRegistryClient = defaultNewRegistryClient }()
+	newRegistryClient = func(credentials map[string]string) *registry.Client {
+		return registry.NewClient(server.Client(), credentials)
+	}
+
+	newCGU := func(name, namespace, configName, configNamespace string) *ranv1alpha1.ClusterGroupUpgrade {
+		cgu := &ranv1alpha1.ClusterGroupUpgrade{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		cgu.Spec.PreCachingConfigRef.Name = configName
+		cgu.Spec.PreCachingConfigRef.Namespace = configNamespace
+		return cgu
+	}
+	cgus := []*ranv1alpha1.ClusterGroupUpgrade{
+		newCGU("cgu-1", "platform", "config", ""),
+		newCGU("cgu-2", "site-a", "config", "platform"),
+		newCGU("cgu-3", "site-a", "config", ""),
+		newCGU("cgu-4", "platform", "other-config", ""),
+	}
+
+	testcases := []struct {
+		name            string
+		spec            ranv1alpha1.PreCachingConfigSpec
+		expectedStatus  metav1.ConditionStatus
+		expectedReason  utils.ConditionReason
+		expectedMessage []string
+		requeue         bool
+	}{
+		{
+			name: "valid config",
+			spec: ranv1alpha1.PreCachingConfigSpec{
+				Overrides:        ranv1alpha1.PlatformPreCachingSpec{OperatorsIndexes: []string{index}},
+				SpaceRequired:    "40 GiB",
+				AdditionalImages: []string{image},
+				ClusterOverrides: []ranv1alpha1.ClusterPreCachingOverride{
+					{Name: "gpu", AdditionalImages: []string{image}},
+				},
+			},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: utils.ConditionReasons.ValidationCompleted,
+		},
+		{
+			name: "invalid spec",
+			spec: ranv1alpha1.PreCachingConfigSpec{
+				Overrides: ranv1alpha1.PlatformPreCachingSpec{
+					OperatorsPackagesAndChannels: []string{"ptp-operator"},
+				},
+				SpaceRequired:    "forty",
+				AdditionalImages: []string{"quay.io/app@invalid"},
+				ClusterOverrides: []ranv1alpha1.ClusterPreCachingOverride{{Name: "gpu"}, {Name: "gpu"}},
+			},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: utils.ConditionReasons.InvalidPreCachingConfig,
+			expectedMessage: []string{
+				`invalid spaceRequired "forty"`, "operators record ptp-operator is malformed",
+				"cluster override names must be set and unique", `invalid image "quay.io/app@invalid"`,
+			},
+		},
+		{
+			name: "unavailable images",
+			spec: ranv1alpha1.PreCachingConfigSpec{
+				AdditionalImages: []string{image},
+				ClusterOverrides: []ranv1alpha1.ClusterPreCachingOverride{
+					{Name: "gpu", AdditionalImages: []string{server.Host() + "/apps/gpu:v1"}},
+				},
+			},
+			expectedStatus:  metav1.ConditionFalse,
+			expectedReason:  utils.ConditionReasons.UnavailableImages,
+			expectedMessage: []string{server.Host() + "/apps/gpu:v1: not found"},
+			requeue:         true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			preCachingConfig := &ranv1alpha1.PreCachingConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "platform"},
+				Spec:       tc.spec,
+			}
+			fakeClient, _ := getFakeClientFromObjects(preCachingConfig, cgus[0], cgus[1], cgus[2], cgus[3])
+			r := &PreCachingConfigReconciler{Client: fakeClient, APIReader: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+			key := types.NamespacedName{Name: "config", Namespace: "platform"}
+			result, err := r.Reconcile(context.TODO(), ctrl.Request{NamespacedName: key})
+			assert.NoError(t, err)
+			assert.Equal(t, tc.requeue, result.RequeueAfter > 0)
+
+			updated := &ranv1alpha1.PreCachingConfig{}
+			assert.NoError(t, fakeClient.Get(context.TODO(), key, updated))
+			assert.Equal(t, []string{"platform/cgu-1", "site-a/cgu-2"}, updated.Status.ReferencedBy)
+			condition := meta.FindStatusCondition(updated.Status.Conditions, string(utils.ConditionTypes.Validated))
+			if assert.NotNil(t, condition) {
+				assert.Equal(t, tc.expectedStatus, condition.Status)
+				assert.Equal(t, string(tc.expectedReason), condition.Reason)
+				for _, msg := range tc.expectedMessage {
+					assert.Contains(t, condition.Message, msg)
+				}
+			}
+		})
+	}
+}
@@ -4,9 +4,12 @@ import (
 	"context"
 	"github.com/go-logr/logr"
 	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry/registrytest"
 	"github.com/openshift-kni/cluster-group-upgrades-operator/pkg/generated/clientset/versioned/scheme"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"testing"
 )
@@ -214,3 +217,141 @@ func TestPreCachingConfig_mapPreCachingConfigSpecToPrecachingSpec(t *testing.T)
 		})
 	}
 }
+
+func TestPreCachingConfig_includeClusterOverrides(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	defaultNewRegistryClient := newRegistryClient
+	defer func() { newRegistryClient = defaultNewRegistryClient }()
+	newRegistryClient = func(credentials map[string]string) *registry.Client {
+		return registry.NewClient(server.Client(), credentials)
+	}
+
+	newCluster := func(name string, clusterLabels map[string]string) *clusterv1.ManagedCluster {
+		return &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: clusterLabels}}
+	}
+	gpuIndex := server.Host() + "/gpu/index:v1"
+	baseSpec := ranv1alpha1.PrecachingSpec{
+		PlatformImage:                "test-platform-image:test-tag",
+		OperatorsIndexes:             []string{"registry.example.com:5000/test-index:v0.0"},
+		OperatorsPackagesAndChannels: []string{"ptp-operator: stable"},
+		OperatorsImages:              []string{"ptp-operator:v1"},
+		ExcludePrecachePatterns:      []string{"aws"},
+		AdditionalImages:             []string{"image1:tag"},
+		SpaceRequired:                "40",
+	}
+
+	testCases := []struct {
+		name                     string
+		overrides                []ranv1alpha1.ClusterPreCachingOverride
+		expectedClusterOverrides map[string]string
+		expectedOverrideSpecs    map[string]*ranv1alpha1.PrecachingSpec
+		expectedError            string
+	}{
+		{
+			name:      "no cluster overrides",
+			overrides: nil,
+		},
+		{
+			name: "clusters take the first matching override",
+			overrides: []ranv1alpha1.ClusterPreCachingOverride{
+				{
+					Name:             "gpu",
+					ClusterSelector:  metav1.LabelSelector{MatchLabels: map[string]string{"hardware": "gpu"}},
+					AdditionalImages: []string{"gpu-driver:tag"},
+					OperatorsIndexes: []string{gpuIndex},
+				},
+				{
+					Name: "edge",
+					ClusterSelector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "profile", Operator: metav1.LabelSelectorOpIn, Values: []string{"edge", "far-edge"}},
+					}},
+					ExcludePrecachePatterns: []string{"vsphere"},
+				},
+				{
+					Name:            "unused",
+					ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"unused": "true"}},
+				},
+			},
+			expectedClusterOverrides: map[string]string{"spoke1": "gpu", "spoke2": "edge"},
+			expectedOverrideSpecs: map[string]*ranv1alpha1.PrecachingSpec{
+				"gpu": {
+					PlatformImage:                "test-platform-image:test-tag",
+					OperatorsIndexes:             []string{gpuIndex},
+					OperatorsPackagesAndChannels: []string{"ptp-operator: stable"},
+					ExcludePrecachePatterns:      []string{"aws"},
+					AdditionalImages:             []string{"image1:tag", "gpu-driver:tag"},
+					SpaceRequired:                "40",
+				},
+				"edge": {
+					PlatformImage:                "test-platform-image:test-tag",
+					OperatorsIndexes:             []string{"registry.example.com:5000/test-index:v0.0"},
+					OperatorsPackagesAndChannels: []string{"ptp-operator: stable"},
+					OperatorsImages:              []string{"ptp-operator:v1"},
+					ExcludePrecachePatterns:      []string{"aws", "vsphere"},
+					AdditionalImages:             []string{"image1:tag"},
+					SpaceRequired:                "40",
+				},
+			},
+		},
+		{
+			name: "duplicate override names",
+			overrides: []ranv1alpha1.ClusterPreCachingOverride{
+				{Name: "gpu", ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"hardware": "gpu"}}},
+				{Name: "gpu", ClusterSelector: metav1.LabelSelector{MatchLabels: map[string]string{"profile": "edge"}}},
+			},
+			expectedError: "must be set and unique",
+		},
+		{
+			name: "invalid selector",
+			overrides: []ranv1alpha1.ClusterPreCachingOverride{
+				{Name: "bad", ClusterSelector: metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "profile", Operator: "Unknown"},
+				}}},
+			},
+			expectedError: "invalid cluster selector",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			preCachingConfig := &ranv1alpha1.PreCachingConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "config", Namespace: "test"},
+				Spec:       ranv1alpha1.PreCachingConfigSpec{ClusterOverrides: tc.overrides},
+			}
+			fakeClient, _ := getFakeClientFromObjects(preCachingConfig,
+				newCluster("spoke1", map[string]string{"hardware": "gpu", "profile": "edge"}),
+				newCluster("spoke2", map[string]string{"profile": "far-edge"}),
+				newCluster("spoke3", map[string]string{"profile": "core"}))
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, APIReader: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "test"}}
+			cgu.Spec.PreCachingConfigRef.Name = "config"
+			cgu.Status.Precaching = &ranv1alpha1.PrecachingStatus{
+				Spec:                &baseSpec,
+				SpaceRequiredSource: SpaceRequiredSourcePreCachingConfig,
+			}
+
+			err := r.includeClusterOverrides(context.TODO(), cgu, []string{"spoke1", "spoke2", "spoke3"}, baseSpec, nil)
+			if tc.expectedError != "" {
+				assert.ErrorContains(t, err, tc.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedClusterOverrides, cgu.Status.Precaching.ClusterOverrides)
+			assert.Equal(t, tc.expectedOverrideSpecs, cgu.Status.Precaching.OverrideSpecs)
+
+			// The spoke pre-cache-spec ConfigMap is rendered from the spec of the cluster
+			for _, cluster := range []string{"spoke1", "spoke2", "spoke3"} {
+				expectedSpec := &baseSpec
+				if name, ok := tc.expectedClusterOverrides[cluster]; ok {
+					expectedSpec = tc.expectedOverrideSpecs[name]
+				}
+				data := r.getPrecacheSpecTemplateData(cgu, cluster)
+				assert.Equal(t, expectedSpec.AdditionalImages, data.AdditionalImages, cluster)
+				assert.Equal(t, expectedSpec.ExcludePrecachePatterns, data.ExcludePrecachePatterns, cluster)
+				assert.Equal(t, expectedSpec.OperatorsIndexes, data.Operators.Indexes, cluster)
+			}
+		})
+	}
+}
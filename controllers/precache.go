@@ -2,12 +2,10 @@ package controllers
 
 import (
 	"context"
-	"crypto/tls"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"io"
 	"math"
-	"net/http"
 	"os"
 	"strconv"
 
@@ -16,11 +14,17 @@ import (
 	"github.com/docker/go-units"
 
 	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry"
 	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // reconcilePrecaching provides the main precaching entry point
@@ -31,19 +35,7 @@ func (r *ClusterGroupUpgradeReconciler) reconcilePrecaching(
 
 	if clusterGroupUpgrade.Spec.PreCaching && len(clusters) > 0 {
 		// Pre-caching is required
-		if clusterGroupUpgrade.Status.Precaching == nil {
-			clusterGroupUpgrade.Status.Precaching = &ranv1alpha1.PrecachingStatus{
-				Spec: &ranv1alpha1.PrecachingSpec{
-					PlatformImage:                "",
-					OperatorsIndexes:             []string{},
-					OperatorsPackagesAndChannels: []string{},
-				},
-				Status:   make(map[string]string),
-				Clusters: []string{},
-			}
-		} else if clusterGroupUpgrade.Status.Precaching.Status == nil {
-			clusterGroupUpgrade.Status.Precaching.Status = make(map[string]string)
-		}
+		initializePrecachingStatus(clusterGroupUpgrade)
 
 		precachingCondition := meta.FindStatusCondition(
 			clusterGroupUpgrade.Status.Conditions, string(utils.ConditionTypes.PrecachingSuceeded))
@@ -60,54 +52,21 @@ func (r *ClusterGroupUpgradeReconciler) reconcilePrecaching(
 	return nil
 }
 
-// getImageForVersionFromUpdateGraph gets the image for the given version
-// by traversing the update graph.
-// Connecting to the upstream URL with the channel passed as a parameter
-// the update graph is returned as JSON. This function then traverses
-// the nodes list from that JSON to find the version and if found
-// then returns the image
-func (r *ClusterGroupUpgradeReconciler) getImageForVersionFromUpdateGraph(
-	upstream string, channel string, version string) (string, error) {
-	updateGraphURL := upstream + "?channel=" + channel
-
-	insecureSkipVerify := os.Getenv("INSECURE_GRAPH_CALL") == "true"
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: insecureSkipVerify},
-	}
-	client := &http.Client{Transport: tr}
-	req, _ := http.NewRequest("GET", updateGraphURL, nil)
-	req.Header.Add("Accept", "application/json")
-	res, err := client.Do(req)
-
-	if err != nil {
-		return "", fmt.Errorf("unable to request update graph on url %s: %w", updateGraphURL, err)
-	}
-	if res.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("error response from update graph url %s: %d", updateGraphURL, res.StatusCode)
-	}
-
-	defer res.Body.Close()
-
-	body, err := io.ReadAll(res.Body)
-	if err != nil && len(body) > 0 {
-		return "", fmt.Errorf("unable to read body from response: %w", err)
-	}
-
-	var graph map[string]interface{}
-	err = json.Unmarshal(body, &graph)
-	if err != nil {
-		return "", fmt.Errorf("unable to unmarshal body: %w", err)
-	}
-
-	if nodes, ok := graph["nodes"]; ok {
-		for _, n := range nodes.([]interface{}) {
-			node := n.(map[string]interface{})
-			if node["version"] == version && node["payload"] != "" {
-				return node["payload"].(string), nil
-			}
+// initializePrecachingStatus initializes the pre-caching status of the ClusterGroupUpgrade
+func initializePrecachingStatus(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) {
+	if clusterGroupUpgrade.Status.Precaching == nil {
+		clusterGroupUpgrade.Status.Precaching = &ranv1alpha1.PrecachingStatus{
+			Spec: &ranv1alpha1.PrecachingSpec{
+				PlatformImage:                "",
+				OperatorsIndexes:             []string{},
+				OperatorsPackagesAndChannels: []string{},
+			},
+			Status:   make(map[string]string),
+			Clusters: []string{},
 		}
+	} else if clusterGroupUpgrade.Status.Precaching.Status == nil {
+		clusterGroupUpgrade.Status.Precaching.Status = make(map[string]string)
 	}
-	return "", fmt.Errorf("unable to find version %s on update graph on url %s", version, updateGraphURL)
 }
 
 // extractPrecachingSpecFromPolicies extracts the software spec to be pre-cached
@@ -121,7 +80,7 @@ func (r *ClusterGroupUpgradeReconciler) getImageForVersionFromUpdateGraph(
 //
 // returns: precachingSpec, error
 func (r *ClusterGroupUpgradeReconciler) extractPrecachingSpecFromPolicies(
-	policies []*unstructured.Unstructured) (ranv1alpha1.PrecachingSpec, error) {
+	ctx context.Context, policies []*unstructured.Unstructured) (ranv1alpha1.PrecachingSpec, error) {
 
 	var spec ranv1alpha1.PrecachingSpec
 	for _, policy := range policies {
@@ -150,7 +109,7 @@ func (r *ClusterGroupUpgradeReconciler) extractPrecachingSpecFromPolicies(
 	}
 
 	// Get the platform image spec from the policies
-	image, err := r.extractOCPImageFromPolicies(policies)
+	image, err := r.extractOCPImageFromPolicies(ctx, policies)
 	if err != nil {
 		return *new(ranv1alpha1.PrecachingSpec), err
 	}
@@ -209,7 +168,7 @@ func (r *ClusterGroupUpgradeReconciler) deployDependencies(
 	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
 	cluster string) (bool, error) {
 
-	spec := r.getPrecacheSpecTemplateData(clusterGroupUpgrade)
+	spec := r.getPrecacheSpecTemplateData(clusterGroupUpgrade, cluster)
 	spec.Cluster = cluster
 	msg := fmt.Sprintf("%v", spec)
 	r.Log.Info("[deployDependencies]", "getPrecacheSpecTemplateData",
@@ -290,18 +249,186 @@ func parseSpaceRequired(spaceRequired string) (string, error) {
 	return strconv.Itoa(resultGiB), nil
 }
 
-// getPrecacheSpecTemplateData: Converts precaching payload spec to template data
+// getClusterPrecachingSpec returns the pre-caching spec of the cluster, which is the spec of the
+// cluster override the cluster matches, if any
+func getClusterPrecachingSpec(
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) *ranv1alpha1.PrecachingSpec {
+
+	spec := clusterGroupUpgrade.Status.Precaching.Spec
+	if name, ok := clusterGroupUpgrade.Status.Precaching.ClusterOverrides[cluster]; ok {
+		if overrideSpec, ok := clusterGroupUpgrade.Status.Precaching.OverrideSpecs[name]; ok {
+			spec = overrideSpec
+		}
+	}
+	return spec
+}
+
+// getPrecachingSpecHash computes the hash of the content pre-cached from the spec.
+// The space required is left out as it doesn't change the content
+// returns: string, error
+func getPrecachingSpecHash(spec *ranv1alpha1.PrecachingSpec) (string, error) {
+	content := *spec
+	content.SpaceRequired = ""
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
+
+// isPrecachingSpecCached checks whether the cluster already holds the content of its pre-caching
+// spec, as recorded on the managed cluster by a previous successful pre-caching. When the cluster has an
+// image inventory, its latest inventory must report the images too. Without inventory, the record alone is
+// trusted, as it is cleared when TALM prunes the images
+// returns: bool
+func (r *ClusterGroupUpgradeReconciler) isPrecachingSpecCached(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) bool {
+
+	if clusterGroupUpgrade.Spec.PreCachingForceRefresh {
+		return false
+	}
+	managedCluster := &clusterv1.ManagedCluster{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cluster}, managedCluster); err != nil {
+		r.Log.Info("[isPrecachingSpecCached]", "cluster", cluster, "err", err)
+		return false
+	}
+	recorded, ok := managedCluster.GetAnnotations()[utils.PrecachedSpecAnnotation]
+	if !ok {
+		return false
+	}
+	hash, err := getPrecachingSpecHash(getClusterPrecachingSpec(clusterGroupUpgrade, cluster))
+	if err != nil {
+		r.Log.Info("[isPrecachingSpecCached]", "cluster", cluster, "err", err)
+		return false
+	}
+	if recorded != hash {
+		return false
+	}
+	inventoryImages, err := r.getInventoryImages(ctx, cluster)
+	if errors.IsNotFound(err) {
+		r.Log.Info("[isPrecachingSpecCached]", "cluster", cluster, "inventory", "none, trusting the pre-cached spec")
+		return true
+	}
+	if err != nil {
+		r.Log.Info("[isPrecachingSpecCached]", "cluster", cluster, "err", err)
+		return false
+	}
+	if missing := getImagesMissingFromInventory(
+		getClusterPrecachingSpec(clusterGroupUpgrade, cluster), inventoryImages); len(missing) > 0 {
+		r.Log.Info("[isPrecachingSpecCached]", "cluster", cluster, "imagesMissingFromInventory", missing)
+		return false
+	}
+	return true
+}
+
+// getInventoryImages reads the references of the images reported by the latest image inventory of the cluster
+// returns: map[string]bool, error
+func (r *ClusterGroupUpgradeReconciler) getInventoryImages(ctx context.Context, cluster string) (map[string]bool, error) {
+	configMap := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: utils.ImageInventoryConfigMapName, Namespace: cluster}, configMap)
+	if err != nil {
+		return nil, err
+	}
+	var images []struct {
+		RepoTags    []string `json:"repoTags"`
+		RepoDigests []string `json:"repoDigests"`
+	}
+	if err := json.Unmarshal([]byte(configMap.Data[inventoryImagesKey]), &images); err != nil {
+		return nil, fmt.Errorf("invalid image inventory: %w", err)
+	}
+	references := make(map[string]bool)
+	for _, image := range images {
+		for _, name := range append(image.RepoTags, image.RepoDigests...) {
+			if ref, err := registry.ParseReference(name); err == nil {
+				references[ref.String()] = true
+			}
+		}
+	}
+	return references, nil
+}
+
+// getImagesMissingFromInventory lists the images of the spec the inventory doesn't report. The images
+// referenced by digest are matched by digest, the others by tag
+// returns: []string
+func getImagesMissingFromInventory(spec *ranv1alpha1.PrecachingSpec, inventoryImages map[string]bool) []string {
+	images := append([]string{spec.PlatformImage}, spec.IntermediatePlatformImages...)
+	images = append(append(images, spec.OperatorsImages...), spec.AdditionalImages...)
+	var missing []string
+	for _, image := range images {
+		if image == "" {
+			continue
+		}
+		ref, err := registry.ParseReference(image)
+		if err != nil {
+			missing = append(missing, image)
+			continue
+		}
+		if ref.Digest != "" {
+			ref.Tag = ""
+		}
+		if !inventoryImages[ref.String()] {
+			missing = append(missing, image)
+		}
+	}
+	return missing
+}
+
+// recordPrecachingSpec records the hash of the spec pre-cached on the cluster in the managed cluster
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) recordPrecachingSpec(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) error {
+
+	hash, err := getPrecachingSpecHash(getClusterPrecachingSpec(clusterGroupUpgrade, cluster))
+	if err != nil {
+		return err
+	}
+	managedCluster := &clusterv1.ManagedCluster{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cluster}, managedCluster); err != nil {
+		return err
+	}
+	patch := client.MergeFrom(managedCluster.DeepCopy())
+	annotations := managedCluster.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[utils.PrecachedSpecAnnotation] = hash
+	managedCluster.SetAnnotations(annotations)
+	return r.Patch(ctx, managedCluster, patch)
+}
+
+// clearPrecachingSpec removes the record of the spec pre-cached on the cluster, once cached images are
+// pruned from the cluster
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) clearPrecachingSpec(ctx context.Context, cluster string) error {
+	managedCluster := &clusterv1.ManagedCluster{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cluster}, managedCluster); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if _, ok := managedCluster.GetAnnotations()[utils.PrecachedSpecAnnotation]; !ok {
+		return nil
+	}
+	patch := client.MergeFrom(managedCluster.DeepCopy())
+	annotations := managedCluster.GetAnnotations()
+	delete(annotations, utils.PrecachedSpecAnnotation)
+	managedCluster.SetAnnotations(annotations)
+	return r.Patch(ctx, managedCluster, patch)
+}
+
+// getPrecacheSpecTemplateData: Converts precaching payload spec of the cluster to template data.
+// Clusters matching a cluster override take the spec of that override
 // returns: precacheTemplateData (softwareSpec)
 //
 //	error
 func (r *ClusterGroupUpgradeReconciler) getPrecacheSpecTemplateData(
-	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) *templateData {
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) *templateData {
 
 	rv := new(templateData)
-	spec := clusterGroupUpgrade.Status.Precaching.Spec
+	spec := getClusterPrecachingSpec(clusterGroupUpgrade, cluster)
 	rv.PlatformImage = spec.PlatformImage
+	rv.IntermediatePlatformImages = spec.IntermediatePlatformImages
 	rv.Operators.Indexes = spec.OperatorsIndexes
 	rv.Operators.PackagesAndChannels = spec.OperatorsPackagesAndChannels
+	rv.Operators.Images = spec.OperatorsImages
 	rv.ExcludePrecachePatterns = spec.ExcludePrecachePatterns
 	rv.AdditionalImages = spec.AdditionalImages
 	rv.SpaceRequired = spec.SpaceRequired
@@ -379,22 +506,26 @@ func (r *ClusterGroupUpgradeReconciler) includePreCachingConfigs(
 	// Retrieve additional user images
 	rv.AdditionalImages = preCachingConfigSpec.AdditionalImages
 
-	// Extract the space required for pre-caching
+	// Extract the space required for pre-caching. When not specified, it is estimated later
+	// from the images to be pre-cached
 	spaceRequired := preCachingConfigSpec.SpaceRequired
+	spaceRequiredSource := SpaceRequiredSourcePreCachingConfig
 	if spaceRequired == "" {
 		overrideField := "precache.spaceRequired"
 		spaceRequired = overrides[overrideField]
-		if spaceRequired == "" {
-			spaceRequired = utils.SpaceRequiredForPrecache
-		} else {
+		spaceRequiredSource = SpaceRequiredSourceOverrides
+		if spaceRequired != "" {
 			r.Log.Info(getDeprecationMessage(overrideField))
 		}
 	}
-	spaceRequired, err = parseSpaceRequired(spaceRequired)
-	if err != nil {
-		return *rv, err
+	if spaceRequired != "" {
+		spaceRequired, err = parseSpaceRequired(spaceRequired)
+		if err != nil {
+			return *rv, err
+		}
+		rv.SpaceRequired = spaceRequired
+		clusterGroupUpgrade.Status.Precaching.SpaceRequiredSource = spaceRequiredSource
 	}
-	rv.SpaceRequired = spaceRequired
 
 	return *rv, nil
 }
@@ -19,9 +19,11 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
 	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -55,6 +57,116 @@ const (
 	UnforeseenCondition        = "UnforeseenCondition"
 )
 
+// computePrecachingSpec computes the pre-caching spec from the policies, the PreCachingConfig and the
+// cluster overrides, and sets the PrecacheSpecValid condition accordingly
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) computePrecachingSpec(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusters []string, policies []*unstructured.Unstructured) error {
+
+	spec, err := r.extractPrecachingSpecFromPolicies(ctx, policies)
+	if err != nil {
+		return err
+	}
+	r.Log.Info("[computePrecachingSpec]", "PrecacheSpecFromPolicies", spec)
+	spec, err = r.includePreCachingConfigs(ctx, clusterGroupUpgrade, &spec)
+	if err != nil {
+		utils.SetStatusCondition(
+			&clusterGroupUpgrade.Status.Conditions,
+			utils.ConditionTypes.PrecacheSpecValid,
+			utils.ConditionReasons.PrecacheSpecIncomplete,
+			metav1.ConditionFalse,
+			fmt.Sprintf("Precaching spec is incomplete: failed to get PreCachingConfig resource due to %s", err.Error()),
+		)
+		return nil
+	}
+	includePlatformUpgradeImages(clusterGroupUpgrade, &spec)
+	ok, msg := r.checkPreCacheSpecConsistency(spec)
+	if !ok {
+		utils.SetStatusCondition(
+			&clusterGroupUpgrade.Status.Conditions,
+			utils.ConditionTypes.PrecacheSpecValid,
+			utils.ConditionReasons.PrecacheSpecIncomplete,
+			metav1.ConditionFalse,
+			fmt.Sprintf("Precaching spec is incomplete: %s", msg),
+		)
+		return nil
+	}
+	if len(spec.OperatorsIndexes) > 0 {
+		images, err := r.resolveOperatorsImages(ctx, spec)
+		if err == errIndexResolutionInProgress {
+			setPrecacheSpecResolving(clusterGroupUpgrade)
+			return nil
+		}
+		if err != nil {
+			// Leave the resolution of the operator bundles to the spoke
+			r.Log.Info("[computePrecachingSpec]", "hub-side operator resolution failed, resolving on the spoke", err.Error())
+		} else {
+			spec.OperatorsImages = images
+		}
+	}
+	if spec.SpaceRequired == "" {
+		spec.SpaceRequired, clusterGroupUpgrade.Status.Precaching.SpaceRequiredSource =
+			r.getEstimatedSpaceRequired(ctx, clusters, spec, policies)
+	}
+	err = r.includeClusterOverrides(ctx, clusterGroupUpgrade, clusters, spec, policies)
+	if err == errIndexResolutionInProgress {
+		setPrecacheSpecResolving(clusterGroupUpgrade)
+		return nil
+	}
+	if err != nil {
+		utils.SetStatusCondition(
+			&clusterGroupUpgrade.Status.Conditions,
+			utils.ConditionTypes.PrecacheSpecValid,
+			utils.ConditionReasons.PrecacheSpecIncomplete,
+			metav1.ConditionFalse,
+			fmt.Sprintf("Precaching spec is incomplete: failed to apply the cluster overrides due to %s", err.Error()),
+		)
+		return nil
+	}
+	missing, unverified, err := r.checkPrecachingImages(ctx, spec, clusterGroupUpgrade.Status.Precaching.OverrideSpecs)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		utils.SetStatusCondition(
+			&clusterGroupUpgrade.Status.Conditions,
+			utils.ConditionTypes.PrecacheSpecValid,
+			utils.ConditionReasons.UnavailableImages,
+			metav1.ConditionFalse,
+			fmt.Sprintf("Precaching spec is incomplete: images not found in the registries: %s", strings.Join(missing, ", ")),
+		)
+		return nil
+	}
+	// The images the hub can't look up are left to the clusters, which may reach registries the hub doesn't
+	message := "Precaching spec is valid and consistent"
+	if len(unverified) > 0 {
+		message += fmt.Sprintf(", images not checked as their registries can't be queried from the hub: %s",
+			strings.Join(unverified, ", "))
+	}
+	utils.SetStatusCondition(
+		&clusterGroupUpgrade.Status.Conditions,
+		utils.ConditionTypes.PrecacheSpecValid,
+		utils.ConditionReasons.PrecacheSpecIsWellFormed,
+		metav1.ConditionTrue,
+		message,
+	)
+
+	clusterGroupUpgrade.Status.Precaching.Spec = &spec
+	return nil
+}
+
+// setPrecacheSpecResolving sets the PrecacheSpecValid condition while the operator index images are
+// resolved in the background
+func setPrecacheSpecResolving(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) {
+	utils.SetStatusCondition(
+		&clusterGroupUpgrade.Status.Conditions,
+		utils.ConditionTypes.PrecacheSpecValid,
+		utils.ConditionReasons.InProgress,
+		metav1.ConditionFalse,
+		"Precaching spec is incomplete: the operator bundles are being resolved from the operator index images",
+	)
+}
+
 // precachingFsm implements the precaching state machine
 // returns: error
 func (r *ClusterGroupUpgradeReconciler) precachingFsm(ctx context.Context,
@@ -62,42 +174,14 @@ func (r *ClusterGroupUpgradeReconciler) precachingFsm(ctx context.Context,
 
 	specCondition := meta.FindStatusCondition(clusterGroupUpgrade.Status.Conditions, utils.PrecacheSpecValidCondition)
 	if specCondition == nil || specCondition.Status == metav1.ConditionFalse {
-		spec, err := r.extractPrecachingSpecFromPolicies(policies)
+		err := r.computePrecachingSpec(ctx, clusterGroupUpgrade, clusters, policies)
 		if err != nil {
 			return err
 		}
-		r.Log.Info("[precachingFsm]", "PrecacheSpecFromPolicies", spec)
-		spec, err = r.includePreCachingConfigs(ctx, clusterGroupUpgrade, &spec)
-		if err != nil {
-			utils.SetStatusCondition(
-				&clusterGroupUpgrade.Status.Conditions,
-				utils.ConditionTypes.PrecacheSpecValid,
-				utils.ConditionReasons.PrecacheSpecIncomplete,
-				metav1.ConditionFalse,
-				fmt.Sprintf("Precaching spec is incomplete: failed to get PreCachingConfig resource due to %s", err.Error()),
-			)
-			return nil
-		}
-		ok, msg := r.checkPreCacheSpecConsistency(spec)
-		if !ok {
-			utils.SetStatusCondition(
-				&clusterGroupUpgrade.Status.Conditions,
-				utils.ConditionTypes.PrecacheSpecValid,
-				utils.ConditionReasons.PrecacheSpecIncomplete,
-				metav1.ConditionFalse,
-				fmt.Sprintf("Precaching spec is incomplete: %s", msg),
-			)
+		specCondition = meta.FindStatusCondition(clusterGroupUpgrade.Status.Conditions, utils.PrecacheSpecValidCondition)
+		if specCondition == nil || specCondition.Status == metav1.ConditionFalse {
 			return nil
 		}
-		utils.SetStatusCondition(
-			&clusterGroupUpgrade.Status.Conditions,
-			utils.ConditionTypes.PrecacheSpecValid,
-			utils.ConditionReasons.PrecacheSpecIsWellFormed,
-			metav1.ConditionTrue,
-			"Precaching spec is valid and consistent",
-		)
-
-		clusterGroupUpgrade.Status.Precaching.Spec = &spec
 	}
 	utils.SetStatusCondition(
 		&clusterGroupUpgrade.Status.Conditions,
@@ -121,6 +205,15 @@ func (r *ClusterGroupUpgradeReconciler) precachingFsm(ctx context.Context,
 		switch currentState {
 		// Initial State
 		case PrecacheStateNotStarted:
+			if r.isPrecachingSpecCached(ctx, clusterGroupUpgrade, cluster) {
+				r.Log.Info("[precachingFsm]", "cluster", cluster, "skipping", "content already pre-cached")
+				clusterGroupUpgrade.Status.Precaching.Status[cluster] = PrecacheStateSucceeded
+				clusterGroupUpgrade.Status.Precaching.SkippedClusters = append(
+					clusterGroupUpgrade.Status.Precaching.SkippedClusters, cluster)
+				r.recordClusterTransition(clusterGroupUpgrade, cluster, corev1.EventTypeNormal,
+					eventReasonClusterPrecaching, PrecacheStateSucceeded, "pre-caching skipped, content already pre-cached")
+				continue
+			}
 			nextState, err = r.handleNotStarted(ctx, cluster)
 
 		case PrecacheStatePreparingToStart:
@@ -152,6 +245,8 @@ func (r *ClusterGroupUpgradeReconciler) precachingFsm(ctx context.Context,
 		clusterGroupUpgrade.Status.Precaching.Status[cluster] = nextState
 		if currentState != nextState {
 			r.Log.Info("[precachingFsm]", "previousState", currentState, "nextState", nextState, "cluster", cluster)
+			r.recordFsmTransition(clusterGroupUpgrade, cluster, eventReasonClusterPrecaching, "pre-caching",
+				currentState, nextState, PrecacheStateTimeout, PrecacheStateError)
 		}
 		if nextState == PrecacheStateSucceeded {
 			// cleanup for succeeded clusters
@@ -160,6 +255,9 @@ func (r *ClusterGroupUpgradeReconciler) precachingFsm(ctx context.Context,
 				// skip cluster status transition if cleanup not successful
 				continue
 			}
+			if err := r.recordPrecachingSpec(ctx, clusterGroupUpgrade, cluster); err != nil {
+				r.Log.Error(err, "[precachingFsm] failed to record the pre-cached spec for", "cluster", cluster)
+			}
 		}
 		clusterGroupUpgrade.Status.Precaching.Status[cluster] = nextState
 	}
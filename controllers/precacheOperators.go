@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// registryRequestTimeout bounds the connection to a registry and the wait for its response headers, and
+	// the lookup of an image, so that an unreachable registry doesn't hold the reconciliation
+	registryRequestTimeout = 30 * time.Second
+	// indexResolutionTimeout bounds the download of the layers of an operator index image
+	indexResolutionTimeout = 5 * time.Minute
+)
+
+// indexResolutionBudget bounds the wait of a reconciliation for the resolution of the operator index images.
+// The resolutions carry on in the background and their results are used by a later reconciliation
+var indexResolutionBudget = 10 * time.Second
+
+// errIndexResolutionInProgress is returned when the operator index images are still being resolved after the budget
+var errIndexResolutionInProgress = fmt.Errorf("the operator index images are still being resolved")
+
+// indexResolution is the resolution of the channels from an operator index image, running in the background
+type indexResolution struct {
+	done   chan struct{}
+	images map[string][]string
+	err    error
+}
+
+var (
+	indexResolutionsMutex sync.Mutex
+	// indexResolutions holds the resolutions in progress, and the completed ones until a reconciliation
+	// picks their result
+	indexResolutions = make(map[string]*indexResolution)
+)
+
+// newRegistryClient creates the client used to access the registries from the hub
+var newRegistryClient = func(credentials map[string]string) *registry.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: registryRequestTimeout}).DialContext
+	transport.TLSHandshakeTimeout = registryRequestTimeout
+	transport.ResponseHeaderTimeout = registryRequestTimeout
+	return registry.NewClient(&http.Client{Transport: transport}, credentials)
+}
+
+// getHubRegistryCredentials reads the registry credentials from the hub global pull secret. The secret is read
+// from the API server, so that the secrets aren't cached by the manager
+// returns: map[string]string, error
+func getHubRegistryCredentials(ctx context.Context, c client.Reader) (map[string]string, error) {
+	secret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{
+		Name:      utils.HubPullSecretName,
+		Namespace: utils.HubPullSecretNamespace,
+	}, secret)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return map[string]string{}, nil
+		}
+		return nil, err
+	}
+	return registry.CredentialsFromDockerConfig(secret.Data[corev1.DockerConfigJsonKey])
+}
+
+// resolveOperatorsImages resolves the operator packages and channels against the operator
+// indexes on the hub, so that the spoke only pulls the resulting list of images
+// returns: []string, error
+func (r *ClusterGroupUpgradeReconciler) resolveOperatorsImages(
+	ctx context.Context, spec ranv1alpha1.PrecachingSpec) ([]string, error) {
+
+	channels, err := registry.ParsePackagesAndChannels(spec.OperatorsPackagesAndChannels)
+	if err != nil {
+		return nil, err
+	}
+	credentials, err := getHubRegistryCredentials(ctx, r.APIReader)
+	if err != nil {
+		return nil, err
+	}
+	registryClient := newRegistryClient(credentials)
+
+	resolutions := make([]*indexResolution, len(spec.OperatorsIndexes))
+	for i, index := range spec.OperatorsIndexes {
+		resolutions[i] = startIndexResolution(registryClient, index, channels, spec.OperatorsPackagesAndChannels)
+	}
+	budget := time.NewTimer(indexResolutionBudget)
+	defer budget.Stop()
+	for _, resolution := range resolutions {
+		select {
+		case <-resolution.done:
+		case <-budget.C:
+			r.Log.Info("[resolveOperatorsImages]", "indexes", spec.OperatorsIndexes, "resolution", "in progress")
+			return nil, errIndexResolutionInProgress
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	resolved := make(map[string]bool)
+	unique := make(map[string]bool)
+	for i, index := range spec.OperatorsIndexes {
+		packageImages, err := finishIndexResolution(index, spec.OperatorsPackagesAndChannels, resolutions[i])
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve operator bundles from index %s: %w", index, err)
+		}
+		for pkg, images := range packageImages {
+			resolved[pkg] = true
+			for _, image := range images {
+				unique[image] = true
+			}
+		}
+	}
+	for pkg, channel := range channels {
+		if !resolved[pkg] {
+			return nil, fmt.Errorf("package %s channel %s not found in the operator indexes", pkg, channel)
+		}
+	}
+
+	images := make([]string, 0, len(unique))
+	for image := range unique {
+		images = append(images, image)
+	}
+	sort.Strings(images)
+	r.Log.Info("[resolveOperatorsImages]", "indexes", spec.OperatorsIndexes, "images", len(images))
+	return images, nil
+}
+
+// indexResolutionKey returns the key of the resolution of the packages and channels from the index image
+// returns: string
+func indexResolutionKey(index string, packagesAndChannels []string) string {
+	records := append([]string{}, packagesAndChannels...)
+	sort.Strings(records)
+	return index + "/" + strings.Join(records, ",")
+}
+
+// startIndexResolution starts resolving the channels from the index image in the background, unless
+// the same resolution is already running or waiting to be picked
+// returns: *indexResolution
+func startIndexResolution(registryClient *registry.Client, index string, channels map[string]string,
+	packagesAndChannels []string) *indexResolution {
+
+	key := indexResolutionKey(index, packagesAndChannels)
+	indexResolutionsMutex.Lock()
+	defer indexResolutionsMutex.Unlock()
+	if resolution, ok := indexResolutions[key]; ok {
+		return resolution
+	}
+	resolution := &indexResolution{done: make(chan struct{})}
+	indexResolutions[key] = resolution
+	go func() {
+		// The resolution outlives the reconciliation that started it
+		resolveCtx, cancel := context.WithTimeout(context.Background(), indexResolutionTimeout)
+		defer cancel()
+		resolution.images, resolution.err = registryClient.ResolveBundleImages(resolveCtx, index, channels)
+		close(resolution.done)
+	}()
+	return resolution
+}
+
+// finishIndexResolution returns the result of the completed resolution and forgets it, so that the next
+// resolution of the index image checks whether its tag moved. The bundle images stay cached by index digest
+// returns: map[string][]string, error
+func finishIndexResolution(index string, packagesAndChannels []string,
+	resolution *indexResolution) (map[string][]string, error) {
+
+	key := indexResolutionKey(index, packagesAndChannels)
+	indexResolutionsMutex.Lock()
+	defer indexResolutionsMutex.Unlock()
+	if indexResolutions[key] == resolution {
+		delete(indexResolutions, key)
+	}
+	return resolution.images, resolution.err
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry/registrytest"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+const testOperatorsCatalog = `
+{"schema": "olm.channel", "package": "ptp-operator", "name": "stable", "entries": [{"name": "ptp-operator.v4.12.1"}]}
+{"schema": "olm.bundle", "package": "ptp-operator", "name": "ptp-operator.v4.12.1",
+  "relatedImages": [{"name": "ptp", "image": "quay.io/ptp/ptp:v4.12.1"}, {"name": "proxy", "image": "quay.io/ptp/proxy:v4.12.1"}]}
+{"schema": "olm.channel", "package": "local-storage-operator", "name": "stable", "entries": [{"name": "lso.v4.12.0"}]}
+{"schema": "olm.bundle", "package": "local-storage-operator", "name": "lso.v4.12.0",
+  "relatedImages": [{"name": "lso", "image": "quay.io/lso/lso:v4.12.0"}, {"name": "proxy", "image": "quay.io/ptp/proxy:v4.12.1"}]}
+`
+
+func TestPrecacheOperators_resolveOperatorsImages(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	server.Token = "hub-token"
+	index := server.AddImage("redhat/operator-index", "v4.12",
+		map[string]string{"operators.operatorframework.io.index.configs.v1": "/configs"},
+		registrytest.Layer(map[string]string{"configs/catalog.json": testOperatorsCatalog}))
+
+	defaultNewRegistryClient := newRegistryClient
+	defer func() { newRegistryClient = defaultNewRegistryClient }()
+	newRegistryClient = func(credentials map[string]string) *registry.Client {
+		assert.Equal(t, map[string]string{"quay.io": "dXNlcjpwYXNz"}, credentials)
+		return registry.NewClient(server.Client(), credentials)
+	}
+
+	pullSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: utils.HubPullSecretName, Namespace: utils.HubPullSecretNamespace},
+		Data:       map[string][]byte{corev1.DockerConfigJsonKey: []byte(`{"auths":{"quay.io":{"auth":"dXNlcjpwYXNz"}}}`)},
+	}
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(pullSecret).Build()
+	r := &ClusterGroupUpgradeReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       logr.Discard(),
+		Scheme:    scheme.Scheme,
+	}
+
+	testcases := []struct {
+		name     string
+		spec     ranv1alpha1.PrecachingSpec
+		expected []string
+		err      bool
+	}{
+		{
+			name: "packages resolved",
+			spec: ranv1alpha1.PrecachingSpec{
+				OperatorsIndexes:             []string{index},
+				OperatorsPackagesAndChannels: []string{"ptp-operator: stable", "local-storage-operator:stable"},
+			},
+			expected: []string{"quay.io/lso/lso:v4.12.0", "quay.io/ptp/proxy:v4.12.1", "quay.io/ptp/ptp:v4.12.1"},
+		},
+		{
+			name: "package missing from the indexes",
+			spec: ranv1alpha1.PrecachingSpec{
+				OperatorsIndexes:             []string{index},
+				OperatorsPackagesAndChannels: []string{"ptp-operator:stable", "sriov-network-operator:stable"},
+			},
+			err: true,
+		},
+		{
+			name: "channel missing from the indexes",
+			spec: ranv1alpha1.PrecachingSpec{
+				OperatorsIndexes:             []string{index},
+				OperatorsPackagesAndChannels: []string{"ptp-operator:4.11"},
+			},
+			err: true,
+		},
+		{
+			name: "index not found",
+			spec: ranv1alpha1.PrecachingSpec{
+				OperatorsIndexes:             []string{server.Host() + "/redhat/missing-index:v4.12"},
+				OperatorsPackagesAndChannels: []string{"ptp-operator:stable"},
+			},
+			err: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			images, err := r.resolveOperatorsImages(context.TODO(), tc.spec)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, images)
+		})
+	}
+}
+
+func TestPrecacheOperators_resolveOperatorsImagesInBackground(t *testing.T) {
+	fakeClient := fake.NewClientBuilder().WithScheme(scheme.Scheme).Build()
+	r := &ClusterGroupUpgradeReconciler{
+		Client:    fakeClient,
+		APIReader: fakeClient,
+		Log:       logr.Discard(),
+		Scheme:    scheme.Scheme,
+	}
+	defaultIndexResolutionBudget := indexResolutionBudget
+	defer func() { indexResolutionBudget = defaultIndexResolutionBudget }()
+	indexResolutionBudget = 10 * time.Millisecond
+
+	spec := ranv1alpha1.PrecachingSpec{
+		OperatorsIndexes:             []string{"registry.example.com/redhat/operator-index:v4.12"},
+		OperatorsPackagesAndChannels: []string{"ptp-operator:stable"},
+	}
+	// A resolution started by a previous reconciliation is still running
+	key := indexResolutionKey(spec.OperatorsIndexes[0], spec.OperatorsPackagesAndChannels)
+	resolution := &indexResolution{done: make(chan struct{})}
+	indexResolutions[key] = resolution
+	defer delete(indexResolutions, key)
+
+	_, err := r.resolveOperatorsImages(context.TODO(), spec)
+	assert.Equal(t, errIndexResolutionInProgress, err)
+	assert.Equal(t, resolution, indexResolutions[key])
+
+	// The next reconciliation picks the result of the resolution
+	resolution.images = map[string][]string{"ptp-operator": {"quay.io/ptp/ptp:v4.12.1"}}
+	close(resolution.done)
+	images, err := r.resolveOperatorsImages(context.TODO(), spec)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"quay.io/ptp/ptp:v4.12.1"}, images)
+	assert.NotContains(t, indexResolutions, key)
+}
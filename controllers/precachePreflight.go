@@ -0,0 +1,196 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// neverContactSource is the ImageDigestMirrorSet mirror source policy preventing pulls from the source
+const neverContactSource = "NeverContactSource"
+
+// imageDigestMirror is a source repository and the mirrors it is pulled from by digest
+type imageDigestMirror struct {
+	source        string
+	mirrors       []string
+	contactSource bool
+}
+
+// getImageDigestMirrors reads the mirrors of the ImageDigestMirrorSets of the hub
+// returns: []imageDigestMirror, error
+func getImageDigestMirrors(ctx context.Context, c client.Client) ([]imageDigestMirror, error) {
+	list := &unstructured.UnstructuredList{}
+	gvk := utils.ImageDigestMirrorSetGroupVersionKind()
+	gvk.Kind += "List"
+	list.SetGroupVersionKind(gvk)
+	if err := c.List(ctx, list); err != nil {
+		if meta.IsNoMatchError(err) {
+			// No ImageDigestMirrorSet on this hub
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var digestMirrors []imageDigestMirror
+	for _, item := range list.Items {
+		entries, _, err := unstructured.NestedSlice(item.Object, "spec", "imageDigestMirrors")
+		if err != nil {
+			return nil, fmt.Errorf("invalid ImageDigestMirrorSet %s: %w", item.GetName(), err)
+		}
+		for _, entry := range entries {
+			fields, ok := entry.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			source, _, _ := unstructured.NestedString(fields, "source")
+			mirrors, _, _ := unstructured.NestedStringSlice(fields, "mirrors")
+			policy, _, _ := unstructured.NestedString(fields, "mirrorSourcePolicy")
+			if source == "" {
+				continue
+			}
+			digestMirrors = append(digestMirrors, imageDigestMirror{
+				source:        source,
+				mirrors:       mirrors,
+				contactSource: policy != neverContactSource,
+			})
+		}
+	}
+	return digestMirrors, nil
+}
+
+// getImageCandidates lists the pull specs an image is pulled from, in order. Images pulled by
+// digest are rewritten to their mirrors, as the cluster nodes do
+// returns: []string
+func getImageCandidates(image string, digestMirrors []imageDigestMirror) []string {
+	name, digest, found := strings.Cut(image, "@")
+	if !found {
+		return []string{image}
+	}
+	name = strings.TrimSuffix(name, ":"+tagOf(name))
+
+	var candidates []string
+	contactSource := true
+	for _, digestMirror := range digestMirrors {
+		if name != digestMirror.source && !strings.HasPrefix(name, digestMirror.source+"/") {
+			continue
+		}
+		suffix := strings.TrimPrefix(name, digestMirror.source)
+		for _, mirror := range digestMirror.mirrors {
+			candidates = append(candidates, mirror+suffix+"@"+digest)
+		}
+		contactSource = contactSource && digestMirror.contactSource
+	}
+	if contactSource {
+		candidates = append(candidates, name+"@"+digest)
+	}
+	return candidates
+}
+
+// tagOf returns the tag of an image name without digest, if any
+func tagOf(name string) string {
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i+1:], "/") {
+		return name[i+1:]
+	}
+	return ""
+}
+
+// getPrecachingSpecImages lists the platform, index and additional images of the pre-caching
+// spec and of the cluster override specs
+// returns: []string
+func getPrecachingSpecImages(spec ranv1alpha1.PrecachingSpec, overrideSpecs map[string]*ranv1alpha1.PrecachingSpec) []string {
+	seen := make(map[string]bool)
+	var images []string
+	add := func(spec ranv1alpha1.PrecachingSpec) {
+		releases := append([]string{spec.PlatformImage}, spec.IntermediatePlatformImages...)
+		for _, image := range append(append(releases, spec.OperatorsIndexes...), spec.AdditionalImages...) {
+			if image != "" && !seen[image] {
+				seen[image] = true
+				images = append(images, image)
+			}
+		}
+	}
+	add(spec)
+	names := make([]string, 0, len(overrideSpecs))
+	for name := range overrideSpecs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		add(*overrideSpecs[name])
+	}
+	return images
+}
+
+// lookupImage looks up an image in the registries it is pulled from. An image is missing when none of
+// them has it, and unverified when a registry couldn't be queried from the hub, for example because it
+// is unreachable or rejects the credentials of the hub, which doesn't mean the clusters can't pull it
+// returns: bool (missing), string (the reason when not found), error (the lookup failure when unverified)
+func lookupImage(ctx context.Context, registryClient *registry.Client, image string,
+	digestMirrors []imageDigestMirror) (bool, string, error) {
+
+	var lookupErr error
+	for _, candidate := range getImageCandidates(image, digestMirrors) {
+		ref, err := registry.ParseReference(candidate)
+		if err != nil {
+			return true, err.Error(), nil
+		}
+		lookupCtx, cancel := context.WithTimeout(ctx, registryRequestTimeout)
+		exists, err := registryClient.ManifestExists(lookupCtx, ref)
+		cancel()
+		if err != nil {
+			lookupErr = err
+			continue
+		}
+		if exists {
+			return false, "", nil
+		}
+	}
+	if lookupErr != nil {
+		return false, "", lookupErr
+	}
+	return true, "not found", nil
+}
+
+// checkPrecachingImages looks up the images of the pre-caching spec in the registries they are
+// pulled from, before the pre-caching workload is deployed
+// returns: []string - the images missing from the registries along with the reason
+//
+//	[]string - the images that couldn't be looked up from the hub along with the error, error
+func (r *ClusterGroupUpgradeReconciler) checkPrecachingImages(ctx context.Context,
+	spec ranv1alpha1.PrecachingSpec, overrideSpecs map[string]*ranv1alpha1.PrecachingSpec) ([]string, []string, error) {
+
+	images := getPrecachingSpecImages(spec, overrideSpecs)
+	if len(images) == 0 {
+		return nil, nil, nil
+	}
+	digestMirrors, err := getImageDigestMirrors(ctx, r.Client)
+	if err != nil {
+		return nil, nil, err
+	}
+	credentials, err := getHubRegistryCredentials(ctx, r.APIReader)
+	if err != nil {
+		return nil, nil, err
+	}
+	registryClient := newRegistryClient(credentials)
+
+	var missing, unverified []string
+	for _, image := range images {
+		isMissing, reason, err := lookupImage(ctx, registryClient, image, digestMirrors)
+		if err != nil {
+			unverified = append(unverified, fmt.Sprintf("%s: %s", image, err))
+		} else if isMissing {
+			missing = append(missing, fmt.Sprintf("%s: %s", image, reason))
+		}
+	}
+	r.Log.Info("[checkPrecachingImages]", "images", len(images), "missing", missing, "unverified", unverified)
+	return missing, unverified, nil
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry/registrytest"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestPrecachePreflight_getImageCandidates(t *testing.T) {
+	digestMirrors := []imageDigestMirror{
+		{source: "quay.io/openshift-release-dev", mirrors: []string{"mirror.example.com/ocp"}, contactSource: true},
+		{source: "registry.redhat.io/rhel8/app", mirrors: []string{"mirror.example.com/app", "mirror2.example.com/app"}},
+	}
+	testcases := []struct {
+		name     string
+		image    string
+		expected []string
+	}{
+		{
+			name:     "image pulled by tag",
+			image:    "quay.io/openshift-release-dev/ocp-release:4.12.1-x86_64",
+			expected: []string{"quay.io/openshift-release-dev/ocp-release:4.12.1-x86_64"},
+		},
+		{
+			name:  "namespace mirror",
+			image: "quay.io/openshift-release-dev/ocp-release@sha256:01",
+			expected: []string{
+				"mirror.example.com/ocp/ocp-release@sha256:01",
+				"quay.io/openshift-release-dev/ocp-release@sha256:01",
+			},
+		},
+		{
+			name:  "repository mirrors never contacting the source",
+			image: "registry.redhat.io/rhel8/app:v1@sha256:02",
+			expected: []string{
+				"mirror.example.com/app@sha256:02",
+				"mirror2.example.com/app@sha256:02",
+			},
+		},
+		{
+			name:     "repository prefix is not a mirror source",
+			image:    "registry.redhat.io/rhel8/application@sha256:03",
+			expected: []string{"registry.redhat.io/rhel8/application@sha256:03"},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, getImageCandidates(tc.image, digestMirrors))
+		})
+	}
+}
+
+func TestPrecachePreflight_checkPrecachingImages(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	mirroredRelease := server.AddImage("mirror/ocp-release", "", nil, []byte("release-layer"))
+	app := server.AddImage("apps/app", "v1", nil, []byte("app-layer"))
+	index := server.AddImage("redhat/operator-index", "v4.12", nil, []byte("index-layer"))
+	_, digest, _ := strings.Cut(mirroredRelease, "@")
+
+	defaultNewRegistryClient := newRegistryClient
+	defer func() { newRegistryClient = defaultNewRegistryClient }()
+	newRegistryClient = func(credentials map[string]string) *registry.Client {
+		return registry.NewClient(server.Client(), credentials)
+	}
+
+	idms := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "release"},
+		"spec": map[string]interface{}{
+			"imageDigestMirrors": []interface{}{
+				map[string]interface{}{
+					"source":             "quay.io/openshift-release-dev",
+					"mirrors":            []interface{}{server.Host() + "/mirror"},
+					"mirrorSourcePolicy": "NeverContactSource",
+				},
+			},
+		},
+	}}
+	idms.SetGroupVersionKind(utils.ImageDigestMirrorSetGroupVersionKind())
+
+	testcases := []struct {
+		name               string
+		spec               ranv1alpha1.PrecachingSpec
+		overrideSpecs      map[string]*ranv1alpha1.PrecachingSpec
+		expectedMissing    []string
+		expectedUnverified []string
+	}{
+		{
+			name: "all images available",
+			spec: ranv1alpha1.PrecachingSpec{
+				PlatformImage:    "quay.io/openshift-release-dev/ocp-release@" + digest,
+				OperatorsIndexes: []string{server.Host() + "/redhat/operator-index:v4.12"},
+				AdditionalImages: []string{app},
+			},
+			overrideSpecs: map[string]*ranv1alpha1.PrecachingSpec{
+				"gpu": {AdditionalImages: []string{index}},
+			},
+		},
+		{
+			name: "missing images",
+			spec: ranv1alpha1.PrecachingSpec{
+				PlatformImage:    "quay.io/openshift-release-dev/ocp-release@sha256:0123",
+				AdditionalImages: []string{app, server.Host() + "/apps/typo:v1"},
+			},
+			overrideSpecs: map[string]*ranv1alpha1.PrecachingSpec{
+				"gpu": {AdditionalImages: []string{server.Host() + "/apps/gpu:v1"}},
+			},
+			expectedMissing: []string{
+				"quay.io/openshift-release-dev/ocp-release@sha256:0123: not found",
+				server.Host() + "/apps/typo:v1: not found",
+				server.Host() + "/apps/gpu:v1: not found",
+			},
+		},
+		{
+			name: "registry unreachable from the hub",
+			spec: ranv1alpha1.PrecachingSpec{
+				AdditionalImages: []string{app, "127.0.0.1:1/apps/app:v1", server.Host() + "/apps/typo:v1"},
+			},
+			expectedMissing: []string{server.Host() + "/apps/typo:v1: not found"},
+			expectedUnverified: []string{
+				"127.0.0.1:1/apps/app:v1: ",
+			},
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			fakeClient, _ := getFakeClientFromObjects(idms)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, APIReader: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			missing, unverified, err := r.checkPrecachingImages(context.TODO(), tc.spec, tc.overrideSpecs)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedMissing, missing)
+			// The lookup errors depend on the platform, only the images are compared
+			assert.Equal(t, len(tc.expectedUnverified), len(unverified))
+			for i, expected := range tc.expectedUnverified {
+				assert.True(t, strings.HasPrefix(unverified[i], expected), unverified[i])
+			}
+		})
+	}
+}
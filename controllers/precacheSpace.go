@@ -0,0 +1,358 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+// Sources of the space required for pre-caching, recorded in the pre-caching status
+const (
+	SpaceRequiredSourcePreCachingConfig = "PreCachingConfig"
+	SpaceRequiredSourceOverrides        = "Overrides"
+	SpaceRequiredSourceEstimated        = "Estimated"
+	SpaceRequiredSourceDefault          = "Default"
+)
+
+const (
+	// precacheSpaceExpansionFactor converts the compressed layer sizes reported by the registry into the
+	// space taken by the unpacked layers in the container storage of the spoke. The registries only report
+	// the gzip compressed sizes, which is usually a ratio of 2 to 3 for the binaries and packages making
+	// the layers of the release and operator images. The factor is the low end of that range
+	precacheSpaceExpansionFactor = 2
+	// precacheSpaceMarginPercent is added to the unpacked size, so that the estimation covers layers
+	// compressed up to 3 times
+	precacheSpaceMarginPercent = 50
+	// precacheSpaceBuffer covers the other disk allocations between the pre-caching and the upgrade, as the
+	// buffer of the default space required
+	precacheSpaceBuffer = 5 * 1024 * 1024 * 1024
+	// precacheSpaceEstimateTimeout bounds all the registry and update graph lookups of an estimate, after
+	// which the default space required is used
+	precacheSpaceEstimateTimeout = 2 * time.Minute
+	// maxCachedReleaseLayers bounds the releases whose layers are kept in memory
+	maxCachedReleaseLayers = 16
+)
+
+// cachedReleaseLayers keeps the layers of the releases already estimated, so that the requeues of the
+// pre-caching and the other upgrades to the same releases do not list them again from the registries
+var cachedReleaseLayers = newReleaseLayersCache(maxCachedReleaseLayers)
+
+// releaseLayersCache maps the manifest digest of a release image and the exclusion patterns to the layers
+// of the release and its components. The oldest releases are evicted first
+type releaseLayersCache struct {
+	mutex   sync.Mutex
+	size    int
+	keys    []string
+	entries map[string]map[string]int64
+}
+
+// newReleaseLayersCache creates a cache holding the layers of up to size releases
+// returns: *releaseLayersCache
+func newReleaseLayersCache(size int) *releaseLayersCache {
+	return &releaseLayersCache{size: size, entries: make(map[string]map[string]int64)}
+}
+
+// get returns a copy of the cached layers
+// returns: map[string]int64, bool
+func (c *releaseLayersCache) get(key string) (map[string]int64, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	layers, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return copyLayers(layers), true
+}
+
+// add caches a copy of the layers, evicting the oldest release when the cache is full
+func (c *releaseLayersCache) add(key string, layers map[string]int64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		if len(c.keys) >= c.size {
+			delete(c.entries, c.keys[0])
+			c.keys = c.keys[1:]
+		}
+		c.keys = append(c.keys, key)
+	}
+	c.entries[key] = copyLayers(layers)
+}
+
+// copyLayers copies the layer sizes so that the cached entries are not shared with the callers
+// returns: map[string]int64
+func copyLayers(layers map[string]int64) map[string]int64 {
+	copied := make(map[string]int64, len(layers))
+	for digest, size := range layers {
+		copied[digest] = size
+	}
+	return copied
+}
+
+// getEstimatedSpaceRequired estimates the space required for pre-caching, falling back
+// to the default value when the estimation is not possible
+// returns: spaceRequired (GiB), source
+func (r *ClusterGroupUpgradeReconciler) getEstimatedSpaceRequired(
+	ctx context.Context, clusters []string, spec ranv1alpha1.PrecachingSpec,
+	policies []*unstructured.Unstructured) (string, string) {
+
+	var spaceRequired string
+	estimateCtx, cancel := context.WithTimeout(ctx, precacheSpaceEstimateTimeout)
+	defer cancel()
+	size, err := r.estimateSpaceRequired(estimateCtx, clusters, spec, policies)
+	if err == nil {
+		spaceRequired, err = parseSpaceRequired(strconv.FormatInt(size, 10))
+		if err == nil {
+			r.Log.Info("[getEstimatedSpaceRequired]", "spaceRequired (GiB)", spaceRequired)
+			return spaceRequired, SpaceRequiredSourceEstimated
+		}
+	}
+	r.Log.Info("[getEstimatedSpaceRequired]", "unable to estimate the space required, using the default", err.Error())
+	// The default value is a constant known to be valid
+	spaceRequired, _ = parseSpaceRequired(utils.SpaceRequiredForPrecache)
+	return spaceRequired, SpaceRequiredSourceDefault
+}
+
+// estimateSpaceRequired estimates the space required for pre-caching from the layer sizes of the
+// images to be pre-cached. Layers known to be present on all the clusters are not counted.
+// returns: size (bytes), error
+func (r *ClusterGroupUpgradeReconciler) estimateSpaceRequired(
+	ctx context.Context, clusters []string, spec ranv1alpha1.PrecachingSpec,
+	policies []*unstructured.Unstructured) (int64, error) {
+
+	if len(spec.OperatorsIndexes) > 0 && len(spec.OperatorsImages) == 0 {
+		return 0, fmt.Errorf("operator images have not been resolved on the hub")
+	}
+	credentials, err := getHubRegistryCredentials(ctx, r.APIReader)
+	if err != nil {
+		return 0, err
+	}
+	client := newRegistryClient(credentials)
+
+	var images []string
+	images = append(images, spec.OperatorsImages...)
+	images = append(images, spec.AdditionalImages...)
+	layers, err := getImagesLayers(ctx, client, images)
+	if err != nil {
+		return 0, err
+	}
+	for _, releaseImage := range append([]string{spec.PlatformImage}, spec.IntermediatePlatformImages...) {
+		if releaseImage == "" {
+			continue
+		}
+		releaseLayers, err := getReleaseLayers(ctx, client, releaseImage, spec.ExcludePrecachePatterns)
+		if err != nil {
+			return 0, err
+		}
+		for digest, layerSize := range releaseLayers {
+			layers[digest] = layerSize
+		}
+	}
+
+	present, err := r.getPresentLayers(ctx, client, clusters, spec, policies)
+	if err != nil {
+		// Count every layer when the content of the clusters is unknown
+		r.Log.Info("[estimateSpaceRequired]", "unable to get the layers present on the clusters", err.Error())
+		present = nil
+	}
+
+	var size int64
+	for digest, layerSize := range layers {
+		if !present[digest] {
+			size += layerSize
+		}
+	}
+	r.Log.Info("[estimateSpaceRequired]", "layers", len(layers),
+		"present layers", len(present), "compressed size", size)
+	return getSpaceRequiredFromCompressedSize(size), nil
+}
+
+// getSpaceRequiredFromCompressedSize converts the compressed size of the layers to pre-cache into the space
+// required on the spoke, including the safety margin and the buffer
+// returns: size (bytes)
+func getSpaceRequiredFromCompressedSize(size int64) int64 {
+	unpacked := size * precacheSpaceExpansionFactor
+	return unpacked + unpacked*precacheSpaceMarginPercent/100 + precacheSpaceBuffer
+}
+
+// getReleaseLayers gets the layers of the release image and its component images, leaving out the components
+// matching the exclusion patterns. The layers are cached by the manifest digest of the release image
+// returns: map[string]int64 (layer digest to compressed size), error
+func getReleaseLayers(ctx context.Context, client *registry.Client,
+	release string, excludePatterns []string) (map[string]int64, error) {
+
+	ref, err := registry.ParseReference(release)
+	if err != nil {
+		return nil, err
+	}
+	digest := ref.Digest
+	if digest == "" {
+		// The tag of a release may move, the lookup of its manifest is the only request then
+		manifest, err := client.GetManifest(ctx, ref)
+		if err != nil {
+			return nil, err
+		}
+		digest = manifest.Digest
+	}
+	patterns := append([]string{}, excludePatterns...)
+	sort.Strings(patterns)
+	cacheKey := digest + "/" + strings.Join(patterns, ",")
+	if layers, ok := cachedReleaseLayers.get(cacheKey); ok {
+		return layers, nil
+	}
+
+	images, err := getReleaseImages(ctx, client, release, excludePatterns)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := getImagesLayers(ctx, client, images)
+	if err != nil {
+		return nil, err
+	}
+	cachedReleaseLayers.add(cacheKey, layers)
+	return layers, nil
+}
+
+// getReleaseImages lists the release image and its component images, leaving out the
+// components matching the exclusion patterns the same way the pre-caching workload does
+// returns: []string, error
+func getReleaseImages(ctx context.Context, client *registry.Client,
+	release string, excludePatterns []string) ([]string, error) {
+
+	references, err := client.ReleaseImageReferences(ctx, release)
+	if err != nil {
+		return nil, err
+	}
+	images := []string{release}
+	for name, image := range references {
+		// The workload matches the patterns against "<name>$<pull spec>" records
+		if matchesAnyPattern(name+"$"+image, excludePatterns) {
+			continue
+		}
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// matchesAnyPattern checks whether the record matches one of the patterns. Patterns that are
+// not valid regular expressions are matched as plain strings
+func matchesAnyPattern(record string, patterns []string) bool {
+	for _, pattern := range patterns {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			if strings.Contains(record, pattern) {
+				return true
+			}
+			continue
+		}
+		if re.MatchString(record) {
+			return true
+		}
+	}
+	return false
+}
+
+// getImagesLayers gets the layers of the images
+// returns: map[string]int64 (layer digest to compressed size), error
+func getImagesLayers(ctx context.Context, client *registry.Client, images []string) (map[string]int64, error) {
+	layers := make(map[string]int64)
+	for _, image := range images {
+		imageLayers, err := client.ImageLayers(ctx, image)
+		if err != nil {
+			return nil, err
+		}
+		for _, layer := range imageLayers {
+			layers[layer.Digest] = layer.Size
+		}
+	}
+	return layers, nil
+}
+
+// getPresentLayers gets the layers of the release currently installed on every cluster.
+// The current release is known when the cluster reports its version through the
+// version.openshift.io cluster claim and that version is found in the update graph.
+// returns: map[string]bool (layers present on all the clusters), error
+func (r *ClusterGroupUpgradeReconciler) getPresentLayers(
+	ctx context.Context, client *registry.Client, clusters []string,
+	spec ranv1alpha1.PrecachingSpec, policies []*unstructured.Unstructured) (map[string]bool, error) {
+
+	if len(clusters) == 0 {
+		return nil, nil
+	}
+	versionInfo, err := extractOCPVersionInfoFromPolicies(policies)
+	if err != nil {
+		return nil, err
+	}
+	if versionInfo.upstream == "" || versionInfo.channel == "" {
+		return nil, fmt.Errorf("no update graph available to look up the current releases")
+	}
+
+	versions := make(map[string]bool)
+	for _, cluster := range clusters {
+		version, err := r.getClusterOpenshiftVersion(ctx, cluster)
+		if err != nil {
+			return nil, err
+		}
+		versions[version] = true
+	}
+
+	var present map[string]bool
+	for version := range versions {
+		release, err := r.getImageForVersionFromUpdateGraph(ctx, versionInfo.upstream, versionInfo.channel, version)
+		if err != nil {
+			return nil, err
+		}
+		layers, err := getReleaseLayers(ctx, client, release, spec.ExcludePrecachePatterns)
+		if err != nil {
+			return nil, err
+		}
+		// Only the layers present on all the clusters can be left out of the estimate
+		if present == nil {
+			present = make(map[string]bool)
+			for digest := range layers {
+				present[digest] = true
+			}
+			continue
+		}
+		for digest := range present {
+			if _, ok := layers[digest]; !ok {
+				delete(present, digest)
+			}
+		}
+	}
+	return present, nil
+}
+
+// getClusterOpenshiftVersion gets the OpenShift version claimed by the managed cluster, or else
+// the version reported in its openshiftVersion label
+// returns: version, error
+func (r *ClusterGroupUpgradeReconciler) getClusterOpenshiftVersion(ctx context.Context, cluster string) (string, error) {
+	managedCluster := &clusterv1.ManagedCluster{}
+	if err := r.Get(ctx, types.NamespacedName{Name: cluster}, managedCluster); err != nil {
+		return "", err
+	}
+	for _, claim := range managedCluster.Status.ClusterClaims {
+		if claim.Name == utils.OpenshiftVersionClaimName && claim.Value != "" {
+			return claim.Value, nil
+		}
+	}
+	if version := managedCluster.GetLabels()[utils.OpenshiftVersionLabelName]; version != "" {
+		return version, nil
+	}
+	return "", fmt.Errorf("cluster %s does not claim its OpenShift version", cluster)
+}
@@ -0,0 +1,274 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry/registrytest"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+)
+
+// newClusterVersionTestPolicy creates a policy holding a ClusterVersion object
+func newClusterVersionTestPolicy(upstream, channel, version string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "policy.open-cluster-management.io/v1",
+		"kind":       "Policy",
+		"metadata":   map[string]interface{}{"name": "cluster-version", "namespace": "default"},
+		"spec": map[string]interface{}{
+			"policy-templates": []interface{}{
+				map[string]interface{}{
+					"objectDefinition": map[string]interface{}{
+						"spec": map[string]interface{}{
+							"object-templates": []interface{}{
+								map[string]interface{}{
+									"complianceType": "musthave",
+									"objectDefinition": map[string]interface{}{
+										"apiVersion": "config.openshift.io/v1",
+										"kind":       "ClusterVersion",
+										"spec": map[string]interface{}{
+											"upstream":      upstream,
+											"channel":       channel,
+											"desiredUpdate": map[string]interface{}{"version": version},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}}
+}
+
+// addTestRelease adds a release image whose components have a single layer of the given size.
+// Components of the same name and size share their layer across releases
+func addTestRelease(server *registrytest.Server, tag string, components map[string]int) string {
+	var tags []map[string]interface{}
+	for name, size := range components {
+		image := server.AddImage("ocp/components", name+"-"+tag, nil,
+			[]byte(fmt.Sprintf("%s%s", name, strings.Repeat("x", size-len(name)))))
+		tags = append(tags, map[string]interface{}{"name": name, "from": map[string]string{"name": image}})
+	}
+	references, _ := json.Marshal(map[string]interface{}{"spec": map[string]interface{}{"tags": tags}})
+	return server.AddImage("ocp/release", tag, nil,
+		registrytest.Layer(map[string]string{"release-manifests/image-references": string(references)}))
+}
+
+func TestPrecacheSpace_estimateSpaceRequired(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+
+	// Layers are referenced by size only, their content is not read
+	targetRelease := addTestRelease(server, "4.12.2", map[string]int{"etcd": 1000, "aws-driver": 2000})
+	currentRelease := addTestRelease(server, "4.12.1", map[string]int{"etcd": 1000, "aws-driver": 3000})
+	operatorImage := server.AddImage("operators/ptp", "v4.12", nil, []byte("ptp-layer"))
+
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"nodes": []map[string]string{
+				{"version": "4.12.1", "payload": currentRelease},
+				{"version": "4.12.2", "payload": targetRelease},
+			},
+		})
+	}))
+	defer graph.Close()
+
+	defaultNewRegistryClient := newRegistryClient
+	defer func() { newRegistryClient = defaultNewRegistryClient }()
+	newRegistryClient = func(credentials map[string]string) *registry.Client {
+		return registry.NewClient(server.Client(), credentials)
+	}
+
+	newCluster := func(name, version string) *clusterv1.ManagedCluster {
+		cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if version != "" {
+			cluster.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{
+				{Name: utils.OpenshiftVersionClaimName, Value: version},
+			}
+		}
+		return cluster
+	}
+	fakeClient, _ := getFakeClientFromObjects(
+		newCluster("spoke1", "4.12.1"), newCluster("spoke2", "4.12.1"), newCluster("spoke3", ""))
+	r := &ClusterGroupUpgradeReconciler{Client: fakeClient, APIReader: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+	releaseLayers := func(release string) int64 {
+		layers, err := getImagesLayers(context.TODO(), newRegistryClient(nil), []string{release})
+		assert.NoError(t, err)
+		var size int64
+		for _, layerSize := range layers {
+			size += layerSize
+		}
+		return size
+	}
+	policies := []*unstructured.Unstructured{newClusterVersionTestPolicy(graph.URL, "stable-4.12", "4.12.2")}
+
+	testcases := []struct {
+		name     string
+		clusters []string
+		spec     ranv1alpha1.PrecachingSpec
+		// expected compressed size before expansion
+		expected int64
+		err      bool
+	}{
+		{
+			name:     "release, operators and additional images, current release unknown",
+			clusters: []string{"spoke1", "spoke3"},
+			spec: ranv1alpha1.PrecachingSpec{
+				PlatformImage:    targetRelease,
+				OperatorsIndexes: []string{"index"},
+				OperatorsImages:  []string{operatorImage},
+				AdditionalImages: []string{operatorImage},
+			},
+			expected: releaseLayers(targetRelease) + 1000 + 2000 + int64(len("ptp-layer")),
+		},
+		{
+			name:     "layers of the current release are left out",
+			clusters: []string{"spoke1", "spoke2"},
+			spec: ranv1alpha1.PrecachingSpec{
+				PlatformImage: targetRelease,
+			},
+			expected: releaseLayers(targetRelease) + 2000,
+		},
+		{
+			name:     "excluded components are left out",
+			clusters: []string{"spoke1", "spoke3"},
+			spec: ranv1alpha1.PrecachingSpec{
+				PlatformImage:           targetRelease,
+				ExcludePrecachePatterns: []string{"aws"},
+			},
+			expected: releaseLayers(targetRelease) + 1000,
+		},
+		{
+			name:     "operator images not resolved",
+			clusters: []string{"spoke1"},
+			spec: ranv1alpha1.PrecachingSpec{
+				PlatformImage:    targetRelease,
+				OperatorsIndexes: []string{"index"},
+			},
+			err: true,
+		},
+		{
+			name:     "missing image",
+			clusters: []string{"spoke1"},
+			spec: ranv1alpha1.PrecachingSpec{
+				AdditionalImages: []string{server.Host() + "/missing/image:v1"},
+			},
+			err: true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			size, err := r.estimateSpaceRequired(context.TODO(), tc.clusters, tc.spec, policies)
+			if tc.err {
+				assert.Error(t, err)
+				spaceRequired, source := r.getEstimatedSpaceRequired(context.TODO(), tc.clusters, tc.spec, policies)
+				assert.Equal(t, "35", spaceRequired)
+				assert.Equal(t, SpaceRequiredSourceDefault, source)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, getSpaceRequiredFromCompressedSize(tc.expected), size)
+			spaceRequired, source := r.getEstimatedSpaceRequired(context.TODO(), tc.clusters, tc.spec, policies)
+			// the buffer of 5 GiB, rounded up
+			assert.Equal(t, "6", spaceRequired)
+			assert.Equal(t, SpaceRequiredSourceEstimated, source)
+		})
+	}
+
+	// The registry lookups stop with the context of the estimate
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	spaceRequired, source := r.getEstimatedSpaceRequired(ctx, []string{"spoke1"},
+		ranv1alpha1.PrecachingSpec{AdditionalImages: []string{operatorImage}}, policies)
+	assert.Equal(t, "35", spaceRequired)
+	assert.Equal(t, SpaceRequiredSourceDefault, source)
+}
+
+func TestPrecacheSpace_matchesAnyPattern(t *testing.T) {
+	assert.True(t, matchesAnyPattern("aws-ebs-csi-driver$quay.io/ocp@sha256:01", []string{"", "aws"}))
+	assert.True(t, matchesAnyPattern("vsphere-problem-detector$quay.io/ocp@sha256:01", []string{"^vsphere"}))
+	assert.True(t, matchesAnyPattern("broken[$quay.io/ocp@sha256:01", []string{"broken["}))
+	assert.False(t, matchesAnyPattern("etcd$quay.io/ocp@sha256:01", []string{"aws", "^vsphere"}))
+}
+
+func TestPrecacheSpace_getSpaceRequiredFromCompressedSize(t *testing.T) {
+	gib := int64(1024 * 1024 * 1024)
+	// 10 GiB compressed unpack to 20 GiB, with a 10 GiB margin and the 5 GiB buffer
+	assert.Equal(t, 35*gib, getSpaceRequiredFromCompressedSize(10*gib))
+	assert.Equal(t, 5*gib, getSpaceRequiredFromCompressedSize(0))
+}
+
+func TestPrecacheSpace_getReleaseLayers(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	release := addTestRelease(server, "4.12.3", map[string]int{"etcd": 1000, "aws-driver": 2000})
+	client := registry.NewClient(server.Client(), nil)
+
+	defaultCachedReleaseLayers := cachedReleaseLayers
+	defer func() { cachedReleaseLayers = defaultCachedReleaseLayers }()
+	cachedReleaseLayers = newReleaseLayersCache(maxCachedReleaseLayers)
+
+	countRequests := func() int {
+		count := 0
+		for _, requests := range server.Requests {
+			count += requests
+		}
+		return count
+	}
+
+	layers, err := getReleaseLayers(context.TODO(), client, release, nil)
+	assert.NoError(t, err)
+	assert.Len(t, layers, 3)
+	requests := countRequests()
+
+	// The release is not listed again from the registry
+	cached, err := getReleaseLayers(context.TODO(), client, release, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, layers, cached)
+	assert.Equal(t, requests, countRequests())
+
+	// Other exclusion patterns list the release again
+	excluded, err := getReleaseLayers(context.TODO(), client, release, []string{"aws"})
+	assert.NoError(t, err)
+	assert.Len(t, excluded, 2)
+	assert.Greater(t, countRequests(), requests)
+
+	// Releases referenced by tag are looked up by the digest of their manifest
+	requests = countRequests()
+	tagged, err := getReleaseLayers(context.TODO(), client, server.Host()+"/ocp/release:4.12.3", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, layers, tagged)
+	assert.Equal(t, requests+1, countRequests())
+}
+
+func TestPrecacheSpace_newReleaseLayersCache(t *testing.T) {
+	cache := newReleaseLayersCache(2)
+	cache.add("release1", map[string]int64{"layer1": 1})
+	cache.add("release2", map[string]int64{"layer2": 2})
+	cache.add("release3", map[string]int64{"layer3": 3})
+
+	_, ok := cache.get("release1")
+	assert.False(t, ok)
+	layers, ok := cache.get("release3")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]int64{"layer3": 3}, layers)
+
+	// The cached entries are not shared with the callers
+	layers["layer4"] = 4
+	layers, _ = cache.get("release3")
+	assert.Len(t, layers, 1)
+}
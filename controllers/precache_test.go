@@ -1,8 +1,17 @@
 package controllers
 
 import (
-	"github.com/stretchr/testify/assert"
+	"context"
 	"testing"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func TestPrecache_parseSpaceRequired(t *testing.T) {
@@ -120,3 +129,165 @@ func TestPrecache_parseSpaceRequired(t *testing.T) {
 		})
 	}
 }
+
+func TestPrecache_precachingFsmSkipsCachedClusters(t *testing.T) {
+	spec := &ranv1alpha1.PrecachingSpec{
+		PlatformImage:    "quay.io/openshift-release-dev/ocp-release:4.12.1-x86_64",
+		AdditionalImages: []string{"quay.io/apps/app:v1"},
+		SpaceRequired:    "40",
+	}
+	recordedSpec := *spec
+	// The space required does not change the pre-cached content
+	recordedSpec.SpaceRequired = "30"
+	hash, err := getPrecachingSpecHash(&recordedSpec)
+	assert.NoError(t, err)
+	otherHash, err := getPrecachingSpecHash(&ranv1alpha1.PrecachingSpec{PlatformImage: spec.PlatformImage})
+	assert.NoError(t, err)
+
+	inventory := `[{"repoTags": ["quay.io/openshift-release-dev/ocp-release:4.12.1-x86_64"]},
+		{"repoTags": ["quay.io/apps/app:v1"], "repoDigests": ["quay.io/apps/app@sha256:0123"]}]`
+
+	testcases := []struct {
+		name            string
+		annotations     map[string]string
+		inventory       string
+		forceRefresh    bool
+		expectedState   string
+		expectedSkipped []string
+	}{
+		{
+			name:            "content already pre-cached",
+			annotations:     map[string]string{utils.PrecachedSpecAnnotation: hash},
+			inventory:       inventory,
+			expectedState:   PrecacheStateSucceeded,
+			expectedSkipped: []string{"spoke1"},
+		},
+		{
+			name:            "pre-cached content without inventory",
+			annotations:     map[string]string{utils.PrecachedSpecAnnotation: hash},
+			expectedState:   PrecacheStateSucceeded,
+			expectedSkipped: []string{"spoke1"},
+		},
+		{
+			name:          "unreadable inventory",
+			annotations:   map[string]string{utils.PrecachedSpecAnnotation: hash},
+			inventory:     `not json`,
+			expectedState: PrecacheStatePreparingToStart,
+		},
+		{
+			name:          "pre-cached images missing from the inventory",
+			annotations:   map[string]string{utils.PrecachedSpecAnnotation: hash},
+			inventory:     `[{"repoTags": ["quay.io/openshift-release-dev/ocp-release:4.12.1-x86_64"]}]`,
+			expectedState: PrecacheStatePreparingToStart,
+		},
+		{
+			name:          "different content pre-cached",
+			annotations:   map[string]string{utils.PrecachedSpecAnnotation: otherHash},
+			inventory:     inventory,
+			expectedState: PrecacheStatePreparingToStart,
+		},
+		{
+			name:          "nothing pre-cached",
+			inventory:     inventory,
+			expectedState: PrecacheStatePreparingToStart,
+		},
+		{
+			name:          "force refresh",
+			annotations:   map[string]string{utils.PrecachedSpecAnnotation: hash},
+			inventory:     inventory,
+			forceRefresh:  true,
+			expectedState: PrecacheStatePreparingToStart,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			enable := false
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"},
+				Spec:       ranv1alpha1.ClusterGroupUpgradeSpec{Enable: &enable, PreCachingForceRefresh: tc.forceRefresh},
+				Status: ranv1alpha1.ClusterGroupUpgradeStatus{
+					Precaching: &ranv1alpha1.PrecachingStatus{Spec: spec, Status: map[string]string{}},
+				},
+			}
+			utils.SetStatusCondition(&cgu.Status.Conditions, utils.ConditionTypes.PrecacheSpecValid,
+				utils.ConditionReasons.PrecacheSpecIsWellFormed, metav1.ConditionTrue, "")
+			managedCluster := &clusterv1.ManagedCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: "spoke1", Annotations: tc.annotations},
+			}
+			objs := []client.Object{managedCluster}
+			if tc.inventory != "" {
+				objs = append(objs, newInventoryConfigMap("spoke1", tc.inventory))
+			}
+			fakeClient, _ := getFakeClientFromObjects(objs...)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+			assert.NoError(t, r.precachingFsm(context.TODO(), cgu, []string{"spoke1"}, nil))
+			assert.Equal(t, tc.expectedState, cgu.Status.Precaching.Status["spoke1"])
+			assert.Equal(t, tc.expectedSkipped, cgu.Status.Precaching.SkippedClusters)
+		})
+	}
+}
+
+func TestPrecache_recordPrecachingSpec(t *testing.T) {
+	spec := &ranv1alpha1.PrecachingSpec{PlatformImage: "quay.io/openshift-release-dev/ocp-release:4.12.1-x86_64"}
+	overrideSpec := &ranv1alpha1.PrecachingSpec{
+		PlatformImage:    spec.PlatformImage,
+		AdditionalImages: []string{"quay.io/apps/gpu:v1"},
+	}
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{
+		Status: ranv1alpha1.ClusterGroupUpgradeStatus{
+			Precaching: &ranv1alpha1.PrecachingStatus{
+				Spec:             spec,
+				ClusterOverrides: map[string]string{"spoke2": "gpu"},
+				OverrideSpecs:    map[string]*ranv1alpha1.PrecachingSpec{"gpu": overrideSpec},
+			},
+		},
+	}
+	fakeClient, _ := getFakeClientFromObjects(
+		&clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "spoke1"}},
+		&clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: "spoke2"}},
+		newInventoryConfigMap("spoke1", `[{"repoTags": ["quay.io/openshift-release-dev/ocp-release:4.12.1-x86_64"]}]`),
+		newInventoryConfigMap("spoke2", `[{"repoTags": ["quay.io/openshift-release-dev/ocp-release:4.12.1-x86_64"]},
+			{"repoTags": ["quay.io/apps/gpu:v1"]}]`),
+	)
+	r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+	for cluster, expectedSpec := range map[string]*ranv1alpha1.PrecachingSpec{"spoke1": spec, "spoke2": overrideSpec} {
+		assert.False(t, r.isPrecachingSpecCached(context.TODO(), cgu, cluster))
+		assert.NoError(t, r.recordPrecachingSpec(context.TODO(), cgu, cluster))
+		assert.True(t, r.isPrecachingSpecCached(context.TODO(), cgu, cluster))
+
+		managedCluster := &clusterv1.ManagedCluster{}
+		assert.NoError(t, fakeClient.Get(context.TODO(), client.ObjectKey{Name: cluster}, managedCluster))
+		expectedHash, err := getPrecachingSpecHash(expectedSpec)
+		assert.NoError(t, err)
+		assert.Equal(t, expectedHash, managedCluster.Annotations[utils.PrecachedSpecAnnotation])
+
+		assert.NoError(t, r.clearPrecachingSpec(context.TODO(), cluster))
+		assert.False(t, r.isPrecachingSpecCached(context.TODO(), cgu, cluster))
+	}
+	// The record of a removed cluster is gone
+	assert.NoError(t, r.clearPrecachingSpec(context.TODO(), "spoke3"))
+}
+
+func newInventoryConfigMap(cluster, images string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: utils.ImageInventoryConfigMapName, Namespace: cluster},
+		Data:       map[string]string{inventoryImagesKey: images},
+	}
+}
+
+func TestPrecache_getImagesMissingFromInventory(t *testing.T) {
+	inventoryImages := map[string]bool{
+		"quay.io/openshift-release-dev/ocp-release:4.12.1-x86_64": true,
+		"quay.io/apps/app@sha256:0123":                            true,
+		"docker.io/library/busybox:latest":                        true,
+	}
+	spec := &ranv1alpha1.PrecachingSpec{
+		PlatformImage:    "quay.io/openshift-release-dev/ocp-release:4.12.1-x86_64",
+		OperatorsImages:  []string{"quay.io/apps/app:v1@sha256:0123", "quay.io/apps/operator@sha256:4567"},
+		AdditionalImages: []string{"busybox", "quay.io/apps/app:v2"},
+	}
+	assert.Equal(t, []string{"quay.io/apps/operator@sha256:4567", "quay.io/apps/app:v2"},
+		getImagesMissingFromInventory(spec, inventoryImages))
+}
@@ -0,0 +1,290 @@
+package registry
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// configsLabel is the index image label pointing at the file based catalog directory
+const configsLabel = "operators.operatorframework.io.index.configs.v1"
+
+// maxResolvedCatalogs bounds the resolutions of index images kept in memory
+const maxResolvedCatalogs = 32
+
+// ErrNoFileBasedCatalog is returned when the index image does not ship a file based catalog
+var ErrNoFileBasedCatalog = errors.New("index image does not contain a file based catalog")
+
+// resolvedCatalogs keeps the bundle images resolved from the index images, so that an index image is only
+// streamed again when its tag moves to a new image
+var resolvedCatalogs = newCatalogCache(maxResolvedCatalogs)
+
+// catalogCache maps the manifest digest of an index image and the requested channels to the bundle images
+// resolved from it. The oldest resolutions are evicted first
+type catalogCache struct {
+	mutex   sync.Mutex
+	size    int
+	keys    []string
+	entries map[string]map[string][]string
+}
+
+// newCatalogCache creates a cache holding up to size resolutions
+// returns: *catalogCache
+func newCatalogCache(size int) *catalogCache {
+	return &catalogCache{size: size, entries: make(map[string]map[string][]string)}
+}
+
+// catalogCacheKey returns the key of the resolution of the channels from the index image with the manifest digest
+// returns: string
+func catalogCacheKey(manifestDigest string, channels map[string]string) string {
+	records := make([]string, 0, len(channels))
+	for pkg, channel := range channels {
+		records = append(records, pkg+":"+channel)
+	}
+	sort.Strings(records)
+	return manifestDigest + "/" + strings.Join(records, ",")
+}
+
+// get returns a copy of the cached resolution
+// returns: map[string][]string, bool
+func (c *catalogCache) get(key string) (map[string][]string, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	images, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	return copyBundleImages(images), true
+}
+
+// add caches a copy of the resolution, evicting the oldest one when the cache is full
+func (c *catalogCache) add(key string, images map[string][]string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		if len(c.keys) >= c.size {
+			delete(c.entries, c.keys[0])
+			c.keys = c.keys[1:]
+		}
+		c.keys = append(c.keys, key)
+	}
+	c.entries[key] = copyBundleImages(images)
+}
+
+// copyBundleImages copies the bundle images of the packages
+// returns: map[string][]string
+func copyBundleImages(images map[string][]string) map[string][]string {
+	copied := make(map[string][]string, len(images))
+	for pkg, pkgImages := range images {
+		copied[pkg] = append([]string{}, pkgImages...)
+	}
+	return copied
+}
+
+// catalogObject is the subset of the olm.channel and olm.bundle schemas used for resolution
+type catalogObject struct {
+	Schema        string                `json:"schema"`
+	Name          string                `json:"name"`
+	Package       string                `json:"package"`
+	Entries       []catalogChannelEntry `json:"entries,omitempty"`
+	RelatedImages []struct {
+		Image string `json:"image"`
+	} `json:"relatedImages,omitempty"`
+}
+
+// catalogChannelEntry is a bundle of a channel, with the bundles it upgrades from
+type catalogChannelEntry struct {
+	Name     string   `json:"name"`
+	Replaces string   `json:"replaces,omitempty"`
+	Skips    []string `json:"skips,omitempty"`
+}
+
+// ParsePackagesAndChannels converts package:channel records into a package to channel map
+// returns: map[string]string, error
+func ParsePackagesAndChannels(packagesAndChannels []string) (map[string]string, error) {
+	channels := make(map[string]string)
+	for _, record := range packagesAndChannels {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+		items := strings.Split(record, ":")
+		if len(items) != 2 || strings.TrimSpace(items[0]) == "" || strings.TrimSpace(items[1]) == "" {
+			return nil, fmt.Errorf("operators record %s is malformed", record)
+		}
+		channels[strings.TrimSpace(items[0])] = strings.TrimSpace(items[1])
+	}
+	return channels, nil
+}
+
+// ResolveBundleImages renders the file based catalog of the index image and returns the
+// related images of the latest bundle in the requested channel of each package.
+// channels maps a package name to its channel. Packages missing from the catalog are
+// not present in the returned map. The resolutions are cached per index image content, so
+// that only the manifest of an index image already resolved is fetched
+// returns: map[string][]string, error
+func (c *Client) ResolveBundleImages(
+	ctx context.Context, index string, channels map[string]string) (map[string][]string, error) {
+
+	ref, err := ParseReference(index)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := c.GetManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	cacheKey := catalogCacheKey(manifest.Digest, channels)
+	if images, ok := resolvedCatalogs.get(cacheKey); ok {
+		return images, nil
+	}
+	config, err := c.GetImageConfig(ctx, ref, manifest)
+	if err != nil {
+		return nil, err
+	}
+	configsDir, ok := config.Config.Labels[configsLabel]
+	if !ok {
+		return nil, ErrNoFileBasedCatalog
+	}
+	configsDir = strings.Trim(path.Clean("/"+configsDir), "/") + "/"
+
+	// Files of upper layers replace the same files of lower layers
+	files := make(map[string][]catalogObject)
+	for _, layer := range manifest.Layers {
+		if err := c.readCatalogLayer(ctx, ref, layer, configsDir, channels, files); err != nil {
+			return nil, err
+		}
+	}
+	if len(files) == 0 {
+		return nil, ErrNoFileBasedCatalog
+	}
+
+	var objects []catalogObject
+	for _, fileObjects := range files {
+		objects = append(objects, fileObjects...)
+	}
+	images := extractRelatedImages(objects, channels)
+	resolvedCatalogs.add(cacheKey, images)
+	return images, nil
+}
+
+// readCatalogLayer reads the catalog files of a single layer into files
+func (c *Client) readCatalogLayer(ctx context.Context, ref Reference, layer Descriptor,
+	configsDir string, channels map[string]string, files map[string][]catalogObject) error {
+
+	return c.walkLayer(ctx, ref, layer, func(name string, header *tar.Header, content io.Reader) error {
+		if !strings.HasPrefix(name, configsDir) {
+			return nil
+		}
+		dir, base := path.Split(name)
+		if strings.HasPrefix(base, ".wh.") {
+			whiteout := dir + strings.TrimPrefix(base, ".wh.")
+			for file := range files {
+				if file == whiteout || strings.HasPrefix(file, whiteout+"/") {
+					delete(files, file)
+				}
+			}
+			return nil
+		}
+		if header.Typeflag != tar.TypeReg {
+			return nil
+		}
+		switch path.Ext(name) {
+		case ".json", ".yaml", ".yml":
+		default:
+			return nil
+		}
+		objects, err := decodeCatalogFile(content, channels)
+		if err != nil {
+			return fmt.Errorf("unable to decode catalog file %s: %w", name, err)
+		}
+		files[name] = objects
+		return nil
+	})
+}
+
+// decodeCatalogFile decodes a stream of catalog objects, keeping only the channels and
+// bundles of the requested packages
+func decodeCatalogFile(reader io.Reader, channels map[string]string) ([]catalogObject, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	var objects []catalogObject
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var object catalogObject
+		err := decoder.Decode(&object)
+		if err == io.EOF {
+			return objects, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := channels[object.Package]; !ok {
+			continue
+		}
+		if object.Schema == "olm.channel" || object.Schema == "olm.bundle" {
+			objects = append(objects, object)
+		}
+	}
+}
+
+// extractRelatedImages selects the head bundle of each requested channel and
+// returns the sorted related images of each package found in the catalog
+func extractRelatedImages(objects []catalogObject, channels map[string]string) map[string][]string {
+	bundles := make(map[string]string)
+	for _, object := range objects {
+		if object.Schema != "olm.channel" || object.Name != channels[object.Package] || len(object.Entries) == 0 {
+			continue
+		}
+		bundles[object.Package] = channelHead(object.Entries)
+	}
+
+	images := make(map[string][]string)
+	for _, object := range objects {
+		if object.Schema != "olm.bundle" || bundles[object.Package] != object.Name {
+			continue
+		}
+		images[object.Package] = []string{}
+		for _, related := range object.RelatedImages {
+			if related.Image != "" {
+				images[object.Package] = append(images[object.Package], related.Image)
+			}
+		}
+		sort.Strings(images[object.Package])
+	}
+	return images
+}
+
+// channelHead returns the bundle of the channel that no other bundle replaces or skips, the one OLM installs
+// from the channel. The entries are not ordered in the catalog. When the upgrade graph has several heads, which
+// opm rejects, the last one listed is returned
+// returns: string
+func channelHead(entries []catalogChannelEntry) string {
+	upgraded := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.Replaces != "" {
+			upgraded[entry.Replaces] = true
+		}
+		for _, skipped := range entry.Skips {
+			upgraded[skipped] = true
+		}
+	}
+	head := entries[len(entries)-1].Name
+	for _, entry := range entries {
+		if !upgraded[entry.Name] {
+			head = entry.Name
+		}
+	}
+	return head
+}
@@ -0,0 +1,193 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry/registrytest"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCatalogJSON = `
+{"schema": "olm.package", "name": "ptp-operator", "defaultChannel": "stable"}
+{"schema": "olm.channel", "package": "ptp-operator", "name": "stable", "entries": [
+  {"name": "ptp-operator.v4.12.0"}, {"name": "ptp-operator.v4.12.1", "replaces": "ptp-operator.v4.12.0"}]}
+{"schema": "olm.channel", "package": "ptp-operator", "name": "4.11", "entries": [{"name": "ptp-operator.v4.11.0"}]}
+{"schema": "olm.bundle", "package": "ptp-operator", "name": "ptp-operator.v4.12.0", "image": "quay.io/ptp/bundle:v4.12.0",
+  "relatedImages": [{"name": "ptp", "image": "quay.io/ptp/ptp:v4.12.0"}]}
+{"schema": "olm.bundle", "package": "ptp-operator", "name": "ptp-operator.v4.12.1", "image": "quay.io/ptp/bundle:v4.12.1",
+  "relatedImages": [{"name": "ptp", "image": "quay.io/ptp/ptp:v4.12.1"}, {"name": "bundle", "image": "quay.io/ptp/bundle:v4.12.1"}]}
+{"schema": "olm.bundle", "package": "ptp-operator", "name": "ptp-operator.v4.11.0", "image": "quay.io/ptp/bundle:v4.11.0",
+  "relatedImages": [{"name": "ptp", "image": "quay.io/ptp/ptp:v4.11.0"}]}
+`
+
+const testCatalogYAML = `
+---
+schema: olm.package
+name: sriov-network-operator
+defaultChannel: stable
+---
+schema: olm.channel
+package: sriov-network-operator
+name: stable
+entries:
+- name: sriov-network-operator.v4.12.0
+---
+schema: olm.bundle
+package: sriov-network-operator
+name: sriov-network-operator.v4.12.0
+image: quay.io/sriov/bundle:v4.12.0
+relatedImages:
+- name: sriov
+  image: quay.io/sriov/sriov:v4.12.0
+- name: webhook
+  image: quay.io/sriov/webhook:v4.12.0
+`
+
+func TestCatalog_ParsePackagesAndChannels(t *testing.T) {
+	channels, err := ParsePackagesAndChannels([]string{"ptp-operator: stable", " sriov-network-operator:4.12 ", ""})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"ptp-operator": "stable", "sriov-network-operator": "4.12"}, channels)
+
+	_, err = ParsePackagesAndChannels([]string{"ptp-operator"})
+	assert.Error(t, err)
+}
+
+func TestCatalog_ResolveBundleImages(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+
+	labels := map[string]string{configsLabel: "/configs"}
+	// The second layer overrides the sriov catalog and removes a stale package
+	index := server.AddImage("redhat/operator-index", "v4.12", labels,
+		registrytest.Layer(map[string]string{
+			"configs/ptp-operator/catalog.json":  testCatalogJSON,
+			"configs/sriov-network-operator.yml": "schema: olm.package\nname: sriov-network-operator\n",
+			"configs/stale/catalog.json":         testCatalogJSON,
+		}),
+		registrytest.Layer(map[string]string{
+			"configs/sriov-network-operator.yml": testCatalogYAML,
+			"configs/.wh.stale":                  "",
+			"bin/opm":                            "binary",
+		}),
+	)
+	sqliteIndex := server.AddImage("redhat/sqlite-index", "v4.10", map[string]string{},
+		registrytest.Layer(map[string]string{"database/index.db": "sqlite"}))
+
+	client := NewClient(server.Client(), nil)
+	images, err := client.ResolveBundleImages(context.TODO(), index, map[string]string{
+		"ptp-operator":           "stable",
+		"sriov-network-operator": "stable",
+		"missing-operator":       "stable",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"ptp-operator":           {"quay.io/ptp/bundle:v4.12.1", "quay.io/ptp/ptp:v4.12.1"},
+		"sriov-network-operator": {"quay.io/sriov/sriov:v4.12.0", "quay.io/sriov/webhook:v4.12.0"},
+	}, images)
+
+	images, err = client.ResolveBundleImages(context.TODO(), index, map[string]string{"ptp-operator": "4.11"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"ptp-operator": {"quay.io/ptp/ptp:v4.11.0"}}, images)
+
+	_, err = client.ResolveBundleImages(context.TODO(), sqliteIndex, map[string]string{"ptp-operator": "stable"})
+	assert.ErrorIs(t, err, ErrNoFileBasedCatalog)
+}
+
+func TestCatalog_ResolveBundleImagesCache(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+
+	labels := map[string]string{configsLabel: "/configs"}
+	layer := registrytest.Layer(map[string]string{"configs/ptp-operator/catalog.json": testCatalogJSON})
+	server.AddImage("redhat/cached-index", "v4.12", labels, layer)
+	index := server.Host() + "/redhat/cached-index:v4.12"
+	layerPath := "/v2/redhat/cached-index/blobs/" + registrytest.Digest(layer)
+
+	client := NewClient(server.Client(), nil)
+	channels := map[string]string{"ptp-operator": "stable"}
+	for i := 0; i < 2; i++ {
+		images, err := client.ResolveBundleImages(context.TODO(), index, channels)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string][]string{
+			"ptp-operator": {"quay.io/ptp/bundle:v4.12.1", "quay.io/ptp/ptp:v4.12.1"},
+		}, images)
+		// The cached resolutions are copies
+		images["ptp-operator"][0] = "changed"
+	}
+	// The index is only streamed once
+	assert.Equal(t, 1, server.Requests[layerPath])
+
+	// The tag moved to a new index image is resolved again
+	newLayer := registrytest.Layer(map[string]string{"configs/ptp-operator/catalog.json": testCatalogJSON + "\n"})
+	server.AddImage("redhat/cached-index", "v4.12", labels, newLayer)
+	images, err := client.ResolveBundleImages(context.TODO(), index, channels)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{
+		"ptp-operator": {"quay.io/ptp/bundle:v4.12.1", "quay.io/ptp/ptp:v4.12.1"},
+	}, images)
+	assert.Equal(t, 1, server.Requests["/v2/redhat/cached-index/blobs/"+registrytest.Digest(newLayer)])
+}
+
+func TestCatalog_catalogCache(t *testing.T) {
+	cache := newCatalogCache(2)
+	cache.add("index1", map[string][]string{"ptp-operator": {"ptp:v1"}})
+	cache.add("index2", map[string][]string{"ptp-operator": {"ptp:v2"}})
+	cache.add("index1", map[string][]string{"ptp-operator": {"ptp:v1"}})
+	cache.add("index3", map[string][]string{"ptp-operator": {"ptp:v3"}})
+
+	// The oldest resolution is evicted
+	_, ok := cache.get("index1")
+	assert.False(t, ok)
+	images, ok := cache.get("index3")
+	assert.True(t, ok)
+	assert.Equal(t, map[string][]string{"ptp-operator": {"ptp:v3"}}, images)
+
+	assert.Equal(t, catalogCacheKey("sha256:01", map[string]string{"sriov": "stable", "ptp": "4.12"}),
+		catalogCacheKey("sha256:01", map[string]string{"ptp": "4.12", "sriov": "stable"}))
+}
+
+func TestCatalog_channelHead(t *testing.T) {
+	testcases := []struct {
+		name     string
+		entries  []catalogChannelEntry
+		expected string
+	}{
+		{
+			name:     "single bundle",
+			entries:  []catalogChannelEntry{{Name: "ptp.v4.12.0"}},
+			expected: "ptp.v4.12.0",
+		},
+		{
+			name: "head listed first",
+			entries: []catalogChannelEntry{
+				{Name: "ptp.v4.12.2", Replaces: "ptp.v4.12.1"},
+				{Name: "ptp.v4.12.0"},
+				{Name: "ptp.v4.12.1", Replaces: "ptp.v4.12.0"},
+			},
+			expected: "ptp.v4.12.2",
+		},
+		{
+			name: "skipped bundles",
+			entries: []catalogChannelEntry{
+				{Name: "ptp.v4.12.2", Replaces: "ptp.v4.12.0", Skips: []string{"ptp.v4.12.1"}},
+				{Name: "ptp.v4.12.1", Replaces: "ptp.v4.12.0"},
+				{Name: "ptp.v4.12.0"},
+			},
+			expected: "ptp.v4.12.2",
+		},
+		{
+			name: "several heads",
+			entries: []catalogChannelEntry{
+				{Name: "ptp.v4.12.1"},
+				{Name: "ptp.v4.12.0"},
+			},
+			expected: "ptp.v4.12.0",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, channelHead(tc.entries))
+		})
+	}
+}
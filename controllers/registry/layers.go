@@ -0,0 +1,84 @@
+package registry
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// errStopWalk stops a layer walk without reporting an error
+var errStopWalk = errors.New("stop walk")
+
+// walkLayer calls fn for every entry of the layer tarball. Entry names are
+// cleaned and relative to the root of the image filesystem
+func (c *Client) walkLayer(ctx context.Context, ref Reference, layer Descriptor,
+	fn func(name string, header *tar.Header, content io.Reader) error) error {
+
+	blob, err := c.GetBlob(ctx, ref, layer.Digest)
+	if err != nil {
+		return err
+	}
+	defer blob.Close()
+
+	reader := bufio.NewReader(blob)
+	var layerReader io.Reader = reader
+	// Layers are usually gzip compressed, check the magic bytes rather than trusting the media type
+	if magic, err := reader.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("unable to decompress layer %s: %w", layer.Digest, err)
+		}
+		defer gz.Close()
+		layerReader = gz
+	}
+
+	tr := tar.NewReader(layerReader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read layer %s: %w", layer.Digest, err)
+		}
+		name := strings.TrimPrefix(path.Clean("/"+header.Name), "/")
+		if err := fn(name, header, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// ReadFile returns the content of a regular file from the image filesystem,
+// looking up the layers from the top
+// returns: []byte, error
+func (c *Client) ReadFile(ctx context.Context, ref Reference, manifest *Manifest, name string) ([]byte, error) {
+	name = strings.TrimPrefix(path.Clean("/"+name), "/")
+	for i := len(manifest.Layers) - 1; i >= 0; i-- {
+		var content []byte
+		found := false
+		err := c.walkLayer(ctx, ref, manifest.Layers[i], func(entry string, header *tar.Header, reader io.Reader) error {
+			if entry != name || header.Typeflag != tar.TypeReg {
+				return nil
+			}
+			data, err := io.ReadAll(reader)
+			if err != nil {
+				return err
+			}
+			content, found = data, true
+			return errStopWalk
+		})
+		if err != nil && !errors.Is(err, errStopWalk) {
+			return nil, err
+		}
+		if found {
+			return content, nil
+		}
+	}
+	return nil, fmt.Errorf("file %s not found in image %s", name, ref.String())
+}
@@ -0,0 +1,404 @@
+package registry
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Manifest media types supported by the client
+const (
+	MediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	MediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	MediaTypeOCIManifest        = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex           = "application/vnd.oci.image.index.v1+json"
+)
+
+const (
+	dockerHubRegistry       = "docker.io"
+	dockerHubRegistryServer = "registry-1.docker.io"
+)
+
+var challengeParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+var acceptedManifestTypes = strings.Join([]string{
+	MediaTypeDockerManifest,
+	MediaTypeDockerManifestList,
+	MediaTypeOCIManifest,
+	MediaTypeOCIIndex,
+}, ", ")
+
+// Reference is a parsed image pull spec
+type Reference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseReference parses an image pull spec in the registry/repository[:tag][@digest] form
+// returns: Reference, error
+func ParseReference(image string) (Reference, error) {
+	var ref Reference
+	name := strings.TrimSpace(image)
+	if name == "" {
+		return ref, fmt.Errorf("empty image reference")
+	}
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !strings.Contains(ref.Digest, ":") {
+			return ref, fmt.Errorf("invalid digest in image reference %s", image)
+		}
+	}
+	if i := strings.LastIndex(name, ":"); i >= 0 && !strings.Contains(name[i+1:], "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if i := strings.Index(name, "/"); i >= 0 && (strings.ContainsAny(name[:i], ".:") || name[:i] == "localhost") {
+		ref.Registry = name[:i]
+		ref.Repository = name[i+1:]
+	} else {
+		ref.Registry = dockerHubRegistry
+		ref.Repository = name
+		if !strings.Contains(name, "/") {
+			ref.Repository = "library/" + name
+		}
+	}
+	if ref.Repository == "" {
+		return ref, fmt.Errorf("invalid image reference %s", image)
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+// String returns the pull spec of the reference
+func (r Reference) String() string {
+	s := r.Registry + "/" + r.Repository
+	if r.Tag != "" {
+		s += ":" + r.Tag
+	}
+	if r.Digest != "" {
+		s += "@" + r.Digest
+	}
+	return s
+}
+
+// manifestRef returns the digest if set, otherwise the tag
+func (r Reference) manifestRef() string {
+	if r.Digest != "" {
+		return r.Digest
+	}
+	return r.Tag
+}
+
+// Descriptor describes a content addressable blob or manifest
+type Descriptor struct {
+	MediaType string    `json:"mediaType,omitempty"`
+	Digest    string    `json:"digest"`
+	Size      int64     `json:"size"`
+	Platform  *Platform `json:"platform,omitempty"`
+}
+
+// Platform describes the platform of a manifest list entry
+type Platform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+}
+
+// Manifest is an image manifest or a manifest list / image index
+type Manifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType,omitempty"`
+	Config        Descriptor   `json:"config,omitempty"`
+	Layers        []Descriptor `json:"layers,omitempty"`
+	Manifests     []Descriptor `json:"manifests,omitempty"`
+	// Digest of the manifest content, identifying the image whatever its tag
+	Digest string `json:"-"`
+}
+
+// IsIndex returns true if the manifest is a manifest list or image index
+func (m *Manifest) IsIndex() bool {
+	return m.MediaType == MediaTypeDockerManifestList || m.MediaType == MediaTypeOCIIndex ||
+		(m.MediaType == "" && len(m.Manifests) > 0)
+}
+
+// ImageConfig is the subset of the image configuration blob used by the client
+type ImageConfig struct {
+	Config struct {
+		Labels map[string]string `json:"Labels,omitempty"`
+	} `json:"config"`
+}
+
+// Client is a minimal read-only client for the registry v2 API
+type Client struct {
+	httpClient *http.Client
+	// credentials maps a registry host to base64 encoded user:password
+	credentials map[string]string
+	mutex       sync.Mutex
+	tokens      map[string]string
+}
+
+// NewClient creates a registry client
+// returns: *Client
+func NewClient(httpClient *http.Client, credentials map[string]string) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	if credentials == nil {
+		credentials = make(map[string]string)
+	}
+	return &Client{
+		httpClient:  httpClient,
+		credentials: credentials,
+		tokens:      make(map[string]string),
+	}
+}
+
+// CredentialsFromDockerConfig extracts the registry credentials from a
+// .dockerconfigjson pull secret payload
+// returns: map[string]string, error
+func CredentialsFromDockerConfig(data []byte) (map[string]string, error) {
+	var config struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal docker config: %w", err)
+	}
+	credentials := make(map[string]string)
+	for host, entry := range config.Auths {
+		if entry.Auth == "" {
+			continue
+		}
+		host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+		credentials[strings.TrimSuffix(host, "/")] = entry.Auth
+	}
+	return credentials, nil
+}
+
+// GetManifest fetches the manifest of the image. Manifest lists are resolved
+// to the entry matching the operator platform (or the first entry)
+// returns: *Manifest, error
+func (c *Client) GetManifest(ctx context.Context, ref Reference) (*Manifest, error) {
+	manifest, err := c.getManifest(ctx, ref, ref.manifestRef())
+	if err != nil {
+		return nil, err
+	}
+	if !manifest.IsIndex() {
+		return manifest, nil
+	}
+	if len(manifest.Manifests) == 0 {
+		return nil, fmt.Errorf("manifest list for %s is empty", ref.String())
+	}
+	selected := manifest.Manifests[0]
+	for _, entry := range manifest.Manifests {
+		if entry.Platform != nil && entry.Platform.OS == "linux" && entry.Platform.Architecture == runtime.GOARCH {
+			selected = entry
+			break
+		}
+	}
+	return c.getManifest(ctx, ref, selected.Digest)
+}
+
+// ManifestExists checks whether the manifest of the image is present in the registry
+// returns: bool, error
+func (c *Client) ManifestExists(ctx context.Context, ref Reference) (bool, error) {
+	res, err := c.do(ctx, ref, http.MethodHead, "/manifests/"+ref.manifestRef(), acceptedManifestTypes)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	switch res.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("unexpected response for manifest %s: %d", ref.String(), res.StatusCode)
+	}
+}
+
+// GetBlob opens the blob with the given digest in the repository of the image
+// returns: io.ReadCloser, error
+func (c *Client) GetBlob(ctx context.Context, ref Reference, digest string) (io.ReadCloser, error) {
+	res, err := c.do(ctx, ref, http.MethodGet, "/blobs/"+digest, "")
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, fmt.Errorf("unable to get blob %s from %s: %d", digest, ref.String(), res.StatusCode)
+	}
+	return res.Body, nil
+}
+
+// GetImageConfig fetches the image configuration blob referenced by the manifest
+// returns: *ImageConfig, error
+func (c *Client) GetImageConfig(ctx context.Context, ref Reference, manifest *Manifest) (*ImageConfig, error) {
+	blob, err := c.GetBlob(ctx, ref, manifest.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	defer blob.Close()
+	config := new(ImageConfig)
+	if err := json.NewDecoder(blob).Decode(config); err != nil {
+		return nil, fmt.Errorf("unable to decode image config of %s: %w", ref.String(), err)
+	}
+	return config, nil
+}
+
+func (c *Client) getManifest(ctx context.Context, ref Reference, tagOrDigest string) (*Manifest, error) {
+	res, err := c.do(ctx, ref, http.MethodGet, "/manifests/"+tagOrDigest, acceptedManifestTypes)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to get manifest %s for %s: %d", tagOrDigest, ref.String(), res.StatusCode)
+	}
+	content, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read manifest of %s: %w", ref.String(), err)
+	}
+	manifest := new(Manifest)
+	if err := json.Unmarshal(content, manifest); err != nil {
+		return nil, fmt.Errorf("unable to decode manifest of %s: %w", ref.String(), err)
+	}
+	manifest.Digest = fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+	if manifest.MediaType == "" {
+		manifest.MediaType = strings.Split(res.Header.Get("Content-Type"), ";")[0]
+	}
+	return manifest, nil
+}
+
+// do sends a request to the registry and negotiates authentication on 401
+func (c *Client) do(ctx context.Context, ref Reference, method, path, accept string) (*http.Response, error) {
+	host := ref.Registry
+	if host == dockerHubRegistry {
+		host = dockerHubRegistryServer
+	}
+	endpoint := fmt.Sprintf("https://%s/v2/%s%s", host, ref.Repository, path)
+	tokenKey := ref.Registry + "/" + ref.Repository
+
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		c.mutex.Lock()
+		authorization := c.tokens[tokenKey]
+		c.mutex.Unlock()
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("unable to request %s: %w", endpoint, err)
+		}
+		return res, nil
+	}
+
+	res, err := send()
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+	challenge := res.Header.Get("WWW-Authenticate")
+	res.Body.Close()
+
+	authorization, err := c.authorize(ctx, ref, challenge)
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	c.tokens[tokenKey] = authorization
+	c.mutex.Unlock()
+	return send()
+}
+
+// authorize answers a WWW-Authenticate challenge and returns the Authorization header value
+func (c *Client) authorize(ctx context.Context, ref Reference, challenge string) (string, error) {
+	credentials := c.credentials[ref.Registry]
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if credentials == "" {
+			return "", fmt.Errorf("registry %s requires credentials", ref.Registry)
+		}
+		return "Basic " + credentials, nil
+	case "bearer":
+		realm, err := url.Parse(params["realm"])
+		if err != nil || params["realm"] == "" {
+			return "", fmt.Errorf("invalid bearer realm from registry %s", ref.Registry)
+		}
+		query := realm.Query()
+		if service := params["service"]; service != "" {
+			query.Set("service", service)
+		}
+		scope := params["scope"]
+		if scope == "" {
+			scope = fmt.Sprintf("repository:%s:pull", ref.Repository)
+		}
+		query.Set("scope", scope)
+		realm.RawQuery = query.Encode()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+		if err != nil {
+			return "", err
+		}
+		if credentials != "" {
+			req.Header.Set("Authorization", "Basic "+credentials)
+		}
+		res, err := c.httpClient.Do(req)
+		if err != nil {
+			return "", fmt.Errorf("unable to request token from %s: %w", realm.String(), err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("token request to %s failed: %d", realm.String(), res.StatusCode)
+		}
+		var token struct {
+			Token       string `json:"token"`
+			AccessToken string `json:"access_token"`
+		}
+		if err := json.NewDecoder(res.Body).Decode(&token); err != nil {
+			return "", fmt.Errorf("unable to decode token from %s: %w", realm.String(), err)
+		}
+		if token.Token == "" {
+			token.Token = token.AccessToken
+		}
+		return "Bearer " + token.Token, nil
+	default:
+		return "", fmt.Errorf("unsupported authentication challenge from registry %s: %q", ref.Registry, challenge)
+	}
+}
+
+// parseChallenge splits a WWW-Authenticate header into the scheme and its parameters
+func parseChallenge(challenge string) (string, map[string]string) {
+	params := make(map[string]string)
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	for _, match := range challengeParamRegex.FindAllStringSubmatch(rest, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	return scheme, params
+}
+
+// BasicAuth encodes user and password for use in the client credentials
+func BasicAuth(user, password string) string {
+	return base64.StdEncoding.EncodeToString([]byte(user + ":" + password))
+}
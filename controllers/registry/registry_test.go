@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry/registrytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry_ParseReference(t *testing.T) {
+	testcases := []struct {
+		image    string
+		expected Reference
+		err      bool
+	}{
+		{
+			image:    "quay.io/openshift-release-dev/ocp-release:4.12.1-x86_64",
+			expected: Reference{Registry: "quay.io", Repository: "openshift-release-dev/ocp-release", Tag: "4.12.1-x86_64"},
+		},
+		{
+			image:    "registry.example.com:5000/redhat/redhat-operator-index@sha256:0123",
+			expected: Reference{Registry: "registry.example.com:5000", Repository: "redhat/redhat-operator-index", Digest: "sha256:0123"},
+		},
+		{
+			image:    "registry.example.com:5000/ubi",
+			expected: Reference{Registry: "registry.example.com:5000", Repository: "ubi", Tag: "latest"},
+		},
+		{
+			image:    "localhost/test:v1",
+			expected: Reference{Registry: "localhost", Repository: "test", Tag: "v1"},
+		},
+		{
+			image:    "busybox",
+			expected: Reference{Registry: "docker.io", Repository: "library/busybox", Tag: "latest"},
+		},
+		{
+			image:    "example/app:v2",
+			expected: Reference{Registry: "docker.io", Repository: "example/app", Tag: "v2"},
+		},
+		{
+			image: "quay.io/test@invalid",
+			err:   true,
+		},
+		{
+			image: "",
+			err:   true,
+		},
+	}
+	for _, tc := range testcases {
+		ref, err := ParseReference(tc.image)
+		if tc.err {
+			assert.Error(t, err, tc.image)
+			continue
+		}
+		assert.NoError(t, err, tc.image)
+		assert.Equal(t, tc.expected, ref, tc.image)
+	}
+}
+
+func TestRegistry_CredentialsFromDockerConfig(t *testing.T) {
+	credentials, err := CredentialsFromDockerConfig([]byte(
+		`{"auths":{"quay.io":{"auth":"dXNlcjpwYXNz"},"https://registry.example.com:5000/":{"auth":"Zm9vOmJhcg=="},"empty.io":{}}}`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"quay.io":                   "dXNlcjpwYXNz",
+		"registry.example.com:5000": "Zm9vOmJhcg==",
+	}, credentials)
+
+	_, err = CredentialsFromDockerConfig([]byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestRegistry_GetManifest(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+	server.Token = "secret-token"
+
+	image := server.AddImage("test/app", "v1", map[string]string{"key": "value"},
+		registrytest.Layer(map[string]string{"file": "content"}))
+	imageRef, err := ParseReference(image)
+	assert.NoError(t, err)
+
+	// Manifest list pointing at the image
+	index, _ := json.Marshal(map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     MediaTypeOCIIndex,
+		"manifests": []map[string]interface{}{
+			{
+				"mediaType": MediaTypeOCIManifest,
+				"digest":    imageRef.Digest,
+				"platform":  map[string]string{"os": "linux", "architecture": "amd64"},
+			},
+		},
+	})
+	server.AddManifest("test/app", "multi", MediaTypeOCIIndex, index)
+
+	client := NewClient(server.Client(), nil)
+	for _, tag := range []string{"v1", "multi"} {
+		ref := Reference{Registry: server.Host(), Repository: "test/app", Tag: tag}
+		manifest, err := client.GetManifest(context.TODO(), ref)
+		assert.NoError(t, err, tag)
+		assert.Equal(t, MediaTypeOCIManifest, manifest.MediaType, tag)
+		assert.Len(t, manifest.Layers, 1, tag)
+
+		config, err := client.GetImageConfig(context.TODO(), ref, manifest)
+		assert.NoError(t, err, tag)
+		assert.Equal(t, "value", config.Config.Labels["key"], tag)
+	}
+
+	exists, err := client.ManifestExists(context.TODO(), imageRef)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = client.ManifestExists(context.TODO(),
+		Reference{Registry: server.Host(), Repository: "test/missing", Tag: "v1"})
+	assert.NoError(t, err)
+	assert.False(t, exists)
+
+	// The token is requested once per repository and reused
+	assert.Equal(t, 2, server.Requests["/token"])
+}
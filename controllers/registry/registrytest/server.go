@@ -0,0 +1,173 @@
+// Package registrytest provides an in-memory registry v2 API stand-in for tests
+package registrytest
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// Server is an in-memory registry serving manifests and blobs over TLS
+type Server struct {
+	*httptest.Server
+	mutex     sync.Mutex
+	manifests map[string]manifestEntry
+	blobs     map[string][]byte
+	// Token, when set, is required as a bearer token obtained from /token
+	Token string
+	// Requests counts the requests received per path
+	Requests map[string]int
+}
+
+type manifestEntry struct {
+	mediaType string
+	content   []byte
+}
+
+// NewServer starts a registry stand-in. Close must be called when done
+func NewServer() *Server {
+	s := &Server{
+		manifests: make(map[string]manifestEntry),
+		blobs:     make(map[string][]byte),
+		Requests:  make(map[string]int),
+	}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// Host returns the host:port of the registry to be used in image references
+func (s *Server) Host() string {
+	return strings.TrimPrefix(s.URL, "https://")
+}
+
+// Digest returns the sha256 digest of the content
+func Digest(content []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(content))
+}
+
+// AddBlob stores a blob and returns its digest
+func (s *Server) AddBlob(content []byte) string {
+	digest := Digest(content)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.blobs[digest] = content
+	return digest
+}
+
+// AddManifest stores a manifest for repository under tag (and its digest) and returns the digest
+func (s *Server) AddManifest(repository, tag, mediaType string, content []byte) string {
+	digest := Digest(content)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	entry := manifestEntry{mediaType: mediaType, content: content}
+	s.manifests[repository+"@"+digest] = entry
+	if tag != "" {
+		s.manifests[repository+":"+tag] = entry
+	}
+	return digest
+}
+
+// AddImage stores an image built from the labels and layers and returns the
+// reference of the image by digest
+func (s *Server) AddImage(repository, tag string, labels map[string]string, layers ...[]byte) string {
+	config, _ := json.Marshal(map[string]interface{}{
+		"architecture": "amd64",
+		"os":           "linux",
+		"config":       map[string]interface{}{"Labels": labels},
+	})
+	manifest := map[string]interface{}{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config": map[string]interface{}{
+			"mediaType": "application/vnd.oci.image.config.v1+json",
+			"digest":    s.AddBlob(config),
+			"size":      len(config),
+		},
+	}
+	var descriptors []map[string]interface{}
+	for _, layer := range layers {
+		descriptors = append(descriptors, map[string]interface{}{
+			"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip",
+			"digest":    s.AddBlob(layer),
+			"size":      len(layer),
+		})
+	}
+	manifest["layers"] = descriptors
+	content, _ := json.Marshal(manifest)
+	digest := s.AddManifest(repository, tag, "application/vnd.oci.image.manifest.v1+json", content)
+	return fmt.Sprintf("%s/%s@%s", s.Host(), repository, digest)
+}
+
+// Layer builds a gzip compressed tar layer holding the files
+func Layer(files map[string]string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		_ = tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		})
+		_, _ = tw.Write([]byte(content))
+	}
+	_ = tw.Close()
+	_ = gz.Close()
+	return buf.Bytes()
+}
+
+func (s *Server) serve(w http.ResponseWriter, req *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.Requests[req.URL.Path]++
+
+	if req.URL.Path == "/token" {
+		_ = json.NewEncoder(w).Encode(map[string]string{"token": s.Token})
+		return
+	}
+	if s.Token != "" && req.Header.Get("Authorization") != "Bearer "+s.Token {
+		w.Header().Set("WWW-Authenticate",
+			fmt.Sprintf(`Bearer realm="%s/token",service="registrytest"`, s.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	if i := strings.LastIndex(path, "/manifests/"); i >= 0 {
+		repository, reference := path[:i], path[i+len("/manifests/"):]
+		separator := ":"
+		if strings.HasPrefix(reference, "sha256:") {
+			separator = "@"
+		}
+		entry, ok := s.manifests[repository+separator+reference]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", entry.mediaType)
+		w.Header().Set("Docker-Content-Digest", Digest(entry.content))
+		w.Header().Set("Content-Length", fmt.Sprint(len(entry.content)))
+		if req.Method != http.MethodHead {
+			_, _ = w.Write(entry.content)
+		}
+		return
+	}
+	if i := strings.LastIndex(path, "/blobs/"); i >= 0 {
+		blob, ok := s.blobs[path[i+len("/blobs/"):]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(blob)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
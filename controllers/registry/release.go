@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// releaseImageReferencesFile lists the component images of an OpenShift release image
+const releaseImageReferencesFile = "release-manifests/image-references"
+
+// ReleaseImageReferences returns the component images of an OpenShift release image
+// keyed by component name
+// returns: map[string]string, error
+func (c *Client) ReleaseImageReferences(ctx context.Context, release string) (map[string]string, error) {
+	ref, err := ParseReference(release)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := c.GetManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	content, err := c.ReadFile(ctx, ref, manifest, releaseImageReferencesFile)
+	if err != nil {
+		return nil, err
+	}
+	var imageStream struct {
+		Spec struct {
+			Tags []struct {
+				Name string `json:"name"`
+				From struct {
+					Name string `json:"name"`
+				} `json:"from"`
+			} `json:"tags"`
+		} `json:"spec"`
+	}
+	if err := json.Unmarshal(content, &imageStream); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal image references of %s: %w", release, err)
+	}
+	references := make(map[string]string)
+	for _, tag := range imageStream.Spec.Tags {
+		if tag.From.Name != "" {
+			references[tag.Name] = tag.From.Name
+		}
+	}
+	return references, nil
+}
+
+// ImageLayers returns the layers of the image. Manifest lists are resolved to
+// the entry matching the operator platform
+// returns: []Descriptor, error
+func (c *Client) ImageLayers(ctx context.Context, image string) ([]Descriptor, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := c.GetManifest(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	return manifest.Layers, nil
+}
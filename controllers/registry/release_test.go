@@ -0,0 +1,39 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/registry/registrytest"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRelease_ReleaseImageReferences(t *testing.T) {
+	server := registrytest.NewServer()
+	defer server.Close()
+
+	references := `{"kind": "ImageStream", "spec": {"tags": [
+  {"name": "etcd", "from": {"kind": "DockerImage", "name": "quay.io/ocp/etcd@sha256:01"}},
+  {"name": "installer", "from": {"kind": "DockerImage", "name": "quay.io/ocp/installer@sha256:02"}}]}}`
+	// The upper layer holds the image references of the release
+	release := server.AddImage("ocp/release", "4.12.2", nil,
+		registrytest.Layer(map[string]string{"release-manifests/image-references": `{"spec": {}}`}),
+		registrytest.Layer(map[string]string{"release-manifests/image-references": references}),
+	)
+	notRelease := server.AddImage("ocp/app", "v1", nil, registrytest.Layer(map[string]string{"bin/app": "app"}))
+
+	client := NewClient(server.Client(), nil)
+	images, err := client.ReleaseImageReferences(context.TODO(), release)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"etcd":      "quay.io/ocp/etcd@sha256:01",
+		"installer": "quay.io/ocp/installer@sha256:02",
+	}, images)
+
+	_, err = client.ReleaseImageReferences(context.TODO(), notRelease)
+	assert.Error(t, err)
+
+	layers, err := client.ImageLayers(context.TODO(), release)
+	assert.NoError(t, err)
+	assert.Len(t, layers, 2)
+}
@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"context"
+	"strings"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// reportName returns the name of the report of the ClusterGroupUpgrade, suffixed with its UID so that a
+// ClusterGroupUpgrade recreated with the same name gets a new report
+// returns: string
+func reportName(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) string {
+	suffix := strings.SplitN(string(clusterGroupUpgrade.UID), "-", 2)[0]
+	return utils.NewSafeResourceName(clusterGroupUpgrade.Name, suffix, utils.MaxObjectNameLength, 0)
+}
+
+// newClusterReport builds the outcome of the remediation of a cluster from its state and its history
+// returns: ranv1alpha1.ClusterReport
+func newClusterReport(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
+	cluster string, batch int, clusterState *ranv1alpha1.ClusterState) ranv1alpha1.ClusterReport {
+
+	report := ranv1alpha1.ClusterReport{Name: cluster, Batch: batch, State: ranv1alpha1.NotStarted}
+	if clusterState != nil {
+		report.State = clusterState.State
+		report.CurrentPolicy = clusterState.CurrentPolicy
+		report.Message = clusterState.Message
+	}
+
+	report.StartedAt, report.CompletedAt = getClusterRemediationTimes(clusterGroupUpgrade, cluster, report.State)
+	if !report.StartedAt.IsZero() && !report.CompletedAt.IsZero() {
+		report.Duration = &metav1.Duration{Duration: report.CompletedAt.Sub(report.StartedAt.Time)}
+	}
+	return report
+}
+
+// newClusterGroupUpgradeReport builds the report of a completed ClusterGroupUpgrade from its status
+// returns: *ranv1alpha1.ClusterGroupUpgradeReport
+func newClusterGroupUpgradeReport(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) *ranv1alpha1.ClusterGroupUpgradeReport {
+	status := clusterGroupUpgrade.Status.DeepCopy()
+	report := &ranv1alpha1.ClusterGroupUpgradeReport{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      reportName(clusterGroupUpgrade),
+			Namespace: clusterGroupUpgrade.Namespace,
+			Labels: map[string]string{
+				"openshift-cluster-group-upgrades/clusterGroupUpgrade": clusterGroupUpgrade.Namespace + "-" + clusterGroupUpgrade.Name,
+			},
+		},
+		Spec: ranv1alpha1.ClusterGroupUpgradeReportSpec{
+			ClusterGroupUpgrade:                   clusterGroupUpgrade.Name,
+			ClusterGroupUpgradeUID:                string(clusterGroupUpgrade.UID),
+			StartedAt:                             status.Status.StartedAt,
+			CompletedAt:                           status.Status.CompletedAt,
+			RemediationPlan:                       status.RemediationPlan,
+			ManagedPoliciesForUpgrade:             status.ManagedPoliciesForUpgrade,
+			ManagedPoliciesCompliantBeforeUpgrade: status.ManagedPoliciesCompliantBeforeUpgrade,
+			Precaching:                            status.Precaching,
+			Backup:                                status.Backup,
+			Restore:                               status.Restore,
+		},
+	}
+	if !report.Spec.StartedAt.IsZero() && !report.Spec.CompletedAt.IsZero() {
+		report.Spec.Duration = &metav1.Duration{Duration: report.Spec.CompletedAt.Sub(report.Spec.StartedAt.Time)}
+	}
+	if condition := meta.FindStatusCondition(status.Conditions, string(utils.ConditionTypes.Succeeded)); condition != nil {
+		report.Spec.Succeeded = condition.Status == metav1.ConditionTrue
+		report.Spec.Result = condition.Reason
+		report.Spec.Message = condition.Message
+	}
+
+	clusterStates := make(map[string]*ranv1alpha1.ClusterState)
+	for i := range status.Clusters {
+		clusterStates[status.Clusters[i].Name] = &status.Clusters[i]
+	}
+	for batchIndex, batch := range status.RemediationPlan {
+		for _, cluster := range batch {
+			report.Spec.Clusters = append(report.Spec.Clusters,
+				newClusterReport(clusterGroupUpgrade, cluster, batchIndex+1, clusterStates[cluster]))
+			delete(clusterStates, cluster)
+		}
+	}
+	// Clusters taken out of the remediation plan keep the state they reached
+	for _, clusterState := range status.Clusters {
+		if _, ok := clusterStates[clusterState.Name]; ok {
+			report.Spec.Clusters = append(report.Spec.Clusters,
+				newClusterReport(clusterGroupUpgrade, clusterState.Name, 0, clusterStates[clusterState.Name]))
+			delete(clusterStates, clusterState.Name)
+		}
+	}
+	return report
+}
+
+// createReport writes the report of the completed ClusterGroupUpgrade, once the clusters are restored and
+// the backups cleaned, and sets ReportCreated. The report is not owned by the ClusterGroupUpgrade so that it
+// is kept once the ClusterGroupUpgrade is deleted, and its spec is immutable
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) createReport(
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) error {
+
+	report := newClusterGroupUpgradeReport(clusterGroupUpgrade)
+	err := r.Create(ctx, report)
+	if errors.IsAlreadyExists(err) {
+		r.Log.Info("[createReport] report already exists", "name", report.Name)
+		clusterGroupUpgrade.Status.Status.ReportCreated = true
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	r.Log.Info("[createReport] report created", "name", report.Name, "result", report.Spec.Result)
+	clusterGroupUpgrade.Status.Status.ReportCreated = true
+	return nil
+}
+
+// reportCompletion records the event and the notification of the completion of the ClusterGroupUpgrade.
+// It follows the update of the status recording the completion, and sets CompletionReported so that the
+// completion is reported once
+func (r *ClusterGroupUpgradeReconciler) reportCompletion(
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, succeededCondition *metav1.Condition) {
+
+	if succeededCondition.Status == metav1.ConditionTrue {
+		r.Recorder.Event(clusterGroupUpgrade, corev1.EventTypeNormal, succeededCondition.Reason, succeededCondition.Message)
+		r.notify(clusterGroupUpgrade, ranv1alpha1.NotificationUpgradeSucceeded, "", 0, succeededCondition.Message)
+	} else {
+		r.Recorder.Event(clusterGroupUpgrade, corev1.EventTypeWarning, succeededCondition.Reason, succeededCondition.Message)
+		event := ranv1alpha1.NotificationUpgradeFailed
+		if succeededCondition.Reason == string(utils.ConditionReasons.TimedOut) {
+			event = ranv1alpha1.NotificationUpgradeTimedOut
+		}
+		r.notify(clusterGroupUpgrade, event, "", 0, succeededCondition.Message)
+	}
+	clusterGroupUpgrade.Status.Status.CompletionReported = true
+}
@@ -0,0 +1,162 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// newCompletedCgu returns a ClusterGroupUpgrade that timed out in its second batch
+func newCompletedCgu() *ranv1alpha1.ClusterGroupUpgrade {
+	startedAt := metav1.NewTime(time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC))
+	at := func(minutes int) metav1.Time {
+		return metav1.NewTime(startedAt.Add(time.Duration(minutes) * time.Minute))
+	}
+
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{
+		ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default",
+			UID: types.UID("0c9b3d6e-8d4f-4bd2-9a0e-1f2d3c4b5a69")},
+	}
+	cgu.Status.Status.StartedAt = startedAt
+	cgu.Status.Status.CompletedAt = at(90)
+	cgu.Status.RemediationPlan = [][]string{{"spoke1", "spoke2"}, {"spoke3"}, {"spoke4"}}
+	cgu.Status.ManagedPoliciesForUpgrade = []ranv1alpha1.ManagedPolicyForUpgrade{{Name: "policy1", Namespace: "default"}}
+	cgu.Status.ManagedPoliciesCompliantBeforeUpgrade = []string{"policy0"}
+	cgu.Status.Precaching = &ranv1alpha1.PrecachingStatus{
+		Status: map[string]string{"spoke1": PrecacheStateSucceeded, "spoke5": PrecacheStateError}}
+	cgu.Status.Backup = &ranv1alpha1.BackupStatus{Status: map[string]string{"spoke1": BackupStateSucceeded}}
+	cgu.Status.Restore = &ranv1alpha1.RestoreStatus{Status: map[string]string{"spoke3": RestoreStateSucceeded}}
+	cgu.Status.Conditions = []metav1.Condition{{
+		Type: string(utils.ConditionTypes.Succeeded), Status: metav1.ConditionFalse,
+		Reason: string(utils.ConditionReasons.TimedOut), Message: "Policy remediation took too long"}}
+	cgu.Status.Clusters = []ranv1alpha1.ClusterState{
+		{Name: "spoke5", State: utils.ClusterRemediationFailed, Message: "pre-caching failed"},
+		{Name: "spoke1", State: utils.ClusterRemediationComplete},
+		{Name: "spoke2", State: utils.ClusterRemediationComplete},
+		{Name: "spoke3", State: utils.ClusterRemediationTimedout,
+			CurrentPolicy: &ranv1alpha1.PolicyStatus{Name: "policy1", Status: utils.ClusterStatusNonCompliant}},
+	}
+	cgu.Status.ClusterHistory = map[string]*ranv1alpha1.ClusterHistory{
+		"spoke1": {Transitions: []ranv1alpha1.ClusterTransition{
+			{Reason: eventReasonClusterBatchStarted, State: ranv1alpha1.NotStarted, Time: at(0)},
+			{Reason: eventReasonClusterPolicyRemediating, State: ranv1alpha1.InProgress, Time: at(1)},
+			{Reason: eventReasonClusterRemediationComplete, State: utils.ClusterRemediationComplete, Time: at(20)},
+		}},
+		// The start of the batch was trimmed from the history
+		"spoke2": {Transitions: []ranv1alpha1.ClusterTransition{
+			{Reason: eventReasonClusterRemediationComplete, State: utils.ClusterRemediationComplete, Time: at(25)},
+		}},
+		"spoke3": {Transitions: []ranv1alpha1.ClusterTransition{
+			{Reason: eventReasonClusterBatchStarted, State: ranv1alpha1.NotStarted, Time: at(30)},
+			{Reason: eventReasonClusterRemediationTimedOut, State: utils.ClusterRemediationTimedout, Time: at(90)},
+		}},
+	}
+	return cgu
+}
+
+func TestReport_newClusterGroupUpgradeReport(t *testing.T) {
+	cgu := newCompletedCgu()
+	report := newClusterGroupUpgradeReport(cgu)
+
+	assert.Equal(t, "cgu-0c9b3d6e", report.Name)
+	assert.Equal(t, "default", report.Namespace)
+	assert.Equal(t, "default-cgu", report.Labels["openshift-cluster-group-upgrades/clusterGroupUpgrade"])
+	assert.Empty(t, report.OwnerReferences)
+
+	assert.Equal(t, "cgu", report.Spec.ClusterGroupUpgrade)
+	assert.Equal(t, string(cgu.UID), report.Spec.ClusterGroupUpgradeUID)
+	assert.False(t, report.Spec.Succeeded)
+	assert.Equal(t, string(utils.ConditionReasons.TimedOut), report.Spec.Result)
+	assert.Equal(t, "Policy remediation took too long", report.Spec.Message)
+	assert.Equal(t, &metav1.Duration{Duration: 90 * time.Minute}, report.Spec.Duration)
+	assert.Equal(t, cgu.Status.RemediationPlan, report.Spec.RemediationPlan)
+	assert.Equal(t, cgu.Status.ManagedPoliciesForUpgrade, report.Spec.ManagedPoliciesForUpgrade)
+	assert.Equal(t, []string{"policy0"}, report.Spec.ManagedPoliciesCompliantBeforeUpgrade)
+	assert.Equal(t, cgu.Status.Precaching, report.Spec.Precaching)
+	assert.Equal(t, cgu.Status.Backup, report.Spec.Backup)
+	assert.Equal(t, cgu.Status.Restore, report.Spec.Restore)
+
+	assert.Equal(t, []ranv1alpha1.ClusterReport{
+		{
+			Name: "spoke1", Batch: 1, State: utils.ClusterRemediationComplete,
+			StartedAt:   cgu.Status.ClusterHistory["spoke1"].Transitions[0].Time,
+			CompletedAt: cgu.Status.ClusterHistory["spoke1"].Transitions[2].Time,
+			Duration:    &metav1.Duration{Duration: 20 * time.Minute},
+		},
+		{
+			Name: "spoke2", Batch: 1, State: utils.ClusterRemediationComplete,
+			CompletedAt: cgu.Status.ClusterHistory["spoke2"].Transitions[0].Time,
+		},
+		{
+			Name: "spoke3", Batch: 2, State: utils.ClusterRemediationTimedout,
+			CurrentPolicy: &ranv1alpha1.PolicyStatus{Name: "policy1", Status: utils.ClusterStatusNonCompliant},
+			StartedAt:     cgu.Status.ClusterHistory["spoke3"].Transitions[0].Time,
+			CompletedAt:   cgu.Status.ClusterHistory["spoke3"].Transitions[1].Time,
+			Duration:      &metav1.Duration{Duration: 60 * time.Minute},
+		},
+		{Name: "spoke4", Batch: 3, State: ranv1alpha1.NotStarted},
+		{Name: "spoke5", State: utils.ClusterRemediationFailed, Message: "pre-caching failed"},
+	}, report.Spec.Clusters)
+
+	// The report does not share the status of the ClusterGroupUpgrade
+	cgu.Status.Backup.Status["spoke1"] = BackupStateError
+	assert.Equal(t, BackupStateSucceeded, report.Spec.Backup.Status["spoke1"])
+}
+
+func TestReport_createReport(t *testing.T) {
+	fakeClient, err := getFakeClientFromObjects()
+	if err != nil {
+		t.Fatalf("error in creating fake client: %v", err)
+	}
+	r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+
+	cgu := newCompletedCgu()
+	assert.NoError(t, r.createReport(context.TODO(), cgu))
+	assert.True(t, cgu.Status.Status.ReportCreated)
+
+	// The report is written once
+	cgu.Status.Conditions[0].Reason = string(utils.ConditionReasons.Completed)
+	assert.NoError(t, r.createReport(context.TODO(), cgu))
+
+	reports := &ranv1alpha1.ClusterGroupUpgradeReportList{}
+	assert.NoError(t, fakeClient.List(context.TODO(), reports, client.InNamespace("default")))
+	assert.Len(t, reports.Items, 1)
+	assert.Equal(t, "cgu-0c9b3d6e", reports.Items[0].Name)
+	assert.Equal(t, string(utils.ConditionReasons.TimedOut), reports.Items[0].Spec.Result)
+	assert.Len(t, reports.Items[0].Spec.Clusters, 5)
+}
+
+func TestReport_reportCompletion(t *testing.T) {
+	fakeClient, err := getFakeClientFromObjects()
+	if err != nil {
+		t.Fatalf("error in creating fake client: %v", err)
+	}
+	recorder := record.NewFakeRecorder(10)
+	r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme, Recorder: recorder,
+		notifier: newNotifier(fakeClient, fakeClient, logr.Discard())}
+
+	cgu := newCompletedCgu()
+	r.reportCompletion(cgu, &cgu.Status.Conditions[0])
+	assert.True(t, cgu.Status.Status.CompletionReported)
+
+	// The report waits for the restore of the clusters and the cleanup of the backups
+	reports := &ranv1alpha1.ClusterGroupUpgradeReportList{}
+	assert.NoError(t, fakeClient.List(context.TODO(), reports, client.InNamespace("default")))
+	assert.Empty(t, reports.Items)
+	assert.False(t, cgu.Status.Status.ReportCreated)
+	assert.Equal(t, []string{"Warning TimedOut Policy remediation took too long"}, drainEvents(recorder))
+	// The notification is held until the status recording CompletionReported is updated
+	pending := r.notifier.pending[types.NamespacedName{Namespace: "default", Name: "cgu"}]
+	if assert.Len(t, pending, 1) {
+		assert.Equal(t, ranv1alpha1.NotificationUpgradeTimedOut, pending[0].Event)
+	}
+}
@@ -0,0 +1,388 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Restore states
+const (
+	RestoreStatePreparingToStart = "PreparingToStart"
+	RestoreStateStarting         = "Starting"
+	RestoreStateActive           = "Active"
+	RestoreStateSucceeded        = "Succeeded"
+	RestoreStateTimeout          = "RestoreTimeout"
+	RestoreStateError            = "UnrecoverableError"
+	RestoreStateNoBackup         = "NoBackup"
+)
+
+const (
+	defaultRestoreTimeout   = 60 * time.Minute
+	restoreJobTimeoutBuffer = 10 * time.Minute
+)
+
+// getRestoreTimeout returns the maximum time for the restore of a cluster
+// returns: time.Duration
+func getRestoreTimeout(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) time.Duration {
+	if clusterGroupUpgrade.Spec.Restore == nil || clusterGroupUpgrade.Spec.Restore.Timeout <= 0 {
+		return defaultRestoreTimeout
+	}
+	return time.Duration(clusterGroupUpgrade.Spec.Restore.Timeout) * time.Minute
+}
+
+// validateRestore checks the clusters can be restored from their backup. The backup must be taken by the CGU
+// with spec.backup, and the restore job restores it from the recovery partition, so the backup content can't
+// be removed from it once uploaded
+// returns: error
+func validateRestore(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) error {
+	if clusterGroupUpgrade.Spec.Restore == nil {
+		return nil
+	}
+	var err error
+	options := clusterGroupUpgrade.Spec.BackupOptions
+	if !clusterGroupUpgrade.Spec.Backup {
+		err = errors.New("restore requires the backup of the clusters, spec.backup must be set")
+	} else if options != nil && options.ObjectStorage != nil && options.ObjectStorage.RemoveLocalCopy {
+		err = errors.New("restore requires the backup content in the recovery partition, " +
+			"backupOptions.objectStorage.removeLocalCopy must not be set")
+	}
+	if err == nil {
+		return nil
+	}
+	utils.SetStatusCondition(
+		&clusterGroupUpgrade.Status.Conditions,
+		utils.ConditionTypes.Validated,
+		utils.ConditionReasons.InvalidRestore,
+		metav1.ConditionFalse,
+		err.Error(),
+	)
+	return err
+}
+
+// getRestoreClusters returns the clusters to restore from their backup: the clusters listed in
+// spec.restore.clusters, and the clusters that failed or timed out the remediation with spec.restore.onFailure
+// returns: []string
+func getRestoreClusters(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) []string {
+	if clusterGroupUpgrade.Spec.Restore == nil {
+		return nil
+	}
+	var clusters []string
+	seen := make(map[string]bool)
+	addCluster := func(cluster string) {
+		if !seen[cluster] {
+			seen[cluster] = true
+			clusters = append(clusters, cluster)
+		}
+	}
+	for _, cluster := range clusterGroupUpgrade.Spec.Restore.Clusters {
+		addCluster(cluster)
+	}
+	if clusterGroupUpgrade.Spec.Restore.OnFailure {
+		for _, clusterState := range clusterGroupUpgrade.Status.Clusters {
+			if clusterState.State == utils.ClusterRemediationFailed || clusterState.State == utils.ClusterRemediationTimedout {
+				addCluster(clusterState.Name)
+			}
+		}
+	}
+	return clusters
+}
+
+// reconcileRestore restores the clusters from the backup taken prior to the upgrade, once the upgrade is completed
+// returns: bool - true when all the clusters reached a final restore state
+//
+//	error
+func (r *ClusterGroupUpgradeReconciler) reconcileRestore(
+	ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) (bool, error) {
+
+	clusters := getRestoreClusters(clusterGroupUpgrade)
+	if len(clusters) == 0 {
+		// No restore required
+		return true, nil
+	}
+
+	if clusterGroupUpgrade.Status.Restore == nil {
+		clusterGroupUpgrade.Status.Restore = &ranv1alpha1.RestoreStatus{
+			Status:    make(map[string]string),
+			StartedAt: metav1.Now(),
+		}
+	}
+
+	restoreCondition := meta.FindStatusCondition(clusterGroupUpgrade.Status.Conditions, string(utils.ConditionTypes.RestoreSucceeded))
+	r.Log.Info("[reconcileRestore]", "FindStatusCondition", restoreCondition)
+	if restoreCondition != nil && restoreCondition.Reason != string(utils.ConditionReasons.InProgress) {
+		// Restore is done, the secrets of the restore job are no longer needed on the clusters
+		return true, r.deleteJobSecretsPolicy(ctx, clusterGroupUpgrade, restore)
+	}
+
+	// Restore is required and not marked as done
+	err := r.triggerRestore(ctx, clusterGroupUpgrade, clusters)
+	return false, err
+}
+
+func (r *ClusterGroupUpgradeReconciler) triggerRestore(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
+	clusters []string) error {
+
+	isTimedOut := time.Since(clusterGroupUpgrade.Status.Restore.StartedAt.Time) >
+		getRestoreTimeout(clusterGroupUpgrade)+restoreJobTimeoutBuffer
+
+	secretsPolicy, err := r.ensureJobSecretsPolicy(ctx, clusterGroupUpgrade, restore, restoreNamespace,
+		clusters, getBackupJobSecrets(clusterGroupUpgrade, restore))
+	if err != nil {
+		return err
+	}
+
+	for _, cluster := range clusters {
+		var (
+			currentState, nextState string
+			err                     error
+			ok                      bool
+		)
+		if currentState, ok = clusterGroupUpgrade.Status.Restore.Status[cluster]; !ok {
+			currentState = RestoreStatePreparingToStart
+			// Only the clusters with a backup can be restored
+			if clusterGroupUpgrade.Status.Backup == nil || clusterGroupUpgrade.Status.Backup.Status[cluster] != BackupStateSucceeded {
+				r.recordFsmTransition(clusterGroupUpgrade, cluster, eventReasonClusterRestore, "restore",
+					currentState, RestoreStateNoBackup, RestoreStateNoBackup)
+				currentState = RestoreStateNoBackup
+				clusterGroupUpgrade.Status.Restore.Status[cluster] = currentState
+			}
+		}
+
+		r.Log.Info("[triggerRestore]", "currentState", currentState, "cluster", cluster)
+		switch currentState {
+		// Initial State
+		case RestoreStatePreparingToStart:
+			nextState, err = r.restorePreparing(ctx, clusterGroupUpgrade, cluster)
+
+		case RestoreStateStarting:
+			nextState, err = r.restoreStarting(ctx, clusterGroupUpgrade, cluster, secretsPolicy)
+
+		case RestoreStateActive:
+			nextState, err = r.restoreActive(ctx, cluster)
+
+		// Final states that don't change for the life of the CR
+		case RestoreStateSucceeded, RestoreStateTimeout, RestoreStateError, RestoreStateNoBackup:
+			r.Log.Info("[triggerRestore]", "cluster", cluster, "final state", currentState)
+			continue
+
+		default:
+			return fmt.Errorf("[triggerRestore] unknown state %s", currentState)
+		}
+
+		if err != nil {
+			r.Log.Info("[triggerRestore]", "cluster", cluster, "err", err)
+		}
+
+		if isTimedOut && (nextState == RestoreStatePreparingToStart || nextState == RestoreStateStarting || nextState == RestoreStateActive) {
+			nextState = RestoreStateTimeout
+		}
+
+		if currentState != nextState {
+			r.Log.Info("[triggerRestore]", "previousState", currentState, "nextState", nextState, "cluster", cluster)
+		}
+		if nextState == RestoreStateSucceeded {
+			// cleanup for succeeded clusters
+			if err := r.jobAndViewCleanup(ctx, cluster, append(restoreViews, restoreMCAs...), restoreDeleteTemplates); err != nil {
+				r.Log.Error(err, "[triggerRestore] failed to cleanup for", "cluster", cluster)
+				// skip cluster status transition if cleanup not successful
+				continue
+			}
+		}
+		if currentState != nextState {
+			r.recordFsmTransition(clusterGroupUpgrade, cluster, eventReasonClusterRestore, "restore",
+				currentState, nextState, RestoreStateTimeout, RestoreStateError)
+		}
+		clusterGroupUpgrade.Status.Restore.Status[cluster] = nextState
+	}
+	r.checkAllRestoreDone(clusterGroupUpgrade)
+	return nil
+}
+
+// restorePreparing handles conditions in RestoreStatePreparingToStart
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) restorePreparing(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster string) (string, error) {
+
+	currentState, nextState := RestoreStatePreparingToStart, RestoreStateStarting
+
+	// delete managedclusterview and managedclusteraction objects if present
+	err := r.deleteManagedClusterResources(ctx, cluster, append(restoreViews, restoreMCAs...))
+	if err != nil {
+		return currentState, err
+	}
+
+	spec, err := r.getRestoreJobTemplateData(clusterGroupUpgrade, cluster)
+	if err != nil {
+		return currentState, err
+	}
+
+	// delete namespace in the spoke with managedclusteraction
+	err = r.createResourcesFromTemplates(ctx, spec, restoreDeleteTemplates)
+	if err != nil {
+		return currentState, err
+	}
+	return nextState, nil
+}
+
+// restoreStarting handles conditions in RestoreStateStarting. The job is deployed once the policy copying its
+// secrets is compliant on the cluster
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) restoreStarting(ctx context.Context,
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, cluster, secretsPolicy string) (string, error) {
+
+	nextState, currentState := RestoreStateStarting, RestoreStateStarting
+
+	condition, err := r.getStartingConditions(ctx, cluster, restoreJobView[0].resourceName, restore)
+	if err != nil {
+		return currentState, err
+	}
+	r.Log.Info("[restoreStarting]", "conditions: ", condition)
+	switch condition {
+	case DependenciesNotPresent:
+		spec, err := r.getRestoreJobTemplateData(clusterGroupUpgrade, cluster)
+		if err != nil {
+			return currentState, err
+		}
+		err = r.createResourcesFromTemplates(ctx, spec, restoreDependenciesCreateTemplates)
+		if err != nil {
+			return currentState, err
+		}
+
+	case NoJobView, NoJobFoundOnSpoke:
+		delivered, err := r.areJobSecretsDelivered(ctx, clusterGroupUpgrade, secretsPolicy, cluster)
+		if err != nil || !delivered {
+			r.Log.Info("[restoreStarting] waiting for the restore secrets", "cluster", cluster, "policy", secretsPolicy)
+			return currentState, err
+		}
+		err = r.deployWorkload(ctx, clusterGroupUpgrade, cluster, restore, restoreJobView[0].resourceName, restoreCreateTemplates)
+		if err != nil {
+			return currentState, err
+		}
+
+	case JobActive:
+		nextState = RestoreStateActive
+
+	case JobSucceeded:
+		nextState = RestoreStateSucceeded
+
+	case JobDeadline:
+		nextState = RestoreStateTimeout
+
+	case JobBackoffLimitExceeded:
+		nextState = RestoreStateError
+
+	default:
+		return currentState, fmt.Errorf(
+			"[restoreStarting] unknown condition %v in %s state", condition, currentState)
+	}
+	return nextState, nil
+}
+
+// restoreActive handles conditions in RestoreStateActive
+// returns: error
+func (r *ClusterGroupUpgradeReconciler) restoreActive(ctx context.Context, cluster string) (string, error) {
+
+	nextState, currentState := RestoreStateActive, RestoreStateActive
+
+	// The cluster is unavailable while the node reboots during the restore
+	condition, err := r.getActiveConditions(ctx, cluster, restoreJobView[0].resourceName)
+	if err != nil {
+		return currentState, err
+	}
+
+	switch condition {
+	case JobActive:
+		nextState = RestoreStateActive
+
+	case NoJobView, NoJobFoundOnSpoke:
+		// The restored cluster has no record of the job, which is launched again to
+		// report the outcome of the restore
+		nextState = RestoreStatePreparingToStart
+
+	case JobSucceeded:
+		nextState = RestoreStateSucceeded
+
+	case JobDeadline:
+		nextState = RestoreStateTimeout
+
+	case JobBackoffLimitExceeded:
+		nextState = RestoreStateError
+
+	default:
+		return currentState, fmt.Errorf("[restoreActive] unknown condition %s in %s state",
+			condition, currentState)
+	}
+	return nextState, nil
+}
+
+// checkAllRestoreDone sets the RestoreSucceeded condition from the restore state of the clusters
+func (r *ClusterGroupUpgradeReconciler) checkAllRestoreDone(
+	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade) {
+
+	// Counts for the various cluster states
+	var failedRestoreCount int = 0
+	var progressingRestoreCount int = 0
+	var successfulRestoreCount int = 0
+
+	// Loop over all the clusters and take count of all their states
+	for _, state := range clusterGroupUpgrade.Status.Restore.Status {
+		switch state {
+		case RestoreStateSucceeded:
+			successfulRestoreCount++
+		case RestoreStateActive, RestoreStateStarting, RestoreStatePreparingToStart:
+			progressingRestoreCount++
+		default:
+			failedRestoreCount++
+		}
+	}
+
+	// Compare the total number of clusters to their status
+	switch len(clusterGroupUpgrade.Status.Restore.Status) {
+	// All clusters were successful
+	case successfulRestoreCount:
+		utils.SetStatusCondition(
+			&clusterGroupUpgrade.Status.Conditions,
+			utils.ConditionTypes.RestoreSucceeded,
+			utils.ConditionReasons.RestoreCompleted,
+			metav1.ConditionTrue,
+			"Restore is completed for all clusters",
+		)
+	// All clusters failed
+	case failedRestoreCount:
+		utils.SetStatusCondition(
+			&clusterGroupUpgrade.Status.Conditions,
+			utils.ConditionTypes.RestoreSucceeded,
+			utils.ConditionReasons.Failed,
+			metav1.ConditionFalse,
+			"Restore failed for all clusters",
+		)
+	// All clusters are completed but some failed
+	case (failedRestoreCount + successfulRestoreCount):
+		utils.SetStatusCondition(
+			&clusterGroupUpgrade.Status.Conditions,
+			utils.ConditionTypes.RestoreSucceeded,
+			utils.ConditionReasons.PartiallyDone,
+			metav1.ConditionFalse,
+			fmt.Sprintf("Restore failed for %d clusters", failedRestoreCount),
+		)
+	// Clusters are still in progress
+	default:
+		utils.SetStatusCondition(
+			&clusterGroupUpgrade.Status.Conditions,
+			utils.ConditionTypes.RestoreSucceeded,
+			utils.ConditionReasons.InProgress,
+			metav1.ConditionFalse,
+			fmt.Sprintf("Restore in progress for %d clusters", progressingRestoreCount),
+		)
+	}
+}
@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRestore_getRestoreClusters(t *testing.T) {
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+	cgu.Status.Clusters = []ranv1alpha1.ClusterState{
+		{Name: "spoke1", State: utils.ClusterRemediationComplete},
+		{Name: "spoke2", State: utils.ClusterRemediationTimedout},
+		{Name: "spoke3", State: utils.ClusterRemediationFailed},
+	}
+	assert.Nil(t, getRestoreClusters(cgu))
+	assert.Equal(t, defaultRestoreTimeout, getRestoreTimeout(cgu))
+
+	cgu.Spec.Restore = &ranv1alpha1.RestoreSpec{Clusters: []string{"spoke1", "spoke3"}, Timeout: 90}
+	assert.Equal(t, []string{"spoke1", "spoke3"}, getRestoreClusters(cgu))
+	assert.Equal(t, 90*time.Minute, getRestoreTimeout(cgu))
+
+	cgu.Spec.Restore.OnFailure = true
+	assert.Equal(t, []string{"spoke1", "spoke3", "spoke2"}, getRestoreClusters(cgu))
+}
+
+func TestRestore_validateRestore(t *testing.T) {
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{
+		Spec: ranv1alpha1.ClusterGroupUpgradeSpec{
+			Backup: true,
+			BackupOptions: &ranv1alpha1.BackupOptions{
+				ObjectStorage: &ranv1alpha1.BackupObjectStorage{Secret: "backup-storage", RemoveLocalCopy: true},
+			},
+		},
+	}
+	assert.NoError(t, validateRestore(cgu))
+
+	// The backup removed from the recovery partition can't be restored
+	cgu.Spec.Restore = &ranv1alpha1.RestoreSpec{OnFailure: true}
+	assert.Error(t, validateRestore(cgu))
+	condition := meta.FindStatusCondition(cgu.Status.Conditions, string(utils.ConditionTypes.Validated))
+	assert.Equal(t, metav1.ConditionFalse, condition.Status)
+	assert.Equal(t, string(utils.ConditionReasons.InvalidRestore), condition.Reason)
+
+	cgu.Spec.BackupOptions.ObjectStorage.RemoveLocalCopy = false
+	assert.NoError(t, validateRestore(cgu))
+
+	// The clusters can't be restored without a backup
+	cgu.Spec.Backup = false
+	err := validateRestore(cgu)
+	assert.EqualError(t, err, "restore requires the backup of the clusters, spec.backup must be set")
+	condition = meta.FindStatusCondition(cgu.Status.Conditions, string(utils.ConditionTypes.Validated))
+	assert.Equal(t, string(utils.ConditionReasons.InvalidRestore), condition.Reason)
+	assert.Equal(t, err.Error(), condition.Message)
+}
+
+func TestRestore_reconcileRestore(t *testing.T) {
+	t.Setenv("RECOVERY_IMG", "quay.io/openshift-kni/cluster-group-upgrades-operator-recovery:latest")
+
+	testcases := []struct {
+		name              string
+		backupStatus      string
+		restoreStatus     string
+		objs              []client.Object
+		expectedState     string
+		expectedReason    string
+		expectedActions   []string
+		expectedCondition metav1.ConditionStatus
+	}{
+		{
+			name:              "cluster without backup",
+			backupStatus:      BackupStateTimeout,
+			expectedState:     RestoreStateNoBackup,
+			expectedReason:    string(utils.ConditionReasons.Failed),
+			expectedCondition: metav1.ConditionFalse,
+		},
+		{
+			name:              "restore preparing",
+			backupStatus:      BackupStateSucceeded,
+			expectedState:     RestoreStateStarting,
+			expectedReason:    string(utils.ConditionReasons.InProgress),
+			expectedActions:   []string{"restore-crb-delete", "restore-ns-delete"},
+			expectedCondition: metav1.ConditionFalse,
+		},
+		{
+			name:              "restored cluster relaunches the job",
+			backupStatus:      BackupStateSucceeded,
+			restoreStatus:     RestoreStateActive,
+			objs:              []client.Object{newUnstructuredNotFoundView("view-restore-job", "spoke1", `jobs.batch "restore-agent" not found`)},
+			expectedState:     RestoreStatePreparingToStart,
+			expectedReason:    string(utils.ConditionReasons.InProgress),
+			expectedCondition: metav1.ConditionFalse,
+		},
+		{
+			name:          "restore succeeded",
+			backupStatus:  BackupStateSucceeded,
+			restoreStatus: RestoreStateActive,
+			objs: []client.Object{newUnstructuredView("view-restore-job", "spoke1", map[string]interface{}{
+				"status": map[string]interface{}{"succeeded": int64(1)},
+			})},
+			expectedState:     RestoreStateSucceeded,
+			expectedReason:    string(utils.ConditionReasons.RestoreCompleted),
+			expectedActions:   []string{"restore-crb-delete", "restore-ns-delete"},
+			expectedCondition: metav1.ConditionTrue,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{
+				ObjectMeta: metav1.ObjectMeta{Name: "cgu", Namespace: "default"},
+			}
+			cgu.Spec.Restore = &ranv1alpha1.RestoreSpec{OnFailure: true}
+			cgu.Status.Clusters = []ranv1alpha1.ClusterState{
+				{Name: "spoke1", State: utils.ClusterRemediationFailed},
+				{Name: "spoke2", State: utils.ClusterRemediationComplete},
+			}
+			cgu.Status.Backup = &ranv1alpha1.BackupStatus{
+				Status: map[string]string{"spoke1": tc.backupStatus, "spoke2": BackupStateSucceeded},
+			}
+			if tc.restoreStatus != "" {
+				cgu.Status.Restore = &ranv1alpha1.RestoreStatus{
+					StartedAt: metav1.Now(),
+					Status:    map[string]string{"spoke1": tc.restoreStatus},
+				}
+			}
+
+			fakeClient, _ := getFakeClientFromObjects(tc.objs...)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			done, err := r.reconcileRestore(context.TODO(), cgu)
+			assert.NoError(t, err)
+			assert.False(t, done)
+			assert.Equal(t, map[string]string{"spoke1": tc.expectedState}, cgu.Status.Restore.Status)
+
+			// The transition is recorded in the history of the cluster
+			transitions := cgu.Status.ClusterHistory["spoke1"].Transitions
+			assert.Len(t, transitions, 1)
+			assert.Equal(t, eventReasonClusterRestore, transitions[0].Reason)
+			assert.Equal(t, tc.expectedState, transitions[0].State)
+
+			condition := meta.FindStatusCondition(cgu.Status.Conditions, string(utils.ConditionTypes.RestoreSucceeded))
+			assert.NotNil(t, condition)
+			assert.Equal(t, tc.expectedReason, condition.Reason)
+			assert.Equal(t, tc.expectedCondition, condition.Status)
+
+			actions := &unstructured.UnstructuredList{}
+			actions.SetGroupVersionKind(actionGroupVersionKind())
+			assert.NoError(t, fakeClient.List(context.TODO(), actions, client.InNamespace("spoke1")))
+			var names []string
+			for _, action := range actions.Items {
+				names = append(names, action.GetName())
+			}
+			assert.Equal(t, tc.expectedActions, names)
+
+			// The restore is done once all the clusters reached a final state
+			done, err = r.reconcileRestore(context.TODO(), cgu)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedReason != string(utils.ConditionReasons.InProgress), done)
+		})
+	}
+}
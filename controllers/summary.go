@@ -0,0 +1,109 @@
+package controllers
+
+import (
+	"time"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	utils "github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newUpgradeSummary counts the clusters of the ClusterGroupUpgrade by remediation state and estimates the
+// completion time of the upgrade
+// returns: *ranv1alpha1.UpgradeSummary
+func newUpgradeSummary(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, now time.Time) *ranv1alpha1.UpgradeSummary {
+	summary := &ranv1alpha1.UpgradeSummary{}
+	clusterStates := make(map[string]string)
+	for _, clusterState := range clusterGroupUpgrade.Status.Clusters {
+		clusterStates[clusterState.Name] = clusterState.State
+	}
+
+	counted := make(map[string]bool)
+	count := func(cluster string) {
+		if counted[cluster] {
+			return
+		}
+		counted[cluster] = true
+		summary.Total++
+		switch clusterStates[cluster] {
+		case utils.ClusterRemediationComplete:
+			summary.Completed++
+			return
+		case utils.ClusterRemediationFailed, utils.ClusterRemediationTimedout:
+			summary.Failed++
+			return
+		}
+		progress := clusterGroupUpgrade.Status.Status.CurrentBatchRemediationProgress[cluster]
+		switch {
+		case progress == nil:
+			summary.Pending++
+		case progress.State == ranv1alpha1.Completed:
+			summary.Completed++
+		default:
+			summary.InProgress++
+		}
+	}
+	for _, batch := range clusterGroupUpgrade.Status.RemediationPlan {
+		for _, cluster := range batch {
+			count(cluster)
+		}
+	}
+	// Clusters already compliant, or taken out of the remediation plan
+	for _, clusterState := range clusterGroupUpgrade.Status.Clusters {
+		count(clusterState.Name)
+	}
+
+	if summary.Total > 0 {
+		summary.PercentComplete = (summary.Completed + summary.Failed) * 100 / summary.Total
+	}
+	summary.EstimatedCompletionTime = estimateCompletionTime(clusterGroupUpgrade, clusterStates, now)
+	return summary
+}
+
+// estimateCompletionTime estimates the completion time of the upgrade from the average remediation duration
+// of the clusters of the previous batches. The current batch is expected to take that duration from its start,
+// and each of the next batches that duration too. The estimate is bounded by the timeout of the upgrade
+// returns: *metav1.Time, nil while no batch completed
+func estimateCompletionTime(clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
+	clusterStates map[string]string, now time.Time) *metav1.Time {
+
+	status := clusterGroupUpgrade.Status.Status
+	if status.CurrentBatch == 0 || !status.CompletedAt.IsZero() || status.CurrentBatchStartedAt.IsZero() {
+		return nil
+	}
+
+	var total time.Duration
+	var durations int
+	for batchIndex := 0; batchIndex < status.CurrentBatch-1 && batchIndex < len(clusterGroupUpgrade.Status.RemediationPlan); batchIndex++ {
+		for _, cluster := range clusterGroupUpgrade.Status.RemediationPlan[batchIndex] {
+			startedAt, completedAt := getClusterRemediationTimes(clusterGroupUpgrade, cluster, clusterStates[cluster])
+			if startedAt.IsZero() || completedAt.IsZero() {
+				continue
+			}
+			total += completedAt.Sub(startedAt.Time)
+			durations++
+		}
+	}
+	if durations == 0 {
+		return nil
+	}
+	average := total / time.Duration(durations)
+
+	estimate := status.CurrentBatchStartedAt.Add(average)
+	if estimate.Before(now) {
+		// The current batch is taking longer than the previous ones
+		estimate = now
+	}
+	if remainingBatches := len(clusterGroupUpgrade.Status.RemediationPlan) - status.CurrentBatch; remainingBatches > 0 {
+		estimate = estimate.Add(average * time.Duration(remainingBatches))
+	}
+	strategy := clusterGroupUpgrade.Spec.RemediationStrategy
+	if strategy != nil && strategy.Timeout > 0 && !status.StartedAt.IsZero() {
+		deadline := status.StartedAt.Add(time.Duration(strategy.Timeout) * time.Minute)
+		if estimate.After(deadline) {
+			estimate = deadline
+		}
+	}
+	estimatedCompletionTime := metav1.NewTime(estimate.Truncate(time.Second))
+	return &estimatedCompletionTime
+}
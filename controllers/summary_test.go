@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// newInProgressCgu returns a ClusterGroupUpgrade in its third batch, whose first two batches took 20 and 40
+// minutes on average
+func newInProgressCgu(startedAt time.Time) *ranv1alpha1.ClusterGroupUpgrade {
+	at := func(minutes int) metav1.Time {
+		return metav1.NewTime(startedAt.Add(time.Duration(minutes) * time.Minute))
+	}
+
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{
+		Spec: ranv1alpha1.ClusterGroupUpgradeSpec{
+			RemediationStrategy: &ranv1alpha1.RemediationStrategySpec{MaxConcurrency: 2, Timeout: 240}},
+	}
+	cgu.Status.Status.StartedAt = at(0)
+	cgu.Status.RemediationPlan = [][]string{{"spoke1", "spoke2"}, {"spoke3", "spoke4"}, {"spoke5", "spoke6"}, {"spoke7"}}
+	cgu.Status.Clusters = []ranv1alpha1.ClusterState{
+		{Name: "spoke0", State: utils.ClusterRemediationComplete},
+		{Name: "spoke1", State: utils.ClusterRemediationComplete},
+		{Name: "spoke2", State: utils.ClusterRemediationComplete},
+		{Name: "spoke3", State: utils.ClusterRemediationComplete},
+		{Name: "spoke4", State: utils.ClusterRemediationTimedout},
+		{Name: "spoke5", State: utils.ClusterRemediationFailed},
+	}
+	cgu.Status.Status.CurrentBatch = 3
+	cgu.Status.Status.CurrentBatchStartedAt = at(60)
+	cgu.Status.Status.CurrentBatchRemediationProgress = map[string]*ranv1alpha1.ClusterRemediationProgress{
+		"spoke6": {State: ranv1alpha1.InProgress},
+	}
+	history := func(start, end int, reason, state string) *ranv1alpha1.ClusterHistory {
+		return &ranv1alpha1.ClusterHistory{Transitions: []ranv1alpha1.ClusterTransition{
+			{Reason: eventReasonClusterBatchStarted, State: ranv1alpha1.NotStarted, Time: at(start)},
+			{Reason: reason, State: state, Time: at(end)},
+		}}
+	}
+	cgu.Status.ClusterHistory = map[string]*ranv1alpha1.ClusterHistory{
+		"spoke1": history(0, 10, eventReasonClusterRemediationComplete, utils.ClusterRemediationComplete),
+		"spoke2": history(0, 30, eventReasonClusterRemediationComplete, utils.ClusterRemediationComplete),
+		"spoke3": history(30, 50, eventReasonClusterRemediationComplete, utils.ClusterRemediationComplete),
+		"spoke4": history(30, 60, eventReasonClusterRemediationTimedOut, utils.ClusterRemediationTimedout),
+	}
+	return cgu
+}
+
+func TestSummary_newUpgradeSummary(t *testing.T) {
+	startedAt := time.Date(2023, 5, 1, 10, 0, 0, 0, time.UTC)
+	// The average remediation duration of the previous batches is 22m30s
+	average := 22*time.Minute + 30*time.Second
+
+	testcases := []struct {
+		name        string
+		update      func(cgu *ranv1alpha1.ClusterGroupUpgrade)
+		now         time.Time
+		expectedEta *metav1.Time
+	}{
+		{
+			name:        "current batch on time",
+			now:         startedAt.Add(70 * time.Minute),
+			expectedEta: &metav1.Time{Time: startedAt.Add(60*time.Minute + 2*average)},
+		},
+		{
+			name:        "current batch late",
+			now:         startedAt.Add(100 * time.Minute),
+			expectedEta: &metav1.Time{Time: startedAt.Add(100*time.Minute + average)},
+		},
+		{
+			name:        "bounded by the timeout",
+			now:         startedAt.Add(230 * time.Minute),
+			expectedEta: &metav1.Time{Time: startedAt.Add(240 * time.Minute)},
+		},
+		{
+			name: "no duration observed",
+			update: func(cgu *ranv1alpha1.ClusterGroupUpgrade) {
+				cgu.Status.ClusterHistory = nil
+			},
+			now: startedAt.Add(70 * time.Minute),
+		},
+		{
+			name: "completed",
+			update: func(cgu *ranv1alpha1.ClusterGroupUpgrade) {
+				cgu.Status.Status.CompletedAt = metav1.NewTime(startedAt.Add(70 * time.Minute))
+			},
+			now: startedAt.Add(70 * time.Minute),
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			cgu := newInProgressCgu(startedAt)
+			if tc.update != nil {
+				tc.update(cgu)
+			}
+			summary := newUpgradeSummary(cgu, tc.now)
+			assert.Equal(t, 8, summary.Total)
+			assert.Equal(t, 4, summary.Completed)
+			assert.Equal(t, 1, summary.InProgress)
+			assert.Equal(t, 2, summary.Failed)
+			assert.Equal(t, 1, summary.Pending)
+			assert.Equal(t, 75, summary.PercentComplete)
+			assert.Equal(t, tc.expectedEta, summary.EstimatedCompletionTime)
+		})
+	}
+}
+
+func TestSummary_newUpgradeSummaryNotStarted(t *testing.T) {
+	cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+	assert.Equal(t, &ranv1alpha1.UpgradeSummary{}, newUpgradeSummary(cgu, time.Now()))
+
+	cgu.Status.RemediationPlan = [][]string{{"spoke1", "spoke2"}, {"spoke3"}}
+	assert.Equal(t, &ranv1alpha1.UpgradeSummary{Total: 3, Pending: 3}, newUpgradeSummary(cgu, time.Now()))
+}
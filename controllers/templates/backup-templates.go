@@ -94,6 +94,17 @@ spec:
               -
                 args:
                   - launchBackup
+                  - --compression={{ .BackupCompression }}
+{{- if .BackupEncrypted }}
+                  - --encryption-key-file=/etc/backup-encryption/key
+{{- end }}
+{{- if .BackupObjectName }}
+                  - --object-storage-config=/etc/backup-object-storage
+                  - --object-name={{ .BackupObjectName }}
+{{- if .BackupRemoveLocal }}
+                  - --remove-local
+{{- end }}
+{{- end }}
                 image: {{ .WorkloadImage }} 
                 name: container-image
                 securityContext:
@@ -104,6 +115,18 @@ spec:
                   -
                     mountPath: /host
                     name: backup
+{{- if .BackupEncrypted }}
+                  -
+                    mountPath: /etc/backup-encryption
+                    name: backup-encryption-key
+                    readOnly: true
+{{- end }}
+{{- if .BackupObjectName }}
+                  -
+                    mountPath: /etc/backup-object-storage
+                    name: backup-object-storage
+                    readOnly: true
+{{- end }}
             restartPolicy: Never
             serviceAccountName: backup-agent
             volumes:
@@ -112,6 +135,18 @@ spec:
                   path: /
                   type: Directory
                 name: backup
+{{- if .BackupEncrypted }}
+              -
+                name: backup-encryption-key
+                secret:
+                  secretName: backup-encryption-key
+{{- end }}
+{{- if .BackupObjectName }}
+              -
+                name: backup-object-storage
+                secret:
+                  secretName: backup-object-storage
+{{- end }}
 `
 
 // MngClusterActDeleteBackupNS deletes namespace
@@ -156,3 +191,151 @@ spec:
     resource: namespaces
     name: openshift-talo-backup
 `
+
+// MngClusterViewBackupReportConfigMap creates mcv to read the backup reported by the job
+const MngClusterViewBackupReportConfigMap string = `
+{{ template "viewGVK"}}
+{{ template "metadata" . }}
+spec:
+  scope:
+    resource: configmap
+    name: backup-report
+    namespace: openshift-talo-backup
+`
+
+// MngClusterActCreateBackupCleanupJob creates k8s job cleaning the backup content
+const MngClusterActCreateBackupCleanupJob string = `
+{{ template "actionGVK"}}
+{{ template "metadata" . }}
+spec:
+  actionType: Create
+  kube:
+    namespace: openshift-talo-backup
+    resource: job
+    template:
+      apiVersion: batch/v1
+      kind: Job
+      metadata:
+        name: backup-cleanup
+        namespace: openshift-talo-backup
+        annotations:
+          target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+      spec:
+        activeDeadlineSeconds: {{ .JobTimeout }}
+        backoffLimit: 0
+        template:
+          metadata:
+            name: backup-cleanup
+            annotations:
+              target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+          spec:
+            containers:
+              -
+                args:
+                  - cleanBackup
+                image: {{ .WorkloadImage }}
+                name: container-image
+                securityContext:
+                  privileged: true
+                  runAsUser: 0
+                tty: true
+                volumeMounts:
+                  -
+                    mountPath: /host
+                    name: backup
+            restartPolicy: Never
+            serviceAccountName: backup-agent
+            volumes:
+              -
+                hostPath:
+                  path: /
+                  type: Directory
+                name: backup
+`
+
+// MngClusterViewBackupCleanupJob creates mcv to monitor the backup cleanup job
+const MngClusterViewBackupCleanupJob string = `
+{{ template "viewGVK"}}
+{{ template "metadata" . }}
+spec:
+  scope:
+    resource: jobs
+    name: backup-cleanup
+    namespace: openshift-talo-backup
+`
+
+// MngClusterActCreateBackupVerifyJob creates k8s job verifying the backup content against its manifest
+const MngClusterActCreateBackupVerifyJob string = `
+{{ template "actionGVK"}}
+{{ template "metadata" . }}
+spec:
+  actionType: Create
+  kube:
+    namespace: openshift-talo-backup
+    resource: job
+    template:
+      apiVersion: batch/v1
+      kind: Job
+      metadata:
+        name: backup-verify
+        namespace: openshift-talo-backup
+        annotations:
+          target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+      spec:
+        activeDeadlineSeconds: {{ .JobTimeout }}
+        backoffLimit: 0
+        template:
+          metadata:
+            name: backup-verify
+            annotations:
+              target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+          spec:
+            containers:
+              -
+                args:
+                  - verify
+{{- if .BackupObjectName }}
+                  - --object-storage-config=/etc/backup-object-storage
+{{- end }}
+                image: {{ .WorkloadImage }}
+                name: container-image
+                securityContext:
+                  privileged: true
+                  runAsUser: 0
+                tty: true
+                volumeMounts:
+                  -
+                    mountPath: /host
+                    name: backup
+{{- if .BackupObjectName }}
+                  -
+                    mountPath: /etc/backup-object-storage
+                    name: backup-object-storage
+                    readOnly: true
+{{- end }}
+            restartPolicy: Never
+            serviceAccountName: backup-agent
+            volumes:
+              -
+                hostPath:
+                  path: /
+                  type: Directory
+                name: backup
+{{- if .BackupObjectName }}
+              -
+                name: backup-object-storage
+                secret:
+                  secretName: backup-object-storage
+{{- end }}
+`
+
+// MngClusterViewBackupVerifyJob creates mcv to monitor the backup verify job
+const MngClusterViewBackupVerifyJob string = `
+{{ template "viewGVK"}}
+{{ template "metadata" . }}
+spec:
+  scope:
+    resource: jobs
+    name: backup-verify
+    namespace: openshift-talo-backup
+`
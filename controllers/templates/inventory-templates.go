@@ -0,0 +1,113 @@
+package templates
+
+// Templates for image inventory job lifecycle
+
+// MngClusterActCreateInventorySpecCM creates the image inventory spec configmap
+const MngClusterActCreateInventorySpecCM string = `
+{{ template "actionGVK"}}
+{{ template "metadata" . }}
+spec:
+  actionType: Create
+  kube:
+    resource: configmap
+    template:
+      apiVersion: v1
+      data:
+        prune: "{{ .PruneImages }}"
+        keepImages: |{{ range .KeepImages }}
+          {{ . }} {{ end }}
+      kind: ConfigMap
+      metadata:
+        name: pre-cache-inventory-spec
+        namespace: openshift-talo-pre-cache
+`
+
+// MngClusterActCreateInventoryJob creates the image inventory k8s job
+const MngClusterActCreateInventoryJob string = `
+{{ template "actionGVK"}}
+{{ template "metadata" . }}
+spec:
+  actionType: Create
+  kube:
+    resource: job
+    namespace: openshift-talo-pre-cache
+    template:
+      apiVersion: batch/v1
+      kind: Job
+      metadata:
+        name: pre-cache-inventory
+        namespace: openshift-talo-pre-cache
+        annotations:
+          target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+      spec:
+        activeDeadlineSeconds: {{ .JobTimeout }}
+        backoffLimit: 0
+        template:
+          metadata:
+            name: pre-cache-inventory
+            annotations:
+              target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+          spec:
+            containers:
+            - args:
+              - /opt/precache/inventory.sh
+              command:
+              - /bin/bash
+              - -c
+              env:
+              - name: config_volume_path
+                value: /tmp/precache/config
+              image: {{ .WorkloadImage }}
+              name: pre-cache-inventory-container
+              resources: {}
+              securityContext:
+                privileged: true
+                runAsUser: 0
+              terminationMessagePath: /dev/termination-log
+              terminationMessagePolicy: File
+              volumeMounts:
+              - mountPath: /etc/config
+                name: config-volume
+                readOnly: true
+              - mountPath: /host
+                name: host
+            dnsPolicy: ClusterFirst
+            restartPolicy: Never
+            schedulerName: default-scheduler
+            securityContext: {}
+            serviceAccountName: pre-cache-agent
+            priorityClassName: system-cluster-critical
+            volumes:
+            - configMap:
+                defaultMode: 420
+                name: pre-cache-inventory-spec
+              name: config-volume
+            - hostPath:
+                path: /
+                type: Directory
+              name: host
+`
+
+// MngClusterViewInventoryJob creates mcv to monitor the image inventory k8s job
+const MngClusterViewInventoryJob string = `
+{{ template "viewGVK"}}
+{{ template "metadata" . }}
+spec:
+  scope:
+    resource: jobs
+    name: pre-cache-inventory
+    namespace: openshift-talo-pre-cache
+    updateIntervalSeconds: {{ .ViewUpdateIntervalSec }}
+`
+
+// MngClusterViewInventoryConfigMap creates mcv to read the image inventory reported by the job
+const MngClusterViewInventoryConfigMap string = `
+{{ template "viewGVK"}}
+{{ template "metadata" . }}
+spec:
+  scope:
+    resource: configmap
+    name: pre-cache-inventory
+    namespace: openshift-talo-pre-cache
+    updateIntervalSeconds: {{ .ViewUpdateIntervalSec }}
+`
@@ -55,11 +55,15 @@ spec:
           {{ . }} {{ end }}
         operators.packagesAndChannels: |{{ range .Operators.PackagesAndChannels }} 
           {{ . }} {{ end }}
+        operators.images: |{{ range .Operators.Images }}
+          {{ . }} {{ end }}
         excludePrecachePatterns: |{{ range .ExcludePrecachePatterns }} 
           {{ . }} {{ end }}
         additionalImages: |{{ range .AdditionalImages }}
           {{ . }} {{ end }}
         platform.image: {{ .PlatformImage }}
+        platform.intermediateImages: |{{ range .IntermediatePlatformImages }}
+          {{ . }} {{ end }}
         spaceRequired: "{{ .SpaceRequired }}"
       kind: ConfigMap
       metadata:
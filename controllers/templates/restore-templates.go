@@ -0,0 +1,169 @@
+package templates
+
+// Templates for restore job lifecycle
+
+// MngClusterActCreateRestoreNS creates namespace
+const MngClusterActCreateRestoreNS string = `
+{{ template "actionGVK"}}
+{{ template "metadata" . }}
+spec:
+  actionType: Create
+  kube:
+    resource: namespace
+    template:
+      apiVersion: v1
+      kind: Namespace
+      metadata:
+        name: openshift-talo-restore
+        labels:
+          pod-security.kubernetes.io/enforce: privileged
+        annotations:
+          workload.openshift.io/allowed: management
+`
+
+// MngClusterActCreateRestoreSA creates serviceaccount
+const MngClusterActCreateRestoreSA string = `
+{{ template "actionGVK"}}
+{{ template "metadata" . }}
+spec:
+  actionType: Create
+  kube:
+    resource: serviceaccount
+    template:
+      apiVersion: v1
+      kind: ServiceAccount
+      metadata:
+        name: restore-agent
+        namespace: openshift-talo-restore
+`
+
+// MngClusterActCreateRestoreRB creates clusterrolebinding
+const MngClusterActCreateRestoreRB string = `
+{{ template "actionGVK"}}
+{{ template "metadata" . }}
+spec:
+  actionType: Create
+  kube:
+    resource: clusterrolebinding
+    template:
+      apiVersion: rbac.authorization.k8s.io/v1
+      kind: ClusterRoleBinding
+      metadata:
+        name: restore-agent
+      roleRef:
+        apiGroup: rbac.authorization.k8s.io
+        kind: ClusterRole
+        name: cluster-admin
+      subjects:
+        - kind: ServiceAccount
+          name: restore-agent
+          namespace: openshift-talo-restore
+`
+
+// MngClusterActCreateRestoreJob creates k8s job
+const MngClusterActCreateRestoreJob string = `
+{{ template "actionGVK"}}
+{{ template "metadata" . }}
+spec:
+  actionType: Create
+  kube:
+    namespace: openshift-talo-restore
+    resource: job
+    template:
+      apiVersion: batch/v1
+      kind: Job
+      metadata:
+        name: restore-agent
+        namespace: openshift-talo-restore
+        annotations:
+          target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+      spec:
+        activeDeadlineSeconds: {{ .JobTimeout }}
+        backoffLimit: 0
+        template:
+          metadata:
+            name: restore-agent
+            annotations:
+              target.workload.openshift.io/management: '{"effect":"PreferredDuringScheduling"}'
+          spec:
+            containers:
+              -
+                args:
+                  - launchRestore
+{{- if .BackupEncrypted }}
+                  - --encryption-key-file=/etc/backup-encryption/key
+{{- end }}
+                image: {{ .WorkloadImage }}
+                name: container-image
+                securityContext:
+                  privileged: true
+                  runAsUser: 0
+                tty: true
+                volumeMounts:
+                  -
+                    mountPath: /host
+                    name: restore
+{{- if .BackupEncrypted }}
+                  -
+                    mountPath: /etc/backup-encryption
+                    name: backup-encryption-key
+                    readOnly: true
+{{- end }}
+            restartPolicy: Never
+            serviceAccountName: restore-agent
+            volumes:
+              -
+                hostPath:
+                  path: /
+                  type: Directory
+                name: restore
+{{- if .BackupEncrypted }}
+              -
+                name: backup-encryption-key
+                secret:
+                  secretName: backup-encryption-key
+{{- end }}
+`
+
+// MngClusterActDeleteRestoreNS deletes namespace
+const MngClusterActDeleteRestoreNS string = `
+{{ template "actionGVK"}}
+{{ template "metadata" . }}
+spec:
+  actionType: Delete
+  kube:
+    name: openshift-talo-restore
+    resource: namespace
+`
+
+// MngClusterActDeleteRestoreCRB deletes clusterrolebinding
+const MngClusterActDeleteRestoreCRB string = `
+{{ template "actionGVK"}}
+{{ template "metadata" . }}
+spec:
+  actionType: Delete
+  kube:
+    name: restore-agent
+    resource: clusterrolebinding
+`
+
+// MngClusterViewRestoreJob creates mcv to monitor k8s job
+const MngClusterViewRestoreJob string = `
+{{ template "viewGVK"}}
+{{ template "metadata" . }}
+spec:
+  scope:
+    resource: jobs
+    name: restore-agent
+    namespace: openshift-talo-restore
+`
+
+// MngClusterViewRestoreNS creates mcv to monitor spoke cluster's namespace
+const MngClusterViewRestoreNS string = `
+{{ template "viewGVK"}}
+{{ template "metadata" . }}
+spec:
+  scope:
+    resource: namespaces
+    name: openshift-talo-restore
+`
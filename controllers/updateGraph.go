@@ -0,0 +1,132 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/updategraph"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Update graph operator configuration, set in the environment of the operator
+const (
+	// UpdateGraphCAFileEnv is the path of a PEM bundle of additional CAs trusted for the update service
+	UpdateGraphCAFileEnv = "UPDATE_GRAPH_CA_FILE"
+	// UpdateGraphProxyEnv is the URL of the proxy used to reach the update service
+	UpdateGraphProxyEnv = "UPDATE_GRAPH_PROXY"
+	// UpdateGraphCacheTTLEnv is how long update graphs are cached, as a duration (10m by default)
+	UpdateGraphCacheTTLEnv = "UPDATE_GRAPH_CACHE_TTL"
+	// UpdateGraphConfigMapEnv is the <namespace>/<name> of a ConfigMap holding the update graphs,
+	// keyed by channel. When set, the update service is not contacted
+	UpdateGraphConfigMapEnv = "UPDATE_GRAPH_CONFIGMAP"
+	// InsecureGraphCallEnv disables the verification of the update service certificate.
+	// Deprecated: use UpdateGraphCAFileEnv
+	InsecureGraphCallEnv = "INSECURE_GRAPH_CALL"
+)
+
+const (
+	// defaultUpdateGraphUpstream is the public OpenShift update service
+	defaultUpdateGraphUpstream = "https://api.openshift.com/api/upgrades_info/v1/graph"
+	updateGraphRequestTimeout  = 30 * time.Second
+	// updateGraphDefaultKey is the key of the offline update graph used for channels without their own key
+	updateGraphDefaultKey = "graph.json"
+)
+
+var (
+	defaultUpdateGraphClient     *updategraph.Client
+	defaultUpdateGraphClientOnce sync.Once
+)
+
+// NewUpdateGraphClient creates the update graph client from the operator configuration
+// returns: *updategraph.Client, error
+func NewUpdateGraphClient() (*updategraph.Client, error) {
+	config := updategraph.Config{
+		Proxy:              os.Getenv(UpdateGraphProxyEnv),
+		InsecureSkipVerify: os.Getenv(InsecureGraphCallEnv) == "true",
+		Timeout:            updateGraphRequestTimeout,
+	}
+	if caFile := os.Getenv(UpdateGraphCAFileEnv); caFile != "" {
+		caBundle, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read the update graph CA bundle: %w", err)
+		}
+		config.CABundle = caBundle
+	}
+	if ttl := os.Getenv(UpdateGraphCacheTTLEnv); ttl != "" {
+		duration, err := time.ParseDuration(ttl)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s value %s: %w", UpdateGraphCacheTTLEnv, ttl, err)
+		}
+		config.CacheTTL = duration
+	}
+	return updategraph.NewClient(config)
+}
+
+// getUpdateGraphClient returns the update graph client of the reconciler, or a default one
+// returns: *updategraph.Client
+func (r *ClusterGroupUpgradeReconciler) getUpdateGraphClient() *updategraph.Client {
+	if r.UpdateGraph != nil {
+		return r.UpdateGraph
+	}
+	defaultUpdateGraphClientOnce.Do(func() {
+		defaultUpdateGraphClient, _ = updategraph.NewClient(updategraph.Config{Timeout: updateGraphRequestTimeout})
+	})
+	return defaultUpdateGraphClient
+}
+
+// getUpdateGraph gets the update graph of the channel, from the offline ConfigMap when configured
+// or else from the upstream update service
+// returns: *updategraph.Graph, error
+func (r *ClusterGroupUpgradeReconciler) getUpdateGraph(
+	ctx context.Context, upstream, channel string) (*updategraph.Graph, error) {
+
+	if offline := os.Getenv(UpdateGraphConfigMapEnv); offline != "" {
+		return r.getOfflineUpdateGraph(ctx, offline, channel)
+	}
+	return r.getUpdateGraphClient().GetGraph(ctx, upstream, channel)
+}
+
+// getOfflineUpdateGraph reads the update graph of the channel from the ConfigMap
+// returns: *updategraph.Graph, error
+func (r *ClusterGroupUpgradeReconciler) getOfflineUpdateGraph(
+	ctx context.Context, configMapRef, channel string) (*updategraph.Graph, error) {
+
+	namespace, name, found := strings.Cut(configMapRef, "/")
+	if !found || namespace == "" || name == "" {
+		return nil, fmt.Errorf("invalid %s value %s, expected <namespace>/<name>", UpdateGraphConfigMapEnv, configMapRef)
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, configMap); err != nil {
+		return nil, fmt.Errorf("unable to get the update graph ConfigMap %s: %w", configMapRef, err)
+	}
+	data, ok := configMap.Data[channel]
+	if !ok {
+		data, ok = configMap.Data[updateGraphDefaultKey]
+	}
+	if !ok {
+		return nil, fmt.Errorf("no update graph for channel %s in ConfigMap %s", channel, configMapRef)
+	}
+	return updategraph.ParseGraph([]byte(data))
+}
+
+// getImageForVersionFromUpdateGraph gets the release image of the version from the update graph
+// of the channel
+// returns: string, error
+func (r *ClusterGroupUpgradeReconciler) getImageForVersionFromUpdateGraph(
+	ctx context.Context, upstream, channel, version string) (string, error) {
+
+	graph, err := r.getUpdateGraph(ctx, upstream, channel)
+	if err != nil {
+		return "", err
+	}
+	image, ok := graph.ReleaseImage(version)
+	if !ok {
+		return "", fmt.Errorf("unable to find version %s on update graph of channel %s", version, channel)
+	}
+	return image, nil
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUpdateGraph_getImageForVersionFromOfflineUpdateGraph(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "update-graph", Namespace: "openshift-cluster-group-upgrades"},
+		Data: map[string]string{
+			"stable-4.12": `{"nodes":[{"version":"4.12.1","payload":"quay.io/openshift-release-dev/ocp-release@sha256:01"}],"edges":[]}`,
+			"graph.json":  `{"nodes":[{"version":"4.13.1","payload":"quay.io/openshift-release-dev/ocp-release@sha256:02"}],"edges":[]}`,
+		},
+	}
+	testcases := []struct {
+		name          string
+		configMapRef  string
+		channel       string
+		version       string
+		expectedImage string
+		expectedErr   bool
+	}{
+		{
+			name:          "graph of the channel",
+			configMapRef:  "openshift-cluster-group-upgrades/update-graph",
+			channel:       "stable-4.12",
+			version:       "4.12.1",
+			expectedImage: "quay.io/openshift-release-dev/ocp-release@sha256:01",
+		},
+		{
+			name:          "default graph",
+			configMapRef:  "openshift-cluster-group-upgrades/update-graph",
+			channel:       "stable-4.13",
+			version:       "4.13.1",
+			expectedImage: "quay.io/openshift-release-dev/ocp-release@sha256:02",
+		},
+		{
+			name:         "version not in the graph",
+			configMapRef: "openshift-cluster-group-upgrades/update-graph",
+			channel:      "stable-4.12",
+			version:      "4.12.2",
+			expectedErr:  true,
+		},
+		{
+			name:         "missing ConfigMap",
+			configMapRef: "openshift-cluster-group-upgrades/missing",
+			channel:      "stable-4.12",
+			version:      "4.12.1",
+			expectedErr:  true,
+		},
+		{
+			name:         "invalid ConfigMap reference",
+			configMapRef: "update-graph",
+			channel:      "stable-4.12",
+			version:      "4.12.1",
+			expectedErr:  true,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(UpdateGraphConfigMapEnv, tc.configMapRef)
+			fakeClient, _ := getFakeClientFromObjects(configMap)
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			image, err := r.getImageForVersionFromUpdateGraph(
+				context.TODO(), "https://api.openshift.com/api/upgrades_info/v1/graph", tc.channel, tc.version)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expectedImage, image)
+		})
+	}
+}
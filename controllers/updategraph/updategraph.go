@@ -0,0 +1,266 @@
+// Package updategraph provides a caching client for the OpenShift update graph (Cincinnati) API
+package updategraph
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DefaultCacheTTL is how long an update graph is served from the cache by default
+const DefaultCacheTTL = 10 * time.Minute
+
+// Node is a release of the update graph
+type Node struct {
+	Version  string            `json:"version"`
+	Payload  string            `json:"payload"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Update is an update from a release version to another
+type Update struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Risk is a known issue of conditional updates, affecting the clusters matching its rules
+type Risk struct {
+	Name    string `json:"name"`
+	Message string `json:"message,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// ConditionalEdge are updates only recommended to the clusters not exposed to the risks
+type ConditionalEdge struct {
+	Edges []Update `json:"edges"`
+	Risks []Risk   `json:"risks"`
+}
+
+// Graph is the update graph of a channel. Edges are pairs of indexes in Nodes
+// of the releases updating from one to the other
+type Graph struct {
+	Nodes            []Node            `json:"nodes"`
+	Edges            [][2]int          `json:"edges"`
+	ConditionalEdges []ConditionalEdge `json:"conditionalEdges,omitempty"`
+}
+
+// ParseGraph parses the update graph JSON document
+// returns: *Graph, error
+func ParseGraph(data []byte) (*Graph, error) {
+	graph := &Graph{}
+	if err := json.Unmarshal(data, graph); err != nil {
+		return nil, fmt.Errorf("unable to unmarshal update graph: %w", err)
+	}
+	for _, edge := range graph.Edges {
+		for _, index := range edge {
+			if index < 0 || index >= len(graph.Nodes) {
+				return nil, fmt.Errorf("invalid update graph edge %v", edge)
+			}
+		}
+	}
+	return graph, nil
+}
+
+// ReleaseImage returns the payload of the release of the version
+// returns: string, bool - false when the version is not in the graph
+func (g *Graph) ReleaseImage(version string) (string, bool) {
+	for _, node := range g.Nodes {
+		if node.Version == version && node.Payload != "" {
+			return node.Payload, true
+		}
+	}
+	return "", false
+}
+
+// HasRelease returns whether the version is a release of the graph
+// returns: bool
+func (g *Graph) HasRelease(version string) bool {
+	for _, node := range g.Nodes {
+		if node.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// HasUpdate returns whether the graph recommends the update from a version to the other
+// without conditions
+// returns: bool
+func (g *Graph) HasUpdate(from, to string) bool {
+	for _, edge := range g.Edges {
+		if g.Nodes[edge[0]].Version == from && g.Nodes[edge[1]].Version == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ConditionalUpdateRisks returns the risks of the conditional update from a version to the other
+// returns: []Risk, bool - false when there is no conditional update between the versions
+func (g *Graph) ConditionalUpdateRisks(from, to string) ([]Risk, bool) {
+	for _, conditionalEdge := range g.ConditionalEdges {
+		for _, update := range conditionalEdge.Edges {
+			if update.From == from && update.To == to {
+				return conditionalEdge.Risks, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Path walks the graph for the shortest chain of updates from a version to the other.
+// Conditional updates are only followed when conditional is set
+// returns: []string - the versions of the path, from and to included, or nil when there is no path
+func (g *Graph) Path(from, to string, conditional bool) []string {
+	next := make(map[string][]string)
+	for _, edge := range g.Edges {
+		next[g.Nodes[edge[0]].Version] = append(next[g.Nodes[edge[0]].Version], g.Nodes[edge[1]].Version)
+	}
+	if conditional {
+		for _, conditionalEdge := range g.ConditionalEdges {
+			for _, update := range conditionalEdge.Edges {
+				next[update.From] = append(next[update.From], update.To)
+			}
+		}
+	}
+
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		version := queue[0]
+		queue = queue[1:]
+		if version == to {
+			var path []string
+			for ; version != from; version = previous[version] {
+				path = append([]string{version}, path...)
+			}
+			return append([]string{from}, path...)
+		}
+		for _, target := range next[version] {
+			if _, seen := previous[target]; !seen {
+				previous[target] = version
+				queue = append(queue, target)
+			}
+		}
+	}
+	return nil
+}
+
+// Config holds the connection settings of the client
+type Config struct {
+	// CABundle is a PEM bundle of the certificate authorities trusted in addition to the system ones
+	CABundle []byte
+	// Proxy is the URL of the proxy used to reach the update service. When empty,
+	// the proxy is taken from the HTTPS_PROXY and NO_PROXY environment variables
+	Proxy string
+	// InsecureSkipVerify disables the verification of the update service certificate
+	InsecureSkipVerify bool
+	// CacheTTL is how long a graph is served from the cache. DefaultCacheTTL when zero
+	CacheTTL time.Duration
+	// Timeout of the requests to the update service
+	Timeout time.Duration
+}
+
+type cacheEntry struct {
+	graph     *Graph
+	fetchedAt time.Time
+}
+
+// Client fetches update graphs and caches them per upstream and channel
+type Client struct {
+	httpClient *http.Client
+	ttl        time.Duration
+	now        func() time.Time
+	mutex      sync.Mutex
+	cache      map[string]cacheEntry
+}
+
+// NewClient creates an update graph client from the configuration
+// returns: *Client, error
+func NewClient(config Config) (*Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: config.InsecureSkipVerify}
+	if len(config.CABundle) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(config.CABundle) {
+			return nil, fmt.Errorf("no certificate found in the update graph CA bundle")
+		}
+		transport.TLSClientConfig.RootCAs = pool
+	}
+	if config.Proxy != "" {
+		proxy, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid update graph proxy %s: %w", config.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	return NewClientWithHTTPClient(&http.Client{Transport: transport, Timeout: config.Timeout}, config.CacheTTL), nil
+}
+
+// NewClientWithHTTPClient creates an update graph client using the HTTP client
+// returns: *Client
+func NewClientWithHTTPClient(httpClient *http.Client, ttl time.Duration) *Client {
+	if ttl == 0 {
+		ttl = DefaultCacheTTL
+	}
+	return &Client{
+		httpClient: httpClient,
+		ttl:        ttl,
+		now:        time.Now,
+		cache:      make(map[string]cacheEntry),
+	}
+}
+
+// GetGraph returns the update graph of the channel from the upstream update service.
+// Graphs are served from the cache until they expire
+// returns: *Graph, error
+func (c *Client) GetGraph(ctx context.Context, upstream, channel string) (*Graph, error) {
+	updateGraphURL := upstream + "?channel=" + url.QueryEscape(channel)
+
+	c.mutex.Lock()
+	entry, ok := c.cache[updateGraphURL]
+	c.mutex.Unlock()
+	if ok && c.now().Sub(entry.fetchedAt) < c.ttl {
+		return entry.graph, nil
+	}
+
+	graph, err := c.fetchGraph(ctx, updateGraphURL)
+	if err != nil {
+		return nil, err
+	}
+	c.mutex.Lock()
+	c.cache[updateGraphURL] = cacheEntry{graph: graph, fetchedAt: c.now()}
+	c.mutex.Unlock()
+	return graph, nil
+}
+
+func (c *Client) fetchGraph(ctx context.Context, updateGraphURL string) (*Graph, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, updateGraphURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("invalid update graph url %s: %w", updateGraphURL, err)
+	}
+	req.Header.Add("Accept", "application/json")
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to request update graph on url %s: %w", updateGraphURL, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("error response from update graph url %s: %d", updateGraphURL, res.StatusCode)
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read body from response: %w", err)
+	}
+	return ParseGraph(body)
+}
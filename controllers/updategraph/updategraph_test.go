@@ -0,0 +1,155 @@
+package updategraph
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testGraph = `{"nodes":[
+	{"version":"4.12.1","payload":"quay.io/openshift-release-dev/ocp-release@sha256:01"},
+	{"version":"4.12.2","payload":"quay.io/openshift-release-dev/ocp-release@sha256:02"}],
+	"edges":[[0,1]]}`
+
+func newGraphServer(requests *int) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		*requests++
+		if req.URL.Query().Get("channel") != "stable-4.12" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(testGraph))
+	}
+}
+
+func TestUpdateGraph_ParseGraph(t *testing.T) {
+	graph, err := ParseGraph([]byte(testGraph))
+	assert.NoError(t, err)
+	assert.Equal(t, [][2]int{{0, 1}}, graph.Edges)
+	image, ok := graph.ReleaseImage("4.12.2")
+	assert.True(t, ok)
+	assert.Equal(t, "quay.io/openshift-release-dev/ocp-release@sha256:02", image)
+	_, ok = graph.ReleaseImage("4.12.3")
+	assert.False(t, ok)
+
+	_, err = ParseGraph([]byte(`{"nodes":[{"version":"4.12.1"}],"edges":[[0,1]]}`))
+	assert.Error(t, err)
+}
+
+func TestUpdateGraph_GetGraphCache(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(newGraphServer(&requests))
+	defer server.Close()
+
+	now := time.Now()
+	client := NewClientWithHTTPClient(server.Client(), time.Minute)
+	client.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		graph, err := client.GetGraph(context.TODO(), server.URL, "stable-4.12")
+		assert.NoError(t, err)
+		assert.Len(t, graph.Nodes, 2)
+	}
+	assert.Equal(t, 1, requests)
+
+	// Another channel is fetched on its own, errors are not cached
+	_, err := client.GetGraph(context.TODO(), server.URL, "stable-4.13")
+	assert.Error(t, err)
+	_, err = client.GetGraph(context.TODO(), server.URL, "stable-4.13")
+	assert.Error(t, err)
+	assert.Equal(t, 3, requests)
+
+	now = now.Add(2 * time.Minute)
+	_, err = client.GetGraph(context.TODO(), server.URL, "stable-4.12")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, requests)
+}
+
+func TestUpdateGraph_NewClient(t *testing.T) {
+	var requests int
+	server := httptest.NewTLSServer(newGraphServer(&requests))
+	defer server.Close()
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+
+	var proxied int
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		proxied++
+		_, _ = w.Write([]byte(testGraph))
+	}))
+	defer proxy.Close()
+
+	testcases := []struct {
+		name            string
+		config          Config
+		upstream        string
+		expectedErr     bool
+		expectedProxied int
+	}{
+		{
+			name:        "untrusted update service",
+			upstream:    server.URL,
+			expectedErr: true,
+		},
+		{
+			name:     "custom CA bundle",
+			config:   Config{CABundle: caBundle},
+			upstream: server.URL,
+		},
+		{
+			name:     "insecure update service",
+			config:   Config{InsecureSkipVerify: true},
+			upstream: server.URL,
+		},
+		{
+			name:            "proxy",
+			config:          Config{Proxy: proxy.URL},
+			upstream:        "http://api.openshift.example.com/api/upgrades_info/v1/graph",
+			expectedProxied: 1,
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			proxied = 0
+			client, err := NewClient(tc.config)
+			assert.NoError(t, err)
+			graph, err := client.GetGraph(context.TODO(), tc.upstream, "stable-4.12")
+			assert.Equal(t, tc.expectedProxied, proxied)
+			if tc.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, graph.Nodes, 2)
+		})
+	}
+
+	_, err := NewClient(Config{CABundle: []byte("not a certificate")})
+	assert.Error(t, err)
+}
+
+func TestUpdateGraph_Path(t *testing.T) {
+	graph, err := ParseGraph([]byte(`{"nodes":[
+		{"version":"4.12.1"},{"version":"4.12.2"},{"version":"4.12.3"},{"version":"4.12.4"}],
+		"edges":[[0,1],[1,2]],
+		"conditionalEdges":[{"edges":[{"from":"4.12.2","to":"4.12.4"}],"risks":[{"name":"ExampleRisk"}]}]}`))
+	assert.NoError(t, err)
+
+	assert.True(t, graph.HasUpdate("4.12.1", "4.12.2"))
+	assert.False(t, graph.HasUpdate("4.12.1", "4.12.3"))
+	assert.False(t, graph.HasUpdate("4.12.2", "4.12.4"))
+	risks, ok := graph.ConditionalUpdateRisks("4.12.2", "4.12.4")
+	assert.True(t, ok)
+	assert.Equal(t, []Risk{{Name: "ExampleRisk"}}, risks)
+	_, ok = graph.ConditionalUpdateRisks("4.12.1", "4.12.2")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"4.12.1", "4.12.2", "4.12.3"}, graph.Path("4.12.1", "4.12.3", false))
+	assert.Nil(t, graph.Path("4.12.1", "4.12.4", false))
+	assert.Equal(t, []string{"4.12.1", "4.12.2", "4.12.4"}, graph.Path("4.12.1", "4.12.4", true))
+	assert.Nil(t, graph.Path("4.12.3", "4.12.1", true))
+}
@@ -11,18 +11,22 @@ type ConditionType string
 // ConditionTypes define the different types of conditions that will be set
 var ConditionTypes = struct {
 	BackupSuceeded     ConditionType
+	ClustersFailed     ConditionType
 	ClustersSelected   ConditionType
 	PrecacheSpecValid  ConditionType
 	PrecachingSuceeded ConditionType
 	Progressing        ConditionType
+	RestoreSucceeded   ConditionType
 	Succeeded          ConditionType
 	Validated          ConditionType
 }{
 	BackupSuceeded:     "BackupSuceeded",
+	ClustersFailed:     "ClustersFailed",
 	ClustersSelected:   "ClustersSelected",
 	PrecacheSpecValid:  "PrecacheSpecValid",
 	PrecachingSuceeded: "PrecachingSuceeded",
 	Progressing:        "Progressing",
+	RestoreSucceeded:   "RestoreSucceeded",
 	Succeeded:          "Succeeded",
 	Validated:          "Validated",
 }
@@ -34,13 +38,18 @@ type ConditionReason string
 var ConditionReasons = struct {
 	Completed                     ConditionReason
 	ClusterSelectionCompleted     ConditionReason
+	DryRun                        ConditionReason
 	ValidationCompleted           ConditionReason
 	BackupCompleted               ConditionReason
 	PrecachingCompleted           ConditionReason
+	RestoreCompleted              ConditionReason
 	Failed                        ConditionReason
 	IncompleteBlockingCR          ConditionReason
 	InProgress                    ConditionReason
+	InvalidImageBasedUpgrade      ConditionReason
+	InvalidRestore                ConditionReason
 	InvalidPlatformImage          ConditionReason
+	InvalidPreCachingConfig       ConditionReason
 	MissingBlockingCR             ConditionReason
 	NotAllManagedPoliciesExist    ConditionReason
 	AmbiguousManagedPoliciesNames ConditionReason
@@ -52,17 +61,24 @@ var ConditionReasons = struct {
 	PrecacheSpecIncomplete        ConditionReason
 	PrecacheSpecIsWellFormed      ConditionReason
 	TimedOut                      ConditionReason
+	UnavailableImages             ConditionReason
 	UnresolvableDenpendency       ConditionReason
+	UpgradePathUnavailable        ConditionReason
 }{
 	Completed:                     "Completed",
 	ClusterSelectionCompleted:     "ClusterSelectionCompleted",
+	DryRun:                        "DryRun",
 	ValidationCompleted:           "ValidationCompleted",
 	BackupCompleted:               "BackupCompleted",
 	PrecachingCompleted:           "PrecachingCompleted",
+	RestoreCompleted:              "RestoreCompleted",
 	Failed:                        "Failed",
 	IncompleteBlockingCR:          "IncompleteBlockingCR",
 	InProgress:                    "InProgress",
+	InvalidImageBasedUpgrade:      "InvalidImageBasedUpgrade",
+	InvalidRestore:                "InvalidRestore",
 	InvalidPlatformImage:          "InvalidPlatformImage",
+	InvalidPreCachingConfig:       "InvalidPreCachingConfig",
 	MissingBlockingCR:             "MissingBlockingCR",
 	NotAllManagedPoliciesExist:    "NotAllManagedPoliciesExist",
 	AmbiguousManagedPoliciesNames: "AmbiguousManagedPoliciesNames",
@@ -74,7 +90,9 @@ var ConditionReasons = struct {
 	PrecacheSpecIncomplete:        "PrecacheSpecIncomplete",
 	PrecacheSpecIsWellFormed:      "PrecacheSpecIsWellFormed",
 	TimedOut:                      "TimedOut",
+	UnavailableImages:             "UnavailableImages",
 	UnresolvableDenpendency:       "UnresolvableDenpendency",
+	UpgradePathUnavailable:        "UpgradePathUnavailable",
 }
 
 // SetStatusCondition is a convenience wrapper for meta.SetStatusCondition that takes in the types defined here and converts them to strings
@@ -8,6 +8,12 @@ const (
 	DefaultCGUControllerWorkerCount = 5
 )
 
+// Namespace of the operator, set from the downward API, holding the secrets of the notifications
+const (
+	OperatorNamespaceEnv     = "POD_NAMESPACE"
+	DefaultOperatorNamespace = "openshift-cluster-group-upgrades"
+)
+
 // RemediationActionEnforce - Policy remediation for policies.
 const (
 	RemediationActionEnforce = "enforce"
@@ -27,6 +33,7 @@ const (
 const (
 	ClusterRemediationComplete = "complete"
 	ClusterRemediationTimedout = "timedout"
+	ClusterRemediationFailed   = "failed"
 )
 
 // Label specific to ACM child policies.
@@ -34,6 +41,24 @@ const (
 	ChildPolicyLabel = "policy.open-cluster-management.io/root-policy"
 )
 
+// Labels of the policy copying the secrets of the backup and restore jobs to the clusters, and of its
+// PlacementRule and PlacementBinding. They are kept apart from the copied policies of the upgrade
+const (
+	SecretsForClusterGroupUpgradeLabel = "openshift-cluster-group-upgrades/secretsForClusterGroupUpgrade"
+	SecretsForJobLabel                 = "openshift-cluster-group-upgrades/secretsForJob"
+)
+
+// Labels of the ManagedClusterViews and ManagedClusterActions created by TALM. The views and actions of a
+// ClusterGroupUpgrade are labelled with its namespace and name, those rendered from the templates of the
+// pre-caching, backup and restore jobs with the name of their template
+const (
+	ClusterGroupUpgradeLabel    = "openshift-cluster-group-upgrades/clusterGroupUpgrade"
+	ManagedClusterResourceLabel = "openshift-cluster-group-upgrades/managedClusterResource"
+)
+
+// NotificationSecretLabel must be set to "true" on the secrets read for the auth header of the notifications
+const NotificationSecretLabel = "openshift-cluster-group-upgrades/notificationSecret"
+
 // Annotation for TALO created object names
 const (
 	DesiredResourceName = CsvNamePrefix + "/rname"
@@ -59,6 +84,18 @@ const (
 	PrecacheSpecValidCondition = "PrecacheSpecValid"
 )
 
+// Image inventory constants
+const (
+	ImageInventoryConfigMapName = "pre-cache-inventory"
+	ImageInventoryCGUAnnotation = CsvNamePrefix + "/clustergroupupgrade"
+)
+
+// Hub pull secret used for resolving operator bundles from the index images
+const (
+	HubPullSecretName      = "pull-secret"
+	HubPullSecretNamespace = "openshift-config"
+)
+
 // ViewUpdateSecPerCluster defines default ManagementClusterView update periodicity
 // When configuring managedclusterview for clusters in precache-starting state,
 // this value is multiplied by number of clusters, then bound by min and max
@@ -83,6 +120,21 @@ func ClusterVersionGroupVersionKind() schema.GroupVersionKind {
 	return schema.GroupVersionKind{Kind: "ClusterVersion", Group: "config.openshift.io"}
 }
 
+// MachineConfigPoolGroupVersionKind for the MachineConfigPools of the managed clusters
+func MachineConfigPoolGroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Kind: "MachineConfigPool", Group: "machineconfiguration.openshift.io", Version: "v1"}
+}
+
+// ImageBasedUpgradeGroupVersionKind for the ImageBasedUpgrade CR of the lifecycle agent on the managed clusters
+func ImageBasedUpgradeGroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Kind: "ImageBasedUpgrade", Group: "lca.openshift.io", Version: "v1"}
+}
+
+// ImageDigestMirrorSetGroupVersionKind for the mirror registries of the images pulled by digest
+func ImageDigestMirrorSetGroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Kind: "ImageDigestMirrorSet", Group: "config.openshift.io", Version: "v1"}
+}
+
 // Subscription possible states
 const (
 	SubscriptionStateAtLatestKnown  = "AtLatestKnown"
@@ -148,8 +200,19 @@ const (
 // SpaceRequiredForPrecache is an env variable for precaching job that indicates the amount of space required
 // for precaching job. This is a rough estimate. 30 GiB for OCP images and 5 GiB as a buffer for operator images
 // and other disk allocations that can happen between the job and the actual upgrade kicked off by the CGU.
+// It is only used when the space required can't be estimated from the sizes of the images to be pre-cached.
 const SpaceRequiredForPrecache = "35 GiB"
 
+// OpenshiftVersionClaimName is the ClusterClaim reporting the OpenShift version of a managed cluster
+const OpenshiftVersionClaimName = "version.openshift.io"
+
+// OpenshiftVersionLabelName is the ManagedCluster label reporting the OpenShift version of a managed cluster
+const OpenshiftVersionLabelName = "openshiftVersion"
+
 // SoakAnnotation is the annotation that can be set on policies, which indicates the least number of seconds
 // which policies should be compliant before the cgu moves on from that policy
 const SoakAnnotation = "ran.openshift.io/soak-seconds"
+
+// PrecachedSpecAnnotation is the annotation set on managed clusters, which records the hash of the
+// last pre-caching spec successfully pre-cached on the cluster
+const PrecachedSpecAnnotation = "ran.openshift.io/precached-spec-hash"
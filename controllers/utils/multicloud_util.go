@@ -25,6 +25,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -287,6 +288,82 @@ func NewManagedClusterActionForInstallPlanSpec(installPlan operatorsv1alpha1.Ins
 	return &actionSpec, nil
 }
 
+// GetManagedClusterViewResult returns the object retrieved by a view.
+// returns: the object, or nil while the view has not retrieved it (yet), error
+func GetManagedClusterViewResult(mcv *viewv1beta1.ManagedClusterView) (*unstructured.Unstructured, error) {
+	condition := meta.FindStatusCondition(mcv.Status.Conditions, viewv1beta1.ConditionViewProcessing)
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != viewv1beta1.ReasonGetResource {
+		multiCloudLog.Info("ManagedClusterView was not able to retrieve the requested resource (yet), trying again later",
+			"managedclusterview", mcv.ObjectMeta.Name, "namespace", mcv.ObjectMeta.Namespace)
+		return nil, nil
+	}
+	object := &unstructured.Unstructured{}
+	if err := object.UnmarshalJSON(mcv.Status.Result.Raw); err != nil {
+		return nil, fmt.Errorf("invalid result of ManagedClusterView %s: %w", mcv.ObjectMeta.Name, err)
+	}
+	return object, nil
+}
+
+// EnsureManagedClusterActionForUpdate creates an action updating an object of a managed cluster to the given
+// content, typically the object retrieved by a view with some changes. The status and server-managed metadata
+// of the object are left out. An action that failed, for example on a resource version conflict, is deleted so
+// that it is created again on the next call.
+// returns: bool - true when the action was created, error
+func EnsureManagedClusterActionForUpdate(
+	ctx context.Context, c client.Client, name, namespace, cguLabel, resource string,
+	object *unstructured.Unstructured) (bool, error) {
+
+	mca := &actionv1beta1.ManagedClusterAction{}
+	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: namespace}, mca)
+	if err == nil {
+		condition := meta.FindStatusCondition(mca.Status.Conditions, actionv1beta1.ConditionActionCompleted)
+		if condition != nil && condition.Status == metav1.ConditionFalse {
+			multiCloudLog.Info("[EnsureManagedClusterActionForUpdate] MCA failed, delete it",
+				"name", name, "namespace", namespace, "reason", condition.Reason, "message", condition.Message)
+			if err := c.Delete(ctx, mca); err != nil && !errors.IsNotFound(err) {
+				return false, err
+			}
+		}
+		return false, nil
+	}
+	if !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	template := object.DeepCopy()
+	unstructured.RemoveNestedField(template.Object, "status")
+	for _, field := range []string{"managedFields", "uid", "creationTimestamp", "generation", "selfLink"} {
+		unstructured.RemoveNestedField(template.Object, "metadata", field)
+	}
+	raw, err := template.MarshalJSON()
+	if err != nil {
+		return false, err
+	}
+
+	multiCloudLog.Info("[EnsureManagedClusterActionForUpdate] MCA doesn't exist, create it", "name", name, "namespace", namespace)
+	mca = &actionv1beta1.ManagedClusterAction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels: map[string]string{
+				"openshift-cluster-group-upgrades/clusterGroupUpgrade": cguLabel,
+			},
+		},
+		Spec: actionv1beta1.ActionSpec{
+			ActionType: actionv1beta1.UpdateActionType,
+			KubeWork: &actionv1beta1.KubeWorkSpec{
+				Resource:       resource,
+				Namespace:      object.GetNamespace(),
+				ObjectTemplate: runtime.RawExtension{Raw: raw},
+			},
+		},
+	}
+	if err := c.Create(ctx, mca); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 // DeleteMultiCloudObjects cleans up views and actions associated to a cluster.
 func DeleteMultiCloudObjects(
 	ctx context.Context, c client.Client, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusterName string) error {
@@ -301,9 +378,10 @@ func DeleteMultiCloudObjects(
 	return nil
 }
 
-// DeleteManagedClusterViews cleans up views associated to a cluster.
+// DeleteManagedClusterViews cleans up views associated to a cluster, except the views named in keep.
 func DeleteManagedClusterViews(
-	ctx context.Context, c client.Client, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusterName string) error {
+	ctx context.Context, c client.Client, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, clusterName string,
+	keep ...string) error {
 
 	var labels = map[string]string{
 		"openshift-cluster-group-upgrades/clusterGroupUpgrade": clusterGroupUpgrade.Namespace + "-" + clusterGroupUpgrade.Name}
@@ -317,7 +395,14 @@ func DeleteManagedClusterViews(
 		return err
 	}
 
+	kept := make(map[string]bool)
+	for _, name := range keep {
+		kept[name] = true
+	}
 	for _, mcv := range mcvList.Items {
+		if kept[mcv.Name] {
+			continue
+		}
 		multiCloudLog.Info("[DeleteManagedClusterViews] Delete ManagedClusterView", "ns", mcv.Namespace, "name", mcv.Name)
 		if err := c.Delete(ctx, &mcv); err != nil {
 			return err
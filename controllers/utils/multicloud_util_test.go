@@ -30,6 +30,7 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
@@ -1247,3 +1248,48 @@ func TestFinalMultiCloudObjectCleanup(t *testing.T) {
 		})
 	}
 }
+
+func TestEnsureManagedClusterActionForUpdate(t *testing.T) {
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "machineconfiguration.openshift.io/v1",
+		"kind":       "MachineConfigPool",
+		"metadata":   map[string]interface{}{"name": "worker", "uid": "1234", "resourceVersion": "10"},
+		"spec":       map[string]interface{}{"paused": true},
+		"status":     map[string]interface{}{"machineCount": int64(2)},
+	}}
+	failedAction := &actionv1beta1.ManagedClusterAction{
+		ObjectMeta: v1.ObjectMeta{Name: "pause", Namespace: "spoke1"},
+		Status: actionv1beta1.ActionStatus{Conditions: []v1.Condition{{
+			Type:   actionv1beta1.ConditionActionCompleted,
+			Status: v1.ConditionFalse,
+			Reason: actionv1beta1.ReasonUpdateResourceFailed,
+		}}},
+	}
+	c, _ := getFakeClientFromObjects(failedAction)
+	ctx := context.TODO()
+
+	// The failed action is deleted, then created again
+	created, err := EnsureManagedClusterActionForUpdate(ctx, c, "pause", "spoke1", "default-cgu", "machineconfigpool", object)
+	assert.NoError(t, err)
+	assert.False(t, created)
+	err = c.Get(ctx, types.NamespacedName{Name: "pause", Namespace: "spoke1"}, &actionv1beta1.ManagedClusterAction{})
+	assert.True(t, errors.IsNotFound(err))
+
+	created, err = EnsureManagedClusterActionForUpdate(ctx, c, "pause", "spoke1", "default-cgu", "machineconfigpool", object)
+	assert.NoError(t, err)
+	assert.True(t, created)
+	mca := &actionv1beta1.ManagedClusterAction{}
+	assert.NoError(t, c.Get(ctx, types.NamespacedName{Name: "pause", Namespace: "spoke1"}, mca))
+	assert.Equal(t, actionv1beta1.UpdateActionType, mca.Spec.ActionType)
+	assert.Equal(t, "machineconfigpool", mca.Spec.KubeWork.Resource)
+	assert.Equal(t, "default-cgu", mca.Labels["openshift-cluster-group-upgrades/clusterGroupUpgrade"])
+	assert.JSONEq(t,
+		`{"apiVersion":"machineconfiguration.openshift.io/v1","kind":"MachineConfigPool",`+
+			`"metadata":{"name":"worker","resourceVersion":"10"},"spec":{"paused":true}}`,
+		string(mca.Spec.KubeWork.ObjectTemplate.Raw))
+
+	// An existing action is left as is
+	created, err = EnsureManagedClusterActionForUpdate(ctx, c, "pause", "spoke1", "default-cgu", "machineconfigpool", object)
+	assert.NoError(t, err)
+	assert.False(t, created)
+}
@@ -45,6 +45,10 @@ func GetChildPolicies(ctx context.Context, c client.Client, clusters []string) (
 			if _, ok := labels["openshift-cluster-group-upgrades/clusterGroupUpgrade"]; ok {
 				continue
 			}
+			// Skip if it's the child policy of a policy copying the backup secrets.
+			if _, ok := labels[SecretsForClusterGroupUpgradeLabel]; ok {
+				continue
+			}
 			// If we can find the child policy specific label, add the child policy name to the list.
 			if _, ok := labels[ChildPolicyLabel]; ok {
 				childPolicies = append(childPolicies, policy)
@@ -1,10 +1,13 @@
 package controllers
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"strings"
 
 	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/updategraph"
 	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -15,6 +18,7 @@ type ocpVersionInfo struct {
 	channel               string
 	version               string
 	image                 string
+	force                 bool
 	clusterVersionCRFound bool
 }
 
@@ -72,6 +76,9 @@ func extractOCPVersionInfoFromPolicies(policies []*unstructured.Unstructured) (o
 							return result, errors.New("platform image defined more then once with conflicting image values")
 						}
 					}
+					if force, ok := object["spec"].(map[string]interface{})["desiredUpdate"].(map[string]interface{})["force"].(bool); ok && force {
+						result.force = true
+					}
 				}
 
 				result.clusterVersionCRFound = true
@@ -85,7 +92,7 @@ func extractOCPVersionInfoFromPolicies(policies []*unstructured.Unstructured) (o
 
 // extractOCPImageFromPolicies validates that there's ClusterVersion policy, validates the content of ClusterVersion and extracts Image if needed
 func (r *ClusterGroupUpgradeReconciler) extractOCPImageFromPolicies(
-	policies []*unstructured.Unstructured) (string, error) {
+	ctx context.Context, policies []*unstructured.Unstructured) (string, error) {
 
 	versionInfo, err := extractOCPVersionInfoFromPolicies(policies)
 
@@ -102,7 +109,7 @@ func (r *ClusterGroupUpgradeReconciler) extractOCPImageFromPolicies(
 		return versionInfo.image, nil
 	}
 
-	image, err := r.getImageForVersionFromUpdateGraph(versionInfo.upstream, versionInfo.channel, versionInfo.version)
+	image, err := r.getImageForVersionFromUpdateGraph(ctx, versionInfo.upstream, versionInfo.channel, versionInfo.version)
 	if err != nil {
 		return "", err
 	}
@@ -114,24 +121,48 @@ func (r *ClusterGroupUpgradeReconciler) extractOCPImageFromPolicies(
 }
 
 func (r *ClusterGroupUpgradeReconciler) validateOpenshiftUpgradeVersion(
-	clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade, policies []*unstructured.Unstructured) error {
+	ctx context.Context, clusterGroupUpgrade *ranv1alpha1.ClusterGroupUpgrade,
+	clusters []string, policies []*unstructured.Unstructured) error {
 
 	versionInfo, err := extractOCPVersionInfoFromPolicies(policies)
 
 	if err == nil {
-		if !versionInfo.clusterVersionCRFound || versionInfo.image != "" {
+		if !versionInfo.clusterVersionCRFound {
 			return nil
 		}
-		// Check for all the required parameters needed to make the update graph HTTP call and retrieve the image
-		if versionInfo.upstream == "" || versionInfo.channel == "" || versionInfo.version == "" {
-			err = errors.New("policy with ClusterVersion must have upstream, channel, and version when image is not provided")
-		} else if utils.ContainsTemplates(versionInfo.upstream) || utils.ContainsTemplates(versionInfo.channel) || utils.ContainsTemplates(versionInfo.version) {
-			if clusterGroupUpgrade.Spec.PreCaching {
-				// return error if the fields contain templates
-				err = errors.New("templatized ClusterVersion fields not supported with precaching")
+		templatized := utils.ContainsTemplates(versionInfo.upstream) || utils.ContainsTemplates(versionInfo.channel) || utils.ContainsTemplates(versionInfo.version)
+		// The update graph is only needed to retrieve the image when it is not provided
+		if versionInfo.image == "" {
+			// Check for all the required parameters needed to make the update graph HTTP call and retrieve the image
+			if versionInfo.upstream == "" || versionInfo.channel == "" || versionInfo.version == "" {
+				err = errors.New("policy with ClusterVersion must have upstream, channel, and version when image is not provided")
+			} else if templatized {
+				if clusterGroupUpgrade.Spec.PreCaching {
+					// return error if the fields contain templates
+					err = errors.New("templatized ClusterVersion fields not supported with precaching")
+				}
+			} else {
+				_, err = r.getImageForVersionFromUpdateGraph(ctx, versionInfo.upstream, versionInfo.channel, versionInfo.version)
+			}
+		}
+		if err == nil && !templatized && !versionInfo.force &&
+			versionInfo.upstream != "" && versionInfo.channel != "" && versionInfo.version != "" {
+
+			var invalidClusters []string
+			invalidClusters, err = r.getClustersWithoutUpgradePath(
+				ctx, versionInfo.upstream, versionInfo.channel, []string{versionInfo.version}, clusters)
+			if err == nil && len(invalidClusters) > 0 {
+				err = fmt.Errorf("clusters without a recommended update to version %s: %s",
+					versionInfo.version, strings.Join(invalidClusters, "; "))
+				utils.SetStatusCondition(
+					&clusterGroupUpgrade.Status.Conditions,
+					utils.ConditionTypes.Validated,
+					utils.ConditionReasons.UpgradePathUnavailable,
+					metav1.ConditionFalse,
+					err.Error(),
+				)
+				return err
 			}
-		} else {
-			_, err = r.getImageForVersionFromUpdateGraph(versionInfo.upstream, versionInfo.channel, versionInfo.version)
 		}
 	}
 
@@ -148,6 +179,65 @@ func (r *ClusterGroupUpgradeReconciler) validateOpenshiftUpgradeVersion(
 	return err
 }
 
+// getClustersWithoutUpgradePath walks the update graph from the current version of each cluster
+// through the desired versions, in turn. Versions of the chain the cluster is already past are skipped.
+// Clusters not reporting their version are not checked
+// returns: []string - the clusters without an unconditional update along with the reason, error
+func (r *ClusterGroupUpgradeReconciler) getClustersWithoutUpgradePath(
+	ctx context.Context, upstream, channel string, versions, clusters []string) ([]string, error) {
+
+	if len(clusters) == 0 {
+		return nil, nil
+	}
+	graph, err := r.getUpdateGraph(ctx, upstream, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	var invalidClusters []string
+	for _, cluster := range clusters {
+		current, err := r.getClusterOpenshiftVersion(ctx, cluster)
+		if err != nil {
+			r.Log.Info("[getClustersWithoutUpgradePath] unable to get the cluster version", "cluster", cluster, "error", err.Error())
+			continue
+		}
+		for _, version := range getRemainingPlatformVersions(versions, current) {
+			if reason := getUpgradePathIssue(graph, channel, current, version); reason != "" {
+				invalidClusters = append(invalidClusters, fmt.Sprintf("%s (%s)", cluster, reason))
+				break
+			}
+			current = version
+		}
+	}
+	r.Log.Info("[getClustersWithoutUpgradePath]", "versions", versions, "invalidClusters", invalidClusters)
+	return invalidClusters, nil
+}
+
+// getUpgradePathIssue checks the update of a cluster from its current version to the desired one
+// returns: string - why the update is not recommended, empty when it is
+func getUpgradePathIssue(graph *updategraph.Graph, channel, current, desired string) string {
+	if current == desired || graph.HasUpdate(current, desired) {
+		return ""
+	}
+	if !graph.HasRelease(current) {
+		return fmt.Sprintf("current version %s is not in channel %s", current, channel)
+	}
+	if risks, ok := graph.ConditionalUpdateRisks(current, desired); ok {
+		var names []string
+		for _, risk := range risks {
+			names = append(names, risk.Name)
+		}
+		return fmt.Sprintf("update from %s is conditional, exposed to risks %s", current, strings.Join(names, ", "))
+	}
+	if path := graph.Path(current, desired, false); path != nil {
+		return fmt.Sprintf("no direct update from %s, recommended path is %s", current, strings.Join(path, " -> "))
+	}
+	if path := graph.Path(current, desired, true); path != nil {
+		return fmt.Sprintf("no direct update from %s, conditional path is %s", current, strings.Join(path, " -> "))
+	}
+	return fmt.Sprintf("no update path from %s", current)
+}
+
 func indexOf(element ranv1alpha1.ManagedPolicyForUpgrade, data []ranv1alpha1.ManagedPolicyForUpgrade) (int, error) {
 	for k, v := range data {
 		if element.Name == v.Name && element.Namespace == v.Namespace {
@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -8,12 +9,16 @@ import (
 
 	"github.com/go-logr/logr"
 	ranv1alpha1 "github.com/openshift-kni/cluster-group-upgrades-operator/api/v1alpha1"
+	"github.com/openshift-kni/cluster-group-upgrades-operator/controllers/utils"
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
+	clusterv1 "open-cluster-management.io/api/cluster/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 )
@@ -284,7 +289,7 @@ func TestClusterGroupUpgradeReconciler_extractOpenshiftImagePlatformFromPolicies
 				tt.args.policies = []*unstructured.Unstructured{mustConvertYamlStrToUnstructured(policyWithOnlyVersion)}
 			}
 
-			got, err := r.extractOCPImageFromPolicies(tt.args.policies)
+			got, err := r.extractOCPImageFromPolicies(context.TODO(), tt.args.policies)
 			if !tt.wantErr(t, err, fmt.Sprintf("extractOpenshiftImagePlatformFromPolicies(%v)", tt.args.policies)) {
 				return
 			}
@@ -413,7 +418,7 @@ func TestClusterGroupUpgradeReconciler_validateOpenshiftUpgradeVersion(t *testin
 				tt.args.policies = []*unstructured.Unstructured{mustConvertYamlStrToUnstructured(policyWithOnlyVersion)}
 			}
 
-			err := r.validateOpenshiftUpgradeVersion(tt.args.cgu, tt.args.policies)
+			err := r.validateOpenshiftUpgradeVersion(context.TODO(), tt.args.cgu, nil, tt.args.policies)
 			if !tt.wantErr(t, err, fmt.Sprintf("extractOpenshiftImagePlatformFromPolicies(%v)", tt.args.policies)) {
 				return
 			}
@@ -935,7 +940,7 @@ spec:
 				Recorder: tt.fields.Recorder,
 			}
 
-			got, err := r.extractPrecachingSpecFromPolicies(tt.args.policies)
+			got, err := r.extractPrecachingSpecFromPolicies(context.TODO(), tt.args.policies)
 			if !tt.wantErr(t, err, fmt.Sprintf("extractPrecachingSpecFromPolicies(%v)", tt.args.policies)) {
 				return
 			}
@@ -962,3 +967,71 @@ func mustConvertYamlStrToUnstructured(cr string) *unstructured.Unstructured {
 	}
 	return uCr
 }
+
+func TestValidation_validateOpenshiftUpgradeVersionUpgradePaths(t *testing.T) {
+	graph := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"nodes":[
+			{"version":"4.11.9","payload":"quay.io/openshift-release-dev/ocp-release@sha256:09"},
+			{"version":"4.11.10","payload":"quay.io/openshift-release-dev/ocp-release@sha256:10"},
+			{"version":"4.11.11","payload":"quay.io/openshift-release-dev/ocp-release@sha256:11"},
+			{"version":"4.11.12","payload":"quay.io/openshift-release-dev/ocp-release@sha256:12"}],
+			"edges":[[0,2],[2,3]],
+			"conditionalEdges":[{"edges":[{"from":"4.11.10","to":"4.11.12"}],"risks":[{"name":"ExampleRisk","message":"Example"}]}]}`))
+	}))
+	defer graph.Close()
+	policies := []*unstructured.Unstructured{
+		mustConvertYamlStrToUnstructured(fmt.Sprintf(policyWithOnlyVersionAndVariableUpstream, graph.URL))}
+
+	newCluster := func(name, claim, label string) *clusterv1.ManagedCluster {
+		cluster := &clusterv1.ManagedCluster{ObjectMeta: metav1.ObjectMeta{Name: name}}
+		if claim != "" {
+			cluster.Status.ClusterClaims = []clusterv1.ManagedClusterClaim{{Name: utils.OpenshiftVersionClaimName, Value: claim}}
+		}
+		if label != "" {
+			cluster.SetLabels(map[string]string{utils.OpenshiftVersionLabelName: label})
+		}
+		return cluster
+	}
+	fakeClient, _ := getFakeClientFromObjects(
+		newCluster("spoke1", "4.11.11", ""),
+		newCluster("spoke2", "", "4.11.12"),
+		newCluster("spoke3", "4.11.10", ""),
+		newCluster("spoke4", "4.11.9", ""),
+		newCluster("spoke5", "", "4.10.40"),
+		newCluster("spoke6", "", ""),
+	)
+
+	testcases := []struct {
+		name            string
+		clusters        []string
+		expectedMessage string
+	}{
+		{
+			name:     "clusters with an update or already updated",
+			clusters: []string{"spoke1", "spoke2", "spoke6"},
+		},
+		{
+			name:     "clusters without an update",
+			clusters: []string{"spoke1", "spoke3", "spoke4", "spoke5"},
+			expectedMessage: "clusters without a recommended update to version 4.11.12: " +
+				"spoke3 (update from 4.11.10 is conditional, exposed to risks ExampleRisk); " +
+				"spoke4 (no direct update from 4.11.9, recommended path is 4.11.9 -> 4.11.11 -> 4.11.12); " +
+				"spoke5 (current version 4.10.40 is not in channel stable-4.11)",
+		},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &ClusterGroupUpgradeReconciler{Client: fakeClient, Log: logr.Discard(), Scheme: testscheme}
+			cgu := &ranv1alpha1.ClusterGroupUpgrade{}
+			err := r.validateOpenshiftUpgradeVersion(context.TODO(), cgu, tc.clusters, policies)
+			if tc.expectedMessage == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tc.expectedMessage)
+			condition := meta.FindStatusCondition(cgu.Status.Conditions, string(utils.ConditionTypes.Validated))
+			assert.Equal(t, string(utils.ConditionReasons.UpgradePathUnavailable), condition.Reason)
+			assert.Equal(t, tc.expectedMessage, condition.Message)
+		})
+	}
+}
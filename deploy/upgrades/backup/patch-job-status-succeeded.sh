@@ -19,3 +19,10 @@ curl -k -s -X PATCH -H "Accept: application/json, */*" \
 -H "Content-Type: application/merge-patch+json" \
 http://localhost:8001/apis/view.open-cluster-management.io/v1beta1/namespaces/spoke6/managedclusterviews/view-backup-job/status \
 --data '{"status":{"conditions":[{"lastTransitionTime":"2022-01-28T17:57:00Z","reason":"GetResourceProcessing","message":"found","status":"True","type":"Processing"}],"result":{"status":{"active":0,"succeeded":1}}}}'
+
+for spoke in spoke1 spoke2 spoke5 spoke6; do
+curl -k -s -X PATCH -H "Accept: application/json, */*" \
+-H "Content-Type: application/merge-patch+json" \
+http://localhost:8001/apis/view.open-cluster-management.io/v1beta1/namespaces/${spoke}/managedclusterviews/view-backup-report/status \
+--data '{"status":{"conditions":[{"lastTransitionTime":"2022-01-28T17:57:00Z","reason":"GetResourceProcessing","message":"found","status":"True","type":"Processing"}],"result":{"data":{"sizeBytes":"28991029248","durationSeconds":"390","completedAt":"2022-01-28T17:57:00Z"}}}}'
+done
@@ -90,10 +90,17 @@ func main() {
 		os.Exit(1)
 	}
 
+	updateGraph, err := controllers.NewUpdateGraphClient()
+	if err != nil {
+		setupLog.Error(err, "unable to create the update graph client")
+		os.Exit(1)
+	}
 	if err = (&controllers.ClusterGroupUpgradeReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("ClusterGroupUpgrade"),
-		Scheme: mgr.GetScheme(),
+		Client:      mgr.GetClient(),
+		APIReader:   mgr.GetAPIReader(),
+		Log:         ctrl.Log.WithName("controllers").WithName("ClusterGroupUpgrade"),
+		Scheme:      mgr.GetScheme(),
+		UpdateGraph: updateGraph,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ClusterGroupUpgrade")
 		os.Exit(1)
@@ -109,6 +116,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	if err = (&controllers.PreCachingConfigReconciler{
+		Client:    mgr.GetClient(),
+		APIReader: mgr.GetAPIReader(),
+		Log:       ctrl.Log.WithName("controllers").WithName("PreCachingConfig"),
+		Scheme:    mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PreCachingConfig")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
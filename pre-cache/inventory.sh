@@ -0,0 +1,39 @@
+#!/usr/bin/bash
+
+set -e
+
+APISERVER=https://kubernetes.default.svc
+SERVICEACCOUNT=/var/run/secrets/kubernetes.io/serviceaccount
+NAMESPACE=$(cat ${SERVICEACCOUNT}/namespace)
+TOKEN=$(cat ${SERVICEACCOUNT}/token)
+CACERT=${SERVICEACCOUNT}/ca.crt
+INVENTORY_CM=pre-cache-inventory
+
+api(){
+    curl --silent --show-error --fail --cacert ${CACERT} --header "Authorization: Bearer ${TOKEN}" \
+        --header "Content-Type: application/json" "$@"
+}
+
+# Setup the environment for the inventory
+rm -rf /host/tmp/precache
+cp -a /opt/precache /host/tmp/
+cp -rLf /etc/config /host/tmp/precache/config
+
+# The release to keep is the one the cluster was upgraded to
+api ${APISERVER}/apis/config.openshift.io/v1/clusterversions/version | \
+    chroot /host jq -r '.status.desired.image // ""' > /host/tmp/precache/config/release.image
+
+chroot /host /tmp/precache/inventory
+
+# Report the inventory in a configmap viewed from the hub
+chroot /host jq -n --arg name ${INVENTORY_CM} --arg namespace ${NAMESPACE} \
+    --rawfile images /tmp/precache/inventory/images.json \
+    --rawfile summary /tmp/precache/inventory/summary.json \
+    '{apiVersion: "v1", kind: "ConfigMap", metadata: {name: $name, namespace: $namespace},
+      data: {"images.json": $images, "summary.json": $summary}}' > /tmp/inventory-cm.json
+api -X PUT --data @/tmp/inventory-cm.json \
+    ${APISERVER}/api/v1/namespaces/${NAMESPACE}/configmaps/${INVENTORY_CM} > /dev/null || \
+api -X POST --data @/tmp/inventory-cm.json \
+    ${APISERVER}/api/v1/namespaces/${NAMESPACE}/configmaps > /dev/null
+
+rm -rf /host/tmp/precache
@@ -17,7 +17,7 @@ cp -a /opt/precache /host/tmp/
 cp -rf /etc/config /host/tmp/precache/config
 
 # Check the available space for the OCP upgrade case or for pre-caching additional images
-{ [ -n "$(cat /etc/config/platform.image)" ] || [ -n "$(cat /etc/config/additionalImages)" ]; } && check_disk_space=1 || check_disk_space=0
+{ [ -n "$(cat /etc/config/platform.image)" ] || [ -n "$(cat /etc/config/additionalImages)" ] || [ -n "$(xargs < /etc/config/platform.intermediateImages 2>/dev/null)" ]; } && check_disk_space=1 || check_disk_space=0
 if [[ $check_disk_space == 1 ]]; then
     /opt/precache/check_space $(cat /etc/config/spaceRequired)
 fi
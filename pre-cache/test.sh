@@ -7,7 +7,7 @@ fatal() {
     exit 1
 }
 
-for f in common olm release pull; do
+for f in common olm release pull inventory; do
     echo "Testing import of $f"
     # shellcheck disable=1090,2154
     . $cwd/$f
@@ -79,6 +79,13 @@ result=$(extract_packages)
 [[ $result == "package1,package2" ]]  || fatal "Package name extraction failure"
 echo " extract_packages - pass"
 
+echo -e "  quay.io/operator1@sha256:01 \n\n  quay.io/operator2@sha256:02 " > /tmp/operators.images
+extract_resolved_images /tmp/operators.images $pull_spec_file
+[[ $? -eq 0 ]] || fatal "extract_resolved_images unexpected exit code"
+[[ $(cat $pull_spec_file) == $'quay.io/operator1@sha256:01\nquay.io/operator2@sha256:02' ]] || fatal "Resolved images extraction failure"
+rm $pull_spec_file
+echo " extract_resolved_images - pass"
+
 # Test release
 echo "Testing release unit:"
 result=$(extract_pull_spec "/tmp")
@@ -86,5 +93,56 @@ result=$(extract_pull_spec "/tmp")
 [[ $(cat $pull_spec_file) == "\"quay.io/1\"" ]] || fatal "release pull spec extract failure"
 echo " release extract_pull_spec pass"
 
+echo "quay.io/ocp/release@sha256:416" > /tmp/platform.image
+echo -e "  quay.io/ocp/release@sha256:414 \n  quay.io/ocp/release@sha256:415 " > /tmp/platform.intermediateImages
+[[ $(release_images | xargs) == "quay.io/ocp/release@sha256:416 quay.io/ocp/release@sha256:414 quay.io/ocp/release@sha256:415" ]] || fatal "release_images failure"
+rm /tmp/platform.intermediateImages
+[[ $(release_images | xargs) == "quay.io/ocp/release@sha256:416" ]] || fatal "release_images without intermediate releases failure"
+rm /tmp/platform.image
+echo " release_images pass"
+
+# Test inventory
+echo "Testing inventory unit:"
+[[ $(image_repository "registry.example.com:5000/ocp/release@sha256:01") == "registry.example.com:5000/ocp/release" ]] || fatal "image_repository digest failure"
+[[ $(image_repository "registry.example.com:5000/ocp/release:4.12.2") == "registry.example.com:5000/ocp/release" ]] || fatal "image_repository tag failure"
+[[ $(image_repository "registry.example.com:5000/ubi") == "registry.example.com:5000/ubi" ]] || fatal "image_repository failure"
+echo " image_repository - pass"
+
+echo "quay.io/ocp/release@sha256:new" > /tmp/keep.txt
+echo -e "  quay.io/ptp/ptp:v2 \n" >> /tmp/keep.txt
+extract_release_references "/tmp" /tmp/keep.txt
+[[ $(tail -2 /tmp/keep.txt) == $'quay.io/1\nquay.io/2' ]] || fatal "extract_release_references failure"
+echo "quay.io/ocp/art@sha256:new1" >> /tmp/keep.txt
+echo " extract_release_references - pass"
+
+cat <<EOF > /tmp/crictl-images.json
+{"images": [
+  {"id": "sha256:a1", "repoTags": [], "repoDigests": ["quay.io/ocp/release@sha256:new"], "size": "100"},
+  {"id": "sha256:a2", "repoTags": [], "repoDigests": ["quay.io/ocp/release@sha256:old"], "size": "200"},
+  {"id": "sha256:a3", "repoTags": [], "repoDigests": ["quay.io/ocp/art@sha256:new1"], "size": "300"},
+  {"id": "sha256:a4", "repoTags": [], "repoDigests": ["quay.io/ocp/art@sha256:old1"], "size": "400"},
+  {"id": "sha256:a5", "repoTags": [], "repoDigests": ["quay.io/ocp/art@sha256:old2"], "size": "500"},
+  {"id": "sha256:a6", "repoTags": ["quay.io/ptp/ptp:v1"], "repoDigests": ["quay.io/ptp/ptp@sha256:v1"], "size": "600"},
+  {"id": "sha256:a7", "repoTags": ["quay.io/ptp/ptp:v2"], "repoDigests": ["quay.io/ptp/ptp@sha256:v2"], "size": "700"},
+  {"id": "sha256:a8", "repoTags": ["quay.io/app/app:v1"], "repoDigests": null, "size": "800"}
+]}
+EOF
+cat <<EOF > /tmp/containers.json
+{"containers": [{"imageRef": "sha256:a5", "image": {"image": "quay.io/ocp/art@sha256:old2"}}]}
+EOF
+result=$(select_prune_candidates /tmp/crictl-images.json /tmp/containers.json /tmp/keep.txt)
+[[ $result == $'sha256:a2 200\nsha256:a4 400\nsha256:a6 600' ]] || fatal "select_prune_candidates failure: $result"
+echo " select_prune_candidates - pass"
+
+# shellcheck disable=SC2034
+inventory_path=/tmp/inventory
+mkdir -p $inventory_path
+echo -e "sha256:a2 200\nsha256:a4 400" > /tmp/pruned.txt
+write_inventory /tmp/crictl-images.json /tmp/pruned.txt
+[[ $(jq length $inventory_path/images.json) == 8 ]] || fatal "write_inventory images failure"
+[[ $(cat $inventory_path/summary.json) == '{"images":8,"sizeBytes":3600,"prunedImages":2,"reclaimedBytes":600}' ]] || fatal "write_inventory summary failure"
+echo " write_inventory - pass"
+rm -rf /tmp/keep.txt /tmp/crictl-images.json /tmp/containers.json /tmp/pruned.txt $inventory_path
+
 # Clean
-rm -rf /tmp/operators.indexes /tmp/release-manifests $pull_spec_file /tmp/operators.packagesAndChannels
+rm -rf /tmp/operators.indexes /tmp/release-manifests $pull_spec_file /tmp/operators.packagesAndChannels /tmp/operators.images
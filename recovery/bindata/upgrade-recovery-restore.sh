@@ -0,0 +1,78 @@
+#!/bin/bash
+#
+# This script drives an unattended restore from the recovery backup.
+# It is run by the upgrade-recovery-restore service installed by the launchRestore command, and
+# is run again by the service after each of the reboots required by the restore:
+#
+#   1. Roll back the platform to the deployment pinned by the backup, if needed
+#   2. Restore the backed up files with upgrade-recovery.sh, which requires a reboot
+#   3. Resume upgrade-recovery.sh to restore the cluster
+#
+# The outcome of the restore is recorded in the result file polled by launchRestore.
+#
+
+PROG=$(basename "$0")
+BACKUP_DIR=${1:-/var/recovery}
+SERVICE=upgrade-recovery-restore.service
+
+declare PROGRESS_FILE="${BACKUP_DIR}/progress"
+declare RESULT_FILE="${BACKUP_DIR}/restore-result"
+declare RECOVERY_SCRIPT="${BACKUP_DIR}/upgrade-recovery.sh"
+
+function log_info {
+    echo "##### $(date -u): ${PROG}: $*"
+}
+
+#
+# finish:
+# Records the outcome of the restore and stops running the service on boot
+#
+function finish {
+    log_info "$1"
+    echo "$1" > "${RESULT_FILE}"
+    systemctl disable "${SERVICE}"
+    exit 0
+}
+
+#
+# reboot_and_resume:
+# Reboots the node, running the service again once it is back. The service is enabled again
+# as the restore of /etc drops it
+#
+function reboot_and_resume {
+    systemctl enable "${BACKUP_DIR}/${SERVICE}"
+    log_info "Rebooting to resume the restore"
+    systemctl reboot
+    exit 0
+}
+
+if [ -f "${RESULT_FILE}" ]; then
+    log_info "Restore already finished: $(cat "${RESULT_FILE}")"
+    systemctl disable "${SERVICE}"
+    exit 0
+fi
+
+if [ ! -f "${PROGRESS_FILE}" ] && ! ostree admin status | grep -A 3 '^\*' | grep -q 'Pinned: yes'; then
+    log_info "Rolling back the platform to the pinned deployment"
+    if ! rpm-ostree rollback; then
+        finish "Failed: unable to roll back the platform to the pinned deployment"
+    fi
+    reboot_and_resume
+fi
+
+if [ -f "${PROGRESS_FILE}" ]; then
+    "${RECOVERY_SCRIPT}" --dir "${BACKUP_DIR}" --resume
+else
+    "${RECOVERY_SCRIPT}" --dir "${BACKUP_DIR}"
+fi
+if [ $? -ne 0 ]; then
+    finish "Failed: ${RECOVERY_SCRIPT} failed, see 'journalctl -u ${SERVICE}' for details"
+fi
+
+# The progress is only cleared once the cluster is restored, the backed up files
+# were restored and the node needs to reboot before resuming
+if [ -f "${PROGRESS_FILE}" ]; then
+    reboot_and_resume
+fi
+
+finish "Succeeded"
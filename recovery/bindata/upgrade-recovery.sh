@@ -26,8 +26,12 @@ Options:
 
 Backup options:
     --take-backup:  Take backup
+    --compress <type>:
+                    Archive the backup, compressed with gzip or zstd.
+                    The archives are encrypted with the key set in the BACKUP_ENCRYPTION_KEY env variable, if any
 
 Recovery options:
+    --unpack:       Unpack the archived backup and exit
     --force:        Skip ostree deployment check
     --step:         Step through recovery stages
     --resume:       Resume recovery after last successful stage
@@ -213,6 +217,106 @@ function trigger_redeployment {
     log_info "Completed ${name} redeployment"
 }
 
+#
+# archive_name:
+# Helper function to name the archive of a backup content
+#
+function archive_name {
+    local name="$1.tar"
+
+    case "${COMPRESSION}" in
+        gzip) name="${name}.gz" ;;
+        zstd) name="${name}.zst" ;;
+    esac
+    if [ -n "${BACKUP_ENCRYPTION_KEY}" ]; then
+        name="${name}.enc"
+    fi
+    echo "${name}"
+}
+
+#
+# archive:
+# Helper function to stream a directory into a compressed, and optionally encrypted, archive
+#
+function archive {
+    local name=$1
+    local dir=$2
+    local archive=
+    archive="${BACKUP_DIR}/$(archive_name "${name}")"
+
+    tar -C "$(dirname "${dir}")" -cpf - --selinux --acls --xattrs "$(basename "${dir}")" \
+        | case "${COMPRESSION}" in
+              gzip) gzip -c ;;
+              zstd) zstd -q -c ;;
+              *) cat ;;
+          esac \
+        | if [ -n "${BACKUP_ENCRYPTION_KEY}" ]; then
+              openssl enc -aes-256-cbc -pbkdf2 -salt -pass env:BACKUP_ENCRYPTION_KEY
+          else
+              cat
+          fi > "${archive}"
+    local rc=("${PIPESTATUS[@]}")
+    if [ "${rc[*]}" != "0 0 0" ]; then
+        rm -f "${archive}"
+        return 1
+    fi
+}
+
+#
+# unpack:
+# Helper function to extract an archive of the backup content into the backup directory
+#
+function unpack {
+    local archive=$1
+    local decompress="cat"
+    local decrypt="cat"
+
+    case "${archive}" in
+        *.enc)
+            if [ -z "${BACKUP_ENCRYPTION_KEY}" ]; then
+                log_error "The encryption key is required to unpack ${archive}"
+                return 1
+            fi
+            decrypt="openssl enc -d -aes-256-cbc -pbkdf2 -pass env:BACKUP_ENCRYPTION_KEY"
+            ;;
+    esac
+    case "${archive%.enc}" in
+        *.gz) decompress="gzip -dc" ;;
+        *.zst) decompress="zstd -q -dc" ;;
+    esac
+
+    ${decrypt} < "${archive}" | ${decompress} | tar -C "${BACKUP_DIR}" -xpf - --selinux --acls --xattrs
+    local rc=("${PIPESTATUS[@]}")
+    if [ "${rc[*]}" != "0 0 0" ]; then
+        return 1
+    fi
+}
+
+#
+# unpack_backup:
+# Procedure for extracting the archived backup content, if needed
+#
+function unpack_backup {
+    local name=
+    local archive=
+
+    for name in cluster etc local kubelet; do
+        if [ -d "${BACKUP_DIR}/${name}" ]; then
+            continue
+        fi
+        for archive in "${BACKUP_DIR}/${name}".tar*; do
+            if [ ! -f "${archive}" ]; then
+                continue
+            fi
+            log_info "Unpacking ${archive}"
+            if ! unpack "${archive}"; then
+                rm -rf "${BACKUP_DIR:?}/${name}"
+                fatal "Failed to unpack ${archive}"
+            fi
+        done
+    done
+}
+
 #
 # take_backup:
 # Procedure for backing up data prior to upgrade
@@ -242,20 +346,41 @@ function take_backup {
         fatal "Cluster backup failed"
     fi
 
+    if [ "${ARCHIVE}" = "yes" ]; then
+        # The cluster backup is staged in the backup directory before being archived
+        archive cluster "${BACKUP_DIR}/cluster"
+        if [ $? -ne 0 ]; then
+            fatal "Failed to archive the cluster backup"
+        fi
+        rm -rf "${BACKUP_DIR:?}/cluster"
+    fi
+
     cat /etc/tmpfiles.d/* | sed 's/#.*//' | awk '{print $2}' | grep '^/etc/' | sed 's#^/etc/##' > ${BACKUP_DIR}/etc.exclude.list
     echo '.updated' >> ${BACKUP_DIR}/etc.exclude.list
     echo 'kubernetes/manifests' >> ${BACKUP_DIR}/etc.exclude.list
-    with_retries 3 1 cp -Ra /etc/ ${BACKUP_DIR}/
+    if [ "${ARCHIVE}" = "yes" ]; then
+        with_retries 3 1 archive etc /etc
+    else
+        with_retries 3 1 cp -Ra /etc/ ${BACKUP_DIR}/
+    fi
     if [ $? -ne 0 ]; then
         fatal "Failed to backup /etc"
     fi
 
-    with_retries 3 1 cp -Ra /usr/local/ ${BACKUP_DIR}/
+    if [ "${ARCHIVE}" = "yes" ]; then
+        with_retries 3 1 archive local /usr/local
+    else
+        with_retries 3 1 cp -Ra /usr/local/ ${BACKUP_DIR}/
+    fi
     if [ $? -ne 0 ]; then
         fatal "Failed to backup /usr/local"
     fi
 
-    with_retries 3 1 cp -Ra /var/lib/kubelet/ ${BACKUP_DIR}/
+    if [ "${ARCHIVE}" = "yes" ]; then
+        with_retries 3 1 archive kubelet /var/lib/kubelet
+    else
+        with_retries 3 1 cp -Ra /var/lib/kubelet/ ${BACKUP_DIR}/
+    fi
     if [ $? -ne 0 ]; then
         fatal "Failed to backup /var/lib/kubelet"
     fi
@@ -459,8 +584,10 @@ declare SKIP_DEPLOY_CHECK="no"
 declare TAKE_BACKUP="no"
 declare STEPTHROUGH="no"
 declare RESUME="no"
+declare COMPRESSION="none"
+declare UNPACK="no"
 
-LONGOPTS="dir:,force,restart,resume,step,take-backup"
+LONGOPTS="compress:,dir:,force,restart,resume,step,take-backup,unpack"
 OPTS=$(getopt -o h --long "${LONGOPTS}" --name "$0" -- "$@")
 
 if [ $? -ne 0 ]; then
@@ -472,6 +599,10 @@ eval set -- "${OPTS}"
 
 while :; do
     case "$1" in
+        --compress)
+            COMPRESSION=$2
+            shift 2
+            ;;
         --dir)
             BACKUP_DIR=$2
             shift 2
@@ -496,6 +627,10 @@ while :; do
             TAKE_BACKUP="yes"
             shift
             ;;
+        --unpack)
+            UNPACK="yes"
+            shift
+            ;;
         --)
             shift
             break
@@ -509,6 +644,19 @@ done
 
 declare PROGRESS_FILE="${BACKUP_DIR}/progress"
 
+case "${COMPRESSION}" in
+    none|gzip|zstd) ;;
+    *)
+        echo "Unsupported compression: ${COMPRESSION}" >&2
+        exit 1
+        ;;
+esac
+
+declare ARCHIVE="no"
+if [ "${COMPRESSION}" != "none" ] || [ -n "${BACKUP_ENCRYPTION_KEY}" ]; then
+    ARCHIVE="yes"
+fi
+
 # shellcheck source=/dev/null
 source /etc/kubernetes/static-pod-resources/etcd-certs/configmaps/etcd-scripts/etcd-common-tools
 
@@ -520,6 +668,14 @@ if [ "${TAKE_BACKUP}" = "yes" ]; then
     exit 0
 fi
 
+#
+# Unpack the archived backup
+#
+unpack_backup
+if [ "${UNPACK}" = "yes" ]; then
+    exit 0
+fi
+
 #
 # Validate environment
 #
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Compression of the backup archives
+const (
+	CompressionNone = "none"
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// encryptionKeyEnv is the env variable passing the encryption key of the backup archives to the recovery script
+const encryptionKeyEnv string = "BACKUP_ENCRYPTION_KEY"
+
+// compressionRatios are conservative estimates of the size of the compressed backup relative to its content
+var compressionRatios = map[string]float64{
+	CompressionNone: 1,
+	CompressionGzip: 0.5,
+	CompressionZstd: 0.45,
+}
+
+// ValidateCompression checks the compression of the backup is supported
+// returns:			error
+func ValidateCompression(compression string) error {
+	if _, ok := compressionRatios[compression]; !ok {
+		return fmt.Errorf("unsupported compression %q, expected one of %s, %s or %s",
+			compression, CompressionNone, CompressionGzip, CompressionZstd)
+	}
+	return nil
+}
+
+// backupArchives returns the archives of a backup content, whatever their compression and encryption
+// returns:			[]string
+func backupArchives(backupPath, name string) []string {
+	archives, _ := filepath.Glob(filepath.Join(backupPath, name+".tar*"))
+	return archives
+}
+
+// setEncryptionKey reads the encryption key of the backup archives from the mounted secret and passes it to
+// the recovery script. It must be called before the chroot to the host, which hides the secret
+// returns:			error
+func setEncryptionKey(keyFile string) error {
+	if keyFile == "" {
+		return nil
+	}
+	content, err := os.ReadFile(keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to read the backup encryption key: %w", err)
+	}
+	key := strings.TrimSpace(string(content))
+	if key == "" {
+		return fmt.Errorf("empty backup encryption key in %s", keyFile)
+	}
+	return os.Setenv(encryptionKeyEnv, key)
+}
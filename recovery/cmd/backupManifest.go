@@ -0,0 +1,216 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// BackupManifestFile is the manifest of the backup content written in the backup path
+const BackupManifestFile string = "backup-manifest.json"
+
+// backupContent lists the entries of the backup path taken by the recovery script
+var backupContent = []string{"cluster", "etc", "local", "kubelet", "etc.exclude.list", "extras.tgz"}
+
+// ManifestEntry describes a file of the backup
+type ManifestEntry struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// BackupManifest describes the content of the backup
+type BackupManifest struct {
+	ClusterVersion string          `json:"clusterVersion,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	Files          []ManifestEntry `json:"files"`
+}
+
+// fileChecksum computes the SHA-256 checksum of a file
+// returns:			string, error
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// backupRoots returns the entries of the backup content present in the backup path, as is or archived
+// returns:			[]string
+func backupRoots(backupPath string) []string {
+	var roots []string
+	for _, content := range backupContent {
+		for _, root := range append([]string{filepath.Join(backupPath, content)}, backupArchives(backupPath, content)...) {
+			if _, err := os.Lstat(root); err == nil {
+				roots = append(roots, root)
+			}
+		}
+	}
+	return roots
+}
+
+// listBackupFiles lists the regular files of the backup content with their size and checksum
+// returns:			map[string]ManifestEntry - indexed by path relative to the backup path, error
+func listBackupFiles(backupPath string) (map[string]ManifestEntry, error) {
+	files := make(map[string]ManifestEntry)
+	for _, root := range backupRoots(backupPath) {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			// Links and special files are restored as they are, their content is not checked
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			checksum, err := fileChecksum(path)
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(backupPath, path)
+			if err != nil {
+				return err
+			}
+			files[rel] = ManifestEntry{Path: rel, Size: info.Size(), SHA256: checksum}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+// GenerateManifest builds the manifest of the backup content
+// returns:			*BackupManifest, error
+func GenerateManifest(backupPath, clusterVersion string) (*BackupManifest, error) {
+	files, err := listBackupFiles(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	manifest := &BackupManifest{
+		ClusterVersion: clusterVersion,
+		CreatedAt:      time.Now().UTC(),
+		Files:          make([]ManifestEntry, 0, len(files)),
+	}
+	for _, entry := range files {
+		manifest.Files = append(manifest.Files, entry)
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Path < manifest.Files[j].Path
+	})
+	return manifest, nil
+}
+
+// WriteManifest writes the manifest in the backup path
+// returns:			error
+func WriteManifest(backupPath string, manifest *BackupManifest) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(backupPath, BackupManifestFile), content, 0600)
+}
+
+// ReadManifest reads the manifest from the backup path
+// returns:			*BackupManifest, error
+func ReadManifest(backupPath string) (*BackupManifest, error) {
+	content, err := os.ReadFile(filepath.Join(backupPath, BackupManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	manifest := &BackupManifest{}
+	if err := json.Unmarshal(content, manifest); err != nil {
+		return nil, fmt.Errorf("invalid backup manifest: %w", err)
+	}
+	return manifest, nil
+}
+
+// VerifyBackup checks the backup content against its manifest
+// returns:			[]string - the files that don't match the manifest, error
+func VerifyBackup(backupPath string) ([]string, error) {
+	manifest, err := ReadManifest(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	files, err := listBackupFiles(backupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var mismatches []string
+	for _, expected := range manifest.Files {
+		actual, ok := files[expected.Path]
+		switch {
+		case !ok:
+			mismatches = append(mismatches, fmt.Sprintf("%s: missing", expected.Path))
+		case actual.Size != expected.Size:
+			mismatches = append(mismatches, fmt.Sprintf("%s: size %d, expected %d", expected.Path, actual.Size, expected.Size))
+		case actual.SHA256 != expected.SHA256:
+			mismatches = append(mismatches, fmt.Sprintf("%s: checksum mismatch", expected.Path))
+		}
+		delete(files, expected.Path)
+	}
+	for path := range files {
+		mismatches = append(mismatches, fmt.Sprintf("%s: not in the manifest", path))
+	}
+	sort.Strings(mismatches)
+	return mismatches, nil
+}
+
+// clusterVersion reads the current version of the cluster
+// returns:			string, error
+func clusterVersion(client kubernetes.Interface) (string, error) {
+	content, err := client.Discovery().RESTClient().Get().
+		AbsPath("/apis/config.openshift.io/v1/clusterversions/version").DoRaw(context.TODO())
+	if err != nil {
+		return "", err
+	}
+	version := struct {
+		Status struct {
+			Desired struct {
+				Version string `json:"version"`
+			} `json:"desired"`
+		} `json:"status"`
+	}{}
+	if err := json.Unmarshal(content, &version); err != nil {
+		return "", err
+	}
+	return version.Status.Desired.Version, nil
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Backup report configmap viewed from the hub
+const (
+	BackupReportConfigMap      = "backup-report"
+	BackupReportSizeKey        = "sizeBytes"
+	BackupReportDurationKey    = "durationSeconds"
+	BackupReportCompletedAtKey = "completedAt"
+	BackupReportObjectKeyKey   = "objectKey"
+)
+
+const namespaceFile string = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+// BackupReport is the backup reported to the hub
+type BackupReport struct {
+	SizeBytes   int64
+	Duration    time.Duration
+	CompletedAt time.Time
+	// ObjectKey is the key of the backup uploaded to the object storage
+	ObjectKey string
+}
+
+// Data returns the content of the backup report configmap
+// returns:			map[string]string
+func (r BackupReport) Data() map[string]string {
+	data := map[string]string{
+		BackupReportSizeKey:        strconv.FormatInt(r.SizeBytes, 10),
+		BackupReportDurationKey:    strconv.FormatInt(int64(r.Duration.Seconds()), 10),
+		BackupReportCompletedAtKey: r.CompletedAt.UTC().Format(time.RFC3339),
+	}
+	if r.ObjectKey != "" {
+		data[BackupReportObjectKeyKey] = r.ObjectKey
+	}
+	return data
+}
+
+// backupReporter reports the backup in a configmap of the job namespace
+type backupReporter struct {
+	client    kubernetes.Interface
+	namespace string
+}
+
+// newBackupReporter reads the service account credentials of the job. It must be called before
+// the chroot to the host, which hides them
+// returns:			*backupReporter, error
+func newBackupReporter() (*backupReporter, error) {
+	config, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	// Keep the credentials read so far rather than reading them again from the files
+	config.BearerTokenFile = ""
+	config.TLSClientConfig.CAData, err = os.ReadFile(config.TLSClientConfig.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	config.TLSClientConfig.CAFile = ""
+
+	namespace, err := os.ReadFile(namespaceFile)
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return &backupReporter{client: client, namespace: strings.TrimSpace(string(namespace))}, nil
+}
+
+// Report creates or updates the backup report configmap
+// returns:			error
+func (r *backupReporter) Report(report BackupReport) error {
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: BackupReportConfigMap, Namespace: r.namespace},
+		Data:       report.Data(),
+	}
+	configMaps := r.client.CoreV1().ConfigMaps(r.namespace)
+	_, err := configMaps.Create(context.TODO(), configMap, metav1.CreateOptions{})
+	if errors.IsAlreadyExists(err) {
+		_, err = configMaps.Update(context.TODO(), configMap, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to report the backup: %w", err)
+	}
+	log.Infof("Backup reported in configmap %s/%s", r.namespace, BackupReportConfigMap)
+	return nil
+}
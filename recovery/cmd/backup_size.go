@@ -3,6 +3,7 @@ package cmd
 import (
 	"errors"
 	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 
@@ -32,13 +33,18 @@ type resourceList struct {
 
 // compareBackupToDisk verifies disk space required against available disk space
 // returns: boolean, error
-func compareBackupToDisk() (bool, error) {
+func compareBackupToDisk(compression string) (bool, error) {
 
-	estimated, err := EstimateFsSpaceRequirements()
+	estDirMap, err := estimateFsSpaceByPath()
 	if err != nil {
 		log.Errorf("Couldn't calculate estimated disk space required for backup")
 		return false, err
 	}
+	var total float64
+	for _, size := range estDirMap {
+		total += size
+	}
+	estimated := EstimateBackupSize(total, estDirMap[clusterPath], compression)
 
 	freeDisk, err := DiskPartitionSize()
 	if err != nil {
@@ -67,6 +73,29 @@ func Compare(freeDisk, estimated float64) bool {
 // EstimateFsSpaceRequirements calculate the required backup size
 // returns: disk size(float64), error
 func EstimateFsSpaceRequirements() (float64, error) {
+	estDirMap, err := estimateFsSpaceByPath()
+	var total float64
+	for _, size := range estDirMap {
+		total += size
+	}
+	return total, err
+}
+
+// EstimateBackupSize applies the compression heuristic to the space required by the backup content. The
+// cluster backup is staged uncompressed before being archived, the disk usage peaks either while it is
+// archived or once the whole backup is archived
+// returns: disk size(float64)
+func EstimateBackupSize(total, cluster float64, compression string) float64 {
+	ratio, ok := compressionRatios[compression]
+	if !ok || ratio >= 1 {
+		return total
+	}
+	return math.Max(cluster*(1+ratio), total*ratio)
+}
+
+// estimateFsSpaceByPath calculate the required backup size of each backed up path
+// returns: disk size by path(map[string]float64), error
+func estimateFsSpaceByPath() (map[string]float64, error) {
 
 	DirList := resourceList{
 		&[]resource{{staticPodsPath},
@@ -78,12 +107,11 @@ func EstimateFsSpaceRequirements() (float64, error) {
 	}
 
 	estDirMap := map[string]float64{}
-	var total float64
 
 	for _, v := range *DirList.resources {
 		_, err := os.Lstat(v.dirPath)
 		if err != nil {
-			return total, err
+			return estDirMap, err
 		}
 
 		switch v.dirPath {
@@ -98,10 +126,8 @@ func EstimateFsSpaceRequirements() (float64, error) {
 			estDirMap[v.dirPath] = DirSize(v.dirPath)
 		}
 
-		total += estDirMap[v.dirPath]
-
 	}
-	return total, nil
+	return estDirMap, nil
 }
 
 // DiskPartitionSize calculate current disk space
@@ -2,6 +2,7 @@ package cmd_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/openshift-kni/cluster-group-upgrades-operator/recovery/cmd"
 	"github.com/stretchr/testify/assert"
@@ -143,3 +144,67 @@ func TestSizeConversion(t *testing.T) {
 		})
 	}
 }
+
+func TestBackupReportData(t *testing.T) {
+	report := cmd.BackupReport{
+		SizeBytes:   27 * 1024 * 1024 * 1024,
+		Duration:    6*time.Minute + 30*time.Second,
+		CompletedAt: time.Date(2023, 8, 15, 10, 30, 0, 0, time.FixedZone("EDT", -4*60*60)),
+	}
+	assert.Equal(t, map[string]string{
+		cmd.BackupReportSizeKey:        "28991029248",
+		cmd.BackupReportDurationKey:    "390",
+		cmd.BackupReportCompletedAtKey: "2023-08-15T14:30:00Z",
+	}, report.Data())
+
+	report.ObjectKey = "sno1/default/cgu/recovery-backup.tar"
+	assert.Equal(t, "sno1/default/cgu/recovery-backup.tar", report.Data()[cmd.BackupReportObjectKeyKey])
+}
+
+func TestEstimateBackupSize(t *testing.T) {
+	const gib = 1024 * 1024 * 1024
+
+	testcases := []struct {
+		total, cluster float64
+		compression    string
+		expected       float64
+		name           string
+	}{
+		{
+			total:       40 * gib,
+			cluster:     4 * gib,
+			compression: cmd.CompressionNone,
+			expected:    40 * gib,
+			name:        "uncompressed backup",
+		},
+		{
+			total:       40 * gib,
+			cluster:     4 * gib,
+			compression: cmd.CompressionGzip,
+			expected:    20 * gib,
+			name:        "gzip compressed backup",
+		},
+		{
+			total:       40 * gib,
+			cluster:     4 * gib,
+			compression: cmd.CompressionZstd,
+			expected:    18 * gib,
+			name:        "zstd compressed backup",
+		},
+		{
+			total:       12 * gib,
+			cluster:     10 * gib,
+			compression: cmd.CompressionGzip,
+			expected:    15 * gib,
+			name:        "staged cluster backup",
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, cmd.EstimateBackupSize(tc.total, tc.cluster, tc.compression))
+		})
+	}
+	assert.NoError(t, cmd.ValidateCompression(cmd.CompressionZstd))
+	assert.Error(t, cmd.ValidateCompression("xz"))
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"os"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// CleanBackup deletes the backup content from the recovery partition once the upgrade is completed
+// returns:			error
+//
+//nolint:gocritic
+func CleanBackup() error {
+
+	// change root directory to /host
+	if err := syscall.Chroot(host); err != nil {
+		log.Errorf("Couldn't do chroot to %s, err: %s", host, err)
+		return err
+	}
+
+	if err := os.Chdir("/"); err != nil {
+		log.Error("Couldn't do chdir")
+		return err
+	}
+
+	// The backup is still needed by a recovery in progress
+	if RecoveryInProgress(backupPath) {
+		err := errors.New("cannot clean backup, recovery is currently in progress")
+		log.Error(err)
+		return err
+	}
+
+	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+		log.Info("No backup to clean")
+		return nil
+	}
+
+	return Cleanup(backupPath)
+}
+
+// cleanBackupCmd represents the cleanBackup command
+var cleanBackupCmd = &cobra.Command{
+	Use:   "cleanBackup",
+	Short: "It will delete the backup of resources in the specified path",
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// start cleaning the backup of the resources
+		return CleanBackup()
+	},
+}
+
+func init() {
+
+	rootCmd.AddCommand(cleanBackupCmd)
+
+}
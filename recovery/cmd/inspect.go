@@ -0,0 +1,117 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// BackupInfo describes the backup found in the backup path
+type BackupInfo struct {
+	BackupPath     string     `json:"backupPath"`
+	Complete       bool       `json:"complete"`
+	SizeBytes      int64      `json:"sizeBytes"`
+	Manifest       bool       `json:"manifest"`
+	ClusterVersion string     `json:"clusterVersion,omitempty"`
+	CreatedAt      *time.Time `json:"createdAt,omitempty"`
+	Files          int        `json:"files,omitempty"`
+	ObjectKey      string     `json:"objectKey,omitempty"`
+}
+
+// InspectBackup reads the metadata of the backup
+// returns:			*BackupInfo, error
+func InspectBackup(backupPath string) (*BackupInfo, error) {
+	if _, err := os.Stat(backupPath); err != nil {
+		return nil, fmt.Errorf("no backup found: %w", err)
+	}
+	info := &BackupInfo{
+		BackupPath: backupPath,
+		Complete:   BackupExists(backupPath),
+		SizeBytes:  int64(DirSize(backupPath)),
+	}
+	if record, err := ReadObjectStorageRecord(backupPath); err == nil {
+		info.ObjectKey = record.Key
+	}
+
+	manifest, err := ReadManifest(backupPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Backups taken by prior versions have no manifest
+			return info, nil
+		}
+		return nil, err
+	}
+	info.Manifest = true
+	info.ClusterVersion = manifest.ClusterVersion
+	info.CreatedAt = &manifest.CreatedAt
+	info.Files = len(manifest.Files)
+	return info, nil
+}
+
+// fields returns the text output of the backup metadata
+// returns:			[]outputField
+func (i *BackupInfo) fields() []outputField {
+	size, unit := SizeConversion(float64(i.SizeBytes))
+	fields := []outputField{
+		{"Backup path", i.BackupPath},
+		{"Complete", i.Complete},
+		{"Size", fmt.Sprintf("%.2f %s", size, unit)},
+		{"Manifest", i.Manifest},
+	}
+	if i.ObjectKey != "" {
+		fields = append(fields, outputField{"Object key", i.ObjectKey})
+	}
+	if i.Manifest {
+		fields = append(fields,
+			outputField{"Cluster version", i.ClusterVersion},
+			outputField{"Created at", i.CreatedAt.Format(time.RFC3339)},
+			outputField{"Files", i.Files},
+		)
+	}
+	return fields
+}
+
+// inspectCmd represents the inspect command
+var inspectCmd = &cobra.Command{
+	Use:   "inspect",
+	Short: "It will print the metadata of the backup in the specified path",
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, _ := cmd.Flags().GetString("root")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		info, err := InspectBackup(filepath.Join(root, backupPath))
+		if err != nil {
+			return err
+		}
+		info.BackupPath = backupPath
+		return printOutput(cmd.OutOrStdout(), jsonOutput, info, info.fields())
+	},
+}
+
+func init() {
+
+	inspectCmd.Flags().String("root", host, "Root directory of the node filesystem")
+	inspectCmd.Flags().Bool("json", false, "Print the backup metadata as JSON")
+	rootCmd.AddCommand(inspectCmd)
+
+}
@@ -17,6 +17,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os/exec"
 	"syscall"
@@ -26,6 +27,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -47,11 +49,38 @@ func RecoveryInProgress(backupPath string) bool {
 	return true
 }
 
-// LaunchBackup triggers the backup procedure
+// LaunchBackup triggers the backup procedure. The backup is archived when compressed or encrypted, and
+// uploaded to an object storage when configured
 // returns:			error
 //
 //nolint:gocritic
-func LaunchBackup() error {
+func LaunchBackup(compression, encryptionKeyFile string, upload UploadOptions) error {
+
+	if err := ValidateCompression(compression); err != nil {
+		return err
+	}
+	if err := setEncryptionKey(encryptionKeyFile); err != nil {
+		log.Error(err)
+		return err
+	}
+	objectStorage, err := upload.readConfig()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	// The backup is reported from the host, once the service account credentials are hidden
+	reporter, err := newBackupReporter()
+	if err != nil {
+		log.Warnf("Backup will not be reported, err: %s", err)
+	}
+	var version string
+	if reporter != nil {
+		if version, err = clusterVersion(reporter.client); err != nil {
+			log.Warnf("Cluster version will not be recorded in the backup manifest, err: %s", err)
+		}
+	}
+	startTime := time.Now()
 
 	// change root directory to /host
 	if err := syscall.Chroot(host); err != nil {
@@ -82,7 +111,7 @@ func LaunchBackup() error {
 		}
 	}
 
-	err := Cleanup(backupPath)
+	err = Cleanup(backupPath)
 	if err != nil {
 		log.Errorf("Old directories couldn't be deleted, err: %s\n", err)
 	}
@@ -90,15 +119,16 @@ func LaunchBackup() error {
 	log.Info("Old contents have been cleaned up")
 
 	// Verify disk space
-	ok, err := compareBackupToDisk()
+	ok, err := compareBackupToDisk(compression)
 	if err != nil {
 		log.Error(err)
 		return err
 	}
 
 	if !ok {
-		log.Error("Insufficient disk space to trigger backup, exiting triggering backup ...")
-		os.Exit(1)
+		err := errors.New("insufficient disk space to trigger backup")
+		log.Error(err)
+		return err
 	}
 
 	log.Info("Sufficient disk space found to trigger backup")
@@ -113,15 +143,44 @@ func LaunchBackup() error {
 	log.Info("Upgrade recovery script written")
 
 	// Take backup
-	backupCmd := fmt.Sprintf("%s --take-backup --dir %s", scriptname, backupPath)
+	backupCmd := fmt.Sprintf("%s --take-backup --dir %s --compress %s", scriptname, backupPath, compression)
 	err = ExecuteCmd(backupCmd)
 	if err != nil {
 		return err
 	}
 
+	// Record the backup content to verify it later
+	manifest, err := GenerateManifest(backupPath, version)
+	if err != nil {
+		log.Errorf("Couldn't generate the backup manifest, err: %s", err)
+		return err
+	}
+	if err := WriteManifest(backupPath, manifest); err != nil {
+		log.Errorf("Couldn't write the backup manifest, err: %s", err)
+		return err
+	}
+	log.Infof("Backup manifest written with %d files", len(manifest.Files))
+
+	report := BackupReport{SizeBytes: int64(DirSize(backupPath))}
+	if objectStorage != nil {
+		record, err := uploadBackup(backupPath, objectStorage, upload)
+		if err != nil {
+			return err
+		}
+		report.ObjectKey = record.Key
+	}
+
 	log.Info(strings.Repeat("-", 60))
 	log.Info("backup has successfully finished ...")
 
+	if reporter != nil {
+		report.Duration = time.Since(startTime)
+		report.CompletedAt = time.Now()
+		if err := reporter.Report(report); err != nil {
+			log.Warn(err)
+		}
+	}
+
 	return nil
 
 }
@@ -188,13 +247,22 @@ var launchBackupCmd = &cobra.Command{
 	Short: "It will trigger backup of resources in the specified path",
 
 	RunE: func(cmd *cobra.Command, args []string) error {
+		compression, _ := cmd.Flags().GetString("compression")
+		encryptionKeyFile, _ := cmd.Flags().GetString("encryption-key-file")
+		upload, err := uploadOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
 		// start launching the backup of the resource
-		return LaunchBackup()
+		return LaunchBackup(compression, encryptionKeyFile, upload)
 	},
 }
 
 func init() {
 
+	launchBackupCmd.Flags().String("compression", CompressionNone, "Compression of the backup archives: none, gzip or zstd")
+	launchBackupCmd.Flags().String("encryption-key-file", "", "File of the key encrypting the backup archives")
+	addUploadFlags(launchBackupCmd)
 	rootCmd.AddCommand(launchBackupCmd)
 
 }
@@ -0,0 +1,193 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/openshift-kni/cluster-group-upgrades-operator/recovery/generated"
+)
+
+const restoreScript string = "upgrade-recovery-restore.sh"
+const restoreService string = "upgrade-recovery-restore.service"
+const restoreResult string = "restore-result"
+const restoreSucceeded string = "Succeeded"
+const restorePollInterval = 10 * time.Second
+const localhostKubeconfig string = "/etc/kubernetes/static-pod-resources/kube-apiserver-certs/secrets/node-kubeconfigs/localhost.kubeconfig"
+
+// restoreServiceTemplate runs the restore script on boot until the restore is finished
+const restoreServiceTemplate string = `[Unit]
+Description=Restore the cluster from the upgrade recovery backup
+Wants=network-online.target
+After=network-online.target crio.service
+
+[Service]
+Type=oneshot
+Environment=KUBECONFIG=%s
+ExecStart=%s %s
+StandardOutput=journal+console
+StandardError=journal+console
+
+[Install]
+WantedBy=multi-user.target
+`
+
+// BackupExists checks if the content required by a restore is present in the backup path, either as is or
+// archived
+// returns:			bool
+func BackupExists(backupPath string) bool {
+	for _, dir := range []string{"cluster", "etc", "local", "kubelet"} {
+		if info, err := os.Stat(filepath.Join(backupPath, dir)); err == nil && info.IsDir() {
+			continue
+		}
+		if len(backupArchives(backupPath, dir)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// RestoreResult reads the outcome of the restore recorded by the restore script
+// returns:			bool - true once the restore is finished, error - the failure of the restore
+func RestoreResult(backupPath string) (bool, error) {
+	content, err := os.ReadFile(filepath.Join(backupPath, restoreResult))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	result := strings.TrimSpace(string(content))
+	if result != restoreSucceeded {
+		return true, errors.New(result)
+	}
+	return true, nil
+}
+
+// startRestore writes the restore scripts and service, and starts the service
+// returns:			error
+func startRestore() error {
+	for _, script := range []string{recoveryScript, restoreScript} {
+		scriptcontent, err := generated.Asset(script)
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(backupPath, script), scriptcontent, 0700); err != nil {
+			return err
+		}
+	}
+	log.Info("Upgrade recovery scripts written")
+
+	// Unpack the archived backup while the encryption key is known, the service restores the backup as is
+	unpackCmd := fmt.Sprintf("%s --dir %s --unpack", filepath.Join(backupPath, recoveryScript), backupPath)
+	if err := ExecuteCmd(unpackCmd); err != nil {
+		return err
+	}
+
+	servicename := filepath.Join(backupPath, restoreService)
+	servicecontent := fmt.Sprintf(restoreServiceTemplate, localhostKubeconfig, filepath.Join(backupPath, restoreScript), backupPath)
+	if err := os.WriteFile(servicename, []byte(servicecontent), 0600); err != nil {
+		return err
+	}
+
+	// The service keeps running across the reboots of the restore, it is not tied to this job
+	return ExecuteCmd(fmt.Sprintf("systemctl daemon-reload && systemctl enable --now --no-block %s", servicename))
+}
+
+// LaunchRestore triggers the restore from the backup and waits for its outcome. The node reboots during
+// the restore, this job is then relaunched to report the outcome recorded by the restore
+// returns:			error
+//
+//nolint:gocritic
+func LaunchRestore(encryptionKeyFile string) error {
+
+	if err := setEncryptionKey(encryptionKeyFile); err != nil {
+		log.Error(err)
+		return err
+	}
+
+	// change root directory to /host
+	if err := syscall.Chroot(host); err != nil {
+		log.Errorf("Couldn't do chroot to %s, err: %s", host, err)
+		return err
+	}
+
+	if err := os.Chdir("/"); err != nil {
+		log.Error("Couldn't do chdir")
+		return err
+	}
+
+	done, err := RestoreResult(backupPath)
+	if !done && err == nil {
+		if RecoveryInProgress(backupPath) {
+			log.Info("Restore is already in progress, waiting for it to finish")
+		} else {
+			if !BackupExists(backupPath) {
+				err := fmt.Errorf("required backup content not found in %s", backupPath)
+				log.Error(err)
+				return err
+			}
+			if err := startRestore(); err != nil {
+				return err
+			}
+			log.Info("Restore has started")
+		}
+
+		for !done && err == nil {
+			time.Sleep(restorePollInterval)
+			done, err = RestoreResult(backupPath)
+		}
+	}
+	if err != nil {
+		log.Errorf("Restore has failed, err: %s", err)
+		return err
+	}
+
+	log.Info(strings.Repeat("-", 60))
+	log.Info("restore has successfully finished ...")
+
+	return nil
+}
+
+// launchRestoreCmd represents the launchRestore command
+var launchRestoreCmd = &cobra.Command{
+	Use:   "launchRestore",
+	Short: "It will restore the node from the backup in the specified path",
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		encryptionKeyFile, _ := cmd.Flags().GetString("encryption-key-file")
+		// start restoring from the backup
+		return LaunchRestore(encryptionKeyFile)
+	},
+}
+
+func init() {
+
+	launchRestoreCmd.Flags().String("encryption-key-file", "", "File of the key decrypting the backup archives")
+	rootCmd.AddCommand(launchRestoreCmd)
+
+}
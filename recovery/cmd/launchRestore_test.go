@@ -0,0 +1,70 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/openshift-kni/cluster-group-upgrades-operator/recovery/cmd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LaunchRestore", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "tmpDir")
+		Expect(err).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).Should(BeNil())
+	})
+
+	Describe("BackupExists", func() {
+		It("returns true with the backup content", func() {
+			for _, subDir := range []string{"cluster", "etc", "local", "kubelet"} {
+				Expect(os.Mkdir(filepath.Join(dir, subDir), 0700)).Should(BeNil())
+			}
+			Expect(cmd.BackupExists(dir)).To(Equal(true))
+		})
+
+		It("returns true with the archived backup content", func() {
+			for _, subDir := range []string{"cluster", "etc", "local"} {
+				Expect(os.WriteFile(filepath.Join(dir, subDir+".tar.zst.enc"), []byte{}, 0600)).Should(BeNil())
+			}
+			Expect(os.Mkdir(filepath.Join(dir, "kubelet"), 0700)).Should(BeNil())
+			Expect(cmd.BackupExists(dir)).To(Equal(true))
+		})
+
+		It("returns false with partial backup content", func() {
+			Expect(os.Mkdir(filepath.Join(dir, "cluster"), 0700)).Should(BeNil())
+			Expect(cmd.BackupExists(dir)).To(Equal(false))
+		})
+	})
+
+	Describe("RestoreResult", func() {
+		It("restore not finished", func() {
+			done, err := cmd.RestoreResult(dir)
+			Expect(done).To(Equal(false))
+			Expect(err).Should(BeNil())
+		})
+
+		It("restore succeeded", func() {
+			Expect(os.WriteFile(filepath.Join(dir, "restore-result"), []byte("Succeeded\n"), 0600)).Should(BeNil())
+			done, err := cmd.RestoreResult(dir)
+			Expect(done).To(Equal(true))
+			Expect(err).Should(BeNil())
+		})
+
+		It("restore failed", func() {
+			Expect(os.WriteFile(filepath.Join(dir, "restore-result"),
+				[]byte("Failed: unable to roll back the platform to the pinned deployment\n"), 0600)).Should(BeNil())
+			done, err := cmd.RestoreResult(dir)
+			Expect(done).To(Equal(true))
+			Expect(err).To(MatchError("Failed: unable to roll back the platform to the pinned deployment"))
+		})
+	})
+})
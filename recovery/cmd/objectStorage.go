@@ -0,0 +1,650 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Entries of the object storage secret mounted in the backup job
+const (
+	ObjectStorageEndpointKey        = "endpoint"
+	ObjectStorageBucketKey          = "bucket"
+	ObjectStorageRegionKey          = "region"
+	ObjectStorageAccessKeyIDKey     = "accessKeyId"
+	ObjectStorageSecretAccessKeyKey = "secretAccessKey"
+	ObjectStoragePrefixKey          = "prefix"
+	ObjectStorageCAKey              = "ca.crt"
+)
+
+// ObjectStorageRecordFile records the upload of the backup in the backup path
+const ObjectStorageRecordFile string = "backup-object.json"
+
+const (
+	defaultObjectStorageRegion = "us-east-1"
+	defaultPartSize            = 16 * 1024 * 1024
+	uploadRetries              = 3
+	signatureAlgorithm         = "AWS4-HMAC-SHA256"
+	amzDateFormat              = "20060102T150405Z"
+)
+
+var uploadRetryInterval = 5 * time.Second
+
+// ObjectStorageConfig defines the S3-compatible object storage the backup is uploaded to
+type ObjectStorageConfig struct {
+	Endpoint        string
+	Bucket          string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	Prefix          string
+	CAData          []byte
+	// PartSize is the size of the uploaded parts, 16 MiB by default
+	PartSize int64
+}
+
+// ObjectStorageRecord describes the backup uploaded to the object storage
+type ObjectStorageRecord struct {
+	Endpoint   string    `json:"endpoint"`
+	Bucket     string    `json:"bucket"`
+	Key        string    `json:"key"`
+	SizeBytes  int64     `json:"sizeBytes"`
+	SHA256     string    `json:"sha256"`
+	UploadedAt time.Time `json:"uploadedAt"`
+}
+
+// ReadObjectStorageConfig reads the object storage configuration from the directory of the mounted secret.
+// It must be called before the chroot to the host, which hides the secret
+// returns:			*ObjectStorageConfig, error
+func ReadObjectStorageConfig(configDir string) (*ObjectStorageConfig, error) {
+	entries := make(map[string]string)
+	for _, key := range []string{ObjectStorageEndpointKey, ObjectStorageBucketKey, ObjectStorageRegionKey,
+		ObjectStorageAccessKeyIDKey, ObjectStorageSecretAccessKeyKey, ObjectStoragePrefixKey, ObjectStorageCAKey} {
+
+		content, err := os.ReadFile(filepath.Join(configDir, key))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read the object storage configuration: %w", err)
+		}
+		entries[key] = strings.TrimSpace(string(content))
+	}
+	for _, key := range []string{ObjectStorageEndpointKey, ObjectStorageBucketKey,
+		ObjectStorageAccessKeyIDKey, ObjectStorageSecretAccessKeyKey} {
+
+		if entries[key] == "" {
+			return nil, fmt.Errorf("no %s entry in the object storage configuration %s", key, configDir)
+		}
+	}
+	endpoint, err := url.Parse(entries[ObjectStorageEndpointKey])
+	if err != nil || endpoint.Host == "" || (endpoint.Scheme != "http" && endpoint.Scheme != "https") {
+		return nil, fmt.Errorf("invalid object storage endpoint %q, expected an http or https URL",
+			entries[ObjectStorageEndpointKey])
+	}
+
+	config := &ObjectStorageConfig{
+		Endpoint:        strings.TrimSuffix(entries[ObjectStorageEndpointKey], "/"),
+		Bucket:          entries[ObjectStorageBucketKey],
+		Region:          entries[ObjectStorageRegionKey],
+		AccessKeyID:     entries[ObjectStorageAccessKeyIDKey],
+		SecretAccessKey: entries[ObjectStorageSecretAccessKeyKey],
+		Prefix:          entries[ObjectStoragePrefixKey],
+	}
+	if config.Region == "" {
+		config.Region = defaultObjectStorageRegion
+	}
+	if ca := entries[ObjectStorageCAKey]; ca != "" {
+		config.CAData = []byte(ca)
+	}
+	return config, nil
+}
+
+// ObjectKey returns the key of an object in the bucket, under the configured prefix
+// returns:			string
+func (c *ObjectStorageConfig) ObjectKey(name string) string {
+	if c.Prefix == "" {
+		return name
+	}
+	return strings.TrimSuffix(c.Prefix, "/") + "/" + strings.TrimPrefix(name, "/")
+}
+
+// objectStorageClient sends the requests of the multipart upload, signed with AWS Signature Version 4
+type objectStorageClient struct {
+	config     *ObjectStorageConfig
+	endpoint   *url.URL
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// newObjectStorageClient creates the client of the object storage
+// returns:			*objectStorageClient, error
+func newObjectStorageClient(config *ObjectStorageConfig) (*objectStorageClient, error) {
+	endpoint, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if len(config.CAData) > 0 {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(config.CAData) {
+			return nil, errors.New("invalid object storage CA bundle")
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+	return &objectStorageClient{
+		config:     config,
+		endpoint:   endpoint,
+		httpClient: &http.Client{Transport: transport, Timeout: 5 * time.Minute},
+		now:        time.Now,
+	}, nil
+}
+
+// s3Error is the error returned by the object storage
+type s3Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Code    string   `xml:"Code"`
+	Message string   `xml:"Message"`
+}
+
+type initiateMultipartUploadResult struct {
+	UploadID string `xml:"UploadId"`
+}
+
+type listMultipartUploadsResult struct {
+	Uploads []struct {
+		Key       string    `xml:"Key"`
+		UploadID  string    `xml:"UploadId"`
+		Initiated time.Time `xml:"Initiated"`
+	} `xml:"Upload"`
+}
+
+type uploadedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+	Size       int64  `xml:"Size,omitempty"`
+}
+
+type listPartsResult struct {
+	IsTruncated          bool           `xml:"IsTruncated"`
+	NextPartNumberMarker int            `xml:"NextPartNumberMarker"`
+	Parts                []uploadedPart `xml:"Part"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+	Parts   []uploadedPart `xml:"Part"`
+}
+
+// awsEscape encodes a string as required by the canonical request of the signature
+// returns:			string
+func awsEscape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', keepSlash && c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// canonicalQuery sorts and encodes the query parameters
+// returns:			string
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			params = append(params, awsEscape(key, false)+"="+awsEscape(value, false))
+		}
+	}
+	return strings.Join(params, "&")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexDigest(h hash.Hash, data []byte) string {
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// sign adds the AWS Signature Version 4 of the request, covering its host, content-md5 and x-amz-* headers
+func (c *objectStorageClient) sign(req *http.Request, payloadHash string) {
+	now := c.now().UTC()
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if strings.HasPrefix(name, "x-amz-") || name == "content-md5" || name == "content-type" || name == "range" {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := now.Format("20060102")
+	scope := strings.Join([]string{date, c.config.Region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		signatureAlgorithm,
+		now.Format(amzDateFormat),
+		scope,
+		hexDigest(sha256.New(), []byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.config.SecretAccessKey), date)
+	for _, part := range []string{c.config.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signatureAlgorithm, c.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// do sends a signed request for an object of the bucket, retrying on server and network errors
+// returns:			[]byte - the response body, http.Header, error
+func (c *objectStorageClient) do(method, key string, query url.Values, body []byte,
+	header http.Header) ([]byte, http.Header, error) {
+
+	path := "/" + c.config.Bucket
+	if key != "" {
+		path += "/" + key
+	}
+	target := *c.endpoint
+	target.Path = strings.TrimSuffix(c.endpoint.Path, "/") + path
+	target.RawPath = awsEscape(strings.TrimSuffix(c.endpoint.Path, "/"), true) + awsEscape(path, true)
+	target.RawQuery = canonicalQuery(query)
+	payloadHash := hexDigest(sha256.New(), body)
+
+	var err error
+	for attempt := 1; attempt <= uploadRetries; attempt++ {
+		var req *http.Request
+		req, err = http.NewRequest(method, target.String(), bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		c.sign(req, payloadHash)
+
+		var resp *http.Response
+		resp, err = c.httpClient.Do(req)
+		if err == nil {
+			var content []byte
+			content, err = io.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err == nil && resp.StatusCode < 300 {
+				return content, resp.Header, nil
+			}
+			if err == nil {
+				s3Err := s3Error{}
+				if xml.Unmarshal(content, &s3Err) != nil || s3Err.Code == "" {
+					s3Err.Code = resp.Status
+				}
+				err = fmt.Errorf("%s %s: %s %s", method, key, s3Err.Code, s3Err.Message)
+				if resp.StatusCode < 500 {
+					return nil, nil, err
+				}
+			}
+		}
+		if attempt < uploadRetries {
+			log.Warnf("Object storage request failed, attempt %d of %d, err: %s", attempt, uploadRetries, err)
+			time.Sleep(uploadRetryInterval)
+		}
+	}
+	return nil, nil, err
+}
+
+// findMultipartUpload looks for an interrupted upload of the object to resume it
+// returns:			string - the upload ID, empty when not found, error
+func (c *objectStorageClient) findMultipartUpload(key string) (string, error) {
+	content, _, err := c.do(http.MethodGet, "", url.Values{"uploads": {""}, "prefix": {key}}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	result := listMultipartUploadsResult{}
+	if err := xml.Unmarshal(content, &result); err != nil {
+		return "", fmt.Errorf("invalid multipart uploads list: %w", err)
+	}
+	uploadID, initiated := "", time.Time{}
+	for _, upload := range result.Uploads {
+		if upload.Key == key && !upload.Initiated.Before(initiated) {
+			uploadID, initiated = upload.UploadID, upload.Initiated
+		}
+	}
+	return uploadID, nil
+}
+
+// createMultipartUpload starts the upload of the object
+// returns:			string - the upload ID, error
+func (c *objectStorageClient) createMultipartUpload(key string) (string, error) {
+	content, _, err := c.do(http.MethodPost, key, url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	result := initiateMultipartUploadResult{}
+	if err := xml.Unmarshal(content, &result); err != nil || result.UploadID == "" {
+		return "", fmt.Errorf("invalid multipart upload of %s: %v", key, err)
+	}
+	return result.UploadID, nil
+}
+
+// listParts lists the parts already uploaded
+// returns:			map[int]uploadedPart - indexed by part number, error
+func (c *objectStorageClient) listParts(key, uploadID string) (map[int]uploadedPart, error) {
+	parts := make(map[int]uploadedPart)
+	marker := 0
+	for {
+		query := url.Values{"uploadId": {uploadID}}
+		if marker > 0 {
+			query.Set("part-number-marker", strconv.Itoa(marker))
+		}
+		content, _, err := c.do(http.MethodGet, key, query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		result := listPartsResult{}
+		if err := xml.Unmarshal(content, &result); err != nil {
+			return nil, fmt.Errorf("invalid parts list: %w", err)
+		}
+		for _, part := range result.Parts {
+			parts[part.PartNumber] = part
+		}
+		if !result.IsTruncated || result.NextPartNumberMarker <= marker {
+			return parts, nil
+		}
+		marker = result.NextPartNumberMarker
+	}
+}
+
+// uploadPart uploads a part of the object
+// returns:			string - the ETag of the part, error
+func (c *objectStorageClient) uploadPart(key, uploadID string, number int, data []byte, md5sum []byte) (string, error) {
+	header := http.Header{}
+	header.Set("Content-MD5", base64.StdEncoding.EncodeToString(md5sum))
+	_, respHeader, err := c.do(http.MethodPut, key,
+		url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}, data, header)
+	if err != nil {
+		return "", err
+	}
+	return respHeader.Get("ETag"), nil
+}
+
+// completeMultipartUpload assembles the uploaded parts into the object
+// returns:			error
+func (c *objectStorageClient) completeMultipartUpload(key, uploadID string, parts []uploadedPart) error {
+	body, err := xml.Marshal(completeMultipartUpload{Parts: parts})
+	if err != nil {
+		return err
+	}
+	content, _, err := c.do(http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body, nil)
+	if err != nil {
+		return err
+	}
+	// The completion may fail after the response status is sent
+	s3Err := s3Error{}
+	if xml.Unmarshal(content, &s3Err) == nil && s3Err.Code != "" {
+		return fmt.Errorf("%s %s: %s %s", http.MethodPost, key, s3Err.Code, s3Err.Message)
+	}
+	return nil
+}
+
+// writeBackupArchive streams the backup content and its manifest as a tar archive, in a stable order so that
+// an interrupted upload can be resumed
+// returns:			error
+func writeBackupArchive(backupPath string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	roots := backupRoots(backupPath)
+	if _, err := os.Stat(filepath.Join(backupPath, BackupManifestFile)); err == nil {
+		roots = append(roots, filepath.Join(backupPath, BackupManifestFile))
+	}
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			link := ""
+			if d.Type()&fs.ModeSymlink != 0 {
+				if link, err = os.Readlink(path); err != nil {
+					return err
+				}
+			}
+			header, err := tar.FileInfoHeader(info, link)
+			if err != nil {
+				return err
+			}
+			if header.Name, err = filepath.Rel(backupPath, path); err != nil {
+				return err
+			}
+			if d.IsDir() {
+				header.Name += "/"
+			}
+			if err := tw.WriteHeader(header); err != nil {
+				return err
+			}
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				return err
+			}
+			defer file.Close()
+			_, err = io.Copy(tw, file)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+// UploadBackup uploads the backup content as a tar archive to the object storage, with a multipart upload.
+// The parts of an interrupted upload of the same object are not uploaded again when they match the backup
+// returns:			*ObjectStorageRecord, error
+func UploadBackup(backupPath string, config *ObjectStorageConfig, name string) (*ObjectStorageRecord, error) {
+	client, err := newObjectStorageClient(config)
+	if err != nil {
+		return nil, err
+	}
+	key := config.ObjectKey(name)
+	partSize := config.PartSize
+	if partSize <= 0 {
+		partSize = defaultPartSize
+	}
+
+	uploadID, err := client.findMultipartUpload(key)
+	if err != nil {
+		return nil, err
+	}
+	uploaded := make(map[int]uploadedPart)
+	if uploadID != "" {
+		if uploaded, err = client.listParts(key, uploadID); err != nil {
+			return nil, err
+		}
+		log.Infof("Resuming the upload of %s with %d parts uploaded", key, len(uploaded))
+	} else if uploadID, err = client.createMultipartUpload(key); err != nil {
+		return nil, err
+	}
+
+	pr, pw := io.Pipe()
+	defer pr.Close()
+	go func() {
+		pw.CloseWithError(writeBackupArchive(backupPath, pw))
+	}()
+
+	record := &ObjectStorageRecord{Endpoint: config.Endpoint, Bucket: config.Bucket, Key: key}
+	checksum := sha256.New()
+	var parts []uploadedPart
+	buffer := make([]byte, partSize)
+	for number := 1; ; number++ {
+		n, err := io.ReadFull(pr, buffer)
+		if err == io.EOF && number > 1 {
+			break
+		}
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("failed to archive the backup: %w", err)
+		}
+		data := buffer[:n]
+		checksum.Write(data)
+		record.SizeBytes += int64(n)
+
+		md5sum := md5.Sum(data) //nolint:gosec
+		etag := hex.EncodeToString(md5sum[:])
+		if part, ok := uploaded[number]; ok && strings.Trim(part.ETag, `"`) == etag && part.Size == int64(n) {
+			log.Infof("Part %d of %s already uploaded", number, key)
+		} else {
+			if etag, err = client.uploadPart(key, uploadID, number, data, md5sum[:]); err != nil {
+				return nil, fmt.Errorf("failed to upload part %d of %s: %w", number, key, err)
+			}
+			log.Infof("Part %d of %s uploaded", number, key)
+		}
+		parts = append(parts, uploadedPart{PartNumber: number, ETag: `"` + strings.Trim(etag, `"`) + `"`})
+		if int64(n) < partSize {
+			break
+		}
+	}
+
+	if err := client.completeMultipartUpload(key, uploadID, parts); err != nil {
+		return nil, err
+	}
+	record.SHA256 = hex.EncodeToString(checksum.Sum(nil))
+	record.UploadedAt = time.Now().UTC()
+	log.Infof("Backup uploaded to %s/%s in %d parts", config.Bucket, key, len(parts))
+	return record, nil
+}
+
+// WriteObjectStorageRecord writes the record of the upload in the backup path
+// returns:			error
+func WriteObjectStorageRecord(backupPath string, record *ObjectStorageRecord) error {
+	content, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(backupPath, ObjectStorageRecordFile), content, 0600)
+}
+
+// ReadObjectStorageRecord reads the record of the upload from the backup path
+// returns:			*ObjectStorageRecord, error
+func ReadObjectStorageRecord(backupPath string) (*ObjectStorageRecord, error) {
+	content, err := os.ReadFile(filepath.Join(backupPath, ObjectStorageRecordFile))
+	if err != nil {
+		return nil, err
+	}
+	record := &ObjectStorageRecord{}
+	if err := json.Unmarshal(content, record); err != nil {
+		return nil, fmt.Errorf("invalid backup upload record: %w", err)
+	}
+	return record, nil
+}
+
+// VerifyUploadedBackup checks the backup object recorded in the backup path is in the object storage, with the
+// recorded size. It verifies the backup whose content was removed from the backup path once uploaded
+// returns:			error
+func VerifyUploadedBackup(config *ObjectStorageConfig, record *ObjectStorageRecord) error {
+	client, err := newObjectStorageClient(config)
+	if err != nil {
+		return err
+	}
+	_, header, err := client.do(http.MethodHead, record.Key, nil, nil, nil)
+	if err != nil {
+		return fmt.Errorf("uploaded backup %s/%s not found: %w", record.Bucket, record.Key, err)
+	}
+	size, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || size != record.SizeBytes {
+		return fmt.Errorf("uploaded backup %s/%s has size %q, expected %d",
+			record.Bucket, record.Key, header.Get("Content-Length"), record.SizeBytes)
+	}
+	return nil
+}
+
+// RemoveLocalBackup removes the backup content uploaded to the object storage from the backup path. The
+// manifest and the record of the upload are kept
+// returns:			error
+func RemoveLocalBackup(backupPath string) error {
+	for _, root := range backupRoots(backupPath) {
+		if err := os.RemoveAll(root); err != nil {
+			return err
+		}
+	}
+	log.Infof("Local backup content removed from %s", backupPath)
+	return nil
+}
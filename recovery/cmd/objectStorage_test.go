@@ -0,0 +1,369 @@
+package cmd_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openshift-kni/cluster-group-upgrades-operator/recovery/cmd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// objectStore is an in-memory stand-in of an S3-compatible object storage, implementing the multipart upload
+type objectStore struct {
+	sync.Mutex
+	accessKeyID, secretAccessKey string
+	uploads                      map[string]*multipartUpload
+	objects                      map[string][]byte
+	uploadIDs                    int
+	// failPart rejects the upload of a part
+	failPart      int
+	uploadedParts int
+}
+
+type multipartUpload struct {
+	key       string
+	initiated time.Time
+	parts     map[int][]byte
+}
+
+func (s *objectStore) error(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// checkSignature verifies the AWS Signature Version 4 of the request
+func (s *objectStore) checkSignature(r *http.Request, body []byte) bool {
+	var credential, signedHeaders, signature string
+	for _, field := range strings.Split(strings.TrimPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 "), ", ") {
+		switch {
+		case strings.HasPrefix(field, "Credential="):
+			credential = strings.TrimPrefix(field, "Credential=")
+		case strings.HasPrefix(field, "SignedHeaders="):
+			signedHeaders = strings.TrimPrefix(field, "SignedHeaders=")
+		case strings.HasPrefix(field, "Signature="):
+			signature = strings.TrimPrefix(field, "Signature=")
+		}
+	}
+	scope := strings.SplitN(credential, "/", 2)
+	payloadHash := sha256.Sum256(body)
+	if len(scope) != 2 || scope[0] != s.accessKeyID ||
+		r.Header.Get("X-Amz-Content-Sha256") != hex.EncodeToString(payloadHash[:]) {
+		return false
+	}
+
+	var headers []string
+	for _, name := range strings.Split(signedHeaders, ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		headers = append(headers, name+":"+value+"\n")
+	}
+	canonicalRequest := strings.Join([]string{r.Method, r.URL.EscapedPath(), r.URL.RawQuery,
+		strings.Join(headers, ""), signedHeaders, r.Header.Get("X-Amz-Content-Sha256")}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", r.Header.Get("X-Amz-Date"), scope[1],
+		hex.EncodeToString(requestHash[:])}, "\n")
+
+	key := []byte("AWS4" + s.secretAccessKey)
+	for _, part := range strings.Split(scope[1], "/") {
+		key = hmacSHA256(key, part)
+	}
+	return hmac.Equal([]byte(signature), []byte(hex.EncodeToString(hmacSHA256(key, stringToSign))))
+}
+
+func (s *objectStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.Lock()
+	defer s.Unlock()
+
+	body, _ := io.ReadAll(r.Body)
+	if !s.checkSignature(r, body) {
+		s.error(w, http.StatusForbidden, "SignatureDoesNotMatch")
+		return
+	}
+	path := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	query := r.URL.Query()
+	key := ""
+	if len(path) == 2 {
+		key = path[1]
+	}
+	upload := s.uploads[query.Get("uploadId")]
+	if query.Has("uploadId") && (upload == nil || upload.key != key) {
+		s.error(w, http.StatusNotFound, "NoSuchUpload")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && query.Has("uploads"):
+		fmt.Fprint(w, "<ListMultipartUploadsResult>")
+		for id, upload := range s.uploads {
+			if strings.HasPrefix(upload.key, query.Get("prefix")) {
+				fmt.Fprintf(w, "<Upload><Key>%s</Key><UploadId>%s</UploadId><Initiated>%s</Initiated></Upload>",
+					upload.key, id, upload.initiated.Format(time.RFC3339))
+			}
+		}
+		fmt.Fprint(w, "</ListMultipartUploadsResult>")
+
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		s.uploadIDs++
+		id := strconv.Itoa(s.uploadIDs)
+		s.uploads[id] = &multipartUpload{key: key, initiated: time.Now(), parts: make(map[int][]byte)}
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		sum := md5.Sum(body) //nolint:gosec
+		if r.Header.Get("Content-MD5") != base64.StdEncoding.EncodeToString(sum[:]) {
+			s.error(w, http.StatusBadRequest, "BadDigest")
+			return
+		}
+		if number == s.failPart {
+			s.error(w, http.StatusBadRequest, "RequestTimeout")
+			return
+		}
+		upload.parts[number] = body
+		s.uploadedParts++
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+
+	case r.Method == http.MethodGet && query.Has("uploadId"):
+		var numbers []int
+		for number := range upload.parts {
+			numbers = append(numbers, number)
+		}
+		sort.Ints(numbers)
+		fmt.Fprint(w, "<ListPartsResult><IsTruncated>false</IsTruncated>")
+		for _, number := range numbers {
+			sum := md5.Sum(upload.parts[number]) //nolint:gosec
+			fmt.Fprintf(w, "<Part><PartNumber>%d</PartNumber><ETag>&quot;%s&quot;</ETag><Size>%d</Size></Part>",
+				number, hex.EncodeToString(sum[:]), len(upload.parts[number]))
+		}
+		fmt.Fprint(w, "</ListPartsResult>")
+
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		complete := struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}{}
+		if err := xml.Unmarshal(body, &complete); err != nil {
+			s.error(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var object []byte
+		for _, part := range complete.Parts {
+			data, ok := upload.parts[part.PartNumber]
+			sum := md5.Sum(data) //nolint:gosec
+			if !ok || strings.Trim(part.ETag, `"`) != hex.EncodeToString(sum[:]) {
+				s.error(w, http.StatusBadRequest, "InvalidPart")
+				return
+			}
+			object = append(object, data...)
+		}
+		s.objects[key] = object
+		delete(s.uploads, query.Get("uploadId"))
+		fmt.Fprintf(w, "<CompleteMultipartUploadResult><Key>%s</Key></CompleteMultipartUploadResult>", key)
+
+	case r.Method == http.MethodHead:
+		object, ok := s.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(object)))
+
+	default:
+		s.error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+var _ = Describe("ObjectStorage", func() {
+	var dir string
+	var store *objectStore
+	var server *httptest.Server
+	var config *cmd.ObjectStorageConfig
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "tmpDir")
+		Expect(err).Should(BeNil())
+		for _, subDir := range []string{"cluster", "etc", "local", "kubelet"} {
+			Expect(os.Mkdir(filepath.Join(dir, subDir), 0700)).Should(BeNil())
+		}
+		Expect(os.WriteFile(filepath.Join(dir, "etc", "hostname"), []byte("sno1"), 0600)).Should(BeNil())
+		Expect(os.WriteFile(filepath.Join(dir, "cluster", "snapshot.db"),
+			bytes.Repeat([]byte("etcd"), 2048), 0600)).Should(BeNil())
+		manifest, err := cmd.GenerateManifest(dir, "4.14.1")
+		Expect(err).Should(BeNil())
+		Expect(cmd.WriteManifest(dir, manifest)).Should(BeNil())
+
+		store = &objectStore{
+			accessKeyID: "backup", secretAccessKey: "secret",
+			uploads: make(map[string]*multipartUpload), objects: make(map[string][]byte),
+		}
+		server = httptest.NewServer(store)
+		config = &cmd.ObjectStorageConfig{
+			Endpoint: server.URL, Bucket: "backups", Region: "us-east-1", Prefix: "sno/",
+			AccessKeyID: "backup", SecretAccessKey: "secret", PartSize: 1024,
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+		Expect(os.RemoveAll(dir)).Should(BeNil())
+	})
+
+	Describe("ReadObjectStorageConfig", func() {
+		var configDir string
+
+		BeforeEach(func() {
+			configDir = filepath.Join(dir, "config")
+			Expect(os.Mkdir(configDir, 0700)).Should(BeNil())
+			for key, value := range map[string]string{
+				cmd.ObjectStorageEndpointKey:        "https://s3.example.com/\n",
+				cmd.ObjectStorageBucketKey:          "backups",
+				cmd.ObjectStorageAccessKeyIDKey:     "backup",
+				cmd.ObjectStorageSecretAccessKeyKey: "secret",
+			} {
+				Expect(os.WriteFile(filepath.Join(configDir, key), []byte(value), 0600)).Should(BeNil())
+			}
+		})
+
+		It("reads the configuration", func() {
+			config, err := cmd.ReadObjectStorageConfig(configDir)
+			Expect(err).Should(BeNil())
+			Expect(config).To(Equal(&cmd.ObjectStorageConfig{
+				Endpoint: "https://s3.example.com", Bucket: "backups", Region: "us-east-1",
+				AccessKeyID: "backup", SecretAccessKey: "secret",
+			}))
+			Expect(config.ObjectKey("sno1/backup.tar")).To(Equal("sno1/backup.tar"))
+		})
+
+		It("rejects an incomplete configuration", func() {
+			Expect(os.Remove(filepath.Join(configDir, cmd.ObjectStorageBucketKey))).Should(BeNil())
+			_, err := cmd.ReadObjectStorageConfig(configDir)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects an invalid endpoint", func() {
+			Expect(os.WriteFile(filepath.Join(configDir, cmd.ObjectStorageEndpointKey),
+				[]byte("s3.example.com"), 0600)).Should(BeNil())
+			_, err := cmd.ReadObjectStorageConfig(configDir)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("UploadBackup", func() {
+		It("uploads the backup content", func() {
+			record, err := cmd.UploadBackup(dir, config, "sno1/backup.tar")
+			Expect(err).Should(BeNil())
+			Expect(record.Key).To(Equal("sno/sno1/backup.tar"))
+
+			object, ok := store.objects["sno/sno1/backup.tar"]
+			Expect(ok).To(Equal(true))
+			sum := sha256.Sum256(object)
+			Expect(record.SizeBytes).To(Equal(int64(len(object))))
+			Expect(record.SHA256).To(Equal(hex.EncodeToString(sum[:])))
+			Expect(store.uploadedParts).To(BeNumerically(">", 1))
+
+			var names []string
+			reader := tar.NewReader(bytes.NewReader(object))
+			for {
+				header, err := reader.Next()
+				if err == io.EOF {
+					break
+				}
+				Expect(err).Should(BeNil())
+				names = append(names, header.Name)
+				if header.Name == "etc/hostname" {
+					content, err := io.ReadAll(reader)
+					Expect(err).Should(BeNil())
+					Expect(string(content)).To(Equal("sno1"))
+				}
+			}
+			Expect(names).To(Equal([]string{"cluster/", "cluster/snapshot.db", "etc/", "etc/hostname",
+				"local/", "kubelet/", cmd.BackupManifestFile}))
+		})
+
+		It("resumes an interrupted upload", func() {
+			store.failPart = 3
+			_, err := cmd.UploadBackup(dir, config, "sno1/backup.tar")
+			Expect(err).To(HaveOccurred())
+			Expect(store.uploadedParts).To(Equal(2))
+			Expect(store.objects).To(BeEmpty())
+
+			store.failPart = 0
+			record, err := cmd.UploadBackup(dir, config, "sno1/backup.tar")
+			Expect(err).Should(BeNil())
+			parts := int((record.SizeBytes + config.PartSize - 1) / config.PartSize)
+			Expect(store.uploadedParts).To(Equal(parts))
+			Expect(store.uploads).To(BeEmpty())
+			Expect(store.objects["sno/sno1/backup.tar"]).To(HaveLen(int(record.SizeBytes)))
+		})
+
+		It("rejects invalid credentials", func() {
+			config.SecretAccessKey = "invalid"
+			_, err := cmd.UploadBackup(dir, config, "sno1/backup.tar")
+			Expect(err).To(MatchError(ContainSubstring("SignatureDoesNotMatch")))
+		})
+	})
+
+	Describe("VerifyUploadedBackup", func() {
+		It("checks the uploaded object", func() {
+			record, err := cmd.UploadBackup(dir, config, "sno1/backup.tar")
+			Expect(err).Should(BeNil())
+			Expect(cmd.VerifyUploadedBackup(config, record)).Should(BeNil())
+
+			store.objects["sno/sno1/backup.tar"] = store.objects["sno/sno1/backup.tar"][:1024]
+			Expect(cmd.VerifyUploadedBackup(config, record)).To(MatchError(ContainSubstring("expected")))
+
+			delete(store.objects, "sno/sno1/backup.tar")
+			Expect(cmd.VerifyUploadedBackup(config, record)).To(MatchError(ContainSubstring("not found")))
+		})
+	})
+
+	Describe("RemoveLocalBackup", func() {
+		It("keeps the manifest and the upload record", func() {
+			record, err := cmd.UploadBackup(dir, config, "sno1/backup.tar")
+			Expect(err).Should(BeNil())
+			Expect(cmd.WriteObjectStorageRecord(dir, record)).Should(BeNil())
+			Expect(cmd.RemoveLocalBackup(dir)).Should(BeNil())
+			Expect(cmd.BackupExists(dir)).To(Equal(false))
+
+			_, err = cmd.ReadManifest(dir)
+			Expect(err).Should(BeNil())
+			recorded, err := cmd.ReadObjectStorageRecord(dir)
+			Expect(err).Should(BeNil())
+			Expect(recorded.Key).To(Equal("sno/sno1/backup.tar"))
+
+			info, err := cmd.InspectBackup(dir)
+			Expect(err).Should(BeNil())
+			Expect(info.ObjectKey).To(Equal("sno/sno1/backup.tar"))
+		})
+	})
+})
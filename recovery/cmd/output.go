@@ -0,0 +1,47 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+)
+
+// outputField is a line of the text output of a command
+type outputField struct {
+	name  string
+	value interface{}
+}
+
+// printOutput prints the result of a command, either as JSON or as aligned text fields
+// returns:			error
+func printOutput(out io.Writer, jsonOutput bool, result interface{}, fields []outputField) error {
+	if jsonOutput {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	writer := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, field := range fields {
+		if _, err := fmt.Fprintf(writer, "%s:\t%v\n", field.name, field.value); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
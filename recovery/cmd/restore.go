@@ -0,0 +1,163 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/openshift-kni/cluster-group-upgrades-operator/recovery/generated"
+)
+
+// RestoreOptions selects the stages of the recovery script to run
+type RestoreOptions struct {
+	// Step runs a single stage of the recovery
+	Step bool
+	// Resume resumes the recovery after the last completed stage
+	Resume bool
+	// Restart restarts the recovery from the first stage
+	Restart bool
+	// Force skips the check of the active ostree deployment
+	Force bool
+	// EncryptionKeyFile is the file of the key decrypting the backup archives
+	EncryptionKeyFile string
+}
+
+// Command returns the recovery script command for the options
+// returns:			string, error
+func (o RestoreOptions) Command(backupPath string) (string, error) {
+	if o.Resume && o.Restart {
+		return "", errors.New("--resume and --restart are mutually exclusive")
+	}
+	command := []string{filepath.Join(backupPath, recoveryScript), "--dir", backupPath}
+	for _, option := range []struct {
+		set  bool
+		flag string
+	}{
+		{o.Step, "--step"},
+		{o.Resume, "--resume"},
+		{o.Restart, "--restart"},
+		{o.Force, "--force"},
+	} {
+		if option.set {
+			command = append(command, option.flag)
+		}
+	}
+	return strings.Join(command, " "), nil
+}
+
+// Restore runs the stages of the recovery script selected by the options from the node filesystem
+// returns:			*RecoveryStatus - the progress of the recovery once the script is done, error
+//
+//nolint:gocritic
+func Restore(root string, options RestoreOptions) (*RecoveryStatus, error) {
+	command, err := options.Command(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	// The archived backup is unpacked by the recovery script
+	if err := setEncryptionKey(options.EncryptionKeyFile); err != nil {
+		return nil, err
+	}
+
+	if root != "/" {
+		// change root directory to the node filesystem
+		if err := syscall.Chroot(root); err != nil {
+			log.Errorf("Couldn't do chroot to %s, err: %s", root, err)
+			return nil, err
+		}
+		if err := os.Chdir("/"); err != nil {
+			log.Error("Couldn't do chdir")
+			return nil, err
+		}
+	}
+
+	if !BackupExists(backupPath) {
+		return nil, fmt.Errorf("required backup content not found in %s", backupPath)
+	}
+
+	scriptcontent, err := generated.Asset(recoveryScript)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(backupPath, recoveryScript), scriptcontent, 0700); err != nil {
+		return nil, err
+	}
+
+	if os.Getenv("KUBECONFIG") == "" {
+		if err := os.Setenv("KUBECONFIG", localhostKubeconfig); err != nil {
+			return nil, err
+		}
+	}
+	// The script output is logged, the outcome is reported from the progress it recorded
+	runErr := ExecuteCmd(command)
+
+	status, err := GetRecoveryStatus(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	if runErr != nil {
+		return status, fmt.Errorf("recovery failed: %w", runErr)
+	}
+	return status, nil
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:   "restore",
+	Short: "It will run the recovery from the backup in the specified path, or some of its stages",
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, _ := cmd.Flags().GetString("root")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+		var options RestoreOptions
+		options.Step, _ = cmd.Flags().GetBool("step")
+		options.Resume, _ = cmd.Flags().GetBool("resume")
+		options.Restart, _ = cmd.Flags().GetBool("restart")
+		options.Force, _ = cmd.Flags().GetBool("force")
+		options.EncryptionKeyFile, _ = cmd.Flags().GetString("encryption-key-file")
+
+		status, err := Restore(root, options)
+		if status != nil {
+			if printErr := printOutput(cmd.OutOrStdout(), jsonOutput, status, status.fields()); printErr != nil {
+				log.Error(printErr)
+			}
+		}
+		return err
+	},
+}
+
+func init() {
+
+	restoreCmd.Flags().String("root", host, "Root directory of the node filesystem")
+	restoreCmd.Flags().Bool("json", false, "Print the recovery status as JSON")
+	restoreCmd.Flags().Bool("step", false, "Run a single stage of the recovery")
+	restoreCmd.Flags().Bool("resume", false, "Resume the recovery after the last completed stage")
+	restoreCmd.Flags().Bool("restart", false, "Restart the recovery from the first stage")
+	restoreCmd.Flags().Bool("force", false, "Skip the check of the active ostree deployment")
+	restoreCmd.Flags().String("encryption-key-file", "", "File of the key decrypting the backup archives")
+	rootCmd.AddCommand(restoreCmd)
+
+}
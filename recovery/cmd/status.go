@@ -0,0 +1,120 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// recoveryStages lists the stages of the recovery script, in order
+var recoveryStages = []string{"restore_files", "restore_cluster"}
+
+// RecoveryStatus is the progress of the recovery from the backup
+type RecoveryStatus struct {
+	BackupPath      string   `json:"backupPath"`
+	BackupPresent   bool     `json:"backupPresent"`
+	InProgress      bool     `json:"inProgress"`
+	CompletedStages []string `json:"completedStages"`
+	NextStage       string   `json:"nextStage,omitempty"`
+	RestoreResult   string   `json:"restoreResult,omitempty"`
+}
+
+// GetRecoveryStatus reads the progress recorded by the recovery script and the outcome of the last restore
+// returns:			*RecoveryStatus, error
+func GetRecoveryStatus(backupPath string) (*RecoveryStatus, error) {
+	status := &RecoveryStatus{
+		BackupPath:      backupPath,
+		BackupPresent:   BackupExists(backupPath),
+		InProgress:      RecoveryInProgress(backupPath),
+		CompletedStages: []string{},
+	}
+
+	if status.InProgress {
+		file, err := os.Open(filepath.Join(backupPath, "progress"))
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		recorded := make(map[string]bool)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			recorded[strings.TrimSpace(scanner.Text())] = true
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		for _, stage := range recoveryStages {
+			if !recorded[stage] {
+				status.NextStage = stage
+				break
+			}
+			status.CompletedStages = append(status.CompletedStages, stage)
+		}
+	}
+
+	content, err := os.ReadFile(filepath.Join(backupPath, restoreResult))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	status.RestoreResult = strings.TrimSpace(string(content))
+	return status, nil
+}
+
+// fields returns the text output of the recovery status
+// returns:			[]outputField
+func (s *RecoveryStatus) fields() []outputField {
+	return []outputField{
+		{"Backup path", s.BackupPath},
+		{"Backup present", s.BackupPresent},
+		{"Recovery in progress", s.InProgress},
+		{"Completed stages", strings.Join(s.CompletedStages, ", ")},
+		{"Next stage", s.NextStage},
+		{"Last restore result", s.RestoreResult},
+	}
+}
+
+// statusCmd represents the status command
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "It will report the progress of the recovery from the backup in the specified path",
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		root, _ := cmd.Flags().GetString("root")
+		jsonOutput, _ := cmd.Flags().GetBool("json")
+
+		status, err := GetRecoveryStatus(filepath.Join(root, backupPath))
+		if err != nil {
+			return err
+		}
+		status.BackupPath = backupPath
+		return printOutput(cmd.OutOrStdout(), jsonOutput, status, status.fields())
+	},
+}
+
+func init() {
+
+	statusCmd.Flags().String("root", host, "Root directory of the node filesystem")
+	statusCmd.Flags().Bool("json", false, "Print the status as JSON")
+	rootCmd.AddCommand(statusCmd)
+
+}
@@ -0,0 +1,95 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/openshift-kni/cluster-group-upgrades-operator/recovery/cmd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recovery CLI", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "tmpDir")
+		Expect(err).Should(BeNil())
+		for _, subDir := range []string{"cluster", "etc", "local", "kubelet"} {
+			Expect(os.Mkdir(filepath.Join(dir, subDir), 0700)).Should(BeNil())
+		}
+		Expect(os.WriteFile(filepath.Join(dir, "etc", "hostname"), []byte("sno1"), 0600)).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).Should(BeNil())
+	})
+
+	Describe("GetRecoveryStatus", func() {
+		It("no recovery", func() {
+			status, err := cmd.GetRecoveryStatus(dir)
+			Expect(err).Should(BeNil())
+			Expect(status).To(Equal(&cmd.RecoveryStatus{
+				BackupPath: dir, BackupPresent: true, CompletedStages: []string{},
+			}))
+		})
+
+		It("recovery in progress", func() {
+			Expect(os.WriteFile(filepath.Join(dir, "progress"), []byte("started\nrestore_files\n"), 0600)).Should(BeNil())
+			status, err := cmd.GetRecoveryStatus(dir)
+			Expect(err).Should(BeNil())
+			Expect(status.InProgress).To(Equal(true))
+			Expect(status.CompletedStages).To(Equal([]string{"restore_files"}))
+			Expect(status.NextStage).To(Equal("restore_cluster"))
+		})
+
+		It("restore finished", func() {
+			Expect(os.WriteFile(filepath.Join(dir, "restore-result"), []byte("Succeeded\n"), 0600)).Should(BeNil())
+			status, err := cmd.GetRecoveryStatus(dir)
+			Expect(err).Should(BeNil())
+			Expect(status.InProgress).To(Equal(false))
+			Expect(status.RestoreResult).To(Equal("Succeeded"))
+		})
+	})
+
+	Describe("InspectBackup", func() {
+		It("backup without manifest", func() {
+			info, err := cmd.InspectBackup(dir)
+			Expect(err).Should(BeNil())
+			Expect(info).To(Equal(&cmd.BackupInfo{BackupPath: dir, Complete: true, SizeBytes: 4}))
+		})
+
+		It("backup with manifest", func() {
+			manifest, err := cmd.GenerateManifest(dir, "4.14.1")
+			Expect(err).Should(BeNil())
+			Expect(cmd.WriteManifest(dir, manifest)).Should(BeNil())
+
+			info, err := cmd.InspectBackup(dir)
+			Expect(err).Should(BeNil())
+			Expect(info.Manifest).To(Equal(true))
+			Expect(info.ClusterVersion).To(Equal("4.14.1"))
+			Expect(info.CreatedAt.Equal(manifest.CreatedAt)).To(Equal(true))
+			Expect(info.Files).To(Equal(1))
+		})
+
+		It("no backup", func() {
+			_, err := cmd.InspectBackup(filepath.Join(dir, "missing"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("RestoreOptions", func() {
+		It("runs the selected stages", func() {
+			command, err := cmd.RestoreOptions{Step: true, Resume: true}.Command("/var/recovery")
+			Expect(err).Should(BeNil())
+			Expect(command).To(Equal("/var/recovery/upgrade-recovery.sh --dir /var/recovery --step --resume"))
+		})
+
+		It("rejects conflicting options", func() {
+			_, err := cmd.RestoreOptions{Resume: true, Restart: true}.Command("/var/recovery")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,158 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// UploadOptions selects the upload of the backup to an object storage
+type UploadOptions struct {
+	// ConfigDir is the directory of the mounted object storage secret, the backup is not uploaded when empty
+	ConfigDir string
+	// ObjectName is the name of the backup object, under the prefix of the object storage
+	ObjectName string
+	// RemoveLocal removes the backup content from the recovery partition once uploaded
+	RemoveLocal bool
+}
+
+// enabled checks if the backup is uploaded
+// returns:			bool
+func (o UploadOptions) enabled() bool {
+	return o.ConfigDir != ""
+}
+
+// readConfig reads the object storage configuration before the chroot to the host
+// returns:			*ObjectStorageConfig, error
+func (o UploadOptions) readConfig() (*ObjectStorageConfig, error) {
+	if !o.enabled() {
+		return nil, nil
+	}
+	if o.ObjectName == "" {
+		return nil, errors.New("no object name for the backup uploaded to the object storage")
+	}
+	return ReadObjectStorageConfig(o.ConfigDir)
+}
+
+// uploadBackup uploads the backup, records the upload in the backup path and removes the local backup
+// content if requested
+// returns:			*ObjectStorageRecord, error
+func uploadBackup(backupPath string, config *ObjectStorageConfig, options UploadOptions) (*ObjectStorageRecord, error) {
+	record, err := UploadBackup(backupPath, config, options.ObjectName)
+	if err != nil {
+		log.Errorf("Couldn't upload the backup, err: %s", err)
+		return nil, err
+	}
+	if err := WriteObjectStorageRecord(backupPath, record); err != nil {
+		log.Errorf("Couldn't record the backup upload, err: %s", err)
+		return nil, err
+	}
+	if options.RemoveLocal {
+		if err := RemoveLocalBackup(backupPath); err != nil {
+			log.Errorf("Couldn't remove the local backup content, err: %s", err)
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+// Upload uploads the backup in the recovery partition to the object storage, resuming an interrupted upload
+// returns:			error
+//
+//nolint:gocritic
+func Upload(options UploadOptions) error {
+
+	config, err := options.readConfig()
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	if config == nil {
+		return errors.New("no object storage configuration")
+	}
+
+	// change root directory to /host
+	if err := syscall.Chroot(host); err != nil {
+		log.Errorf("Couldn't do chroot to %s, err: %s", host, err)
+		return err
+	}
+
+	if err := os.Chdir("/"); err != nil {
+		log.Error("Couldn't do chdir")
+		return err
+	}
+
+	if !BackupExists(backupPath) {
+		err := fmt.Errorf("required backup content not found in %s", backupPath)
+		log.Error(err)
+		return err
+	}
+
+	_, err = uploadBackup(backupPath, config, options)
+	return err
+}
+
+// uploadCmd represents the upload command
+var uploadCmd = &cobra.Command{
+	Use:   "upload",
+	Short: "It will upload the backup of resources in the specified path to an S3-compatible object storage",
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		options, err := uploadOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		// start uploading the backup of the resources
+		return Upload(options)
+	},
+}
+
+// uploadOptionsFromFlags reads the upload options of a command
+// returns:			UploadOptions, error
+func uploadOptionsFromFlags(cmd *cobra.Command) (UploadOptions, error) {
+	var options UploadOptions
+	var err error
+	if options.ConfigDir, err = cmd.Flags().GetString("object-storage-config"); err != nil {
+		return options, err
+	}
+	if options.ObjectName, err = cmd.Flags().GetString("object-name"); err != nil {
+		return options, err
+	}
+	options.RemoveLocal, err = cmd.Flags().GetBool("remove-local")
+	return options, err
+}
+
+// addUploadFlags adds the upload options to a command
+func addUploadFlags(cmd *cobra.Command) {
+	cmd.Flags().String("object-storage-config", "", "Directory of the mounted object storage secret")
+	cmd.Flags().String("object-name", "", "Name of the backup object, under the prefix of the object storage")
+	cmd.Flags().Bool("remove-local", false, "Remove the backup content from the recovery partition once uploaded")
+}
+
+func init() {
+
+	addUploadFlags(uploadCmd)
+	rootCmd.AddCommand(uploadCmd)
+
+}
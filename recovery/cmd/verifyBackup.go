@@ -0,0 +1,113 @@
+/*
+ * Copyright 2022 Red Hat, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *   http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Verify checks the backup in the recovery partition against its manifest. The backup stored in the object
+// storage only is checked in the object storage, configured by the secret mounted at configDir
+// returns:			error
+//
+//nolint:gocritic
+func Verify(configDir string) error {
+
+	// The object storage configuration is read before the chroot to the host, which hides the secret
+	var config *ObjectStorageConfig
+	if configDir != "" {
+		var err error
+		if config, err = ReadObjectStorageConfig(configDir); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+
+	// change root directory to /host
+	if err := syscall.Chroot(host); err != nil {
+		log.Errorf("Couldn't do chroot to %s, err: %s", host, err)
+		return err
+	}
+
+	if err := os.Chdir("/"); err != nil {
+		log.Error("Couldn't do chdir")
+		return err
+	}
+
+	// The backup content uploaded to the object storage may have been removed from the recovery partition
+	if record, err := ReadObjectStorageRecord(backupPath); err == nil && !BackupExists(backupPath) {
+		log.Infof("Backup stored in the object storage only, as %s/%s", record.Bucket, record.Key)
+		if config == nil {
+			err := errors.New("no object storage configuration to verify the uploaded backup")
+			log.Error(err)
+			return err
+		}
+		if err := VerifyUploadedBackup(config, record); err != nil {
+			log.Errorf("Couldn't verify the uploaded backup, err: %s", err)
+			return err
+		}
+		log.Info("uploaded backup has been successfully verified ...")
+		return nil
+	}
+
+	mismatches, err := VerifyBackup(backupPath)
+	if err != nil {
+		log.Errorf("Couldn't verify the backup, err: %s", err)
+		return err
+	}
+	if len(mismatches) > 0 {
+		for _, mismatch := range mismatches {
+			log.Error(mismatch)
+		}
+		return fmt.Errorf("backup verification failed for %d files", len(mismatches))
+	}
+
+	log.Info(strings.Repeat("-", 60))
+	log.Info("backup has been successfully verified ...")
+
+	return nil
+}
+
+// verifyCmd represents the verify command
+var verifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "It will verify the backup of resources in the specified path against its manifest",
+
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configDir, err := cmd.Flags().GetString("object-storage-config")
+		if err != nil {
+			return err
+		}
+		// start verifying the backup of the resources
+		return Verify(configDir)
+	},
+}
+
+func init() {
+
+	rootCmd.AddCommand(verifyCmd)
+	verifyCmd.Flags().String("object-storage-config", "", "Directory of the mounted object storage secret")
+
+}
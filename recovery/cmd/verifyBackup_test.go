@@ -0,0 +1,93 @@
+package cmd_test
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/openshift-kni/cluster-group-upgrades-operator/recovery/cmd"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Verify", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "tmpDir")
+		Expect(err).Should(BeNil())
+
+		Expect(os.MkdirAll(filepath.Join(dir, "etc", "kubernetes"), 0700)).Should(BeNil())
+		Expect(os.WriteFile(filepath.Join(dir, "etc", "kubernetes", "kubelet.conf"), []byte("kubelet"), 0600)).Should(BeNil())
+		Expect(os.WriteFile(filepath.Join(dir, "etc.exclude.list"), []byte(".updated\n"), 0600)).Should(BeNil())
+		Expect(os.Symlink("kubelet.conf", filepath.Join(dir, "etc", "kubernetes", "link"))).Should(BeNil())
+		// Not part of the backup content
+		Expect(os.WriteFile(filepath.Join(dir, "upgrade-recovery.sh"), []byte("#!/bin/bash"), 0700)).Should(BeNil())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).Should(BeNil())
+	})
+
+	Describe("GenerateManifest", func() {
+		It("lists the backup files", func() {
+			manifest, err := cmd.GenerateManifest(dir, "4.14.1")
+			Expect(err).Should(BeNil())
+			Expect(manifest.ClusterVersion).To(Equal("4.14.1"))
+			Expect(manifest.Files).To(Equal([]cmd.ManifestEntry{
+				{Path: "etc.exclude.list", Size: 9,
+					SHA256: "c35b86146e2af244977cd4176507a102efa9285c2ec2ca275c6a512c63af62f9"},
+				{Path: "etc/kubernetes/kubelet.conf", Size: 7,
+					SHA256: "1ca4bc7eb9b3d6f1e205da9cfab437c89d3760d0765a29a6bcbccf4ad51a2cb1"},
+			}))
+		})
+	})
+
+	Describe("GenerateManifest with archives", func() {
+		It("lists the backup archives", func() {
+			Expect(os.WriteFile(filepath.Join(dir, "kubelet.tar.gz.enc"), []byte("kubelet"), 0600)).Should(BeNil())
+			manifest, err := cmd.GenerateManifest(dir, "4.14.1")
+			Expect(err).Should(BeNil())
+			var paths []string
+			for _, entry := range manifest.Files {
+				paths = append(paths, entry.Path)
+			}
+			Expect(paths).To(Equal([]string{"etc.exclude.list", "etc/kubernetes/kubelet.conf", "kubelet.tar.gz.enc"}))
+		})
+	})
+
+	Describe("VerifyBackup", func() {
+		BeforeEach(func() {
+			manifest, err := cmd.GenerateManifest(dir, "4.14.1")
+			Expect(err).Should(BeNil())
+			Expect(cmd.WriteManifest(dir, manifest)).Should(BeNil())
+		})
+
+		It("backup matches the manifest", func() {
+			mismatches, err := cmd.VerifyBackup(dir)
+			Expect(err).Should(BeNil())
+			Expect(mismatches).To(BeEmpty())
+		})
+
+		It("backup doesn't match the manifest", func() {
+			Expect(os.WriteFile(filepath.Join(dir, "etc", "kubernetes", "kubelet.conf"), []byte("changed"), 0600)).Should(BeNil())
+			Expect(os.Remove(filepath.Join(dir, "etc.exclude.list"))).Should(BeNil())
+			Expect(os.WriteFile(filepath.Join(dir, "etc", "extra"), []byte("extra"), 0600)).Should(BeNil())
+
+			mismatches, err := cmd.VerifyBackup(dir)
+			Expect(err).Should(BeNil())
+			Expect(mismatches).To(Equal([]string{
+				"etc.exclude.list: missing",
+				"etc/extra: not in the manifest",
+				"etc/kubernetes/kubelet.conf: checksum mismatch",
+			}))
+		})
+
+		It("backup without manifest", func() {
+			Expect(os.Remove(filepath.Join(dir, cmd.BackupManifestFile))).Should(BeNil())
+			_, err := cmd.VerifyBackup(dir)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -1,5 +1,6 @@
 // Code generated for package generated by go-bindata DO NOT EDIT. (@generated)
 // sources:
+// recovery/bindata/upgrade-recovery-restore.sh
 // recovery/bindata/upgrade-recovery.sh
 package generated
 
@@ -54,6 +55,101 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
+var _upgradeRecoveryRestoreSh = []byte(`#!/bin/bash
+#
+# This script drives an unattended restore from the recovery backup.
+# It is run by the upgrade-recovery-restore service installed by the launchRestore command, and
+# is run again by the service after each of the reboots required by the restore:
+#
+#   1. Roll back the platform to the deployment pinned by the backup, if needed
+#   2. Restore the backed up files with upgrade-recovery.sh, which requires a reboot
+#   3. Resume upgrade-recovery.sh to restore the cluster
+#
+# The outcome of the restore is recorded in the result file polled by launchRestore.
+#
+
+PROG=$(basename "$0")
+BACKUP_DIR=${1:-/var/recovery}
+SERVICE=upgrade-recovery-restore.service
+
+declare PROGRESS_FILE="${BACKUP_DIR}/progress"
+declare RESULT_FILE="${BACKUP_DIR}/restore-result"
+declare RECOVERY_SCRIPT="${BACKUP_DIR}/upgrade-recovery.sh"
+
+function log_info {
+    echo "##### $(date -u): ${PROG}: $*"
+}
+
+#
+# finish:
+# Records the outcome of the restore and stops running the service on boot
+#
+function finish {
+    log_info "$1"
+    echo "$1" > "${RESULT_FILE}"
+    systemctl disable "${SERVICE}"
+    exit 0
+}
+
+#
+# reboot_and_resume:
+# Reboots the node, running the service again once it is back. The service is enabled again
+# as the restore of /etc drops it
+#
+function reboot_and_resume {
+    systemctl enable "${BACKUP_DIR}/${SERVICE}"
+    log_info "Rebooting to resume the restore"
+    systemctl reboot
+    exit 0
+}
+
+if [ -f "${RESULT_FILE}" ]; then
+    log_info "Restore already finished: $(cat "${RESULT_FILE}")"
+    systemctl disable "${SERVICE}"
+    exit 0
+fi
+
+if [ ! -f "${PROGRESS_FILE}" ] && ! ostree admin status | grep -A 3 '^\*' | grep -q 'Pinned: yes'; then
+    log_info "Rolling back the platform to the pinned deployment"
+    if ! rpm-ostree rollback; then
+        finish "Failed: unable to roll back the platform to the pinned deployment"
+    fi
+    reboot_and_resume
+fi
+
+if [ -f "${PROGRESS_FILE}" ]; then
+    "${RECOVERY_SCRIPT}" --dir "${BACKUP_DIR}" --resume
+else
+    "${RECOVERY_SCRIPT}" --dir "${BACKUP_DIR}"
+fi
+if [ $? -ne 0 ]; then
+    finish "Failed: ${RECOVERY_SCRIPT} failed, see 'journalctl -u ${SERVICE}' for details"
+fi
+
+# The progress is only cleared once the cluster is restored, the backed up files
+# were restored and the node needs to reboot before resuming
+if [ -f "${PROGRESS_FILE}" ]; then
+    reboot_and_resume
+fi
+
+finish "Succeeded"
+`)
+
+func upgradeRecoveryRestoreShBytes() ([]byte, error) {
+	return _upgradeRecoveryRestoreSh, nil
+}
+
+func upgradeRecoveryRestoreSh() (*asset, error) {
+	bytes, err := upgradeRecoveryRestoreShBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "upgrade-recovery-restore.sh", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info}
+	return a, nil
+}
+
 var _upgradeRecoverySh = []byte(`#!/bin/bash
 #
 # This script manages post-rollback recovery.
@@ -82,8 +178,12 @@ Options:
 
 Backup options:
     --take-backup:  Take backup
+    --compress <type>:
+                    Archive the backup, compressed with gzip or zstd.
+                    The archives are encrypted with the key set in the BACKUP_ENCRYPTION_KEY env variable, if any
 
 Recovery options:
+    --unpack:       Unpack the archived backup and exit
     --force:        Skip ostree deployment check
     --step:         Step through recovery stages
     --resume:       Resume recovery after last successful stage
@@ -269,6 +369,106 @@ function trigger_redeployment {
     log_info "Completed ${name} redeployment"
 }
 
+#
+# archive_name:
+# Helper function to name the archive of a backup content
+#
+function archive_name {
+    local name="$1.tar"
+
+    case "${COMPRESSION}" in
+        gzip) name="${name}.gz" ;;
+        zstd) name="${name}.zst" ;;
+    esac
+    if [ -n "${BACKUP_ENCRYPTION_KEY}" ]; then
+        name="${name}.enc"
+    fi
+    echo "${name}"
+}
+
+#
+# archive:
+# Helper function to stream a directory into a compressed, and optionally encrypted, archive
+#
+function archive {
+    local name=$1
+    local dir=$2
+    local archive=
+    archive="${BACKUP_DIR}/$(archive_name "${name}")"
+
+    tar -C "$(dirname "${dir}")" -cpf - --selinux --acls --xattrs "$(basename "${dir}")" \
+        | case "${COMPRESSION}" in
+              gzip) gzip -c ;;
+              zstd) zstd -q -c ;;
+              *) cat ;;
+          esac \
+        | if [ -n "${BACKUP_ENCRYPTION_KEY}" ]; then
+              openssl enc -aes-256-cbc -pbkdf2 -salt -pass env:BACKUP_ENCRYPTION_KEY
+          else
+              cat
+          fi > "${archive}"
+    local rc=("${PIPESTATUS[@]}")
+    if [ "${rc[*]}" != "0 0 0" ]; then
+        rm -f "${archive}"
+        return 1
+    fi
+}
+
+#
+# unpack:
+# Helper function to extract an archive of the backup content into the backup directory
+#
+function unpack {
+    local archive=$1
+    local decompress="cat"
+    local decrypt="cat"
+
+    case "${archive}" in
+        *.enc)
+            if [ -z "${BACKUP_ENCRYPTION_KEY}" ]; then
+                log_error "The encryption key is required to unpack ${archive}"
+                return 1
+            fi
+            decrypt="openssl enc -d -aes-256-cbc -pbkdf2 -pass env:BACKUP_ENCRYPTION_KEY"
+            ;;
+    esac
+    case "${archive%.enc}" in
+        *.gz) decompress="gzip -dc" ;;
+        *.zst) decompress="zstd -q -dc" ;;
+    esac
+
+    ${decrypt} < "${archive}" | ${decompress} | tar -C "${BACKUP_DIR}" -xpf - --selinux --acls --xattrs
+    local rc=("${PIPESTATUS[@]}")
+    if [ "${rc[*]}" != "0 0 0" ]; then
+        return 1
+    fi
+}
+
+#
+# unpack_backup:
+# Procedure for extracting the archived backup content, if needed
+#
+function unpack_backup {
+    local name=
+    local archive=
+
+    for name in cluster etc local kubelet; do
+        if [ -d "${BACKUP_DIR}/${name}" ]; then
+            continue
+        fi
+        for archive in "${BACKUP_DIR}/${name}".tar*; do
+            if [ ! -f "${archive}" ]; then
+                continue
+            fi
+            log_info "Unpacking ${archive}"
+            if ! unpack "${archive}"; then
+                rm -rf "${BACKUP_DIR:?}/${name}"
+                fatal "Failed to unpack ${archive}"
+            fi
+        done
+    done
+}
+
 #
 # take_backup:
 # Procedure for backing up data prior to upgrade
@@ -298,20 +498,41 @@ function take_backup {
         fatal "Cluster backup failed"
     fi
 
+    if [ "${ARCHIVE}" = "yes" ]; then
+        # The cluster backup is staged in the backup directory before being archived
+        archive cluster "${BACKUP_DIR}/cluster"
+        if [ $? -ne 0 ]; then
+            fatal "Failed to archive the cluster backup"
+        fi
+        rm -rf "${BACKUP_DIR:?}/cluster"
+    fi
+
     cat /etc/tmpfiles.d/* | sed 's/#.*//' | awk '{print $2}' | grep '^/etc/' | sed 's#^/etc/##' > ${BACKUP_DIR}/etc.exclude.list
     echo '.updated' >> ${BACKUP_DIR}/etc.exclude.list
     echo 'kubernetes/manifests' >> ${BACKUP_DIR}/etc.exclude.list
-    with_retries 3 1 cp -Ra /etc/ ${BACKUP_DIR}/
+    if [ "${ARCHIVE}" = "yes" ]; then
+        with_retries 3 1 archive etc /etc
+    else
+        with_retries 3 1 cp -Ra /etc/ ${BACKUP_DIR}/
+    fi
     if [ $? -ne 0 ]; then
         fatal "Failed to backup /etc"
     fi
 
-    with_retries 3 1 cp -Ra /usr/local/ ${BACKUP_DIR}/
+    if [ "${ARCHIVE}" = "yes" ]; then
+        with_retries 3 1 archive local /usr/local
+    else
+        with_retries 3 1 cp -Ra /usr/local/ ${BACKUP_DIR}/
+    fi
     if [ $? -ne 0 ]; then
         fatal "Failed to backup /usr/local"
     fi
 
-    with_retries 3 1 cp -Ra /var/lib/kubelet/ ${BACKUP_DIR}/
+    if [ "${ARCHIVE}" = "yes" ]; then
+        with_retries 3 1 archive kubelet /var/lib/kubelet
+    else
+        with_retries 3 1 cp -Ra /var/lib/kubelet/ ${BACKUP_DIR}/
+    fi
     if [ $? -ne 0 ]; then
         fatal "Failed to backup /var/lib/kubelet"
     fi
@@ -515,8 +736,10 @@ declare SKIP_DEPLOY_CHECK="no"
 declare TAKE_BACKUP="no"
 declare STEPTHROUGH="no"
 declare RESUME="no"
+declare COMPRESSION="none"
+declare UNPACK="no"
 
-LONGOPTS="dir:,force,restart,resume,step,take-backup"
+LONGOPTS="compress:,dir:,force,restart,resume,step,take-backup,unpack"
 OPTS=$(getopt -o h --long "${LONGOPTS}" --name "$0" -- "$@")
 
 if [ $? -ne 0 ]; then
@@ -528,6 +751,10 @@ eval set -- "${OPTS}"
 
 while :; do
     case "$1" in
+        --compress)
+            COMPRESSION=$2
+            shift 2
+            ;;
         --dir)
             BACKUP_DIR=$2
             shift 2
@@ -552,6 +779,10 @@ while :; do
             TAKE_BACKUP="yes"
             shift
             ;;
+        --unpack)
+            UNPACK="yes"
+            shift
+            ;;
         --)
             shift
             break
@@ -565,6 +796,19 @@ done
 
 declare PROGRESS_FILE="${BACKUP_DIR}/progress"
 
+case "${COMPRESSION}" in
+    none|gzip|zstd) ;;
+    *)
+        echo "Unsupported compression: ${COMPRESSION}" >&2
+        exit 1
+        ;;
+esac
+
+declare ARCHIVE="no"
+if [ "${COMPRESSION}" != "none" ] || [ -n "${BACKUP_ENCRYPTION_KEY}" ]; then
+    ARCHIVE="yes"
+fi
+
 # shellcheck source=/dev/null
 source /etc/kubernetes/static-pod-resources/etcd-certs/configmaps/etcd-scripts/etcd-common-tools
 
@@ -576,6 +820,14 @@ if [ "${TAKE_BACKUP}" = "yes" ]; then
     exit 0
 fi
 
+#
+# Unpack the archived backup
+#
+unpack_backup
+if [ "${UNPACK}" = "yes" ]; then
+    exit 0
+fi
+
 #
 # Validate environment
 #
@@ -710,7 +962,8 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
-	"upgrade-recovery.sh": upgradeRecoverySh,
+	"upgrade-recovery-restore.sh": upgradeRecoveryRestoreSh,
+	"upgrade-recovery.sh":         upgradeRecoverySh,
 }
 
 // AssetDir returns the file names below a certain
@@ -756,7 +1009,8 @@ type bintree struct {
 }
 
 var _bintree = &bintree{nil, map[string]*bintree{
-	"upgrade-recovery.sh": {upgradeRecoverySh, map[string]*bintree{}},
+	"upgrade-recovery-restore.sh": {upgradeRecoveryRestoreSh, map[string]*bintree{}},
+	"upgrade-recovery.sh":         {upgradeRecoverySh, map[string]*bintree{}},
 }}
 
 // RestoreAsset restores an asset under the given directory